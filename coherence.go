@@ -0,0 +1,154 @@
+package nlp
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// TopicCoherence scores topics, as returned by the TopTerms() methods of topic models
+// such as LatentDirichletAllocation and NMF, by how frequently their top terms
+// co-occur within the documents of a corpus.  Topics whose top terms frequently
+// appear together in the same documents are more likely to be interpretable by
+// humans.  Comparing the mean coherence of models fitted with different numbers of
+// topics provides a means of automatically selecting the number of topics.
+//
+// Two measures are supported:
+//
+// UMass coherence (Mimno et al, http://dirichlet.net/pdf/mimno11optimizing.pdf) which
+// scores each pair of top terms wi, wj (where wj is ranked higher than wi) as
+// log((D(wi, wj) + Epsilon) / D(wj)) where D(w) is the number of documents containing
+// w and D(wi, wj) the number of documents containing both.  Scores are typically <= 0
+// with values closer to 0 indicating greater coherence.
+//
+// NPMI (Normalised Pointwise Mutual Information) coherence (Bouma,
+// https://svn.spraakdata.gu.se/repos/gerlof/pub/www/Docs/npmi-pfd.pdf) which scores
+// each pair of top terms as log(P(wi, wj) / (P(wi)P(wj))) / -log(P(wi, wj)) where
+// probabilities are estimated from document frequencies.  Scores range from -1 (the
+// terms never co-occur) to 1 (the terms always co-occur).
+//
+// For both measures, the score of a topic is the mean score across all pairs of its
+// top terms.
+type TopicCoherence struct {
+	// Orientation of the corpus matrix i.e. whether terms are represented by rows
+	// or columns.
+	Orientation Orientation
+
+	// Epsilon is the smoothing count added to co-document frequencies for UMass
+	// coherence to avoid taking the logarithm of zero.
+	Epsilon float64
+
+	corpus     mat.Matrix
+	vocabulary map[string]int
+}
+
+// NewTopicCoherence creates a new TopicCoherence for scoring topics against the
+// specified corpus.  m is the term document matrix for the corpus (e.g. the output of
+// CountVectoriser.FitTransform()) and vocabulary maps each term to its index within
+// m (e.g. CountVectoriser.Vocabulary).  Epsilon defaults to 1.
+func NewTopicCoherence(m mat.Matrix, vocabulary map[string]int) *TopicCoherence {
+	return &TopicCoherence{
+		Epsilon:    1,
+		corpus:     m,
+		vocabulary: vocabulary,
+	}
+}
+
+// UMass returns the UMass coherence of each of the specified topics.  Each topic is
+// a slice of terms in descending order of weight within the topic e.g. as returned
+// by LatentDirichletAllocation.TopTerms().  An error is returned if any of the terms
+// are not present in the vocabulary.
+func (c *TopicCoherence) UMass(topics ...[]string) ([]float64, error) {
+	return c.score(topics, func(i, j, joint, numDocs int) float64 {
+		if j == 0 {
+			return 0
+		}
+		return math.Log((float64(joint) + c.Epsilon) / float64(j))
+	})
+}
+
+// NPMI returns the NPMI coherence of each of the specified topics.  Each topic is
+// a slice of terms in descending order of weight within the topic e.g. as returned
+// by LatentDirichletAllocation.TopTerms().  An error is returned if any of the terms
+// are not present in the vocabulary.
+func (c *TopicCoherence) NPMI(topics ...[]string) ([]float64, error) {
+	return c.score(topics, func(i, j, joint, numDocs int) float64 {
+		if joint == 0 {
+			return -1
+		}
+		pJoint := float64(joint) / float64(numDocs)
+		if pJoint == 1 {
+			return 1
+		}
+		pmi := math.Log(pJoint / ((float64(i) / float64(numDocs)) * (float64(j) / float64(numDocs))))
+		return pmi / -math.Log(pJoint)
+	})
+}
+
+// score returns the mean of fn across all pairs of top terms within each of the
+// topics.  fn is called with the document frequencies of the lower ranked term (i),
+// the higher ranked term (j), their co-document frequency and the total number of
+// documents in the corpus.
+func (c *TopicCoherence) score(topics [][]string, fn func(i, j, joint, numDocs int) float64) ([]float64, error) {
+	docs := make(map[int]map[int]struct{})
+	indices := make([][]int, len(topics))
+	for t, topic := range topics {
+		indices[t] = make([]int, len(topic))
+		for k, term := range topic {
+			index, exists := c.vocabulary[term]
+			if !exists {
+				return nil, fmt.Errorf("nlp: Term '%s' is not present in the vocabulary", term)
+			}
+			indices[t][k] = index
+			docs[index] = make(map[int]struct{})
+		}
+	}
+
+	r, cols := c.corpus.Dims()
+	numDocs := cols
+	if c.Orientation == DocumentsAsRows {
+		numDocs = r
+	}
+	nonZeroDo(c.corpus, func(i, j int, v float64) {
+		term := c.Orientation.term(i, j)
+		if termDocs, needed := docs[term]; needed {
+			doc := j
+			if c.Orientation == DocumentsAsRows {
+				doc = i
+			}
+			termDocs[doc] = struct{}{}
+		}
+	})
+
+	scores := make([]float64, len(topics))
+	for t, topic := range indices {
+		var sum float64
+		var pairs int
+		for i := 1; i < len(topic); i++ {
+			for j := 0; j < i; j++ {
+				sum += fn(len(docs[topic[i]]), len(docs[topic[j]]), coDocFreq(docs[topic[i]], docs[topic[j]]), numDocs)
+				pairs++
+			}
+		}
+		if pairs > 0 {
+			scores[t] = sum / float64(pairs)
+		}
+	}
+
+	return scores, nil
+}
+
+// coDocFreq returns the number of documents common to both sets of documents a and b.
+func coDocFreq(a, b map[int]struct{}) int {
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+	var n int
+	for doc := range a {
+		if _, exists := b[doc]; exists {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,86 @@
+package nlp
+
+import (
+	"math"
+	"testing"
+
+	"github.com/james-bowman/sparse"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestTopicCoherence(t *testing.T) {
+	vocabulary := map[string]int{"a": 0, "b": 1, "c": 2, "d": 3}
+	corpus := mat.NewDense(4, 4, []float64{
+		1, 2, 0, 0,
+		3, 1, 0, 0,
+		0, 0, 1, 2,
+		0, 1, 0, 1,
+	})
+
+	var tests = []struct {
+		orientation Orientation
+		m           mat.Matrix
+		topics      [][]string
+		umass       []float64
+		npmi        []float64
+	}{
+		{
+			orientation: TermsAsRows,
+			m:           corpus,
+			topics:      [][]string{{"a", "b"}, {"a", "c"}, {"c", "d", "a"}},
+			umass:       []float64{math.Log(1.5), math.Log(0.5), math.Log(0.5) / 3},
+			npmi:        []float64{1, -1, -1.0 / 3},
+		},
+		{
+			orientation: TermsAsRows,
+			m:           sparse.NewCSR(4, 4, []int{0, 2, 4, 6, 8}, []int{0, 1, 0, 1, 2, 3, 1, 3}, []float64{1, 2, 3, 1, 1, 2, 1, 1}),
+			topics:      [][]string{{"a", "b"}, {"a", "c"}, {"c", "d", "a"}},
+			umass:       []float64{math.Log(1.5), math.Log(0.5), math.Log(0.5) / 3},
+			npmi:        []float64{1, -1, -1.0 / 3},
+		},
+		{
+			orientation: DocumentsAsRows,
+			m:           corpus.T(),
+			topics:      [][]string{{"a", "b"}, {"a", "c"}, {"c", "d", "a"}},
+			umass:       []float64{math.Log(1.5), math.Log(0.5), math.Log(0.5) / 3},
+			npmi:        []float64{1, -1, -1.0 / 3},
+		},
+		{
+			orientation: TermsAsRows,
+			m:           corpus,
+			topics:      [][]string{{"a"}},
+			umass:       []float64{0},
+			npmi:        []float64{0},
+		},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		coherence := NewTopicCoherence(test.m, vocabulary)
+		coherence.Orientation = test.orientation
+
+		umass, err := coherence.UMass(test.topics...)
+		if err != nil {
+			t.Errorf("Failed to calculate UMass coherence because %v", err)
+		}
+		npmi, err := coherence.NPMI(test.topics...)
+		if err != nil {
+			t.Errorf("Failed to calculate NPMI coherence because %v", err)
+		}
+
+		for i := range test.topics {
+			if math.Abs(test.umass[i]-umass[i]) > 1e-9 {
+				t.Errorf("Topic %d: Expected UMass %f but found %f", i, test.umass[i], umass[i])
+			}
+			if math.Abs(test.npmi[i]-npmi[i]) > 1e-9 {
+				t.Errorf("Topic %d: Expected NPMI %f but found %f", i, test.npmi[i], npmi[i])
+			}
+		}
+	}
+
+	coherence := NewTopicCoherence(corpus, vocabulary)
+	if _, err := coherence.UMass([]string{"a", "z"}); err == nil {
+		t.Errorf("Expected error for term not present in vocabulary but received none")
+	}
+}
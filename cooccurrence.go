@@ -0,0 +1,170 @@
+package nlp
+
+import (
+	"github.com/james-bowman/sparse"
+	"gonum.org/v1/gonum/mat"
+)
+
+// CooccurrenceVectoriser encodes a corpus of text documents into a term-term
+// co-occurrence matrix where each row and each column represents a term within the
+// Vocabulary.  Each element represents how often the corresponding terms occur within
+// Window tokens of one another across the documents e.g. X(i, j) = 5 would mean that
+// term j (perhaps the word "bark") occurs within the context window of term i (perhaps
+// the word "dog") 5 times.  Windows do not span document boundaries.  The resulting
+// matrix is symmetric and is suitable as input for building word vectors e.g. by
+// weighting with a PPMITransformer followed by dimensionality reduction with
+// TruncatedSVD or by training GloVe.
+type CooccurrenceVectoriser struct {
+	// Vocabulary is a map of words to indices that point to the row (and column)
+	// number representing that word in the co-occurrence matrix output from the
+	// Transform() and FitTransform() methods.  The Vocabulary map is populated by the
+	// Fit() or FitTransform() methods based upon the words occurring in the datasets
+	// supplied to those methods.  Within Transform(), any words not present in the
+	// Vocabulary are ignored.
+	Vocabulary map[string]int
+
+	// Tokeniser is used to tokenise input text into terms
+	Tokeniser Tokeniser
+
+	// Window is the number of tokens either side of each term considered to be its
+	// context
+	Window int
+
+	// DistanceWeighting, if true, weights each co-occurrence by the reciprocal of the
+	// distance between the terms (1/d) so that terms occurring closer together
+	// contribute more, as used by GloVe.  If false, each co-occurrence contributes 1.
+	DistanceWeighting bool
+
+	// MinCount is the minimum number of times a term must occur within the training
+	// documents to be included in the Vocabulary during Fit().  Less frequent terms
+	// are ignored.
+	MinCount int
+}
+
+// NewCooccurrenceVectoriser creates a new CooccurrenceVectoriser counting terms
+// occurring within window tokens of each other with a default MinCount of 1 and no
+// DistanceWeighting.  If stopWords is not an empty slice then stop words will be
+// removed.
+func NewCooccurrenceVectoriser(window int, stopWords ...string) *CooccurrenceVectoriser {
+	return &CooccurrenceVectoriser{
+		Vocabulary: make(map[string]int),
+		Tokeniser:  NewTokeniser(stopWords...),
+		Window:     window,
+		MinCount:   1,
+	}
+}
+
+// Fit processes the supplied training data (a variable number of strings representing
+// documents).  Each word occurring at least MinCount times inside the training data
+// will be added to the Vocabulary in the order it first occurs.  Calling the Fit()
+// method a second time re-trains the model from scratch (discarding the previously
+// learnt Vocabulary).
+func (v *CooccurrenceVectoriser) Fit(train ...string) Vectoriser {
+	v.fit(len(train), func(d int) tokenIterator {
+		return tokenise(v.Tokeniser, train[d])
+	})
+	return v
+}
+
+// FitTokens is equivalent to Fit() but accepts pre-tokenised training documents,
+// each represented as a slice of tokens, rather than raw text.  The Tokeniser is not
+// used.
+func (v *CooccurrenceVectoriser) FitTokens(train ...[]string) *CooccurrenceVectoriser {
+	v.fit(len(train), func(d int) tokenIterator {
+		return sliceTokens(train[d])
+	})
+	return v
+}
+
+// fit learns the Vocabulary from n training documents, the tokens of which are
+// iterated over by the iterators returned from doc for each document index.
+func (v *CooccurrenceVectoriser) fit(n int, doc func(d int) tokenIterator) {
+	c := newTermCounter()
+	for d := 0; d < n; d++ {
+		c.count(doc(d))
+	}
+
+	v.Vocabulary = make(map[string]int)
+	for _, term := range c.terms {
+		if c.tf[term] >= v.MinCount {
+			v.Vocabulary[term] = len(v.Vocabulary)
+		}
+	}
+}
+
+// Transform counts the co-occurrences of terms within the supplied documents returning
+// a symmetric co-occurrence matrix of shape V x V where V is the size of the
+// Vocabulary.  The returned matrix is a sparse matrix type.
+func (v *CooccurrenceVectoriser) Transform(docs ...string) (mat.Matrix, error) {
+	return v.transform(len(docs), func(d int) tokenIterator {
+		return tokenise(v.Tokeniser, docs[d])
+	}), nil
+}
+
+// TransformTokens is equivalent to Transform() but accepts pre-tokenised documents,
+// each represented as a slice of tokens, rather than raw text.  The Tokeniser is not
+// used.  The returned matrix is a sparse matrix type.
+func (v *CooccurrenceVectoriser) TransformTokens(docs ...[]string) (mat.Matrix, error) {
+	return v.transform(len(docs), func(d int) tokenIterator {
+		return sliceTokens(docs[d])
+	}), nil
+}
+
+// transform counts the co-occurrences within n documents, the tokens of which are
+// iterated over by the iterators returned from doc for each document index.
+func (v *CooccurrenceVectoriser) transform(n int, doc func(d int) tokenIterator) mat.Matrix {
+	counts := make(map[[2]int]float64)
+
+	for d := 0; d < n; d++ {
+		// tokens not in the Vocabulary are retained as -1 so that distances between
+		// the remaining terms reflect their positions within the document
+		var indices []int
+		doc(d)(func(token string) {
+			i, exists := v.Vocabulary[token]
+			if !exists {
+				i = -1
+			}
+			indices = append(indices, i)
+		})
+
+		for p, i := range indices {
+			if i < 0 {
+				continue
+			}
+			for dist := 1; dist <= v.Window && p+dist < len(indices); dist++ {
+				j := indices[p+dist]
+				if j < 0 {
+					continue
+				}
+				weight := 1.0
+				if v.DistanceWeighting {
+					weight /= float64(dist)
+				}
+				counts[[2]int{i, j}] += weight
+				counts[[2]int{j, i}] += weight
+			}
+		}
+	}
+
+	matrix := sparse.NewDOK(len(v.Vocabulary), len(v.Vocabulary))
+	for key, count := range counts {
+		matrix.Set(key[0], key[1], count)
+	}
+	return matrix.ToCSR()
+}
+
+// FitTransform is exactly equivalent to calling Fit() followed by Transform() on the
+// same documents.  The returned matrix is a sparse matrix type.
+func (v *CooccurrenceVectoriser) FitTransform(docs ...string) (mat.Matrix, error) {
+	return v.Fit(docs...).Transform(docs...)
+}
+
+// GetFeatureNames returns the names of the terms corresponding to each row (and
+// column) of the co-occurrence matrices output by Transform(), in index order.
+func (v *CooccurrenceVectoriser) GetFeatureNames() []string {
+	terms := make([]string, len(v.Vocabulary))
+	for term, i := range v.Vocabulary {
+		terms[i] = term
+	}
+	return terms
+}
@@ -0,0 +1,139 @@
+package nlp
+
+import (
+	"reflect"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestCooccurrenceVectoriser(t *testing.T) {
+	var tests = []struct {
+		train     [][]string
+		docs      [][]string
+		window    int
+		weighting bool
+		minCount  int
+		vocab     []string
+		expected  []float64
+	}{
+		// window of 1 only counts adjacent terms
+		{
+			train:  [][]string{{"the", "dog", "barked"}},
+			docs:   [][]string{{"the", "dog", "barked"}},
+			window: 1,
+			vocab:  []string{"the", "dog", "barked"},
+			expected: []float64{
+				0, 1, 0,
+				1, 0, 1,
+				0, 1, 0,
+			},
+		},
+		// window of 2 also counts terms 2 tokens apart
+		{
+			train:  [][]string{{"the", "dog", "barked"}},
+			docs:   [][]string{{"the", "dog", "barked"}},
+			window: 2,
+			vocab:  []string{"the", "dog", "barked"},
+			expected: []float64{
+				0, 1, 1,
+				1, 0, 1,
+				1, 1, 0,
+			},
+		},
+		// distance weighting
+		{
+			train:     [][]string{{"the", "dog", "barked"}},
+			docs:      [][]string{{"the", "dog", "barked"}},
+			window:    2,
+			weighting: true,
+			vocab:     []string{"the", "dog", "barked"},
+			expected: []float64{
+				0, 1, 0.5,
+				1, 0, 1,
+				0.5, 1, 0,
+			},
+		},
+		// windows do not span documents and counts accumulate across documents
+		{
+			train:  [][]string{{"the", "dog"}, {"the", "cat"}},
+			docs:   [][]string{{"the", "dog"}, {"the", "cat"}, {"dog", "the"}},
+			window: 5,
+			vocab:  []string{"the", "dog", "cat"},
+			expected: []float64{
+				0, 2, 1,
+				2, 0, 0,
+				1, 0, 0,
+			},
+		},
+		// terms below MinCount are excluded but still occupy positions
+		{
+			train:    [][]string{{"the", "big", "dog"}, {"the", "dog"}},
+			docs:     [][]string{{"the", "big", "dog"}},
+			window:   1,
+			minCount: 2,
+			vocab:    []string{"the", "dog"},
+			expected: []float64{
+				0, 0,
+				0, 0,
+			},
+		},
+		// repeated terms co-occur with themselves
+		{
+			train:  [][]string{{"go", "go", "go"}},
+			docs:   [][]string{{"go", "go", "go"}},
+			window: 2,
+			vocab:  []string{"go"},
+			expected: []float64{
+				6,
+			},
+		},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		vectoriser := NewCooccurrenceVectoriser(test.window)
+		vectoriser.DistanceWeighting = test.weighting
+		if test.minCount > 0 {
+			vectoriser.MinCount = test.minCount
+		}
+		vectoriser.FitTokens(test.train...)
+
+		if !reflect.DeepEqual(test.vocab, vectoriser.GetFeatureNames()) {
+			t.Errorf("Expected vocabulary %v but found %v", test.vocab, vectoriser.GetFeatureNames())
+		}
+
+		result, err := vectoriser.TransformTokens(test.docs...)
+		if err != nil {
+			t.Errorf("Failed to transform documents because %v", err)
+		}
+		expected := mat.NewDense(len(test.vocab), len(test.vocab), test.expected)
+		if !mat.Equal(expected, result) {
+			t.Errorf("Expected:\n%v\nbut found:\n%v\n", mat.Formatted(expected), mat.Formatted(result))
+		}
+	}
+}
+
+func TestCooccurrenceVectoriserFitTransform(t *testing.T) {
+	vectoriser := NewCooccurrenceVectoriser(2)
+	result, err := vectoriser.FitTransform("The quick brown fox", "the lazy dog")
+	if err != nil {
+		t.Fatalf("Failed to fit and transform documents because %v", err)
+	}
+
+	if r, c := result.Dims(); r != 6 || c != 6 {
+		t.Errorf("Expected dims 6x6 but found %dx%d", r, c)
+	}
+	if !mat.Equal(result, result.T()) {
+		t.Errorf("Expected symmetric co-occurrence matrix but found:\n%v\n", mat.Formatted(result))
+	}
+	the, fox := vectoriser.Vocabulary["the"], vectoriser.Vocabulary["fox"]
+	if v := result.At(the, fox); v != 0 {
+		t.Errorf("Expected terms beyond the window not to co-occur but found %f", v)
+	}
+	brown := vectoriser.Vocabulary["brown"]
+	if v := result.At(the, brown); v != 1 {
+		t.Errorf("Expected 'the' and 'brown' to co-occur once but found %f", v)
+	}
+}
@@ -0,0 +1,179 @@
+package nlp
+
+// DedupMethod specifies the signatures used by a Deduplicator to find near-duplicate
+// documents.
+type DedupMethod int
+
+const (
+	// MinHashDedup compares documents by the Jaccard similarity of their shingle sets,
+	// estimated from MinHash signatures indexed with MinHashLSH.  This is the most
+	// accurate method for near-duplicate detection.
+	MinHashDedup DedupMethod = iota
+
+	// SimHashDedup compares documents by the proportion of matching bits within the
+	// 64 bit SimHash fingerprints of their (weighted) shingles.  Fingerprints require
+	// far less memory than MinHash signatures (8 bytes per document) making this
+	// method suited to very large corpora, although estimates of similarity are
+	// coarser.
+	SimHashDedup
+)
+
+// Deduplicator finds clusters of near-duplicate documents within a corpus e.g. to
+// remove duplicate web pages or syndicated news articles before training models.
+// Documents are shingled (divided into overlapping sequences of tokens), each
+// document's shingles summarised as a compact signature (MinHash or SimHash according
+// to Method) and the signatures indexed to efficiently find candidate pairs of
+// near-duplicates without comparing every pair of documents.  Candidate pairs with a
+// similarity of at least Threshold are then clustered so that each cluster contains
+// documents linked by a chain of near-duplicate pairs (single linkage).
+type Deduplicator struct {
+	// Method selects the signatures used to find near-duplicates
+	Method DedupMethod
+
+	// Threshold is the minimum similarity, between 0 and 1, of near-duplicate
+	// documents.  For MinHashDedup this is the estimated Jaccard similarity of the
+	// documents' shingle sets and for SimHashDedup the proportion of matching bits
+	// within their fingerprints.
+	Threshold float64
+
+	// MinHash shingles documents and, for MinHashDedup, generates their signatures.
+	// Its Tokeniser and ShingleSize determine the shingles of documents for both
+	// methods.
+	MinHash *MinHash
+
+	// Fingerprinter generates the fingerprints of the shingles of documents for
+	// SimHashDedup
+	Fingerprinter *SimHashFingerprinter
+}
+
+// NewDeduplicator creates a new Deduplicator finding near-duplicates with an
+// estimated Jaccard similarity of at least threshold using MinHash signatures of 128
+// hashes over shingles of 3 tokens.
+func NewDeduplicator(threshold float64) *Deduplicator {
+	return &Deduplicator{
+		Method:        MinHashDedup,
+		Threshold:     threshold,
+		MinHash:       NewMinHash(128, 3),
+		Fingerprinter: NewSimHashFingerprinter(),
+	}
+}
+
+// Clusters returns the clusters of near-duplicate documents within docs.  Each
+// cluster contains the indices of 2 or more documents in ascending order and clusters
+// are ordered by their first document.  Documents without near-duplicates, and
+// documents containing no tokens, are not included within any cluster.
+func (d *Deduplicator) Clusters(docs ...string) [][]int {
+	var pairs [][2]int
+	if d.Method == SimHashDedup {
+		pairs = d.simHashPairs(docs)
+	} else {
+		pairs = d.minHashPairs(docs)
+	}
+
+	// cluster the pairs into connected components using union-find
+	parent := make([]int, len(docs))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for _, pair := range pairs {
+		a, b := find(pair[0]), find(pair[1])
+		if a < b {
+			parent[b] = a
+		} else if b < a {
+			parent[a] = b
+		}
+	}
+
+	// roots are always the lowest index within their cluster so iterating in index
+	// order yields clusters ordered by their first document
+	members := make(map[int][]int)
+	var roots []int
+	for i := range docs {
+		root := find(i)
+		if root == i {
+			roots = append(roots, i)
+		}
+		members[root] = append(members[root], i)
+	}
+	var clusters [][]int
+	for _, root := range roots {
+		if len(members[root]) > 1 {
+			clusters = append(clusters, members[root])
+		}
+	}
+	return clusters
+}
+
+// minHashPairs returns the pairs of documents with an estimated Jaccard similarity of
+// at least Threshold found using MinHashLSH.
+func (d *Deduplicator) minHashPairs(docs []string) [][2]int {
+	lsh := NewMinHashLSH(d.MinHash.NumHashes, d.Threshold)
+	for i, doc := range docs {
+		if shingles := d.MinHash.shingles(doc); len(shingles) > 0 {
+			lsh.Put(i, d.MinHash.signature(shingles))
+		}
+	}
+
+	var pairs [][2]int
+	for _, pair := range lsh.CandidatePairs() {
+		pairs = append(pairs, [2]int{pair.A.(int), pair.B.(int)})
+	}
+	return pairs
+}
+
+// simHashPairs returns the pairs of documents whose fingerprints match in at least
+// Threshold of their bits.  Fingerprints differing in at most k bits must be identical
+// within at least 1 of any k+1 disjoint blocks of bits (pigeonhole principle) so
+// candidates are found by indexing each block of the fingerprints.
+func (d *Deduplicator) simHashPairs(docs []string) [][2]int {
+	maxDistance := int((1 - d.Threshold) * 64)
+	if maxDistance > 63 {
+		maxDistance = 63
+	} else if maxDistance < 0 {
+		maxDistance = 0
+	}
+	blocks := maxDistance + 1
+
+	type blockKey struct {
+		block int
+		bits  uint64
+	}
+	index := make(map[blockKey][]int)
+	fingerprints := make([]uint64, len(docs))
+	seen := make(map[[2]int]bool)
+	var pairs [][2]int
+
+	for i, doc := range docs {
+		shingles := d.MinHash.shingles(doc)
+		if len(shingles) == 0 {
+			continue
+		}
+		var sums [64]float64
+		for _, shingle := range shingles {
+			d.Fingerprinter.accumulate(&sums, int(shingle), 1)
+		}
+		fingerprints[i] = fingerprint(&sums)
+
+		for b := 0; b < blocks; b++ {
+			start, end := b*64/blocks, (b+1)*64/blocks
+			mask := uint64(1)<<uint(end-start) - 1
+			key := blockKey{block: b, bits: (fingerprints[i] >> uint(start)) & mask}
+			for _, j := range index[key] {
+				pair := [2]int{j, i}
+				if !seen[pair] && HammingDistance(fingerprints[i], fingerprints[j]) <= maxDistance {
+					seen[pair] = true
+					pairs = append(pairs, pair)
+				}
+			}
+			index[key] = append(index[key], i)
+		}
+	}
+	return pairs
+}
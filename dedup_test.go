@@ -0,0 +1,58 @@
+package nlp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDeduplicatorClusters(t *testing.T) {
+	docs := []string{
+		"the quick brown fox jumped over the lazy dog near the river bank on a sunny afternoon in june",
+		"a completely unrelated document about the price of coffee beans in south america this year",
+		"the quick brown fox jumped over the lazy dog near the river bank on a sunny afternoon in july",
+		"",
+		"the quick brown fox jumped over the lazy dog near the river bank on a sunny afternoon in june",
+		"a completely unrelated document about the price of coffee beans in south america this year",
+		"yet another document discussing the weather forecast for the coming weekend across europe",
+		"",
+	}
+
+	tests := []struct {
+		method    DedupMethod
+		threshold float64
+		expected  [][]int
+	}{
+		{method: MinHashDedup, threshold: 0.7, expected: [][]int{{0, 2, 4}, {1, 5}}},
+		{method: MinHashDedup, threshold: 1, expected: [][]int{{0, 4}, {1, 5}}},
+		{method: SimHashDedup, threshold: 0.8, expected: [][]int{{0, 2, 4}, {1, 5}}},
+		{method: SimHashDedup, threshold: 1, expected: [][]int{{0, 4}, {1, 5}}},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		dedup := NewDeduplicator(test.threshold)
+		dedup.Method = test.method
+		clusters := dedup.Clusters(docs...)
+		if !reflect.DeepEqual(clusters, test.expected) {
+			t.Errorf("Expected %v but found %v", test.expected, clusters)
+		}
+	}
+
+	if clusters := NewDeduplicator(0.5).Clusters(docs[6], docs[7]); len(clusters) != 0 {
+		t.Errorf("Expected no clusters but found %v", clusters)
+	}
+}
+
+func TestDeduplicatorSingleLinkage(t *testing.T) {
+	// documents a and c are only near-duplicates via b
+	a := "one two three four five six seven eight nine ten eleven twelve"
+	b := "one two three four five six seven eight nine ten eleven twelve thirteen fourteen fifteen sixteen"
+	c := "five six seven eight nine ten eleven twelve thirteen fourteen fifteen sixteen"
+
+	dedup := NewDeduplicator(0.5)
+	clusters := dedup.Clusters(a, "something else entirely", b, c)
+	if !reflect.DeepEqual(clusters, [][]int{{0, 2, 3}}) {
+		t.Errorf("Expected chained near-duplicates to be clustered together but found %v", clusters)
+	}
+}
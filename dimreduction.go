@@ -4,6 +4,9 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"time"
+
+	"golang.org/x/exp/rand"
 
 	"github.com/james-bowman/sparse"
 	"gonum.org/v1/gonum/mat"
@@ -27,12 +30,52 @@ type TruncatedSVD struct {
 	// input matrix and min(m, n, K) is the lowest value of m, n, K where m is the number of
 	// rows in the original, input matrix.
 	K int
+
+	// Randomised specifies whether the randomised SVD algorithm (Halko et al.) should be
+	// used in place of an exact SVD.  Randomised SVD approximates the top K singular
+	// values and vectors by projecting the input matrix onto a random subspace and is
+	// significantly faster and more memory efficient than an exact SVD for large, sparse
+	// matrices e.g. with millions of documents.
+	Randomised bool
+
+	// Oversampling is the number of additional random vectors, beyond K, sampled by
+	// the randomised SVD algorithm to improve the accuracy of the approximation.
+	Oversampling int
+
+	// PowerIterations is the number of power iterations performed by the randomised SVD
+	// algorithm.  Additional iterations improve accuracy for matrices whose singular
+	// values decay slowly (as is typical for term document matrices) at the expense of
+	// additional passes over the input matrix.
+	PowerIterations int
+
+	// Rnd is the random number source used by the randomised SVD algorithm.  Setting
+	// Rnd with a fixed seed allows for reproducible results.
+	Rnd *rand.Rand
+
+	// Progress, if not nil, is called during Fit() (stage "svd") as the factorisation
+	// progresses.  The exact SVD reports only its start and completion whereas the
+	// randomised SVD also reports the completion of each power iteration.
+	Progress ProgressFunc
+
+	// singularValues are the singular values corresponding to Components, retained to
+	// support incremental updates through PartialFit()
+	singularValues []float64
+
+	// totalVariance is the squared Frobenius norm of the training data used to
+	// calculate the proportion of variance explained by each component
+	totalVariance float64
 }
 
 // NewTruncatedSVD creates a new TruncatedSVD transformer with K (the truncated
-// dimensionality) being set to the specified value k
+// dimensionality) being set to the specified value k.  If the randomised SVD algorithm
+// is enabled, it will use an Oversampling of 10 and 4 PowerIterations by default.
 func NewTruncatedSVD(k int) *TruncatedSVD {
-	return &TruncatedSVD{K: k}
+	return &TruncatedSVD{
+		K:               k,
+		Oversampling:    10,
+		PowerIterations: 4,
+		Rnd:             rand.New(rand.NewSource(uint64(time.Now().UnixNano()))),
+	}
 }
 
 // Fit performs the SVD factorisation on the input training data matrix, mat and
@@ -55,16 +98,74 @@ func (t *TruncatedSVD) Transform(m mat.Matrix) (mat.Matrix, error) {
 	return &product, nil
 }
 
+// FoldIn projects ("folds in") the term vector q, e.g. a vectorised and weighted
+// query, into the reduced dimensional space of the fitted model, equivalent to
+// transforming a single column matrix with Transform().  Only the non-zero elements of
+// sparse vectors are accessed.  Terms beyond those the model was fitted with (e.g.
+// added to the vocabulary of a CountVectoriser since fitting) are ignored.
+func (t *TruncatedSVD) FoldIn(q mat.Vector) *mat.VecDense {
+	m, k := t.Components.Dims()
+	folded := mat.NewVecDense(k, nil)
+	add := func(i int, v float64) {
+		if i >= m || v == 0 {
+			return
+		}
+		for c := 0; c < k; c++ {
+			folded.SetVec(c, folded.AtVec(c)+t.Components.At(i, c)*v)
+		}
+	}
+	if s, isSparse := q.(*sparse.Vector); isSparse {
+		s.DoNonZero(func(i, j int, v float64) {
+			add(i, v)
+		})
+	} else {
+		for i := 0; i < q.Len(); i++ {
+			add(i, q.AtVec(i))
+		}
+	}
+	return folded
+}
+
+// InverseTransform maps the supplied matrix of reduced dimensional (K x C) document
+// vectors back into the original term space by multiplying by the Components.  As
+// the dimensionality reduction discards information, the result is the closest rank K
+// approximation of the original matrix rather than an exact reconstruction.  The
+// returned matrix is a dense matrix type.
+func (t *TruncatedSVD) InverseTransform(m mat.Matrix) (mat.Matrix, error) {
+	if r, _ := m.Dims(); r != t.Components.RawMatrix().Cols {
+		return nil, fmt.Errorf("nlp: Matrix has %d dimensions but the transformer was fitted with %d", r, t.Components.RawMatrix().Cols)
+	}
+	var product mat.Dense
+
+	product.Mul(t.Components, m)
+
+	return &product, nil
+}
+
 // FitTransform is approximately equivalent to calling Fit() followed by Transform()
 // on the same matrix.  This is a useful shortcut where separate training data is not being
 // used to fit the model i.e. the model is fitted on the fly to the test data.
 // The returned matrix is a dense matrix type.
 func (t *TruncatedSVD) FitTransform(m mat.Matrix) (mat.Matrix, error) {
-	var svd mat.SVD
-	if ok := svd.Factorize(m, mat.SVDThin); !ok {
-		return nil, fmt.Errorf("Failed SVD Factorisation of working matrix")
+	var s []float64
+	var u, v *mat.Dense
+	if t.Randomised {
+		if tc, isTypeConv := m.(sparse.TypeConverter); isTypeConv {
+			m = tc.ToCSR()
+		}
+		var err error
+		if s, u, v, err = randomisedSVD(m, nil, t.K, t.Oversampling, t.PowerIterations, t.Rnd, t.Progress); err != nil {
+			return nil, err
+		}
+	} else {
+		t.Progress.report("svd", 0, 1)
+		var svd mat.SVD
+		if ok := svd.Factorize(m, mat.SVDThin); !ok {
+			return nil, fmt.Errorf("Failed SVD Factorisation of working matrix")
+		}
+		s, u, v = t.extractSVD(&svd)
+		t.Progress.report("svd", 1, 1)
 	}
-	s, u, v := t.extractSVD(&svd)
 
 	r, c := m.Dims()
 	min := minimum(t.K, r, c)
@@ -74,6 +175,8 @@ func (t *TruncatedSVD) FitTransform(m mat.Matrix) (mat.Matrix, error) {
 	vk := v.Slice(0, c, 0, min)
 
 	t.Components = uk.(*mat.Dense)
+	t.singularValues = s[:min]
+	t.totalVariance = squaredNorm(m)
 
 	// multiply Sigma by transpose of V.  As sigma is a symmetrical (square) diagonal matrix it is
 	// more efficient to simply multiply each element from the array of diagonal values with each
@@ -86,6 +189,166 @@ func (t *TruncatedSVD) FitTransform(m mat.Matrix) (mat.Matrix, error) {
 	return &product, nil
 }
 
+// FeatureDims returns the number of features (terms) expected in input matrices and
+// the number of dimensions output, as determined by the fitted Components, or -1 if
+// the transformer has not been fitted.
+func (t *TruncatedSVD) FeatureDims() (in, out int) {
+	if t.Components == nil {
+		return -1, -1
+	}
+	return t.Components.Dims()
+}
+
+// PartialFit incrementally updates the model to take account of the new documents
+// (columns) within m using Brand's incremental SVD algorithm ("Fast low-rank
+// modifications of the thin singular value decomposition"), allowing the LSA space to
+// evolve with the corpus without periodic retraining on the full corpus.  The SVD of
+// the previously seen documents augmented with m is computed from the existing
+// Components and singular values and the projection of m onto them, requiring only a
+// small dense SVD of size K + c where c is the number of columns in m.  The result is
+// then truncated back to K dimensions.  The matrix may contain more terms (rows) than
+// previously seen (e.g. as the vocabulary of a CountVectoriser is extended by its
+// PartialFit() method) in which case the new terms are added to the model.  Terms
+// previously seen but absent from the matrix (fewer rows) are treated as not
+// occurring within the new documents.  If the model has not yet been fitted,
+// PartialFit() is equivalent to Fit().  PartialFit() panics if the model cannot be
+// updated, see Update() for details.
+func (t *TruncatedSVD) PartialFit(m mat.Matrix) OnlineTransformer {
+	if err := t.Update(m); err != nil {
+		panic("nlp: Failed to PartialFit truncated SVD because " + err.Error())
+	}
+	return t
+}
+
+// Update is equivalent to PartialFit() but returns an error, rather than panicking,
+// if the model cannot be updated because it has no singular values (e.g. it was loaded
+// from a model saved without them) or the SVD factorisation fails.
+func (t *TruncatedSVD) Update(m mat.Matrix) error {
+	if t.Components == nil {
+		_, err := t.FitTransform(m)
+		return err
+	}
+	if t.singularValues == nil {
+		return fmt.Errorf("nlp: Cannot update truncated SVD model without singular values")
+	}
+	if tc, isTypeConv := m.(sparse.TypeConverter); isTypeConv {
+		m = tc.ToCSR()
+	}
+
+	r, c := m.Dims()
+	ur, k := t.Components.Dims()
+	if r < ur {
+		// pad the matrix with zero rows for the terms absent from the new documents
+		padded := sparse.NewDOK(ur, c)
+		nonZeroDo(m, func(i, j int, v float64) {
+			padded.Set(i, j, v)
+		})
+		m = padded.ToCSR()
+		r = ur
+	}
+	u := t.Components
+	if r > ur {
+		// extend the components with zero rows for the new terms
+		u = mat.NewDense(r, k, nil)
+		u.Slice(0, ur, 0, k).(*mat.Dense).Copy(t.Components)
+	}
+
+	// project the new columns onto the existing components, l = U^T * m, and find the
+	// orthonormal basis j (and coefficients kc) of the residual h = m - U * l = j * kc
+	l := mat.DenseCopyOf(mulSparse(m, u, true).T())
+	var h mat.Dense
+	h.Mul(u, l)
+	h.Scale(-1, &h)
+	nonZeroDo(m, func(i, j int, v float64) {
+		h.Set(i, j, h.At(i, j)+v)
+	})
+	j := mat.DenseCopyOf(&h)
+	orthonormalise(j)
+	var kc mat.Dense
+	kc.Mul(j.T(), &h)
+
+	// q = [ S  l  ]
+	//     [ 0  kc ]
+	q := mat.NewDense(k+c, k+c, nil)
+	for i, v := range t.singularValues {
+		q.Set(i, i, v)
+	}
+	q.Slice(0, k, k, k+c).(*mat.Dense).Copy(l)
+	q.Slice(k, k+c, k, k+c).(*mat.Dense).Copy(&kc)
+
+	var svd mat.SVD
+	if ok := svd.Factorize(q, mat.SVDThin); !ok {
+		return fmt.Errorf("nlp: Failed SVD Factorisation of working matrix")
+	}
+	var uq mat.Dense
+	svd.UTo(&uq)
+	s := svd.Values(nil)
+
+	// rotate the augmented basis [U j] and truncate back to K dimensions
+	basis := mat.NewDense(r, k+c, nil)
+	basis.Slice(0, r, 0, k).(*mat.Dense).Copy(u)
+	basis.Slice(0, r, k, k+c).(*mat.Dense).Copy(j)
+	newK := min(t.K, min(r, k+c))
+	var components mat.Dense
+	components.Mul(basis, uq.Slice(0, k+c, 0, newK))
+
+	t.Components = &components
+	t.singularValues = s[:newK]
+	t.totalVariance += squaredNorm(m)
+
+	return nil
+}
+
+// SingularValues returns the singular values corresponding to each of the components
+// (columns of Components) in descending order.  Plotting the singular values (a scree
+// plot) can help choose an appropriate value for K by identifying the point beyond
+// which additional components contribute little.  Returns nil if the model has not
+// been fitted.
+func (t *TruncatedSVD) SingularValues() []float64 {
+	if t.singularValues == nil {
+		return nil
+	}
+	return append([]float64(nil), t.singularValues...)
+}
+
+// ExplainedVarianceRatio returns the proportion of the variance in the training data
+// explained by each of the components (columns of Components).  As the training data
+// is not centred, variance is measured about the origin i.e. the ratio for each
+// component is its squared singular value divided by the squared Frobenius norm of the
+// training data.  The sum of the ratios indicates how well the truncated model
+// approximates the training data.  Returns nil if the model has not been fitted.
+func (t *TruncatedSVD) ExplainedVarianceRatio() []float64 {
+	if t.singularValues == nil {
+		return nil
+	}
+	ratios := make([]float64, len(t.singularValues))
+	for i, v := range t.singularValues {
+		if t.totalVariance > 0 {
+			ratios[i] = v * v / t.totalVariance
+		}
+	}
+	return ratios
+}
+
+// squaredNorm returns the squared Frobenius norm of m.
+func squaredNorm(m mat.Matrix) float64 {
+	var sum float64
+	nonZeroDo(m, func(i, j int, v float64) {
+		sum += v * v
+	})
+	return sum
+}
+
+// TopTerms returns the k terms with the highest weight within the specified component
+// (column of Components) in descending order of weight.  As components may contain
+// negative weights, terms strongly negatively associated with the component are
+// ranked lowest.  featureNames should contain the name of each term ordered by index
+// (row) in the training matrix e.g. as returned by the GetFeatureNames() method of the
+// vectoriser used to produce it.
+func (t *TruncatedSVD) TopTerms(component, k int, featureNames []string) []string {
+	return topTerms(t.Components, component, true, k, featureNames)
+}
+
 func minimum(k, m, n int) int {
 	return min(k, min(m, n))
 }
@@ -105,13 +368,174 @@ func (t *TruncatedSVD) extractSVD(svd *mat.SVD) (s []float64, u, v *mat.Dense) {
 	return s, &um, &vm
 }
 
-// Save binary serialises the model and writes it into w.  This is useful for persisting
-// a trained model to disk so that it may be loaded (using the Load() method)in another
-// context (e.g. production) for reproducible results.
+// randomisedSVD approximates the top k singular values and vectors of m using the
+// randomised algorithm described by Halko, Martinsson and Tropp in "Finding structure
+// with randomness: Probabilistic algorithms for constructing approximate matrix
+// decompositions".  The range of m is approximated by an orthonormal basis Q computed
+// from the product of m with a random Gaussian matrix of k + oversampling columns,
+// refined using power iterations, and the SVD is then computed exactly from the much
+// smaller matrix Q^T * m.  If mean is not nil, the SVD of m with mean subtracted from
+// each column (i.e. each row i centred by mean[i]) is computed with the centring
+// performed implicitly.  Only the non-zero elements of m are accessed so sparse
+// matrices are never converted to dense.  progress, if not nil, is called after each
+// power iteration and on completion.
+func randomisedSVD(m mat.Matrix, mean []float64, k, oversampling, iterations int, rnd *rand.Rand, progress ProgressFunc) (s []float64, u, v *mat.Dense, err error) {
+	r, c := m.Dims()
+	l := minimum(k+oversampling, r, c)
+
+	if rnd == nil {
+		rnd = rand.New(rand.NewSource(uint64(time.Now().UnixNano())))
+	}
+	omega := mat.NewDense(c, l, nil)
+	omega.Apply(func(i, j int, v float64) float64 {
+		return rnd.NormFloat64()
+	}, omega)
+
+	// sample the range of m and refine using power iterations, re-orthonormalising
+	// after each multiplication to preserve numerical stability
+	q := mulCentred(m, mean, omega, false)
+	orthonormalise(q)
+	progress.report("svd", 0, iterations+1)
+	for i := 0; i < iterations; i++ {
+		z := mulCentred(m, mean, q, true)
+		orthonormalise(z)
+		q = mulCentred(m, mean, z, false)
+		orthonormalise(q)
+		progress.report("svd", i+1, iterations+1)
+	}
+
+	// b = q^T * m calculated as (m^T * q)^T
+	bt := mulCentred(m, mean, q, true)
+
+	var svd mat.SVD
+	if ok := svd.Factorize(bt, mat.SVDThin); !ok {
+		return nil, nil, nil, fmt.Errorf("Failed SVD Factorisation of working matrix")
+	}
+	// as b^T = V * S * Ub^T, the left singular vectors of m are q * Ub and the right
+	// singular vectors are V
+	var ub, vm, um mat.Dense
+	svd.UTo(&vm)
+	svd.VTo(&ub)
+	um.Mul(q, &ub)
+	progress.report("svd", iterations+1, iterations+1)
+
+	return svd.Values(nil), &um, &vm, nil
+}
+
+// mulSparse returns the dense product of m (or the transpose of m if transpose is true)
+// and the dense matrix d, iterating over only the non-zero elements of m.
+func mulSparse(m mat.Matrix, d *mat.Dense, transpose bool) *mat.Dense {
+	r, c := m.Dims()
+	_, dc := d.Dims()
+	if transpose {
+		r = c
+	}
+	product := mat.NewDense(r, dc, nil)
+	nonZeroDo(m, func(i, j int, v float64) {
+		if transpose {
+			i, j = j, i
+		}
+		row := product.RawRowView(i)
+		for k, dv := range d.RawRowView(j) {
+			row[k] += v * dv
+		}
+	})
+	return product
+}
+
+// mulCentred returns the dense product of m (or the transpose of m if transpose is
+// true) and the dense matrix d, as mulSparse, but with mean subtracted from each column
+// of m.  The centring is applied to the product rather than m so that sparse matrices
+// are never converted to dense.  If mean is nil, no centring is performed.
+func mulCentred(m mat.Matrix, mean []float64, d *mat.Dense, transpose bool) *mat.Dense {
+	product := mulSparse(m, d, transpose)
+	if mean == nil {
+		return product
+	}
+
+	// (m - mean * 1^T) * d = m * d - mean * (1^T * d)
+	// (m - mean * 1^T)^T * d = m^T * d - 1 * (mean^T * d)
+	r, c := d.Dims()
+	adjust := make([]float64, c)
+	for i := 0; i < r; i++ {
+		for j, v := range d.RawRowView(i) {
+			if transpose {
+				adjust[j] += mean[i] * v
+			} else {
+				adjust[j] += v
+			}
+		}
+	}
+	pr, _ := product.Dims()
+	for i := 0; i < pr; i++ {
+		row := product.RawRowView(i)
+		for j := range row {
+			if transpose {
+				row[j] -= adjust[j]
+			} else {
+				row[j] -= mean[i] * adjust[j]
+			}
+		}
+	}
+	return product
+}
+
+// orthonormalise orthonormalises the columns of m in place using modified Gram-Schmidt.
+// Columns that are linearly dependent upon previous columns are set to zero.
+func orthonormalise(m *mat.Dense) {
+	_, c := m.Dims()
+	for j := 0; j < c; j++ {
+		col := m.ColView(j).(*mat.VecDense)
+		for k := 0; k < j; k++ {
+			prev := m.ColView(k)
+			col.AddScaledVec(col, -mat.Dot(prev, col), prev)
+		}
+		if norm := mat.Norm(col, 2); norm > 1e-10 {
+			col.ScaleVec(1/norm, col)
+		} else {
+			col.Zero()
+		}
+	}
+}
+
+// svdVersion is the version of the serialisation format written by Save().  Models
+// saved by earlier releases contain only K and the Components, without the magic
+// number and header.
+const svdVersion = 1
+
+// svdHeader is the fixed size portion of a serialised TruncatedSVD following the magic
+// number.
+type svdHeader struct {
+	Version         int64
+	K               int64
+	Randomised      bool
+	Oversampling    int64
+	PowerIterations int64
+	TotalVariance   float64
+	Values          int64
+}
+
+// Save binary serialises the model, including its hyperparameters, and writes it into
+// w.  This is useful for persisting a trained model to disk so that it may be loaded
+// (using the Load() method) in another context (e.g. production) for reproducible
+// results.
 func (t TruncatedSVD) Save(w io.Writer) error {
-	var buf [8]byte
-	binary.LittleEndian.PutUint64(buf[:], uint64(t.K))
-	if _, err := w.Write(buf[:]); err != nil {
+	if _, err := w.Write(modelMagic[:]); err != nil {
+		return err
+	}
+	header := svdHeader{
+		Version:         svdVersion,
+		K:               int64(t.K),
+		Randomised:      t.Randomised,
+		Oversampling:    int64(t.Oversampling),
+		PowerIterations: int64(t.PowerIterations),
+		TotalVariance:   t.totalVariance,
+		Values:          int64(len(t.singularValues)),
+	}
+	if err := binary.Write(w, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, t.singularValues); err != nil {
 		return err
 	}
 
@@ -123,31 +547,66 @@ func (t TruncatedSVD) Save(w io.Writer) error {
 // Load binary deserialises the previously serialised model into the receiver.  This is
 // useful for loading a previously trained and saved model from another context
 // (e.g. offline training) for use within another context (e.g. production) for
-// reproducible results.  Load should only be performed with trusted data.
+// reproducible results.  Load should only be performed with trusted data.  Models
+// saved by earlier releases are loaded without singular values, retaining the other
+// hyperparameters of the receiver, so do not support PartialFit() (see Update()) or
+// ExplainedVarianceRatio().  An error is returned if the serialisation format version
+// is not supported.
 func (t *TruncatedSVD) Load(r io.Reader) error {
-	var n int
-	var buf [8]byte
-	var err error
-	for n < len(buf) && err == nil {
-		var nn int
-		nn, err = r.Read(buf[n:])
-		n += nn
+	r, versioned, err := readMagic(r)
+	if err != nil {
+		return err
 	}
-	if err == io.EOF {
-		return io.ErrUnexpectedEOF
+	if !versioned {
+		return t.loadLegacy(r)
 	}
-	if err != nil {
+	var header svdHeader
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
 		return err
 	}
-	k := int(binary.LittleEndian.Uint64(buf[:]))
+	if header.Version != svdVersion {
+		return fmt.Errorf("nlp: Unsupported serialisation version %d", header.Version)
+	}
+	var values []float64
+	if header.Values > 0 {
+		values = make([]float64, header.Values)
+		if err := binary.Read(r, binary.LittleEndian, values); err != nil {
+			return err
+		}
+	}
+
+	var model mat.Dense
+	if _, err := model.UnmarshalBinaryFrom(r); err != nil {
+		return err
+	}
+
+	t.K = int(header.K)
+	t.Randomised = header.Randomised
+	t.Oversampling = int(header.Oversampling)
+	t.PowerIterations = int(header.PowerIterations)
+	t.Components = &model
+	t.singularValues = values
+	t.totalVariance = header.TotalVariance
+
+	return nil
+}
 
+// loadLegacy binary deserialises a model saved by an earlier release, consisting of K
+// followed by the Components, from r into the receiver.
+func (t *TruncatedSVD) loadLegacy(r io.Reader) error {
+	var k uint64
+	if err := binary.Read(r, binary.LittleEndian, &k); err != nil {
+		return err
+	}
 	var model mat.Dense
 	if _, err := model.UnmarshalBinaryFrom(r); err != nil {
 		return err
 	}
 
-	t.K = k
+	t.K = int(k)
 	t.Components = &model
+	t.singularValues = nil
+	t.totalVariance = 0
 
 	return nil
 }
@@ -159,18 +618,49 @@ type PCA struct {
 	// K is the number of components
 	K  int
 	pc *stat.PC
+
+	// Implicit specifies whether the principal components should be computed using a
+	// randomised SVD with the mean centring of the matrix performed implicitly.  This
+	// avoids materialising the dense, centred, matrix allowing the principal components
+	// of large sparse matrices (e.g. TF-IDF weighted term document matrices) to be
+	// computed without exhausting memory.
+	Implicit bool
+
+	// Oversampling and PowerIterations control the accuracy of the randomised SVD used
+	// when Implicit is true (see TruncatedSVD).
+	Oversampling    int
+	PowerIterations int
+
+	// Rnd is the random number source used by the randomised SVD when Implicit is true.
+	Rnd *rand.Rand
+
+	components *mat.Dense
+	variances  []float64
 }
 
 // NewPCA constructs a new Principal Component Analysis transformer to reduce the dimensionality,
 // projecting matrices onto the axis of greatest variance
 func NewPCA(k int) *PCA {
-	return &PCA{K: k, pc: &stat.PC{}}
+	return &PCA{
+		K:               k,
+		pc:              &stat.PC{},
+		Oversampling:    10,
+		PowerIterations: 4,
+		Rnd:             rand.New(rand.NewSource(uint64(time.Now().UnixNano()))),
+	}
 }
 
 // Fit calculates the principal component directions (axis of greatest variance) within the
 // training data which can then be used to project matrices onto those principal components using
 // the Transform() method.
 func (p *PCA) Fit(m mat.Matrix) Transformer {
+	if p.Implicit {
+		if err := p.fitImplicit(m); err != nil {
+			panic("nlp: PCA analysis failed during fitting because " + err.Error())
+		}
+		return p
+	}
+
 	if ok := p.pc.PrincipalComponents(m.T(), nil); !ok {
 		panic("nlp: PCA analysis failed during fitting")
 	}
@@ -178,10 +668,48 @@ func (p *PCA) Fit(m mat.Matrix) Transformer {
 	return p
 }
 
+// fitImplicit calculates the principal components of m using a randomised SVD of m
+// with the mean of each row (feature) implicitly subtracted.
+func (p *PCA) fitImplicit(m mat.Matrix) error {
+	if t, isTypeConv := m.(sparse.TypeConverter); isTypeConv {
+		m = t.ToCSR()
+	}
+	r, c := m.Dims()
+
+	mean := make([]float64, r)
+	nonZeroDo(m, func(i, j int, v float64) {
+		mean[i] += v
+	})
+	for i := range mean {
+		mean[i] /= float64(c)
+	}
+
+	s, u, _, err := randomisedSVD(m, mean, p.K, p.Oversampling, p.PowerIterations, p.Rnd, nil)
+	if err != nil {
+		return err
+	}
+
+	k := minimum(p.K, r, c)
+	p.components = mat.DenseCopyOf(u.Slice(0, r, 0, k))
+	p.variances = make([]float64, k)
+	for i := range p.variances {
+		p.variances[i] = s[i] * s[i] / float64(c-1)
+	}
+
+	return nil
+}
+
 // Transform projects the matrix onto the first K principal components calculated during training
 // (the Fit() method).  The returned matrix will be of reduced dimensionality compared to the input
 // (K x c compared to r x c of the input).
 func (p *PCA) Transform(m mat.Matrix) (mat.Matrix, error) {
+	if p.Implicit {
+		if t, isTypeConv := m.(sparse.TypeConverter); isTypeConv {
+			m = t.ToCSR()
+		}
+		return mat.DenseCopyOf(mulSparse(m, p.components, true).T()), nil
+	}
+
 	r, _ := m.Dims()
 
 	//var proj mat.Dense
@@ -209,5 +737,10 @@ func (p *PCA) FitTransform(m mat.Matrix) (mat.Matrix, error) {
 // ExplainedVariance returns a slice of float64 values representing the variances of the
 // principal component scores.
 func (p *PCA) ExplainedVariance() []float64 {
+	if p.Implicit {
+		variances := make([]float64, len(p.variances))
+		copy(variances, p.variances)
+		return variances
+	}
 	return p.pc.VarsTo(nil)
 }
@@ -2,8 +2,14 @@ package nlp
 
 import (
 	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
 	"testing"
 
+	"golang.org/x/exp/rand"
+
+	"github.com/james-bowman/sparse"
 	"gonum.org/v1/gonum/mat"
 )
 
@@ -69,6 +75,252 @@ func TestTruncatedSVDFitTransform(t *testing.T) {
 	}
 }
 
+func TestTruncatedSVDRandomised(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	// construct a 60 x 40 sparse matrix of rank 3
+	factors := mat.NewDense(60, 3, nil)
+	loadings := mat.NewDense(3, 40, nil)
+	factors.Apply(func(i, j int, v float64) float64 {
+		if rnd.Float64() < 0.3 {
+			return float64(rnd.Intn(5) + 1)
+		}
+		return 0
+	}, factors)
+	loadings.Apply(func(i, j int, v float64) float64 {
+		if rnd.Float64() < 0.5 {
+			return float64(rnd.Intn(3) + 1)
+		}
+		return 0
+	}, loadings)
+	var dense mat.Dense
+	dense.Mul(factors, loadings)
+	coo := sparse.NewCOO(60, 40, nil, nil, nil)
+	nonZeroDo(&dense, func(i, j int, v float64) {
+		coo.Set(i, j, v)
+	})
+
+	var tests = []struct {
+		k               int
+		oversampling    int
+		powerIterations int
+	}{
+		{k: 3, oversampling: 0, powerIterations: 0},
+		{k: 3, oversampling: 5, powerIterations: 2},
+		{k: 2, oversampling: 10, powerIterations: 4},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+		exact := NewTruncatedSVD(test.k)
+		expected, err := exact.FitTransform(&dense)
+		if err != nil {
+			t.Errorf("Failed Truncated SVD transform caused by %v", err)
+		}
+
+		transformer := NewTruncatedSVD(test.k)
+		transformer.Randomised = true
+		transformer.Oversampling = test.oversampling
+		transformer.PowerIterations = test.powerIterations
+		transformer.Rnd = rand.New(rand.NewSource(uint64(testRun)))
+		result, err := transformer.FitTransform(coo.ToCSR())
+		if err != nil {
+			t.Errorf("Failed randomised Truncated SVD transform caused by %v", err)
+		}
+
+		// singular vectors are only unique up to sign so compare the projection of the
+		// input onto the singular vectors (the rank k approximation)
+		var expectedApprox, approx mat.Dense
+		expectedApprox.Mul(exact.Components, expected)
+		approx.Mul(transformer.Components, result)
+		if !mat.EqualApprox(&expectedApprox, &approx, 1e-6) {
+			t.Errorf("Expected rank %d approximation: \n%v\n but found: \n%v\n", test.k,
+				mat.Formatted(&expectedApprox), mat.Formatted(&approx))
+		}
+
+		result2, _ := transformer.Transform(&dense)
+		if !mat.EqualApprox(result, result2, 1e-6) {
+			t.Errorf("Expected Transform() to match FitTransform() but found: \n%v\n and: \n%v\n",
+				mat.Formatted(result), mat.Formatted(result2))
+		}
+	}
+}
+
+func TestTruncatedSVDPartialFit(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	// construct a 30 x 20 matrix of rank 4
+	factors := mat.NewDense(30, 4, nil)
+	loadings := mat.NewDense(4, 20, nil)
+	for _, m := range []*mat.Dense{factors, loadings} {
+		m.Apply(func(i, j int, v float64) float64 {
+			return float64(rnd.Intn(4))
+		}, m)
+	}
+	var input mat.Dense
+	input.Mul(factors, loadings)
+
+	var tests = []struct {
+		k       int
+		batches []int
+	}{
+		{k: 4, batches: []int{8, 12, 20}},
+		{k: 6, batches: []int{5, 6, 10, 20}},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+		exact := NewTruncatedSVD(test.k)
+		expected, _ := exact.FitTransform(&input)
+
+		var transformer OnlineTransformer = NewTruncatedSVD(test.k)
+		var start int
+		for _, end := range test.batches {
+			transformer = transformer.PartialFit(input.Slice(0, 30, start, end))
+			start = end
+		}
+		result, err := transformer.Transform(&input)
+		if err != nil {
+			t.Errorf("Failed Truncated SVD transform caused by %v", err)
+		}
+
+		// singular vectors are only unique up to sign so compare the rank k approximations
+		var expectedApprox, approx mat.Dense
+		expectedApprox.Mul(exact.Components, expected)
+		approx.Mul(transformer.(*TruncatedSVD).Components, result)
+		if !mat.EqualApprox(&expectedApprox, &approx, 1e-6) {
+			t.Errorf("Expected rank %d approximation: \n%v\n but found: \n%v\n", test.k,
+				mat.Formatted(&expectedApprox), mat.Formatted(&approx))
+		}
+	}
+
+	// new terms should be added to the model
+	transformer := NewTruncatedSVD(4)
+	transformer.PartialFit(input.Slice(0, 25, 0, 10))
+	transformer.PartialFit(input.Slice(0, 30, 10, 20))
+	if r, c := transformer.Components.Dims(); r != 30 || c != 4 {
+		t.Errorf("Expected components of dimensions 30 x 4 but found %d x %d", r, c)
+	}
+
+	// terms absent from the new documents should be treated as not occurring
+	padded := mat.NewDense(30, 10, nil)
+	padded.Slice(0, 25, 0, 10).(*mat.Dense).Copy(input.Slice(0, 25, 10, 20))
+	expected := NewTruncatedSVD(4)
+	expected.PartialFit(input.Slice(0, 30, 0, 10))
+	expected.PartialFit(padded)
+	transformer = NewTruncatedSVD(4)
+	transformer.PartialFit(input.Slice(0, 30, 0, 10))
+	transformer.PartialFit(input.Slice(0, 25, 10, 20))
+	if !mat.EqualApprox(expected.Components, transformer.Components, 1e-12) {
+		t.Errorf("Expected components: \n%v\n but found: \n%v\n",
+			mat.Formatted(expected.Components), mat.Formatted(transformer.Components))
+	}
+
+	// models without singular values cannot be updated
+	transformer = NewTruncatedSVD(4)
+	transformer.Components = mat.DenseCopyOf(expected.Components)
+	if err := transformer.Update(&input); err == nil {
+		t.Errorf("Expected error updating model without singular values but received nil")
+	}
+}
+
+func TestTruncatedSVDExplainedVariance(t *testing.T) {
+	input := mat.NewDense(5, 4, []float64{
+		2, 0, 1, 0,
+		1, 3, 0, 0,
+		0, 1, 0, 4,
+		0, 0, 2, 1,
+		1, 0, 0, 2,
+	})
+
+	var tests = []struct {
+		k       int
+		partial bool
+		sum     float64
+	}{
+		{k: 4, sum: 1},
+		{k: 2},
+		{k: 4, partial: true, sum: 1},
+	}
+
+	var svd mat.SVD
+	svd.Factorize(input, mat.SVDNone)
+	values := svd.Values(nil)
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		transformer := NewTruncatedSVD(test.k)
+		if test.partial {
+			transformer.Fit(input.Slice(0, 5, 0, 2))
+			transformer.PartialFit(input.Slice(0, 5, 2, 4))
+		} else {
+			transformer.Fit(input)
+		}
+
+		s := transformer.SingularValues()
+		ratios := transformer.ExplainedVarianceRatio()
+		if len(s) != test.k || len(ratios) != test.k {
+			t.Errorf("Expected %d singular values and ratios but found %d and %d", test.k, len(s), len(ratios))
+			continue
+		}
+
+		var sum float64
+		for i := range ratios {
+			if math.Abs(values[i]-s[i]) > 1e-9 {
+				t.Errorf("Expected singular value %f but found %f", values[i], s[i])
+			}
+			if i > 0 && ratios[i] > ratios[i-1] {
+				t.Errorf("Expected ratios in descending order but found %v", ratios)
+			}
+			sum += ratios[i]
+		}
+		if test.sum != 0 && math.Abs(test.sum-sum) > 1e-9 {
+			t.Errorf("Expected ratios to sum to %f but found %f", test.sum, sum)
+		}
+		if test.sum == 0 && (sum <= 0 || sum >= 1) {
+			t.Errorf("Expected ratios of truncated model to sum to between 0 and 1 but found %f", sum)
+		}
+	}
+
+	var buf bytes.Buffer
+	transformer := NewTruncatedSVD(2)
+	transformer.Fit(input)
+	transformer.Save(&buf)
+	loaded := NewTruncatedSVD(2)
+	loaded.Load(&buf)
+	if !reflect.DeepEqual(transformer.SingularValues(), loaded.SingularValues()) ||
+		!reflect.DeepEqual(transformer.ExplainedVarianceRatio(), loaded.ExplainedVarianceRatio()) {
+		t.Errorf("Expected singular values and ratios of loaded model to match those of the saved model")
+	}
+}
+
+func TestTruncatedSVDTopTerms(t *testing.T) {
+	transformer := NewTruncatedSVD(2)
+	transformer.Components = mat.NewDense(4, 2, []float64{
+		0.1, -0.7,
+		0.6, 0.2,
+		-0.3, 0.5,
+		0.4, 0.1,
+	})
+	names := []string{"cat", "dog", "pet", "car"}
+
+	var tests = []struct {
+		component int
+		k         int
+		expected  []string
+	}{
+		{component: 0, k: 2, expected: []string{"dog", "car"}},
+		{component: 1, k: 3, expected: []string{"pet", "dog", "car"}},
+		{component: 1, k: 5, expected: []string{"pet", "dog", "car", "cat"}},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+		if top := transformer.TopTerms(test.component, test.k, names); !reflect.DeepEqual(test.expected, top) {
+			t.Errorf("Expected top terms %v but found %v", test.expected, top)
+		}
+	}
+}
+
 func TestPCAFitTransform(t *testing.T) {
 	var tests = []struct {
 		m      int
@@ -131,6 +383,48 @@ func TestPCAFitTransform(t *testing.T) {
 	}
 }
 
+func TestPCAImplicit(t *testing.T) {
+	input := sparse.NewCSR(6, 4, []int{0, 4, 6, 9, 9, 10, 11}, []int{0, 1, 2, 3, 0, 1, 0, 1, 3, 3, 1}, []float64{
+		1, 3, 5, 2,
+		8, 1,
+		2, 1, 1,
+		1,
+		1,
+	})
+
+	exact := NewPCA(2)
+	expected, err := exact.FitTransform(input.ToDense())
+	if err != nil {
+		t.Errorf("Failed PCA transform caused by %v", err)
+	}
+
+	transformer := NewPCA(2)
+	transformer.Implicit = true
+	transformer.Rnd = rand.New(rand.NewSource(uint64(0)))
+	result, err := transformer.FitTransform(input)
+	if err != nil {
+		t.Errorf("Failed implicit PCA transform caused by %v", err)
+	}
+
+	// principal components are only unique up to sign
+	r, c := expected.Dims()
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			if math.Abs(math.Abs(expected.At(i, j))-math.Abs(result.At(i, j))) > 1e-6 {
+				t.Errorf("Expected matrix: \n%v\n but found: \n%v\n", mat.Formatted(expected), mat.Formatted(result))
+				break
+			}
+		}
+	}
+
+	variances := transformer.ExplainedVariance()
+	for i, v := range exact.ExplainedVariance()[:2] {
+		if math.Abs(v-variances[i]) > 1e-6 {
+			t.Errorf("Expected explained variance %v but found %v", exact.ExplainedVariance()[:2], variances)
+		}
+	}
+}
+
 func TestTruncatedSVDSaveLoad(t *testing.T) {
 	var transforms = []struct {
 		wanted *TruncatedSVD
@@ -146,6 +440,21 @@ func TestTruncatedSVDSaveLoad(t *testing.T) {
 				K: 2,
 			},
 		},
+		{
+			wanted: &TruncatedSVD{
+				Components: mat.NewDense(3, 2, []float64{
+					0.5, 0.1,
+					0.2, 0.7,
+					0.4, 0.3,
+				}),
+				K:               2,
+				Randomised:      true,
+				Oversampling:    5,
+				PowerIterations: 2,
+				singularValues:  []float64{4.5, 1.2},
+				totalVariance:   23.1,
+			},
+		},
 	}
 
 	for ti, test := range transforms {
@@ -171,5 +480,111 @@ func TestTruncatedSVDSaveLoad(t *testing.T) {
 			t.Logf("K value mismatch: Wanted %d but got %d\n", test.wanted.K, b.K)
 			t.Fail()
 		}
+		if test.wanted.Randomised != b.Randomised || test.wanted.Oversampling != b.Oversampling || test.wanted.PowerIterations != b.PowerIterations {
+			t.Errorf("Hyperparameter mismatch: Wanted %v, %d, %d but got %v, %d, %d\n",
+				test.wanted.Randomised, test.wanted.Oversampling, test.wanted.PowerIterations,
+				b.Randomised, b.Oversampling, b.PowerIterations)
+		}
+		if !reflect.DeepEqual(test.wanted.singularValues, b.singularValues) || test.wanted.totalVariance != b.totalVariance {
+			t.Errorf("Singular values mismatch: Wanted %v (%f) but got %v (%f)\n",
+				test.wanted.singularValues, test.wanted.totalVariance, b.singularValues, b.totalVariance)
+		}
+	}
+}
+
+func TestTruncatedSVDLoadLegacy(t *testing.T) {
+	components := mat.NewDense(4, 2, []float64{
+		1, 5,
+		3, 2,
+		9, 0,
+		8, 4,
+	})
+
+	// models saved by earlier releases contain only K and the components
+	buf := new(bytes.Buffer)
+	if err := binary.Write(buf, binary.LittleEndian, uint64(2)); err != nil {
+		t.Fatalf("Error encoding: %v\n", err)
+	}
+	if _, err := components.MarshalBinaryTo(buf); err != nil {
+		t.Fatalf("Error encoding: %v\n", err)
+	}
+
+	b := NewTruncatedSVD(5)
+	if err := b.Load(buf); err != nil {
+		t.Fatalf("Error unencoding: %v\n", err)
+	}
+	if b.K != 2 || !mat.Equal(components, b.Components) {
+		t.Errorf("Expected K 2 and components %v but found %d and %v", mat.Formatted(components), b.K, mat.Formatted(b.Components))
+	}
+	if b.SingularValues() != nil {
+		t.Errorf("Expected no singular values but found %v", b.SingularValues())
+	}
+	if err := b.Update(mat.NewDense(4, 1, []float64{1, 0, 2, 0})); err == nil {
+		t.Errorf("Expected error updating legacy model but received nil")
+	}
+
+	// unsupported versions should be rejected
+	buf.Reset()
+	if err := b.Save(buf); err != nil {
+		t.Fatalf("Error encoding: %v\n", err)
+	}
+	buf.Bytes()[len(modelMagic)] = 99
+	if err := b.Load(buf); err == nil {
+		t.Errorf("Expected error loading unsupported version but received nil")
+	}
+}
+
+func TestTruncatedSVDInverseTransform(t *testing.T) {
+	input := mat.NewDense(5, 4, []float64{
+		1, 0, 2, 0,
+		0, 3, 1, 0,
+		2, 1, 0, 4,
+		1, 1, 1, 1,
+		0, 2, 0, 3,
+	})
+
+	var tests = []struct {
+		k       int
+		maxDiff float64
+	}{
+		{k: 4, maxDiff: 1e-10},
+		{k: 2, maxDiff: 3},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		svd := NewTruncatedSVD(test.k)
+		reduced, err := svd.FitTransform(input)
+		if err != nil {
+			t.Errorf("Failed to fit SVD because %v", err)
+		}
+		result, err := svd.InverseTransform(reduced)
+		if err != nil {
+			t.Errorf("Failed to inverse transform because %v", err)
+		}
+
+		var diff mat.Dense
+		diff.Sub(input, result)
+		if norm := mat.Norm(&diff, 2); norm > test.maxDiff {
+			t.Errorf("Expected reconstruction error less than %f but was %f", test.maxDiff, norm)
+		}
+
+		// the reconstruction should be the closest rank k approximation
+		var full mat.SVD
+		full.Factorize(input, mat.SVDThin)
+		var u, v mat.Dense
+		full.UTo(&u)
+		full.VTo(&v)
+		values := full.Values(nil)
+		sigma := mat.NewDense(test.k, test.k, nil)
+		for i := 0; i < test.k; i++ {
+			sigma.Set(i, i, values[i])
+		}
+		var expected mat.Dense
+		expected.Product(u.Slice(0, 5, 0, test.k), sigma, v.Slice(0, 4, 0, test.k).T())
+		if !mat.EqualApprox(&expected, result, 1e-10) {
+			t.Errorf("Expected matrix:\n%v\nbut found:\n%v", mat.Formatted(&expected), mat.Formatted(result))
+		}
 	}
 }
@@ -0,0 +1,220 @@
+package nlp
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Doc2Vec learns dense vector representations of documents (paragraph vectors)
+// directly from a corpus using the PV-DBOW (Distributed Bag of Words) model described
+// by Le and Mikolov in "Distributed Representations of Sentences and Documents"
+// (https://arxiv.org/pdf/1405.4053.pdf).  Each document vector is trained to predict
+// the terms occurring within the document using negative sampling.  Documents using
+// similar terms, or terms that occur in similar documents, are therefore represented
+// by similar vectors, providing an alternative to LSA (TruncatedSVD) for semantic
+// document representations.  Vectors for documents not present within the training
+// data are inferred by training a new document vector whilst holding the rest of the
+// model fixed.
+type Doc2Vec struct {
+	// K is the dimensionality of the document vectors
+	K int
+
+	// Epochs is the number of training passes across the documents during Fit() and
+	// across each document when inferring vectors during Transform()
+	Epochs int
+
+	// Negative is the number of negative (noise) terms sampled for each term
+	Negative int
+
+	// LearningRate is the initial learning rate which decays linearly to
+	// MinLearningRate over the course of training
+	LearningRate float64
+
+	// MinLearningRate is the final learning rate
+	MinLearningRate float64
+
+	// MinCount is the minimum number of times a term must occur within the training
+	// documents to be included in the vocabulary.  Less frequent terms are ignored.
+	MinCount int
+
+	// Tokeniser is used to tokenise input text into terms
+	Tokeniser Tokeniser
+
+	// Orientation specifies the layout of matrices output from Transform().  By
+	// default (TermsAsRows) each row represents a dimension of the document vectors and
+	// each column a document.  If set to DocumentsAsRows, the output matrices are
+	// transposed so that each row represents a document.
+	Orientation Orientation
+
+	// Rnd is the random number generator used to initialise vectors and sample
+	// negative terms
+	Rnd *rand.Rand
+
+	// Vocabulary is a map of terms to indices learnt during Fit()
+	Vocabulary map[string]int
+
+	// docs holds the document vectors learnt during Fit() (documents x K)
+	docs []float64
+
+	// words holds the output vectors of each term in the Vocabulary (terms x K)
+	words []float64
+
+	// noise is the cumulative distribution from which negative terms are sampled
+	noise []float64
+}
+
+// NewDoc2Vec creates a new Doc2Vec learning document vectors of k dimensions with
+// default values of 20 Epochs, 5 Negative samples, a LearningRate of 0.025 decaying
+// to 0.0001 and a MinCount of 1.  If stopWords is not an empty slice then stop words
+// will be removed.
+func NewDoc2Vec(k int, stopWords ...string) *Doc2Vec {
+	return &Doc2Vec{
+		K:               k,
+		Epochs:          20,
+		Negative:        5,
+		LearningRate:    0.025,
+		MinLearningRate: 0.0001,
+		MinCount:        1,
+		Tokeniser:       NewTokeniser(stopWords...),
+		Rnd:             rand.New(rand.NewSource(uint64(time.Now().UnixNano()))),
+	}
+}
+
+// Fit trains document vectors for the specified training documents along with the
+// output vectors of the terms within them used to infer vectors for new documents.  If
+// there are no training documents, or no terms occur at least MinCount times, the
+// model is left unfitted and subsequent calls to Transform() return an error.
+func (v *Doc2Vec) Fit(train ...string) Vectoriser {
+	v.Vocabulary = nil
+	v.fit(v.tokenise(train))
+	return v
+}
+
+// FitTransform trains the model on the specified documents, as Fit(), and returns
+// the trained document vectors.  The returned matrix is a dense matrix of shape K x C
+// where C is the number of documents (or C x K if Orientation is DocumentsAsRows).  An
+// error is returned if there are no documents or no terms occur at least MinCount
+// times.
+func (v *Doc2Vec) FitTransform(docs ...string) (mat.Matrix, error) {
+	v.Vocabulary = nil
+	if err := v.fit(v.tokenise(docs)); err != nil {
+		return nil, err
+	}
+
+	r, c := v.Orientation.index(v.K, len(docs))
+	matrix := mat.NewDense(r, c, nil)
+	for d := range docs {
+		for i, x := range v.docs[d*v.K : (d+1)*v.K] {
+			r, c := v.Orientation.index(i, d)
+			matrix.Set(r, c, x)
+		}
+	}
+	return matrix, nil
+}
+
+// Transform infers vectors for the specified documents using the model trained during
+// Fit().  A new vector is trained for each document over Epochs passes whilst holding
+// the output vectors of the terms fixed.  Terms not present within the Vocabulary are
+// ignored.  The returned matrix is a dense matrix of shape K x C where C is the number
+// of documents (or C x K if Orientation is DocumentsAsRows).
+func (v *Doc2Vec) Transform(docs ...string) (mat.Matrix, error) {
+	if v.words == nil {
+		return nil, fmt.Errorf("nlp: Doc2Vec must be fitted before calling Transform")
+	}
+	r, c := v.Orientation.index(v.K, len(docs))
+	matrix := mat.NewDense(r, c, nil)
+	grad := make([]float64, v.K)
+
+	for d, doc := range v.tokenise(docs) {
+		vec := v.initVector()
+		for epoch := 0; epoch < v.Epochs; epoch++ {
+			rate := v.rate(float64(epoch) / float64(v.Epochs))
+			for _, word := range doc {
+				negativeSampling(vec, v.words, word, v.Negative, v.noise, v.Rnd, rate, grad, false)
+			}
+		}
+		for i, x := range vec {
+			r, c := v.Orientation.index(i, d)
+			matrix.Set(r, c, x)
+		}
+	}
+	return matrix, nil
+}
+
+// tokenise converts the documents into slices of indices of terms within the
+// Vocabulary.  If the Vocabulary is nil, it is first built from the documents.
+func (v *Doc2Vec) tokenise(docs []string) [][]int {
+	tokens := make([][]string, len(docs))
+	for d, doc := range docs {
+		tokenise(v.Tokeniser, doc)(func(token string) {
+			tokens[d] = append(tokens[d], token)
+		})
+	}
+	if v.Vocabulary == nil {
+		var counts []int
+		v.Vocabulary, counts = learnVocabulary(tokens, v.MinCount)
+		v.noise = unigramNoise(counts)
+	}
+
+	indices := make([][]int, len(docs))
+	for d, doc := range tokens {
+		for _, token := range doc {
+			if i, exists := v.Vocabulary[token]; exists {
+				indices[d] = append(indices[d], i)
+			}
+		}
+	}
+	return indices
+}
+
+// fit trains the document and term output vectors for the tokenised documents
+// returning an error, and leaving the model unfitted, if there are no documents or
+// the Vocabulary is empty.
+func (v *Doc2Vec) fit(docs [][]int) error {
+	v.docs, v.words = nil, nil
+	if len(docs) == 0 {
+		return fmt.Errorf("nlp: No documents to fit Doc2Vec")
+	}
+	if len(v.Vocabulary) == 0 {
+		return fmt.Errorf("nlp: No terms occur at least %d times in the documents", v.MinCount)
+	}
+	v.docs = make([]float64, len(docs)*v.K)
+	for d := range docs {
+		copy(v.docs[d*v.K:(d+1)*v.K], v.initVector())
+	}
+	v.words = make([]float64, len(v.noise)*v.K)
+
+	order := make([]int, len(docs))
+	for i := range order {
+		order[i] = i
+	}
+	grad := make([]float64, v.K)
+	for epoch := 0; epoch < v.Epochs; epoch++ {
+		rate := v.rate(float64(epoch) / float64(v.Epochs))
+		v.Rnd.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+		for _, d := range order {
+			vec := v.docs[d*v.K : (d+1)*v.K]
+			for _, word := range docs[d] {
+				negativeSampling(vec, v.words, word, v.Negative, v.noise, v.Rnd, rate, grad, true)
+			}
+		}
+	}
+	return nil
+}
+
+// initVector returns a new randomly initialised document vector.
+func (v *Doc2Vec) initVector() []float64 {
+	vec := make([]float64, v.K)
+	for i := range vec {
+		vec[i] = (v.Rnd.Float64() - 0.5) / float64(v.K)
+	}
+	return vec
+}
+
+// rate returns the learning rate after the specified proportion of training.
+func (v *Doc2Vec) rate(progress float64) float64 {
+	return v.LearningRate - (v.LearningRate-v.MinLearningRate)*progress
+}
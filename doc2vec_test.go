@@ -0,0 +1,121 @@
+package nlp
+
+import (
+	"testing"
+
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestDoc2Vec(t *testing.T) {
+	train := []string{
+		"cat dog pet kitten",
+		"dog puppy pet cat",
+		"kitten cat puppy dog",
+		"pet dog kitten puppy",
+		"car road wheel truck",
+		"truck wheel traffic car",
+		"road traffic car wheel",
+		"wheel truck road traffic",
+	}
+
+	var tests = []struct {
+		orientation Orientation
+		doc         string
+		similar     []int
+	}{
+		{orientation: TermsAsRows, doc: "the dog and the cat are pets", similar: []int{0, 1, 2, 3}},
+		{orientation: DocumentsAsRows, doc: "the car has a wheel on the road", similar: []int{4, 5, 6, 7}},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		d2v := NewDoc2Vec(10)
+		// set Rnd to fixed constant seed for deterministic results
+		d2v.Rnd = rand.New(rand.NewSource(uint64(0)))
+		d2v.Epochs = 200
+		d2v.Orientation = test.orientation
+
+		trained, err := d2v.FitTransform(train...)
+		if err != nil {
+			t.Errorf("Failed to fit Doc2Vec because %v", err)
+		}
+		inferred, err := d2v.Transform(test.doc)
+		if err != nil {
+			t.Errorf("Failed to transform using Doc2Vec because %v", err)
+		}
+
+		if r, c := trained.Dims(); r != len(train) && c != len(train) {
+			t.Errorf("Expected %d documents but found dims %dx%d", len(train), r, c)
+		}
+		er, ec := test.orientation.index(10, 1)
+		if r, c := inferred.Dims(); r != er || c != ec {
+			t.Errorf("Expected dims %dx%d but found %dx%d", er, ec, r, c)
+		}
+
+		vector := func(m mat.Matrix, d int) mat.Vector {
+			if test.orientation == DocumentsAsRows {
+				return m.(mat.RowViewer).RowView(d)
+			}
+			return m.(mat.ColViewer).ColView(d)
+		}
+
+		// the inferred vector should be more similar, on average, to the training
+		// documents sharing its subject than to the others
+		query := vector(inferred, 0)
+		similar := make(map[int]bool)
+		for _, d := range test.similar {
+			similar[d] = true
+		}
+		var same, other float64
+		for d := range train {
+			if similar[d] {
+				same += cosine(query, vector(trained, d))
+			} else {
+				other += cosine(query, vector(trained, d))
+			}
+		}
+		same /= float64(len(test.similar))
+		other /= float64(len(train) - len(test.similar))
+		if same <= other {
+			t.Errorf("Expected inferred vector to be closest to documents %v but mean similarity was %f vs %f for others", test.similar, same, other)
+		}
+	}
+}
+
+func TestDoc2VecTransformBeforeFit(t *testing.T) {
+	d2v := NewDoc2Vec(10)
+	if _, err := d2v.Transform("the cat sat"); err == nil {
+		t.Errorf("Expected error transforming before fitting but found none")
+	}
+}
+
+func TestDoc2VecEmptyCorpus(t *testing.T) {
+	var tests = []struct {
+		minCount int
+		docs     []string
+	}{
+		{minCount: 1, docs: nil},
+		{minCount: 1, docs: []string{"", ""}},
+		{minCount: 5, docs: []string{"the cat sat", "the dog sat"}},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		d2v := NewDoc2Vec(5)
+		d2v.MinCount = test.minCount
+		if _, err := d2v.FitTransform(test.docs...); err == nil {
+			t.Errorf("Expected error fitting without terms but found none")
+		}
+		d2v.Fit(test.docs...)
+		if _, err := d2v.Transform("the cat sat"); err == nil {
+			t.Errorf("Expected error transforming with an unfitted model but found none")
+		}
+	}
+}
+
+func cosine(a, b mat.Vector) float64 {
+	return mat.Dot(a, b) / (mat.Norm(a, 2) * mat.Norm(b, 2))
+}
@@ -0,0 +1,725 @@
+package nlp
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Embeddings is a lookup of pretrained word embeddings i.e. dense vectors representing
+// the meaning of terms learnt from a large corpus by models such as GloVe
+// (https://nlp.stanford.edu/projects/glove/) or word2vec
+// (https://code.google.com/archive/p/word2vec/).  Terms with similar meanings have
+// similar vectors and so the nearest neighbours of a term's vector are typically its
+// synonyms or otherwise semantically related terms.  Vectors are stored contiguously
+// at single (32 bit) precision to minimise the memory required by large vocabularies.
+type Embeddings struct {
+	// Dims is the dimensionality of the vectors
+	Dims int
+
+	// Vocabulary is a map of terms to their index within the embeddings
+	Vocabulary map[string]int
+
+	terms   []string
+	vectors []float32
+	norms   []float64
+
+	// subwords holds the character n-gram vectors of fastText models used to compose
+	// vectors for out of vocabulary terms
+	subwords *subwordVectors
+}
+
+// NewEmbeddings creates a new empty Embeddings of the specified dimensionality.
+// Vectors may be added using the Add() method.
+func NewEmbeddings(dims int) *Embeddings {
+	return &Embeddings{
+		Dims:       dims,
+		Vocabulary: make(map[string]int),
+	}
+}
+
+// Add adds the specified vector for term to the embeddings replacing any existing
+// vector for the term.  The method will panic if the length of the vector does not
+// match the dimensionality of the embeddings.
+func (e *Embeddings) Add(term string, vector []float64) {
+	if len(vector) != e.Dims {
+		panic(fmt.Sprintf("nlp: Vector has %d dimensions but the embeddings have %d", len(vector), e.Dims))
+	}
+	index, exists := e.Vocabulary[term]
+	if !exists {
+		index = len(e.terms)
+		e.Vocabulary[term] = index
+		e.terms = append(e.terms, term)
+		e.vectors = append(e.vectors, make([]float32, e.Dims)...)
+		e.norms = append(e.norms, 0)
+	}
+	var norm float64
+	row := e.vectors[index*e.Dims : (index+1)*e.Dims]
+	for i, v := range vector {
+		row[i] = float32(v)
+		norm += float64(row[i]) * float64(row[i])
+	}
+	e.norms[index] = math.Sqrt(norm)
+}
+
+// Len returns the number of terms within the embeddings.
+func (e *Embeddings) Len() int {
+	return len(e.terms)
+}
+
+// Terms returns the terms within the embeddings ordered by index.
+func (e *Embeddings) Terms() []string {
+	return e.terms
+}
+
+// Vector returns the vector for the specified term.  If the term is not present
+// within the embeddings but the embeddings were loaded from a fastText model (see
+// LoadFastText), a vector is composed from the vectors of the character n-grams within
+// the term.  If no vector can be found or composed for the term, ok will be false.
+func (e *Embeddings) Vector(term string) (v *mat.VecDense, ok bool) {
+	index, ok := e.Vocabulary[term]
+	if !ok {
+		if e.subwords == nil {
+			return nil, false
+		}
+		data := make([]float64, e.Dims)
+		if !e.subwords.compose(term, data) {
+			return nil, false
+		}
+		return mat.NewVecDense(e.Dims, data), true
+	}
+	return e.vector(index), true
+}
+
+// vector returns the vector at the specified index.
+func (e *Embeddings) vector(index int) *mat.VecDense {
+	data := make([]float64, e.Dims)
+	for i, v := range e.vectors[index*e.Dims : (index+1)*e.Dims] {
+		data[i] = float64(v)
+	}
+	return mat.NewVecDense(e.Dims, data)
+}
+
+// MostSimilar returns the k terms whose vectors are nearest (most similar) to the
+// vector of the specified term, excluding the term itself, in ascending order of
+// cosine distance.  Returns nil if no vector can be found for the term (see Vector()).
+func (e *Embeddings) MostSimilar(term string, k int) []Match {
+	v, ok := e.Vector(term)
+	if !ok {
+		return nil
+	}
+	index, exists := e.Vocabulary[term]
+	if !exists {
+		index = -1
+	}
+	return e.nearest(v, k, index)
+}
+
+// Nearest returns the k terms whose vectors are nearest (most similar) to the
+// specified vector in ascending order of cosine distance.  The ID of each Match is
+// the term (string) and the Distance is the cosine distance (1 - cosine similarity)
+// between the term's vector and v.
+func (e *Embeddings) Nearest(v mat.Vector, k int) []Match {
+	return e.nearest(v, k, -1)
+}
+
+// nearest performs a linear scan across all vectors, excluding the vector at index
+// exclude, compiling the top-k nearest neighbours of v.
+func (e *Embeddings) nearest(v mat.Vector, k int, exclude int) []Match {
+	if v.Len() != e.Dims {
+		panic(fmt.Sprintf("nlp: Vector has %d dimensions but the embeddings have %d", v.Len(), e.Dims))
+	}
+	query := make([]float64, e.Dims)
+	var qnorm float64
+	for i := range query {
+		query[i] = v.AtVec(i)
+		qnorm += query[i] * query[i]
+	}
+	qnorm = math.Sqrt(qnorm)
+
+	var results resultHeap
+	results.matches = make([]Match, 0, k)
+	for index, term := range e.terms {
+		if index == exclude || k <= 0 {
+			continue
+		}
+		dist := 1.0
+		if norm := e.norms[index] * qnorm; norm != 0 {
+			var dot float64
+			for i, x := range e.vectors[index*e.Dims : (index+1)*e.Dims] {
+				dot += float64(x) * query[i]
+			}
+			dist = 1 - dot/norm
+		}
+		if len(results.matches) < k {
+			heap.Push(&results, Match{Distance: dist, ID: term})
+		} else if dist < results.matches[0].Distance {
+			heap.Pop(&results)
+			heap.Push(&results, Match{Distance: dist, ID: term})
+		}
+	}
+
+	sort.Slice(results.matches, func(i, j int) bool {
+		return results.matches[i].Distance < results.matches[j].Distance
+	})
+	return results.matches
+}
+
+// Pooling specifies how the vectors of the terms within a document are combined into
+// a single vector representing the document.
+type Pooling int
+
+const (
+	// MeanPooling represents documents as the mean of the vectors of their terms
+	MeanPooling Pooling = iota
+
+	// MaxPooling represents documents as the element-wise maximum of the vectors of
+	// their terms
+	MaxPooling
+
+	// SIFPooling represents documents as the Smooth Inverse Frequency (SIF) weighted
+	// mean of the vectors of their terms with the common component removed, as
+	// described by Arora et al in "A Simple but Tough-to-Beat Baseline for Sentence
+	// Embeddings" (https://openreview.net/pdf?id=SyK00v5xx).  The vector of each term w
+	// is weighted by a / (a + p(w)) where p(w) is the probability of w within the
+	// training documents and a is the Smoothing parameter, so that frequent terms
+	// contribute less.  The projection onto the first principal component (the common
+	// component) of the training document vectors, which largely reflects syntax and
+	// common terms rather than meaning, is then subtracted from each document vector.
+	// SIFPooling requires the vectoriser to be fitted to training documents.
+	SIFPooling
+)
+
+// EmbeddingVectoriser encodes text documents into dense vectors by pooling (averaging
+// or taking the element-wise maximum of) the pretrained word embeddings of the terms
+// within them.  Unlike the sparse term document matrices produced by CountVectoriser,
+// the resulting vectors capture the similarity of documents expressing related
+// concepts using different terms.  Terms without a vector within the Embeddings are
+// ignored and documents containing no such terms are represented by a zero vector.
+// As the embeddings are pretrained, the vectoriser only requires fitting for
+// SIFPooling.
+type EmbeddingVectoriser struct {
+	// Embeddings are the pretrained word embeddings used to vectorise documents
+	Embeddings *Embeddings
+
+	// Tokeniser is used to tokenise input text into terms
+	Tokeniser Tokeniser
+
+	// Pooling specifies how the vectors of the terms within each document are combined
+	Pooling Pooling
+
+	// Smoothing is the parameter a used to weight terms for SIFPooling.  Typical
+	// values are between 1e-4 and 1e-3 with smaller values more heavily down weighting
+	// frequent terms.
+	Smoothing float64
+
+	// Orientation specifies the layout of matrices output from Transform().  By
+	// default (TermsAsRows) each row represents a dimension of the embeddings and each
+	// column a document.  If set to DocumentsAsRows, the output matrices are transposed
+	// so that each row represents a document.
+	Orientation Orientation
+
+	// freqs holds the frequency of each term within the training documents and
+	// tokens the total number of terms within the training documents
+	freqs  map[string]int
+	tokens int
+
+	// component is the common component of the training documents for SIFPooling
+	component []float64
+}
+
+// NewEmbeddingVectoriser creates a new EmbeddingVectoriser using the specified
+// embeddings and MeanPooling.  Smoothing defaults to 1e-3.  If stopWords is not an
+// empty slice then stop words will be removed.
+func NewEmbeddingVectoriser(embeddings *Embeddings, stopWords ...string) *EmbeddingVectoriser {
+	return &EmbeddingVectoriser{
+		Embeddings: embeddings,
+		Tokeniser:  NewTokeniser(stopWords...),
+		Smoothing:  1e-3,
+	}
+}
+
+// Fit fits the vectoriser to the specified training documents.  For SIFPooling, the
+// frequency of each term and the common component of the training documents are
+// learnt.  For other types of pooling, the method does nothing as the embeddings are
+// pretrained and is included for compatibility with other vectorisers.
+func (v *EmbeddingVectoriser) Fit(train ...string) Vectoriser {
+	v.fit(len(train), func(d int) tokenIterator {
+		return tokenise(v.Tokeniser, train[d])
+	})
+	return v
+}
+
+// FitTokens is equivalent to Fit() but accepts pre-tokenised documents, each
+// represented as a slice of tokens, rather than raw text.  The Tokeniser is not used.
+func (v *EmbeddingVectoriser) FitTokens(train ...[]string) *EmbeddingVectoriser {
+	v.fit(len(train), func(d int) tokenIterator {
+		return sliceTokens(train[d])
+	})
+	return v
+}
+
+// fit learns the term frequencies and common component of the n documents returned
+// by doc for SIFPooling.
+func (v *EmbeddingVectoriser) fit(n int, doc func(d int) tokenIterator) {
+	if v.Pooling != SIFPooling {
+		return
+	}
+	v.freqs = make(map[string]int)
+	v.tokens = 0
+	v.component = nil
+	for d := 0; d < n; d++ {
+		doc(d)(func(token string) {
+			v.freqs[token]++
+			v.tokens++
+		})
+	}
+
+	// the common component is the first left singular vector of the (dims x n) matrix
+	// of document vectors M i.e. the principal eigenvector of M * M^T
+	dims := v.Embeddings.Dims
+	matrix := mat.DenseCopyOf(v.pool(n, doc, TermsAsRows))
+	var gram mat.SymDense
+	gram.SymOuterK(1, matrix)
+	var eig mat.EigenSym
+	if ok := eig.Factorize(&gram, true); !ok {
+		panic("nlp: Failed to calculate common component of training documents")
+	}
+	var vectors mat.Dense
+	eig.VectorsTo(&vectors)
+	values := eig.Values(nil)
+	if len(values) == 0 || values[len(values)-1] <= 0 {
+		return
+	}
+	// eigenvalues are in ascending order so the principal eigenvector is the last
+	v.component = mat.Col(nil, dims-1, &vectors)
+}
+
+// Transform transforms the supplied documents into a matrix where each column is
+// a dense vector representing one of the supplied documents.  The returned matrix is
+// a dense matrix of shape D x C where D is the dimensionality of the embeddings and C
+// is the number of documents (or C x D if Orientation is DocumentsAsRows).  An error
+// is returned if Pooling is SIFPooling and the vectoriser has not been fitted.
+func (v *EmbeddingVectoriser) Transform(docs ...string) (mat.Matrix, error) {
+	return v.transform(len(docs), func(d int) tokenIterator {
+		return tokenise(v.Tokeniser, docs[d])
+	})
+}
+
+// TransformTokens is equivalent to Transform() but accepts pre-tokenised documents,
+// each represented as a slice of tokens, rather than raw text.  The Tokeniser is not
+// used.
+func (v *EmbeddingVectoriser) TransformTokens(docs ...[]string) (mat.Matrix, error) {
+	return v.transform(len(docs), func(d int) tokenIterator {
+		return sliceTokens(docs[d])
+	})
+}
+
+// FitTransform is equivalent to calling Fit() followed by Transform() on the same
+// documents.
+func (v *EmbeddingVectoriser) FitTransform(docs ...string) (mat.Matrix, error) {
+	return v.Fit(docs...).Transform(docs...)
+}
+
+// transform pools the vectors of the tokens of each of the n documents returned by
+// doc removing the common component for SIFPooling.
+func (v *EmbeddingVectoriser) transform(n int, doc func(d int) tokenIterator) (mat.Matrix, error) {
+	if v.Pooling == SIFPooling && v.freqs == nil {
+		return nil, fmt.Errorf("nlp: EmbeddingVectoriser must be fitted before transforming using SIFPooling")
+	}
+	matrix := v.pool(n, doc, v.Orientation)
+	if v.component == nil {
+		return matrix, nil
+	}
+
+	for d := 0; d < n; d++ {
+		var dot float64
+		for i, u := range v.component {
+			dot += u * matrix.At(v.Orientation.index(i, d))
+		}
+		for i, u := range v.component {
+			r, c := v.Orientation.index(i, d)
+			matrix.Set(r, c, matrix.At(r, c)-dot*u)
+		}
+	}
+	return matrix, nil
+}
+
+// pool pools the vectors of the tokens of each of the n documents returned by doc
+// into a matrix of the specified orientation.
+func (v *EmbeddingVectoriser) pool(n int, doc func(d int) tokenIterator, orientation Orientation) *mat.Dense {
+	dims := v.Embeddings.Dims
+	r, c := orientation.index(dims, n)
+	matrix := mat.NewDense(r, c, nil)
+	pooled := make([]float64, dims)
+
+	for d := 0; d < n; d++ {
+		var count int
+		doc(d)(func(token string) {
+			vec, ok := v.Embeddings.Vector(token)
+			if !ok {
+				return
+			}
+			weight := 1.0
+			if v.Pooling == SIFPooling && v.tokens > 0 {
+				weight = v.Smoothing / (v.Smoothing + float64(v.freqs[token])/float64(v.tokens))
+			}
+			for i := 0; i < dims; i++ {
+				x := weight * vec.AtVec(i)
+				if count == 0 {
+					pooled[i] = x
+				} else if v.Pooling == MaxPooling {
+					pooled[i] = math.Max(pooled[i], x)
+				} else {
+					pooled[i] += x
+				}
+			}
+			count++
+		})
+		if count == 0 {
+			continue
+		}
+		for i, x := range pooled {
+			if v.Pooling != MaxPooling {
+				x /= float64(count)
+			}
+			r, c := orientation.index(i, d)
+			matrix.Set(r, c, x)
+		}
+	}
+
+	return matrix
+}
+
+// LoadGloVe loads embeddings in the GloVe text format where each line contains a term
+// followed by the space separated values of its vector.  A leading header line
+// containing the number of terms and dimensions, as used by the word2vec text format,
+// is also supported and skipped.  If vocabulary is not nil, only terms present within
+// vocabulary are loaded e.g. restricting the embeddings to the Vocabulary of a fitted
+// CountVectoriser to reduce memory usage.
+func LoadGloVe(r io.Reader, vocabulary map[string]int) (*Embeddings, error) {
+	var e *Embeddings
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var line int
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if line == 1 && len(fields) == 2 {
+			if _, err := strconv.Atoi(fields[0]); err == nil {
+				// word2vec text format header
+				continue
+			}
+		}
+		if e == nil {
+			e = NewEmbeddings(len(fields) - 1)
+		}
+		if len(fields)-1 != e.Dims {
+			return nil, fmt.Errorf("nlp: Line %d has %d dimensions but expected %d", line, len(fields)-1, e.Dims)
+		}
+		if vocabulary != nil {
+			if _, exists := vocabulary[fields[0]]; !exists {
+				continue
+			}
+		}
+		vector := make([]float64, e.Dims)
+		for i, field := range fields[1:] {
+			v, err := strconv.ParseFloat(field, 32)
+			if err != nil {
+				return nil, fmt.Errorf("nlp: Invalid value on line %d: %v", line, err)
+			}
+			vector[i] = v
+		}
+		e.Add(fields[0], vector)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if e == nil {
+		e = NewEmbeddings(0)
+	}
+
+	return e, nil
+}
+
+// LoadWord2VecBinary loads embeddings in the binary format used by the original C
+// implementation of word2vec.  The format consists of a header line containing the
+// number of terms and dimensions followed by each term, terminated by a space, and
+// its vector as little endian 32 bit floating point values.  If vocabulary is not
+// nil, only terms present within vocabulary are loaded e.g. restricting the embeddings
+// to the Vocabulary of a fitted CountVectoriser to reduce memory usage.
+func LoadWord2VecBinary(r io.Reader, vocabulary map[string]int) (*Embeddings, error) {
+	br := bufio.NewReader(r)
+	header, err := br.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("nlp: Failed to read header: %v", err)
+	}
+	var count, dims int
+	if _, err := fmt.Sscanf(header, "%d %d", &count, &dims); err != nil {
+		return nil, fmt.Errorf("nlp: Invalid header '%s': %v", strings.TrimSpace(header), err)
+	}
+
+	e := NewEmbeddings(dims)
+	raw := make([]float32, dims)
+	vector := make([]float64, dims)
+	for n := 0; n < count; n++ {
+		term, err := br.ReadString(' ')
+		if err != nil {
+			return nil, fmt.Errorf("nlp: Failed to read term %d: %v", n+1, err)
+		}
+		term = strings.TrimLeft(term[:len(term)-1], "\n")
+		if err := binary.Read(br, binary.LittleEndian, raw); err != nil {
+			return nil, fmt.Errorf("nlp: Failed to read vector for term '%s': %v", term, err)
+		}
+		if vocabulary != nil {
+			if _, exists := vocabulary[term]; !exists {
+				continue
+			}
+		}
+		for i, v := range raw {
+			vector[i] = float64(v)
+		}
+		e.Add(term, vector)
+	}
+
+	return e, nil
+}
+
+const (
+	fastTextMagic   = 793712314
+	fastTextVersion = 12
+)
+
+// fastTextArgs are the training arguments persisted within fastText model files.
+type fastTextArgs struct {
+	Dim          int32
+	WS           int32
+	Epoch        int32
+	MinCount     int32
+	Neg          int32
+	WordNgrams   int32
+	Loss         int32
+	Model        int32
+	Bucket       int32
+	Minn         int32
+	Maxn         int32
+	LRUpdateRate int32
+	T            float64
+}
+
+// fastTextDictHeader is the fixed size portion of the dictionary persisted within
+// fastText model files.
+type fastTextDictHeader struct {
+	Size        int32
+	NWords      int32
+	NLabels     int32
+	NTokens     int64
+	PruneIdxLen int64
+}
+
+// subwordVectors holds the hashed character n-gram vectors of a fastText model.
+type subwordVectors struct {
+	dims     int
+	minn     int
+	maxn     int
+	bucket   int
+	vectors  []float32
+	pruneIdx map[int32]int32
+}
+
+// ngrams returns the indices of the vectors for the character n-grams of length
+// minn to maxn within term (surrounded by the boundary symbols '<' and '>').
+func (s *subwordVectors) ngrams(term string) []int {
+	if s.maxn <= 0 || s.bucket <= 0 {
+		return nil
+	}
+	word := "<" + term + ">"
+	var ngrams []int
+	for i := 0; i < len(word); i++ {
+		// skip UTF-8 continuation bytes so n-grams are composed of whole characters
+		if word[i]&0xC0 == 0x80 {
+			continue
+		}
+		j := i
+		for n := 1; j < len(word) && n <= s.maxn; n++ {
+			j++
+			for j < len(word) && word[j]&0xC0 == 0x80 {
+				j++
+			}
+			if n >= s.minn && !(n == 1 && (i == 0 || j == len(word))) {
+				h := int32(fastTextHash(word[i:j]) % uint32(s.bucket))
+				if s.pruneIdx != nil {
+					var exists bool
+					if h, exists = s.pruneIdx[h]; !exists {
+						continue
+					}
+				}
+				ngrams = append(ngrams, int(h))
+			}
+		}
+	}
+	return ngrams
+}
+
+// compose sets dst to the mean of the vectors of the character n-grams within term
+// returning false if term contains no n-grams.
+func (s *subwordVectors) compose(term string, dst []float64) bool {
+	ngrams := s.ngrams(term)
+	if len(ngrams) == 0 {
+		return false
+	}
+	for _, ngram := range ngrams {
+		for i, v := range s.vectors[ngram*s.dims : (ngram+1)*s.dims] {
+			dst[i] += float64(v)
+		}
+	}
+	for i := range dst {
+		dst[i] /= float64(len(ngrams))
+	}
+	return true
+}
+
+// fastTextHash is the FNV-1a variant used by fastText to hash character n-grams.
+// Each byte is sign extended before being combined with the hash for compatibility
+// with the C++ implementation.
+func fastTextHash(s string) uint32 {
+	h := uint32(2166136261)
+	for i := 0; i < len(s); i++ {
+		h ^= uint32(int8(s[i]))
+		h *= 16777619
+	}
+	return h
+}
+
+// LoadFastText loads embeddings from the binary model format (.bin) of fastText
+// (https://fasttext.cc/).  As well as a vector for each term within the vocabulary,
+// fastText models contain vectors for hashed character n-grams (subwords) from
+// which the vectors of terms are composed.  This allows vectors to be produced for
+// terms not present within the vocabulary (e.g. misspellings or rare morphological
+// variants) through the Vector() method.  If vocabulary is not nil, only terms present
+// within vocabulary are loaded although vectors may still be composed for other terms.
+// Quantised (.ftz) models are not supported.  The text format (.vec) of fastText,
+// which contains no subword information, may be loaded using LoadGloVe().
+func LoadFastText(r io.Reader, vocabulary map[string]int) (*Embeddings, error) {
+	br := bufio.NewReader(r)
+
+	var magic [2]int32
+	if err := binary.Read(br, binary.LittleEndian, &magic); err != nil {
+		return nil, fmt.Errorf("nlp: Failed to read fastText header: %v", err)
+	}
+	if magic[0] != fastTextMagic {
+		return nil, fmt.Errorf("nlp: Invalid fastText model file")
+	}
+	if magic[1] > fastTextVersion {
+		return nil, fmt.Errorf("nlp: Unsupported fastText model version %d", magic[1])
+	}
+
+	var args fastTextArgs
+	if err := binary.Read(br, binary.LittleEndian, &args); err != nil {
+		return nil, fmt.Errorf("nlp: Failed to read fastText arguments: %v", err)
+	}
+
+	var dict fastTextDictHeader
+	if err := binary.Read(br, binary.LittleEndian, &dict); err != nil {
+		return nil, fmt.Errorf("nlp: Failed to read fastText dictionary: %v", err)
+	}
+	words := make([]string, 0, dict.NWords)
+	for i := 0; i < int(dict.Size); i++ {
+		word, err := br.ReadString(0)
+		if err != nil {
+			return nil, fmt.Errorf("nlp: Failed to read fastText dictionary entry %d: %v", i+1, err)
+		}
+		var entry struct {
+			Count int64
+			Type  int8
+		}
+		if err := binary.Read(br, binary.LittleEndian, &entry); err != nil {
+			return nil, fmt.Errorf("nlp: Failed to read fastText dictionary entry %d: %v", i+1, err)
+		}
+		if entry.Type == 0 {
+			words = append(words, word[:len(word)-1])
+		}
+	}
+	var pruneIdx map[int32]int32
+	if dict.PruneIdxLen > 0 {
+		pairs := make([]int32, 2*dict.PruneIdxLen)
+		if err := binary.Read(br, binary.LittleEndian, pairs); err != nil {
+			return nil, fmt.Errorf("nlp: Failed to read fastText pruned n-grams: %v", err)
+		}
+		pruneIdx = make(map[int32]int32, dict.PruneIdxLen)
+		for i := 0; i < len(pairs); i += 2 {
+			pruneIdx[pairs[i]] = pairs[i+1]
+		}
+	}
+
+	var quantised int8
+	if err := binary.Read(br, binary.LittleEndian, &quantised); err != nil {
+		return nil, fmt.Errorf("nlp: Failed to read fastText model: %v", err)
+	}
+	if quantised != 0 {
+		return nil, fmt.Errorf("nlp: Quantised fastText models are not supported")
+	}
+	var dims [2]int64
+	if err := binary.Read(br, binary.LittleEndian, &dims); err != nil {
+		return nil, fmt.Errorf("nlp: Failed to read fastText input matrix: %v", err)
+	}
+	if dims[0] < int64(len(words)) {
+		return nil, fmt.Errorf("nlp: fastText input matrix has %d rows but the dictionary contains %d words", dims[0], len(words))
+	}
+	input := make([]float32, dims[0]*dims[1])
+	if err := binary.Read(br, binary.LittleEndian, input); err != nil {
+		return nil, fmt.Errorf("nlp: Failed to read fastText input matrix: %v", err)
+	}
+
+	n := int(dims[1])
+	e := NewEmbeddings(n)
+	e.subwords = &subwordVectors{
+		dims:     n,
+		minn:     int(args.Minn),
+		maxn:     int(args.Maxn),
+		bucket:   int(args.Bucket),
+		vectors:  input[len(words)*n:],
+		pruneIdx: pruneIdx,
+	}
+
+	vector := make([]float64, n)
+	for i, word := range words {
+		if vocabulary != nil {
+			if _, exists := vocabulary[word]; !exists {
+				continue
+			}
+		}
+		// the vector of each word is the mean of its own vector and those of its n-grams
+		// (excluding the end of sentence token for which fastText computes no n-grams)
+		var ngrams []int
+		if word != "</s>" {
+			ngrams = e.subwords.ngrams(word)
+		}
+		for j, v := range input[i*n : (i+1)*n] {
+			vector[j] = float64(v)
+		}
+		for _, ngram := range ngrams {
+			for j, v := range e.subwords.vectors[ngram*n : (ngram+1)*n] {
+				vector[j] += float64(v)
+			}
+		}
+		for j := range vector {
+			vector[j] /= float64(len(ngrams) + 1)
+		}
+		e.Add(word, vector)
+	}
+
+	return e, nil
+}
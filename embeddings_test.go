@@ -0,0 +1,474 @@
+package nlp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+const testGloVe = `king 0.5 0.7 0.1
+queen 0.45 0.75 0.2
+man 0.6 0.1 0.05
+woman 0.55 0.15 0.2
+car -0.3 0.1 0.9
+`
+
+func testWord2VecBinary(header string, terms []string, vectors [][]float32) *bytes.Buffer {
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	for i, term := range terms {
+		buf.WriteString(term + " ")
+		binary.Write(&buf, binary.LittleEndian, vectors[i])
+		buf.WriteString("\n")
+	}
+	return &buf
+}
+
+func TestLoadEmbeddings(t *testing.T) {
+	terms := []string{"king", "queen", "man", "woman", "car"}
+	vectors := [][]float32{
+		{0.5, 0.7, 0.1},
+		{0.45, 0.75, 0.2},
+		{0.6, 0.1, 0.05},
+		{0.55, 0.15, 0.2},
+		{-0.3, 0.1, 0.9},
+	}
+
+	var tests = []struct {
+		load       func(map[string]int) (*Embeddings, error)
+		vocabulary map[string]int
+		expected   []string
+	}{
+		{
+			load: func(v map[string]int) (*Embeddings, error) {
+				return LoadGloVe(strings.NewReader(testGloVe), v)
+			},
+			expected: terms,
+		},
+		{
+			load: func(v map[string]int) (*Embeddings, error) {
+				return LoadGloVe(strings.NewReader("5 3\n"+testGloVe), v)
+			},
+			expected: terms,
+		},
+		{
+			load: func(v map[string]int) (*Embeddings, error) {
+				return LoadGloVe(strings.NewReader(testGloVe), v)
+			},
+			vocabulary: map[string]int{"queen": 0, "car": 1, "unknown": 2},
+			expected:   []string{"queen", "car"},
+		},
+		{
+			load: func(v map[string]int) (*Embeddings, error) {
+				return LoadWord2VecBinary(testWord2VecBinary("5 3\n", terms, vectors), v)
+			},
+			expected: terms,
+		},
+		{
+			load: func(v map[string]int) (*Embeddings, error) {
+				return LoadWord2VecBinary(testWord2VecBinary("5 3\n", terms, vectors), v)
+			},
+			vocabulary: map[string]int{"man": 0, "woman": 1},
+			expected:   []string{"man", "woman"},
+		},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		e, err := test.load(test.vocabulary)
+		if err != nil {
+			t.Errorf("Failed to load embeddings because %v", err)
+			continue
+		}
+		if e.Dims != 3 {
+			t.Errorf("Expected 3 dimensions but found %d", e.Dims)
+		}
+		if !reflect.DeepEqual(test.expected, e.Terms()) {
+			t.Errorf("Expected terms %v but found %v", test.expected, e.Terms())
+		}
+
+		for i, term := range terms {
+			v, ok := e.Vector(term)
+			if _, wanted := e.Vocabulary[term]; ok != wanted {
+				t.Errorf("Expected Vector() ok to be %v for term '%s' but found %v", wanted, term, ok)
+			}
+			if !ok {
+				continue
+			}
+			for j, x := range vectors[i] {
+				if v.AtVec(j) != float64(x) {
+					t.Errorf("Expected vector %v for term '%s' but found %v", vectors[i], term, mat.Formatted(v.T()))
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestLoadEmbeddingsErrors(t *testing.T) {
+	if _, err := LoadGloVe(strings.NewReader("king 0.5 0.7 0.1\nqueen 0.45 0.75\n"), nil); err == nil {
+		t.Errorf("Expected error for inconsistent dimensions but received none")
+	}
+	if _, err := LoadGloVe(strings.NewReader("king 0.5 x 0.1\n"), nil); err == nil {
+		t.Errorf("Expected error for invalid value but received none")
+	}
+	if _, err := LoadWord2VecBinary(strings.NewReader("three dims\n"), nil); err == nil {
+		t.Errorf("Expected error for invalid header but received none")
+	}
+	truncated := testWord2VecBinary("2 3\n", []string{"king"}, [][]float32{{0.5, 0.7, 0.1}})
+	if _, err := LoadWord2VecBinary(truncated, nil); err == nil {
+		t.Errorf("Expected error for truncated file but received none")
+	}
+}
+
+func TestEmbeddingsMostSimilar(t *testing.T) {
+	e, err := LoadGloVe(strings.NewReader(testGloVe), nil)
+	if err != nil {
+		t.Fatalf("Failed to load embeddings because %v", err)
+	}
+
+	var tests = []struct {
+		term     string
+		k        int
+		expected []string
+	}{
+		{term: "king", k: 2, expected: []string{"queen", "woman"}},
+		{term: "man", k: 1, expected: []string{"woman"}},
+		{term: "car", k: 10, expected: []string{"queen", "woman", "king", "man"}},
+		{term: "unknown", k: 2, expected: nil},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		matches := e.MostSimilar(test.term, test.k)
+		var result []string
+		for i, match := range matches {
+			result = append(result, match.ID.(string))
+			if i > 0 && match.Distance < matches[i-1].Distance {
+				t.Errorf("Expected matches in ascending order of distance but found %v", matches)
+			}
+		}
+		if !reflect.DeepEqual(test.expected, result) {
+			t.Errorf("Expected %v but found %v", test.expected, result)
+		}
+	}
+
+	// vector arithmetic: king - man + woman should be closest to queen
+	king, _ := e.Vector("king")
+	man, _ := e.Vector("man")
+	woman, _ := e.Vector("woman")
+	var query mat.VecDense
+	query.SubVec(king, man)
+	query.AddVec(&query, woman)
+	matches := e.Nearest(&query, 1)
+	if matches[0].ID != "queen" {
+		t.Errorf("Expected 'queen' but found %v", matches[0].ID)
+	}
+	if math.IsNaN(matches[0].Distance) {
+		t.Errorf("Expected valid distance but found NaN")
+	}
+}
+
+// testFastTextModel returns a fastText binary model containing the specified words,
+// labels and input matrix (with a row for each word followed by a row for each bucket).
+func testFastTextModel(words, labels []string, dim, bucket, minn, maxn int, input []float32) *bytes.Buffer {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, []int32{fastTextMagic, fastTextVersion})
+	binary.Write(&buf, binary.LittleEndian, fastTextArgs{
+		Dim: int32(dim), WS: 5, Epoch: 5, MinCount: 1, Neg: 5, WordNgrams: 1, Loss: 2,
+		Model: 2, Bucket: int32(bucket), Minn: int32(minn), Maxn: int32(maxn), LRUpdateRate: 100, T: 1e-4,
+	})
+	binary.Write(&buf, binary.LittleEndian, fastTextDictHeader{
+		Size:    int32(len(words) + len(labels)),
+		NWords:  int32(len(words)),
+		NLabels: int32(len(labels)),
+		NTokens: 100,
+	})
+	for i, entry := range append(append([]string{}, words...), labels...) {
+		buf.WriteString(entry)
+		buf.WriteByte(0)
+		var entryType int8
+		if i >= len(words) {
+			entryType = 1
+		}
+		binary.Write(&buf, binary.LittleEndian, int64(10))
+		binary.Write(&buf, binary.LittleEndian, entryType)
+	}
+	buf.WriteByte(0)
+	binary.Write(&buf, binary.LittleEndian, []int64{int64(len(input) / dim), int64(dim)})
+	binary.Write(&buf, binary.LittleEndian, input)
+	return &buf
+}
+
+func TestLoadFastText(t *testing.T) {
+	// with a single bucket, all n-grams share the final row of the input matrix
+	input := []float32{
+		1, 2,
+		3, 4,
+		8, 2,
+	}
+
+	var tests = []struct {
+		model      *bytes.Buffer
+		vocabulary map[string]int
+		terms      []string
+		vectors    map[string][]float64
+	}{
+		{
+			// minn = maxn = 3: "cat" has 3 n-grams (<ca, cat, at>), "dogs" has 4 and "x" 1
+			model: testFastTextModel([]string{"cat", "dog"}, []string{"__label__pets"}, 2, 1, 3, 3, input),
+			terms: []string{"cat", "dog"},
+			vectors: map[string][]float64{
+				"cat":  {(1 + 3*8) / 4.0, (2 + 3*2) / 4.0},
+				"dog":  {(3 + 3*8) / 4.0, (4 + 3*2) / 4.0},
+				"dogs": {8, 2},
+				"x":    {8, 2},
+			},
+		},
+		{
+			model:      testFastTextModel([]string{"cat", "dog"}, nil, 2, 1, 3, 3, input),
+			vocabulary: map[string]int{"dog": 0},
+			terms:      []string{"dog"},
+			vectors: map[string][]float64{
+				"dog": {(3 + 3*8) / 4.0, (4 + 3*2) / 4.0},
+				"cat": {8, 2},
+			},
+		},
+		{
+			// maxn = 0 disables subwords so no vectors can be composed
+			model: testFastTextModel([]string{"cat", "dog"}, nil, 2, 0, 0, 0, input[:4]),
+			terms: []string{"cat", "dog"},
+			vectors: map[string][]float64{
+				"cat":  {1, 2},
+				"dog":  {3, 4},
+				"dogs": nil,
+			},
+		},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		e, err := LoadFastText(test.model, test.vocabulary)
+		if err != nil {
+			t.Errorf("Failed to load fastText model because %v", err)
+			continue
+		}
+		if !reflect.DeepEqual(test.terms, e.Terms()) {
+			t.Errorf("Expected terms %v but found %v", test.terms, e.Terms())
+		}
+		for term, expected := range test.vectors {
+			v, ok := e.Vector(term)
+			if ok != (expected != nil) {
+				t.Errorf("Expected Vector() ok to be %v for term '%s' but found %v", expected != nil, term, ok)
+				continue
+			}
+			for i := range expected {
+				if math.Abs(expected[i]-v.AtVec(i)) > 1e-6 {
+					t.Errorf("Expected vector %v for term '%s' but found %v", expected, term, mat.Formatted(v.T()))
+					break
+				}
+			}
+		}
+	}
+
+	if _, err := LoadFastText(bytes.NewReader([]byte{1, 2, 3, 4, 5, 6, 7, 8}), nil); err == nil {
+		t.Errorf("Expected error for invalid model but received none")
+	}
+}
+
+func TestFastTextSubwords(t *testing.T) {
+	for _, s := range []string{"a", "<ab", "cat>", "hello world"} {
+		h := fnv.New32a()
+		h.Write([]byte(s))
+		if expected := h.Sum32(); fastTextHash(s) != expected {
+			t.Errorf("Expected hash of '%s' to be %d but found %d", s, expected, fastTextHash(s))
+		}
+	}
+
+	var tests = []struct {
+		term     string
+		minn     int
+		maxn     int
+		expected []string
+	}{
+		{term: "ab", minn: 2, maxn: 3, expected: []string{"<a", "<ab", "ab", "ab>", "b>"}},
+		{term: "ab", minn: 1, maxn: 1, expected: []string{"a", "b"}},
+		{term: "é", minn: 1, maxn: 3, expected: []string{"<é", "<é>", "é", "é>"}},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		s := subwordVectors{minn: test.minn, maxn: test.maxn, bucket: 1 << 30}
+		var expected []int
+		for _, ngram := range test.expected {
+			expected = append(expected, int(fastTextHash(ngram)%uint32(s.bucket)))
+		}
+		if result := s.ngrams(test.term); !reflect.DeepEqual(expected, result) {
+			t.Errorf("Expected n-grams %v (%v) but found %v", test.expected, expected, result)
+		}
+	}
+}
+
+func TestEmbeddingVectoriser(t *testing.T) {
+	e, err := LoadGloVe(strings.NewReader(testGloVe), nil)
+	if err != nil {
+		t.Fatalf("Failed to load embeddings because %v", err)
+	}
+	docs := []string{
+		"the king and the queen",
+		"nothing known here",
+		"man car man",
+	}
+
+	var tests = []struct {
+		pooling     Pooling
+		orientation Orientation
+		expected    *mat.Dense
+	}{
+		{
+			pooling: MeanPooling,
+			expected: mat.NewDense(3, 3, []float64{
+				0.475, 0, (0.6 + 0.6 - 0.3) / 3,
+				0.725, 0, (0.1 + 0.1 + 0.1) / 3,
+				0.15, 0, (0.05 + 0.05 + 0.9) / 3,
+			}),
+		},
+		{
+			pooling: MaxPooling,
+			expected: mat.NewDense(3, 3, []float64{
+				0.5, 0, 0.6,
+				0.75, 0, 0.1,
+				0.2, 0, 0.9,
+			}),
+		},
+		{
+			pooling:     MeanPooling,
+			orientation: DocumentsAsRows,
+			expected: mat.NewDense(3, 3, []float64{
+				0.475, 0.725, 0.15,
+				0, 0, 0,
+				(0.6 + 0.6 - 0.3) / 3, (0.1 + 0.1 + 0.1) / 3, (0.05 + 0.05 + 0.9) / 3,
+			}),
+		},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		var vectoriser Vectoriser = NewEmbeddingVectoriser(e, "the", "and")
+		vectoriser.(*EmbeddingVectoriser).Pooling = test.pooling
+		vectoriser.(*EmbeddingVectoriser).Orientation = test.orientation
+
+		result, err := vectoriser.Fit(docs...).Transform(docs...)
+		if err != nil {
+			t.Errorf("Failed to vectorise documents because %v", err)
+			continue
+		}
+		if !mat.EqualApprox(test.expected, result, 1e-6) {
+			t.Errorf("Expected %v but found %v", mat.Formatted(test.expected), mat.Formatted(result))
+		}
+
+		tokens := [][]string{{"king", "queen"}, {"nothing"}, {"man", "car", "man"}}
+		result, _ = vectoriser.(*EmbeddingVectoriser).TransformTokens(tokens...)
+		if !mat.EqualApprox(test.expected, result, 1e-6) {
+			t.Errorf("Expected %v but found %v", mat.Formatted(test.expected), mat.Formatted(result))
+		}
+	}
+}
+
+func TestEmbeddingVectoriserSIF(t *testing.T) {
+	e, err := LoadGloVe(strings.NewReader(testGloVe), nil)
+	if err != nil {
+		t.Fatalf("Failed to load embeddings because %v", err)
+	}
+	docs := [][]string{
+		{"king", "queen", "man"},
+		{"man", "woman", "man", "unknown"},
+		{"car", "man"},
+		{"queen", "woman", "car"},
+	}
+
+	var tests = []struct {
+		smoothing   float64
+		orientation Orientation
+	}{
+		{smoothing: 1e-3},
+		{smoothing: 0.5},
+		{smoothing: 1e-3, orientation: DocumentsAsRows},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		vectoriser := NewEmbeddingVectoriser(e)
+		vectoriser.Pooling = SIFPooling
+		vectoriser.Smoothing = test.smoothing
+		vectoriser.Orientation = test.orientation
+
+		if _, err := vectoriser.TransformTokens(docs...); err == nil {
+			t.Errorf("Expected error transforming with unfitted vectoriser but received none")
+		}
+
+		// calculate the expected SIF weighted averages
+		freqs := make(map[string]float64)
+		var total float64
+		for _, doc := range docs {
+			for _, term := range doc {
+				freqs[term]++
+				total++
+			}
+		}
+		weighted := mat.NewDense(3, len(docs), nil)
+		for j, doc := range docs {
+			var count float64
+			for _, term := range doc {
+				v, ok := e.Vector(term)
+				if !ok {
+					continue
+				}
+				weight := test.smoothing / (test.smoothing + freqs[term]/total)
+				for i := 0; i < 3; i++ {
+					weighted.Set(i, j, weighted.At(i, j)+weight*v.AtVec(i))
+				}
+				count++
+			}
+			for i := 0; i < 3; i++ {
+				weighted.Set(i, j, weighted.At(i, j)/count)
+			}
+		}
+
+		// remove the projection onto the first left singular vector
+		var svd mat.SVD
+		svd.Factorize(weighted, mat.SVDThin)
+		var u mat.Dense
+		svd.UTo(&u)
+		u1 := u.ColView(0)
+		var projection mat.Dense
+		projection.Mul(u1, u1.T())
+		var expected mat.Dense
+		expected.Mul(&projection, weighted)
+		expected.Sub(weighted, &expected)
+
+		result, err := vectoriser.FitTokens(docs...).TransformTokens(docs...)
+		if err != nil {
+			t.Errorf("Failed to vectorise documents because %v", err)
+			continue
+		}
+		if test.orientation == DocumentsAsRows {
+			result = result.T()
+		}
+		if !mat.EqualApprox(&expected, result, 1e-6) {
+			t.Errorf("Expected %v but found %v", mat.Formatted(&expected), mat.Formatted(result))
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package nlp
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// TokenFilter transforms or removes tokens produced by a Tokeniser.  Filters may be
+// composed into an ordered chain using a FilteringTokeniser, similar to the analysis
+// chains found in search engines such as Lucene, to customise the features produced
+// by vectorisers.
+type TokenFilter interface {
+	// Filter returns the (potentially transformed) token and true if the token
+	// should be retained or false if the token should be removed.
+	Filter(token string) (string, bool)
+}
+
+// TokenFilterFunc is an adapter allowing an ordinary function to be used as a
+// TokenFilter.
+type TokenFilterFunc func(token string) (string, bool)
+
+// Filter calls f(token).
+func (f TokenFilterFunc) Filter(token string) (string, bool) {
+	return f(token)
+}
+
+// LowerCaseFilter returns a TokenFilter that converts tokens to lower case.
+func LowerCaseFilter() TokenFilter {
+	return TokenFilterFunc(func(token string) (string, bool) {
+		return strings.ToLower(token), true
+	})
+}
+
+// StopWordFilter returns a TokenFilter that removes any tokens present in stopWords.
+// As the comparison is case sensitive, the filter should typically follow a
+// LowerCaseFilter within a chain.
+func StopWordFilter(stopWords ...string) TokenFilter {
+	stop := make(map[string]bool, len(stopWords))
+	for _, word := range stopWords {
+		stop[word] = true
+	}
+	return TokenFilterFunc(func(token string) (string, bool) {
+		return token, !stop[token]
+	})
+}
+
+// StemmingFilter returns a TokenFilter that reduces tokens to their stem (root form)
+// using the supplied stem function e.g. to treat "go" and "going" as the same
+// feature.  This allows any stemming algorithm (e.g. Porter or Snowball) to be used.
+func StemmingFilter(stem func(token string) string) TokenFilter {
+	return TokenFilterFunc(func(token string) (string, bool) {
+		return stem(token), true
+	})
+}
+
+// LengthFilter returns a TokenFilter that removes tokens containing fewer than min
+// or more than max characters (runes).  A max of 0 means no maximum is applied.
+func LengthFilter(min, max int) TokenFilter {
+	return TokenFilterFunc(func(token string) (string, bool) {
+		n := utf8.RuneCountInString(token)
+		return token, n >= min && (max <= 0 || n <= max)
+	})
+}
+
+// PatternFilter returns a TokenFilter that retains only those tokens matching the
+// supplied regular expression, removing all others.  For example, the pattern
+// `^\D+$` would remove any tokens containing digits.
+func PatternFilter(pattern *regexp.Regexp) TokenFilter {
+	return TokenFilterFunc(func(token string) (string, bool) {
+		return token, pattern.MatchString(token)
+	})
+}
+
+// FilteringTokeniser is a Tokeniser that applies an ordered chain of TokenFilters
+// to the tokens produced by an underlying Tokeniser.  Each token is passed through
+// each filter in turn with the output of each filter becoming the input to the
+// next.  If any filter removes a token, it is not passed to subsequent filters.
+type FilteringTokeniser struct {
+	// Tokeniser is the underlying Tokeniser used to split text into tokens prior to
+	// filtering.
+	Tokeniser Tokeniser
+
+	// Filters is the ordered chain of filters applied to each token.
+	Filters []TokenFilter
+}
+
+// NewFilteringTokeniser returns a new FilteringTokeniser applying the specified
+// filters, in order, to the tokens produced by tokeniser.  For example, the
+// following splits text on whitespace before converting the resulting tokens to
+// lower case and removing stop words and words shorter than 3 characters:
+//
+//	NewFilteringTokeniser(
+//		TokeniserFunc(strings.Fields),
+//		LowerCaseFilter(),
+//		StopWordFilter(stopWords...),
+//		LengthFilter(3, 0),
+//	)
+func NewFilteringTokeniser(tokeniser Tokeniser, filters ...TokenFilter) *FilteringTokeniser {
+	return &FilteringTokeniser{
+		Tokeniser: tokeniser,
+		Filters:   filters,
+	}
+}
+
+// ForEachIn iterates over each token within text, as produced by the underlying
+// Tokeniser and filtered by Filters, and invokes function f with the token as
+// parameter.
+func (t *FilteringTokeniser) ForEachIn(text string, f func(token string)) {
+	t.Tokeniser.ForEachIn(text, func(token string) {
+		for _, filter := range t.Filters {
+			var keep bool
+			if token, keep = filter.Filter(token); !keep {
+				return
+			}
+		}
+		f(token)
+	})
+}
+
+// Tokenise returns a slice of all the tokens contained in string text, as produced
+// by the underlying Tokeniser and filtered by Filters.
+func (t *FilteringTokeniser) Tokenise(text string) []string {
+	var tokens []string
+	t.ForEachIn(text, func(token string) {
+		tokens = append(tokens, token)
+	})
+	return tokens
+}
@@ -0,0 +1,83 @@
+package nlp
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestFilteringTokeniser(t *testing.T) {
+	var tests = []struct {
+		filters  []TokenFilter
+		text     string
+		expected []string
+	}{
+		{
+			filters:  nil,
+			text:     "The Quick brown fox",
+			expected: []string{"The", "Quick", "brown", "fox"},
+		},
+		{
+			filters:  []TokenFilter{LowerCaseFilter()},
+			text:     "The Quick brown fox",
+			expected: []string{"the", "quick", "brown", "fox"},
+		},
+		{
+			filters:  []TokenFilter{StopWordFilter("the"), LowerCaseFilter()},
+			text:     "The Quick brown fox the",
+			expected: []string{"the", "quick", "brown", "fox"},
+		},
+		{
+			filters:  []TokenFilter{LowerCaseFilter(), StopWordFilter("the")},
+			text:     "The Quick brown fox the",
+			expected: []string{"quick", "brown", "fox"},
+		},
+		{
+			filters:  []TokenFilter{LengthFilter(4, 5)},
+			text:     "a quick brown fox jumped",
+			expected: []string{"quick", "brown"},
+		},
+		{
+			filters:  []TokenFilter{LengthFilter(4, 0)},
+			text:     "a quick brown fox jumped",
+			expected: []string{"quick", "brown", "jumped"},
+		},
+		{
+			filters:  []TokenFilter{PatternFilter(regexp.MustCompile(`^\D+$`))},
+			text:     "route 66 r2d2 droid",
+			expected: []string{"route", "droid"},
+		},
+		{
+			filters:  []TokenFilter{StemmingFilter(func(token string) string { return strings.TrimSuffix(token, "ing") })},
+			text:     "going jumping fox",
+			expected: []string{"go", "jump", "fox"},
+		},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+		tokeniser := NewFilteringTokeniser(TokeniserFunc(strings.Fields), test.filters...)
+
+		tokens := tokeniser.Tokenise(test.text)
+		if !reflect.DeepEqual(test.expected, tokens) {
+			t.Errorf("Expected tokens %v but found %v", test.expected, tokens)
+		}
+	}
+}
+
+func TestFilteringTokeniserVectoriser(t *testing.T) {
+	expected := NewCountVectoriser(stopWords...)
+	expected.Fit(trainSet...)
+
+	vectoriser := NewCountVectoriser()
+	vectoriser.Tokeniser = NewFilteringTokeniser(
+		NewTokeniser(),
+		StopWordFilter(stopWords...),
+	)
+	vectoriser.Fit(trainSet...)
+
+	if !reflect.DeepEqual(expected.Vocabulary, vectoriser.Vocabulary) {
+		t.Errorf("Expected vocabulary %v but found %v", expected.Vocabulary, vectoriser.Vocabulary)
+	}
+}
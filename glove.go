@@ -0,0 +1,173 @@
+package nlp
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+)
+
+// GloVe trains word vectors by factorising a term-term co-occurrence matrix (e.g. as
+// output from a CooccurrenceVectoriser) using the Global Vectors model described by
+// Pennington, Socher and Manning in "GloVe: Global Vectors for Word Representation"
+// (https://nlp.stanford.edu/pubs/glove.pdf).  The model learns a word vector w_i
+// and context vector w~_j, along with biases, for each term such that
+//
+//	w_i . w~_j + b_i + b~_j ~= log(X_ij)
+//
+// for each non-zero co-occurrence count X_ij, weighting the squared error of each
+// count by f(X_ij) = min(1, (X_ij/XMax)^Alpha) so that rare co-occurrences carry
+// less weight and frequent co-occurrences are not over weighted.  Training uses
+// AdaGrad as in the reference implementation.  The final vector for each term is the
+// sum of its word and context vectors.
+type GloVe struct {
+	// K is the dimensionality of the word vectors
+	K int
+
+	// Iterations is the number of training passes over the non-zero co-occurrences
+	Iterations int
+
+	// XMax is the co-occurrence count above which counts are given full weight
+	XMax float64
+
+	// Alpha is the exponent of the weighting function applied to counts below XMax
+	Alpha float64
+
+	// LearningRate is the initial AdaGrad learning rate
+	LearningRate float64
+
+	// Rnd is the random number generator used to initialise the vectors and shuffle
+	// the co-occurrences each iteration
+	Rnd *rand.Rand
+
+	// w and wc hold the word and context vectors (V x (K + 1)) with the bias stored
+	// as the last element of each vector
+	w, wc []float64
+
+	// cost is the weighted least squares cost of the final training iteration
+	cost float64
+}
+
+// NewGloVe creates a new GloVe trainer learning word vectors of k dimensions with
+// default values of 25 Iterations, an XMax of 100, an Alpha of 0.75 and a
+// LearningRate of 0.05 (as per the reference implementation).
+func NewGloVe(k int) *GloVe {
+	return &GloVe{
+		K:            k,
+		Iterations:   25,
+		XMax:         100,
+		Alpha:        0.75,
+		LearningRate: 0.05,
+		Rnd:          rand.New(rand.NewSource(uint64(time.Now().UnixNano()))),
+	}
+}
+
+// glovePair is a single non-zero co-occurrence count between 2 terms.
+type glovePair struct {
+	i, j  int
+	count float64
+}
+
+// Fit trains the word vectors from the specified V x V co-occurrence matrix m where V
+// is the number of terms.  Calling Fit() a second time re-trains the model from
+// scratch.  Fit panics if m is not square.
+func (g *GloVe) Fit(m mat.Matrix) *GloVe {
+	r, c := m.Dims()
+	if r != c {
+		panic(fmt.Sprintf("nlp: Co-occurrence matrix must be square but was %dx%d", r, c))
+	}
+
+	var pairs []glovePair
+	nonZeroDo(m, func(i, j int, v float64) {
+		if v > 0 {
+			pairs = append(pairs, glovePair{i: i, j: j, count: v})
+		}
+	})
+
+	dims := g.K + 1
+	g.w = make([]float64, r*dims)
+	g.wc = make([]float64, r*dims)
+	for i := range g.w {
+		g.w[i] = (g.Rnd.Float64() - 0.5) / float64(dims)
+		g.wc[i] = (g.Rnd.Float64() - 0.5) / float64(dims)
+	}
+	gradsq := make([]float64, r*dims)
+	gradsqc := make([]float64, r*dims)
+	for i := range gradsq {
+		gradsq[i], gradsqc[i] = 1, 1
+	}
+
+	for iter := 0; iter < g.Iterations; iter++ {
+		g.Rnd.Shuffle(len(pairs), func(a, b int) { pairs[a], pairs[b] = pairs[b], pairs[a] })
+		g.cost = 0
+
+		for _, pair := range pairs {
+			w := g.w[pair.i*dims : (pair.i+1)*dims]
+			wc := g.wc[pair.j*dims : (pair.j+1)*dims]
+			sq := gradsq[pair.i*dims : (pair.i+1)*dims]
+			sqc := gradsqc[pair.j*dims : (pair.j+1)*dims]
+
+			diff := w[g.K] + wc[g.K] - math.Log(pair.count)
+			for k := 0; k < g.K; k++ {
+				diff += w[k] * wc[k]
+			}
+			weight := 1.0
+			if pair.count < g.XMax {
+				weight = math.Pow(pair.count/g.XMax, g.Alpha)
+			}
+			fdiff := weight * diff
+			g.cost += 0.5 * fdiff * diff
+
+			fdiff *= g.LearningRate
+			for k := 0; k < g.K; k++ {
+				gw := fdiff * wc[k]
+				gwc := fdiff * w[k]
+				w[k] -= gw / math.Sqrt(sq[k])
+				wc[k] -= gwc / math.Sqrt(sqc[k])
+				sq[k] += gw * gw
+				sqc[k] += gwc * gwc
+			}
+			w[g.K] -= fdiff / math.Sqrt(sq[g.K])
+			wc[g.K] -= fdiff / math.Sqrt(sqc[g.K])
+			sq[g.K] += fdiff * fdiff
+			sqc[g.K] += fdiff * fdiff
+		}
+	}
+	return g
+}
+
+// Vectors returns the trained word vectors as a dense matrix of shape K x V where V is
+// the number of terms.  Each column is the sum of the word and context vectors of the
+// corresponding term (row/column) in the co-occurrence matrix supplied to Fit().
+func (g *GloVe) Vectors() mat.Matrix {
+	dims := g.K + 1
+	v := len(g.w) / dims
+	vectors := mat.NewDense(g.K, v, nil)
+	for i := 0; i < v; i++ {
+		for k := 0; k < g.K; k++ {
+			vectors.Set(k, i, g.w[i*dims+k]+g.wc[i*dims+k])
+		}
+	}
+	return vectors
+}
+
+// Embeddings returns the trained word vectors as Embeddings labelled with the
+// specified terms, which must be in the same order as the rows of the co-occurrence
+// matrix supplied to Fit() e.g. as returned from CooccurrenceVectoriser's
+// GetFeatureNames() method.  An error is returned if the number of terms does not
+// match the number of trained vectors.
+func (g *GloVe) Embeddings(terms []string) (*Embeddings, error) {
+	vectors := g.Vectors()
+	_, v := vectors.Dims()
+	if len(terms) != v {
+		return nil, fmt.Errorf("nlp: Expected %d terms but received %d", v, len(terms))
+	}
+
+	embeddings := NewEmbeddings(g.K)
+	for i, term := range terms {
+		embeddings.Add(term, mat.Col(nil, i, vectors))
+	}
+	return embeddings, nil
+}
@@ -0,0 +1,94 @@
+package nlp
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestGloVe(t *testing.T) {
+	animals := []string{"cat", "dog", "pet", "kitten", "puppy"}
+	vehicles := []string{"car", "road", "wheel", "truck", "traffic"}
+
+	// documents randomly mixing terms from within only one of the 2 groups
+	rnd := rand.New(rand.NewSource(uint64(0)))
+	var docs []string
+	for d := 0; d < 200; d++ {
+		group := animals
+		if d%2 == 1 {
+			group = vehicles
+		}
+		var doc []string
+		for i := 0; i < 8; i++ {
+			doc = append(doc, group[rnd.Intn(len(group))])
+		}
+		docs = append(docs, strings.Join(doc, " "))
+	}
+
+	vectoriser := NewCooccurrenceVectoriser(3)
+	vectoriser.DistanceWeighting = true
+	cooccurrences, err := vectoriser.FitTransform(docs...)
+	if err != nil {
+		t.Fatalf("Failed to build co-occurrence matrix because %v", err)
+	}
+
+	var tests = []struct {
+		term     string
+		expected []string
+	}{
+		{term: "cat", expected: []string{"dog", "kitten", "pet", "puppy"}},
+		{term: "road", expected: []string{"car", "traffic", "truck", "wheel"}},
+	}
+
+	glove := NewGloVe(10)
+	// set Rnd to fixed constant seed for deterministic results
+	glove.Rnd = rand.New(rand.NewSource(uint64(0)))
+	glove.XMax = 10
+	glove.Iterations = 1
+	glove.Fit(cooccurrences)
+	initialCost := glove.cost
+	glove.Iterations = 50
+	glove.Fit(cooccurrences)
+	if glove.cost >= initialCost {
+		t.Errorf("Expected training cost to reduce from %f but found %f", initialCost, glove.cost)
+	}
+
+	if r, c := glove.Vectors().Dims(); r != 10 || c != 10 {
+		t.Errorf("Expected vectors of dims 10x10 but found %dx%d", r, c)
+	}
+
+	embeddings, err := glove.Embeddings(vectoriser.GetFeatureNames())
+	if err != nil {
+		t.Fatalf("Failed to create embeddings because %v", err)
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		var similar []string
+		for _, match := range embeddings.MostSimilar(test.term, 4) {
+			similar = append(similar, match.ID.(string))
+		}
+		sort.Strings(similar)
+		if !reflect.DeepEqual(test.expected, similar) {
+			t.Errorf("Expected terms most similar to '%s' to be %v but found %v", test.term, test.expected, similar)
+		}
+	}
+
+	if _, err := glove.Embeddings([]string{"cat"}); err == nil {
+		t.Errorf("Expected error for mismatched number of terms but found none")
+	}
+}
+
+func TestGloVeNonSquare(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected panic fitting a non square matrix")
+		}
+	}()
+	NewGloVe(2).Fit(mat.NewDense(2, 3, nil))
+}
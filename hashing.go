@@ -1,9 +1,13 @@
 package nlp
 
 import (
+	"encoding/binary"
+	"math/bits"
 	"math/rand"
+	"sync"
 
 	"github.com/james-bowman/sparse"
+	"github.com/spaolacci/murmur3"
 	"gonum.org/v1/gonum/mat"
 )
 
@@ -18,6 +22,7 @@ import (
 // STOC ’02, 2002, p. 380.
 // https://www.cs.princeton.edu/courses/archive/spr04/cos598B/bib/CharikarEstim.pdf
 type SimHash struct {
+	lock        sync.RWMutex
 	hyperplanes []*mat.VecDense
 }
 
@@ -44,14 +49,20 @@ func NewSimHash(bits int, dim int) *SimHash {
 }
 
 // Hash accepts a Vector and outputs a BinaryVec (which also implements the
-// Gonum Vector interface).  This method will panic if the input vector is of a
-// different length than the dim parameter used when constructing the SimHash.
+// Gonum Vector interface).  Vectors longer than the dim parameter used when
+// constructing the SimHash (e.g. following growth of the Vocabulary of a
+// CountVectoriser via PartialFit()) extend the hyperplanes with additional random
+// dimensions.  As the extra dimensions of shorter vectors are zero, hashes of
+// vectors output before the growth remain comparable with those output after.
 func (h *SimHash) Hash(v mat.Vector) *sparse.BinaryVec {
+	h.grow(v.Len())
+
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
 	bits := len(h.hyperplanes)
 	dim := h.hyperplanes[0].Len()
-	if dim != v.Len() {
-		panic("The supplied vector has a different number of dimensions from the projected hyperplanes")
-	}
+	v = padVector(v, dim)
 	sig := sparse.NewBinaryVec(bits)
 	for i := 0; i < bits; i++ {
 		if sparse.Dot(v, h.hyperplanes[i]) >= 0 {
@@ -60,3 +71,145 @@ func (h *SimHash) Hash(v mat.Vector) *sparse.BinaryVec {
 	}
 	return sig
 }
+
+// grow extends each of the hyperplanes with random components up to dim dimensions.
+func (h *SimHash) grow(dim int) {
+	h.lock.RLock()
+	current := h.hyperplanes[0].Len()
+	h.lock.RUnlock()
+	if dim <= current {
+		return
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	for j, hyperplane := range h.hyperplanes {
+		if hyperplane.Len() >= dim {
+			continue
+		}
+		p := make([]float64, dim)
+		for i := range p {
+			if i < hyperplane.Len() {
+				p[i] = hyperplane.AtVec(i)
+			} else {
+				p[i] = rand.NormFloat64()
+			}
+		}
+		h.hyperplanes[j] = mat.NewVecDense(dim, p)
+	}
+}
+
+// SimHashFingerprinter is a transformer that converts each document's (weighted)
+// feature vector into a 64 bit SimHash fingerprint as described by Manku, Jain and
+// Das Sarma for near-duplicate detection of web pages.  Each feature (row) is hashed
+// to 64 bits and the fingerprint is formed from the sign of the sum of the feature
+// weights for each bit position, adding the weight where the feature's hash bit is
+// set and subtracting it otherwise.  Unlike SimHash, no random hyperplanes are
+// required so fingerprints are independent of the dimensionality of the input and
+// may be compared across differently sized feature spaces sharing the same feature
+// indices (e.g. those produced by a HashingVectoriser).  Documents sharing most of
+// their weighted features produce fingerprints differing in only a few bits so
+// near-duplicates may be found by comparing the HammingDistance() between
+// fingerprints.
+//
+// Manku, Gurmeet Singh, Jain, Arvind and Das Sarma, Anish. "Detecting Near-Duplicates
+// for Web Crawling" in Proceedings of the 16th international conference on World Wide
+// Web - WWW '07, 2007, p. 141.
+// https://www2007.org/papers/paper215.pdf
+type SimHashFingerprinter struct {
+	// Seed is the seed for the hash function used to hash each feature
+	Seed uint32
+}
+
+// NewSimHashFingerprinter creates a new SimHashFingerprinter transformer.
+func NewSimHashFingerprinter() *SimHashFingerprinter {
+	return &SimHashFingerprinter{}
+}
+
+// Fit is a no-op as fingerprints are computed independently of any training data.
+// It is provided to satisfy the Transformer interface.
+func (f *SimHashFingerprinter) Fit(m mat.Matrix) Transformer {
+	return f
+}
+
+// Transform computes the fingerprint of each column (document) in m, as per
+// Fingerprints().  The returned matrix is a Binary matrix of shape 64 x C where C is
+// the number of columns in m with each column holding the bits of the corresponding
+// fingerprint.
+func (f *SimHashFingerprinter) Transform(m mat.Matrix) (mat.Matrix, error) {
+	if t, isTypeConv := m.(sparse.TypeConverter); isTypeConv {
+		m = t.ToCSC()
+	}
+	fingerprints := f.Fingerprints(m)
+	sigs := make([]sparse.BinaryVec, len(fingerprints))
+	for j, fingerprint := range fingerprints {
+		sig := sparse.NewBinaryVec(64)
+		for i := 0; i < 64; i++ {
+			if fingerprint&(1<<uint(i)) != 0 {
+				sig.SetBit(i)
+			}
+		}
+		sigs[j] = *sig
+	}
+	return sparse.NewBinary(64, len(fingerprints), sigs), nil
+}
+
+// FitTransform is equivalent to calling Transform() as Fit() is a no-op.
+func (f *SimHashFingerprinter) FitTransform(m mat.Matrix) (mat.Matrix, error) {
+	return f.Transform(m)
+}
+
+// Fingerprints returns the 64 bit fingerprint of each column (document) in m.
+func (f *SimHashFingerprinter) Fingerprints(m mat.Matrix) []uint64 {
+	_, c := m.Dims()
+	fingerprints := make([]uint64, c)
+	var sums [64]float64
+	for j := range fingerprints {
+		for i := range sums {
+			sums[i] = 0
+		}
+		ColNonZeroElemDo(m, j, func(i, j int, v float64) {
+			f.accumulate(&sums, i, v)
+		})
+		fingerprints[j] = fingerprint(&sums)
+	}
+	return fingerprints
+}
+
+// Fingerprint returns the 64 bit fingerprint of the feature vector v.
+func (f *SimHashFingerprinter) Fingerprint(v mat.Vector) uint64 {
+	return f.Fingerprints(v)[0]
+}
+
+// accumulate adds the weight v of feature i to the sums for each bit position that
+// is set within the hash of the feature and subtracts it from all others.
+func (f *SimHashFingerprinter) accumulate(sums *[64]float64, i int, v float64) {
+	var key [8]byte
+	binary.LittleEndian.PutUint64(key[:], uint64(i))
+	h := murmur3.Sum64WithSeed(key[:], f.Seed)
+	for b := range sums {
+		if h&(1<<uint(b)) != 0 {
+			sums[b] += v
+		} else {
+			sums[b] -= v
+		}
+	}
+}
+
+// fingerprint returns the fingerprint with bits set for each positive sum.
+func fingerprint(sums *[64]float64) uint64 {
+	var fp uint64
+	for b, sum := range sums {
+		if sum > 0 {
+			fp |= 1 << uint(b)
+		}
+	}
+	return fp
+}
+
+// HammingDistance returns the number of bits that differ between the fingerprints
+// a and b.
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
@@ -0,0 +1,90 @@
+package nlp
+
+import (
+	"testing"
+
+	"github.com/james-bowman/sparse"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestSimHashFingerprinter(t *testing.T) {
+	corpus := []string{
+		"the quick brown fox jumped over the lazy dog and ran into the woods to find a place to sleep",
+		"the quick brown fox jumped over the lazy dog and ran into the forest to find a place to sleep",
+		"stock markets fell sharply today as investors worried about rising interest rates and inflation",
+		"the quick brown fox jumped over the lazy dog and ran into the woods to find a place to sleep",
+	}
+
+	var tests = []struct {
+		a, b        int
+		maxDistance int
+		minDistance int
+	}{
+		// identical documents
+		{a: 0, b: 3, maxDistance: 0, minDistance: 0},
+		// near-duplicate documents
+		{a: 0, b: 1, maxDistance: 12, minDistance: 0},
+		// unrelated documents
+		{a: 0, b: 2, maxDistance: 64, minDistance: 20},
+		{a: 1, b: 2, maxDistance: 64, minDistance: 20},
+	}
+
+	vectoriser := NewCountVectoriser()
+	features, err := vectoriser.FitTransform(corpus...)
+	if err != nil {
+		t.Fatalf("Failed to vectorise corpus because %v", err)
+	}
+
+	fingerprinter := NewSimHashFingerprinter()
+	fingerprints := fingerprinter.Fingerprints(features)
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		distance := HammingDistance(fingerprints[test.a], fingerprints[test.b])
+		if distance > test.maxDistance || distance < test.minDistance {
+			t.Errorf("Expected distance between documents %d and %d in range %d-%d but found %d", test.a, test.b, test.minDistance, test.maxDistance, distance)
+		}
+	}
+
+	// Transform should produce the same fingerprints as a binary matrix
+	result, err := fingerprinter.Transform(features)
+	if err != nil {
+		t.Fatalf("Failed to transform because %v", err)
+	}
+	binary := result.(*sparse.Binary)
+	if r, c := binary.Dims(); r != 64 || c != len(corpus) {
+		t.Errorf("Expected dims 64x%d but found %dx%d", len(corpus), r, c)
+	}
+	for j, fingerprint := range fingerprints {
+		if fp := fingerprinter.Fingerprint(mat.NewVecDense(len(vectoriser.Vocabulary), mat.Col(nil, j, features))); fp != fingerprint {
+			t.Errorf("Expected fingerprint %x for document %d but found %x", fingerprint, j, fp)
+		}
+		for i := 0; i < 64; i++ {
+			expected := float64((fingerprint >> uint(i)) & 1)
+			if v := binary.At(i, j); v != expected {
+				t.Errorf("Expected bit %d of document %d to be %f but found %f", i, j, expected, v)
+			}
+		}
+	}
+}
+
+func TestHammingDistance(t *testing.T) {
+	var tests = []struct {
+		a, b     uint64
+		expected int
+	}{
+		{a: 0, b: 0, expected: 0},
+		{a: 0, b: 1, expected: 1},
+		{a: 0xff, b: 0x0f, expected: 4},
+		{a: 0, b: ^uint64(0), expected: 64},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		if d := HammingDistance(test.a, test.b); d != test.expected {
+			t.Errorf("Expected %d but found %d", test.expected, d)
+		}
+	}
+}
@@ -0,0 +1,457 @@
+package nlp
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/james-bowman/sparse"
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// HierarchicalDirichletProcess (HDP) is a non-parametric topic model that, unlike
+// LatentDirichletAllocation, does not require the number of topics to be specified in
+// advance.  Instead, the number of topics is inferred from the training data with
+// new topics being created, and unused topics being discarded, as the model is fitted.
+// Documents share a corpus level distribution over an unbounded number of topics with
+// each document drawing its own distribution over topics from it.
+//
+// This transformer uses the direct assignment Gibbs sampling scheme described by
+// Teh et al (https://people.eecs.berkeley.edu/~jordan/papers/hdp.pdf).  As a Gibbs
+// sampler, the values of the input matrix are treated as word counts and rounded to
+// the nearest integer.
+type HierarchicalDirichletProcess struct {
+	// Iterations is the number of Gibbs sampling sweeps through the training data
+	Iterations int
+
+	// TransformationPasses is the number of Gibbs sampling sweeps through each document
+	// when transforming new documents given a previously fitted topic model
+	TransformationPasses int
+
+	// Alpha is the concentration parameter of the document level Dirichlet processes.
+	// Larger values allow documents to draw on more topics.
+	Alpha float64
+
+	// Gamma is the concentration parameter of the corpus level Dirichlet process.
+	// Larger values lead to more topics being inferred.
+	Gamma float64
+
+	// Eta is the prior of phi (the topics over words distribution)
+	Eta float64
+
+	// Rnd is the random number generator used for sampling
+	Rnd *rand.Rand
+
+	w int
+
+	// beta is the corpus level distribution over topics with the final element holding
+	// the probability mass of all, as yet, unused topics
+	beta []float64
+
+	// nPhi is the number of words assigned to each topic by word (topic x word)
+	nPhi [][]int
+
+	// nZ is the number of words assigned to each topic
+	nZ []int
+}
+
+// NewHierarchicalDirichletProcess returns a new HierarchicalDirichletProcess type
+// initialised with default values.
+func NewHierarchicalDirichletProcess() *HierarchicalDirichletProcess {
+	return &HierarchicalDirichletProcess{
+		Iterations:           200,
+		TransformationPasses: 50,
+		Alpha:                1,
+		Gamma:                1,
+		Eta:                  0.1,
+		Rnd:                  rand.New(rand.NewSource(uint64(time.Now().UnixNano()))),
+	}
+}
+
+// hdpDoc holds the words of a single document along with their topic assignments
+// and the number of words assigned to each topic.
+type hdpDoc struct {
+	words  []int
+	z      []int
+	nTheta []int
+}
+
+// newHdpDocs converts the columns of the matrix m into documents, expanding each
+// element into the (rounded) number of occurrences of the word.  Words with an index
+// of w or greater are discarded.
+func newHdpDocs(m mat.Matrix, w int) []*hdpDoc {
+	_, c := m.Dims()
+	docs := make([]*hdpDoc, c)
+	for j := range docs {
+		doc := &hdpDoc{}
+		ColNonZeroElemDo(m, j, func(i, j int, v float64) {
+			if i >= w {
+				return
+			}
+			for n := int(math.Round(v)); n > 0; n-- {
+				doc.words = append(doc.words, i)
+			}
+		})
+		doc.z = make([]int, len(doc.words))
+		for i := range doc.z {
+			doc.z[i] = -1
+		}
+		docs[j] = doc
+	}
+	return docs
+}
+
+// K returns the number of topics inferred during Fit().
+func (h *HierarchicalDirichletProcess) K() int {
+	return len(h.nZ)
+}
+
+// Fit fits the model to the specified matrix m.  The number of topics and the
+// probability distribution of topics over words are learnt and stored to be used
+// for future transformations and analysis.
+func (h *HierarchicalDirichletProcess) Fit(m mat.Matrix) Transformer {
+	h.FitTransform(m)
+	return h
+}
+
+// FitTransform is approximately equivalent to calling Fit() followed by Transform()
+// on the same matrix.  This is a useful shortcut where separate training data is not being
+// used to fit the model i.e. the model is fitted on the fly to the test data.
+// The returned matrix contains the document over topic distributions where each element
+// is the probability of the corresponding document being related to the corresponding
+// topic.  The returned matrix is a Dense matrix of shape K x C where K is the number
+// of inferred topics and C is the number of columns in the input matrix (representing
+// the documents).
+func (h *HierarchicalDirichletProcess) FitTransform(m mat.Matrix) (mat.Matrix, error) {
+	return h.FitTransformCtx(context.Background(), m)
+}
+
+// FitTransformCtx is equivalent to FitTransform() except that fitting stops, returning
+// ctx.Err(), if ctx is cancelled or its deadline expires.  The context is checked
+// before each Gibbs sampling sweep so the model is left partially fitted.
+func (h *HierarchicalDirichletProcess) FitTransformCtx(ctx context.Context, m mat.Matrix) (mat.Matrix, error) {
+	if t, isTypeConv := m.(sparse.TypeConverter); isTypeConv {
+		m = t.ToCSC()
+	}
+	h.w, _ = m.Dims()
+	h.beta = []float64{1}
+	h.nPhi = nil
+	h.nZ = nil
+
+	docs := newHdpDocs(m, h.w)
+	for iter := 0; iter < h.Iterations; iter++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for _, doc := range docs {
+			h.sampleDoc(doc, true)
+		}
+		h.compact(docs)
+		h.sampleBeta(docs)
+	}
+
+	return h.theta(docs), nil
+}
+
+// Transform transforms the input matrix into a matrix representing the distribution
+// of the documents over topics.  The topics learnt during Fit() are held fixed and no
+// new topics are created.
+// The returned matrix contains the document over topic distributions where each element
+// is the probability of the corresponding document being related to the corresponding
+// topic.  The returned matrix is a Dense matrix of shape K x C where K is the number
+// of inferred topics and C is the number of columns in the input matrix (representing
+// the documents).
+func (h *HierarchicalDirichletProcess) Transform(m mat.Matrix) (mat.Matrix, error) {
+	if t, isTypeConv := m.(sparse.TypeConverter); isTypeConv {
+		m = t.ToCSC()
+	}
+	docs := newHdpDocs(m, h.w)
+	for _, doc := range docs {
+		if h.K() == 0 {
+			break
+		}
+		for pass := 0; pass < h.TransformationPasses; pass++ {
+			h.sampleDoc(doc, false)
+		}
+	}
+
+	return h.theta(docs), nil
+}
+
+// sampleDoc performs a single Gibbs sampling sweep through the words of doc,
+// resampling the topic assigned to each word.  If fit is true, the global topic
+// statistics are updated and new topics may be created otherwise they are held fixed.
+func (h *HierarchicalDirichletProcess) sampleDoc(doc *hdpDoc, fit bool) {
+	weightedEta := float64(h.w) * h.Eta
+	p := make([]float64, h.K()+1)
+	if len(doc.nTheta) < h.K() {
+		doc.nTheta = append(doc.nTheta, make([]int, h.K()-len(doc.nTheta))...)
+	}
+
+	for i, word := range doc.words {
+		if k := doc.z[i]; k >= 0 {
+			doc.nTheta[k]--
+			if fit {
+				h.nPhi[k][word]--
+				h.nZ[k]--
+			}
+		}
+
+		k := h.K()
+		p = p[:0]
+		var sum float64
+		for t := 0; t < k; t++ {
+			sum += (float64(doc.nTheta[t]) + h.Alpha*h.beta[t]) *
+				(float64(h.nPhi[t][word]) + h.Eta) / (float64(h.nZ[t]) + weightedEta)
+			p = append(p, sum)
+		}
+		if fit {
+			sum += h.Alpha * h.beta[k] / float64(h.w)
+			p = append(p, sum)
+		}
+
+		u := h.Rnd.Float64() * sum
+		topic := 0
+		for topic < len(p)-1 && p[topic] < u {
+			topic++
+		}
+
+		if topic == k {
+			h.newTopic()
+		}
+		if topic >= len(doc.nTheta) {
+			doc.nTheta = append(doc.nTheta, 0)
+		}
+		doc.z[i] = topic
+		doc.nTheta[topic]++
+		if fit {
+			h.nPhi[topic][word]++
+			h.nZ[topic]++
+		}
+	}
+}
+
+// newTopic creates a new topic splitting the probability mass of the unused topics
+// according to the stick breaking construction.
+func (h *HierarchicalDirichletProcess) newTopic() {
+	k := h.K()
+	b := 1 - math.Pow(h.Rnd.Float64(), 1/h.Gamma)
+	h.beta = append(h.beta, h.beta[k]*(1-b))
+	h.beta[k] *= b
+	h.nPhi = append(h.nPhi, make([]int, h.w))
+	h.nZ = append(h.nZ, 0)
+}
+
+// compact discards any topics no longer assigned to any words returning their
+// probability mass to the unused topics.
+func (h *HierarchicalDirichletProcess) compact(docs []*hdpDoc) {
+	mapping := make([]int, h.K())
+	var k int
+	for t, n := range h.nZ {
+		if n == 0 {
+			mapping[t] = -1
+			h.beta[len(h.beta)-1] += h.beta[t]
+			continue
+		}
+		mapping[t] = k
+		h.beta[k] = h.beta[t]
+		h.nPhi[k] = h.nPhi[t]
+		h.nZ[k] = n
+		k++
+	}
+	if k == h.K() {
+		return
+	}
+	h.beta[k] = h.beta[len(h.beta)-1]
+	h.beta = h.beta[:k+1]
+	h.nPhi = h.nPhi[:k]
+	h.nZ = h.nZ[:k]
+
+	for _, doc := range docs {
+		nTheta := make([]int, k)
+		for i, t := range doc.z {
+			doc.z[i] = mapping[t]
+			nTheta[doc.z[i]]++
+		}
+		doc.nTheta = nTheta
+	}
+}
+
+// sampleBeta resamples the corpus level distribution over topics given the number
+// of tables (sampled using the Antoniak distribution) serving each topic across all
+// the documents.
+func (h *HierarchicalDirichletProcess) sampleBeta(docs []*hdpDoc) {
+	k := h.K()
+	tables := make([]float64, k)
+	for _, doc := range docs {
+		for t, n := range doc.nTheta {
+			concentration := h.Alpha * h.beta[t]
+			for j := 0; j < n; j++ {
+				if h.Rnd.Float64() < concentration/(concentration+float64(j)) {
+					tables[t]++
+				}
+			}
+		}
+	}
+
+	var sum float64
+	for t := 0; t <= k; t++ {
+		shape := h.Gamma
+		if t < k {
+			shape = tables[t]
+		}
+		if shape > 0 {
+			h.beta[t] = distuv.Gamma{Alpha: shape, Beta: 1, Src: h.Rnd}.Rand()
+		} else {
+			h.beta[t] = 0
+		}
+		sum += h.beta[t]
+	}
+	for t := range h.beta {
+		h.beta[t] /= sum
+	}
+}
+
+// theta returns the normalised document over topic distributions for docs as a
+// K x C matrix.
+func (h *HierarchicalDirichletProcess) theta(docs []*hdpDoc) mat.Matrix {
+	k := h.K()
+	theta := mat.NewDense(k, len(docs), nil)
+	for j, doc := range docs {
+		var sum float64
+		for t := 0; t < k; t++ {
+			var n int
+			if t < len(doc.nTheta) {
+				n = doc.nTheta[t]
+			}
+			v := float64(n) + h.Alpha*h.beta[t]
+			theta.Set(t, j, v)
+			sum += v
+		}
+		for t := 0; t < k; t++ {
+			theta.Set(t, j, theta.At(t, j)/sum)
+		}
+	}
+	return theta
+}
+
+// Components returns the topic over words probability distribution.  The returned
+// matrix is of dimensions K x W where W was the number of rows in the training matrix
+// and each column represents a unique words in the vocabulary and K is the number of
+// inferred topics.
+func (h *HierarchicalDirichletProcess) Components() mat.Matrix {
+	k := h.K()
+	phi := mat.NewDense(k, h.w, nil)
+	weightedEta := float64(h.w) * h.Eta
+	for t := 0; t < k; t++ {
+		for w := 0; w < h.w; w++ {
+			phi.Set(t, w, (float64(h.nPhi[t][w])+h.Eta)/(float64(h.nZ[t])+weightedEta))
+		}
+	}
+	return phi
+}
+
+// TopTerms returns the k terms with the highest probability within the specified topic
+// in descending order of probability.  featureNames should contain the name of each
+// term ordered by index (row) in the training matrix e.g. as returned by the
+// GetFeatureNames() method of the vectoriser used to produce it.
+func (h *HierarchicalDirichletProcess) TopTerms(topic, k int, featureNames []string) []string {
+	return topTerms(h.Components(), topic, false, k, featureNames)
+}
+
+// hdpVersion is the version of the serialisation format written by Save().
+const hdpVersion = 1
+
+// hdpHeader is the fixed size portion of a serialised HierarchicalDirichletProcess.
+type hdpHeader struct {
+	Version              int64
+	Iterations           int64
+	TransformationPasses int64
+	Alpha                float64
+	Gamma                float64
+	Eta                  float64
+	W                    int64
+	K                    int64
+}
+
+// Save binary serialises the model, including its hyperparameters, and writes it into
+// w.  This is useful for persisting a trained model to disk so that it may be loaded
+// (using the Load() method) in another context (e.g. production) for reproducible
+// results.
+func (h HierarchicalDirichletProcess) Save(w io.Writer) error {
+	header := hdpHeader{
+		Version:              hdpVersion,
+		Iterations:           int64(h.Iterations),
+		TransformationPasses: int64(h.TransformationPasses),
+		Alpha:                h.Alpha,
+		Gamma:                h.Gamma,
+		Eta:                  h.Eta,
+		W:                    int64(h.w),
+		K:                    int64(h.K()),
+	}
+	if err := binary.Write(w, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, h.beta); err != nil {
+		return err
+	}
+	counts := make([]int64, 0, len(h.nZ)*(h.w+1))
+	for t, n := range h.nZ {
+		counts = append(counts, int64(n))
+		for _, v := range h.nPhi[t] {
+			counts = append(counts, int64(v))
+		}
+	}
+	return binary.Write(w, binary.LittleEndian, counts)
+}
+
+// Load binary deserialises the previously serialised model into the receiver.  This is
+// useful for loading a previously trained and saved model from another context
+// (e.g. offline training) for use within another context (e.g. production) for
+// reproducible results.  Load should only be performed with trusted data.
+func (h *HierarchicalDirichletProcess) Load(r io.Reader) error {
+	var header hdpHeader
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return err
+	}
+	if header.Version != hdpVersion {
+		return fmt.Errorf("nlp: Unsupported serialisation version %d", header.Version)
+	}
+	beta := make([]float64, header.K+1)
+	if err := binary.Read(r, binary.LittleEndian, beta); err != nil {
+		return err
+	}
+	counts := make([]int64, header.K*(header.W+1))
+	if err := binary.Read(r, binary.LittleEndian, counts); err != nil {
+		return err
+	}
+
+	w := int(header.W)
+	nZ := make([]int, header.K)
+	nPhi := make([][]int, header.K)
+	for t := range nZ {
+		topic := counts[t*(w+1) : (t+1)*(w+1)]
+		nZ[t] = int(topic[0])
+		nPhi[t] = make([]int, w)
+		for i, v := range topic[1:] {
+			nPhi[t][i] = int(v)
+		}
+	}
+
+	h.Iterations = int(header.Iterations)
+	h.TransformationPasses = int(header.TransformationPasses)
+	h.Alpha = header.Alpha
+	h.Gamma = header.Gamma
+	h.Eta = header.Eta
+	h.w = w
+	h.beta = beta
+	h.nZ = nZ
+	h.nPhi = nPhi
+
+	return nil
+}
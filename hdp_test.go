@@ -0,0 +1,128 @@
+package nlp
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/james-bowman/sparse"
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestHierarchicalDirichletProcess(t *testing.T) {
+	names := []string{"cat", "dog", "pet", "car", "road", "wheel"}
+
+	// 20 documents alternating between 2 blocks of terms
+	coo := sparse.NewCOO(6, 20, nil, nil, nil)
+	for j := 0; j < 20; j++ {
+		if j%2 == 0 {
+			coo.Set(0, j, 3)
+			coo.Set(1, j, 2)
+			coo.Set(2, j, 4)
+		} else {
+			coo.Set(3, j, 2)
+			coo.Set(4, j, 4)
+			coo.Set(5, j, 3)
+		}
+	}
+
+	var tests = []struct {
+		m mat.Matrix
+	}{
+		{m: mat.DenseCopyOf(coo)},
+		{m: coo.ToCSR()},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		hdp := NewHierarchicalDirichletProcess()
+		// set Rnd to fixed constant seed for deterministic results
+		hdp.Rnd = rand.New(rand.NewSource(uint64(0)))
+
+		theta, err := hdp.FitTransform(test.m)
+		if err != nil {
+			t.Errorf("Failed to fit HDP because %v", err)
+		}
+		if hdp.K() < 2 {
+			t.Errorf("Expected at least 2 topics to be inferred but found %d", hdp.K())
+		}
+
+		transformed, err := hdp.Transform(test.m)
+		if err != nil {
+			t.Errorf("Failed to transform using HDP because %v", err)
+		}
+
+		for _, result := range []mat.Matrix{theta, transformed} {
+			r, c := result.Dims()
+			if r != hdp.K() || c != 20 {
+				t.Errorf("Expected dims %dx20 but found %dx%d", hdp.K(), r, c)
+				continue
+			}
+			for j := 0; j < c; j++ {
+				col := mat.Col(nil, j, result)
+				if math.Abs(floats.Sum(col)-1) > 1e-9 {
+					t.Errorf("Expected document %d topic distribution to sum to 1 but found %f", j, floats.Sum(col))
+				}
+
+				// the top terms of each document's most probable topic should all
+				// come from the same block as the document
+				top := hdp.TopTerms(floats.MaxIdx(col), 3, names)
+				sort.Strings(top)
+				expected := []string{"cat", "dog", "pet"}
+				if j%2 != 0 {
+					expected = []string{"car", "road", "wheel"}
+				}
+				if !reflect.DeepEqual(expected, top) {
+					t.Errorf("Document %d: Expected top terms %v but found %v", j, expected, top)
+				}
+			}
+		}
+	}
+}
+
+func TestHierarchicalDirichletProcessSaveLoad(t *testing.T) {
+	input := mat.NewDense(6, 4, []float64{
+		3, 0, 2, 0,
+		2, 0, 3, 0,
+		4, 0, 1, 0,
+		0, 2, 0, 1,
+		0, 4, 0, 3,
+		0, 3, 0, 2,
+	})
+
+	wanted := NewHierarchicalDirichletProcess()
+	// set Rnd to fixed constant seed for deterministic results
+	wanted.Rnd = rand.New(rand.NewSource(uint64(0)))
+	wanted.Gamma = 1.5
+	wanted.Fit(input)
+
+	var buf bytes.Buffer
+	if err := wanted.Save(&buf); err != nil {
+		t.Fatalf("Error encoding: %v\n", err)
+	}
+	loaded := NewHierarchicalDirichletProcess()
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatalf("Error unencoding: %v\n", err)
+	}
+
+	if wanted.K() != loaded.K() || wanted.Gamma != loaded.Gamma || !reflect.DeepEqual(wanted.beta, loaded.beta) {
+		t.Errorf("Expected %d topics with beta %v but found %d with %v", wanted.K(), wanted.beta, loaded.K(), loaded.beta)
+	}
+	if !mat.Equal(wanted.Components(), loaded.Components()) {
+		t.Errorf("Expected components %v but found %v", mat.Formatted(wanted.Components()), mat.Formatted(loaded.Components()))
+	}
+
+	// both models should produce the same transform given the same random initialisation
+	wanted.Rnd = rand.New(rand.NewSource(uint64(1)))
+	loaded.Rnd = rand.New(rand.NewSource(uint64(1)))
+	expected, _ := wanted.Transform(input)
+	result, _ := loaded.Transform(input)
+	if !mat.Equal(expected, result) {
+		t.Errorf("Expected %v but found %v", mat.Formatted(expected), mat.Formatted(result))
+	}
+}
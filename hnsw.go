@@ -0,0 +1,494 @@
+package nlp
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+)
+
+// HNSWIndex is a Hierarchical Navigable Small World (HNSW) graph index supporting fast
+// Approximate Nearest Neighbour (ANN) searches over dense vectors, for example the
+// reduced dimensional document vectors output from TruncatedSVD or averaged word
+// embeddings, as described by Malkov and Yashunin in "Efficient and robust approximate
+// nearest neighbor search using Hierarchical Navigable Small World graphs"
+// (https://arxiv.org/abs/1603.09320).  Vectors are linked to their nearest neighbours
+// within a hierarchy of proximity graphs where each layer contains an exponentially
+// decreasing subset of the vectors.  Searches start at the sparse top layer and
+// greedily descend towards the query, visiting only a small fraction of the vectors
+// so that searches scale logarithmically with the number of vectors.  Recall and
+// speed may be traded off using EfSearch.
+//
+// Deleted (and replaced) vectors are marked as deleted, and excluded from search
+// results, but remain within the graph to preserve its connectivity.  Searches still
+// traverse deleted vectors, so their cost grows with the proportion of deleted vectors
+// and, as deleted vectors are never reused, so does memory.  Compact() rebuilds the
+// graph from the remaining vectors to reclaim both.  An HNSWIndex is safe for
+// concurrent use.
+type HNSWIndex struct {
+	// M is the maximum number of neighbours linked to each vector within each layer
+	// above the bottom layer, which may link up to 2 * M.  Higher values improve
+	// recall, particularly for high dimensional vectors, at the expense of memory and
+	// slower insertion.
+	M int
+
+	// EfConstruction is the number of candidate neighbours considered when adding
+	// vectors.  Higher values produce a better quality graph at the expense of slower
+	// insertion.
+	EfConstruction int
+
+	// EfSearch is the number of candidate neighbours considered when searching.
+	// Higher values improve recall at the expense of speed.  If less than k, k
+	// candidates are considered.
+	EfSearch int
+
+	// Metric is the measure of similarity between vectors
+	Metric SimilarityMetric
+
+	// Rnd is the random number generator used to assign vectors to layers
+	Rnd *rand.Rand
+
+	lock     sync.RWMutex
+	nodes    []*hnswNode
+	ids      map[string]int
+	entry    int
+	maxLevel int
+	dim      int
+}
+
+// hnswNode is a vector within an HNSWIndex along with its neighbours within each layer
+// from 0 up to and including the layer to which it was assigned.
+type hnswNode struct {
+	id         string
+	vector     []float64
+	neighbours [][]int
+	deleted    bool
+}
+
+// NewHNSWIndex creates a new empty HNSWIndex scoring vectors using the specified
+// similarity metric with default values of an M of 16, an EfConstruction of 200 and
+// an EfSearch of 50.
+func NewHNSWIndex(metric SimilarityMetric) *HNSWIndex {
+	return &HNSWIndex{
+		M:              16,
+		EfConstruction: 200,
+		EfSearch:       50,
+		Metric:         metric,
+		Rnd:            rand.New(rand.NewSource(uint64(time.Now().UnixNano()))),
+		ids:            make(map[string]int),
+		entry:          -1,
+	}
+}
+
+// Len returns the number of (undeleted) vectors within the index.
+func (h *HNSWIndex) Len() int {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return len(h.ids)
+}
+
+// Add adds the vector v with associated id to the index.  If the index already
+// contains a vector with the same id, it is replaced.  All vectors must be of the same
+// length.
+func (h *HNSWIndex) Add(id string, v mat.Vector) error {
+	vector := make([]float64, v.Len())
+	for i := range vector {
+		vector[i] = v.AtVec(i)
+	}
+	if h.Metric == Cosine {
+		normalise(vector)
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	return h.add(id, vector)
+}
+
+// add adds the (normalised, if appropriate for the metric) vector with associated id
+// to the index.  The caller must hold the write lock.
+func (h *HNSWIndex) add(id string, vector []float64) error {
+	if len(h.nodes) == 0 {
+		h.dim = len(vector)
+	} else if len(vector) != h.dim {
+		return fmt.Errorf("nlp: Expected vector of length %d but found %d", h.dim, len(vector))
+	}
+	if existing, ok := h.ids[id]; ok {
+		h.nodes[existing].deleted = true
+	}
+
+	level := int(-math.Log(1-h.Rnd.Float64()) / math.Log(float64(h.maxM(1))))
+	node := &hnswNode{id: id, vector: vector, neighbours: make([][]int, level+1)}
+	n := len(h.nodes)
+	h.nodes = append(h.nodes, node)
+	h.ids[id] = n
+
+	if h.entry < 0 {
+		h.entry = n
+		h.maxLevel = level
+		return nil
+	}
+
+	entry := h.entry
+	for l := h.maxLevel; l > level; l-- {
+		entry = h.searchLayer(vector, entry, 1, l, false)[0].node
+	}
+	for l := min(level, h.maxLevel); l >= 0; l-- {
+		candidates := h.searchLayer(vector, entry, h.EfConstruction, l, false)
+		neighbours := candidates
+		if len(neighbours) > h.maxM(l) {
+			neighbours = neighbours[:h.maxM(l)]
+		}
+		for _, c := range neighbours {
+			node.neighbours[l] = append(node.neighbours[l], c.node)
+			h.link(c.node, n, l)
+		}
+		entry = candidates[0].node
+	}
+
+	if level > h.maxLevel {
+		h.entry = n
+		h.maxLevel = level
+	}
+	return nil
+}
+
+// Delete removes the vector with the specified id from the index returning true or
+// false if the index contains no vector with that id.
+func (h *HNSWIndex) Delete(id string) bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	n, ok := h.ids[id]
+	if !ok {
+		return false
+	}
+	h.nodes[n].deleted = true
+	delete(h.ids, id)
+	return true
+}
+
+// Compact rebuilds the graph from the undeleted vectors, discarding deleted and
+// replaced vectors.  This reclaims their memory and restores search performance after
+// many vectors have been deleted or replaced but is as expensive as re-adding every
+// remaining vector.
+func (h *HNSWIndex) Compact() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	nodes := h.nodes
+	h.nodes = nil
+	h.ids = make(map[string]int)
+	h.entry = -1
+	h.maxLevel = 0
+	for _, node := range nodes {
+		if !node.deleted {
+			h.add(node.id, node.vector)
+		}
+	}
+}
+
+// Search returns the ids and similarity scores of the (approximately) k vectors most
+// similar to the query vector q in descending order of score.  Fewer than k results
+// are returned if the index contains fewer than k vectors.  Deleted vectors are
+// traversed, but not counted amongst the EfSearch candidates, so many deletions slow
+// searches (see Compact()).
+func (h *HNSWIndex) Search(q mat.Vector, k int) ([]string, []float64) {
+	query := make([]float64, q.Len())
+	for i := range query {
+		query[i] = q.AtVec(i)
+	}
+	if h.Metric == Cosine {
+		normalise(query)
+	}
+
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	if h.entry < 0 || k < 1 || len(query) != h.dim {
+		return nil, nil
+	}
+	entry := h.entry
+	for l := h.maxLevel; l > 0; l-- {
+		entry = h.searchLayer(query, entry, 1, l, false)[0].node
+	}
+	ef := h.EfSearch
+	if ef < k {
+		ef = k
+	}
+
+	var ids []string
+	var scores []float64
+	for _, c := range h.searchLayer(query, entry, ef, 0, true) {
+		ids = append(ids, h.nodes[c.node].id)
+		scores = append(scores, c.score)
+		if len(ids) == k {
+			break
+		}
+	}
+	return ids, scores
+}
+
+// maxM returns the maximum number of neighbours of each node within layer l.
+func (h *HNSWIndex) maxM(l int) int {
+	m := h.M
+	if m < 2 {
+		m = 2
+	}
+	if l == 0 {
+		return 2 * m
+	}
+	return m
+}
+
+// link adds target as a neighbour of node n within layer l, pruning the neighbours of
+// n back to the most similar if the maximum for the layer is exceeded.
+func (h *HNSWIndex) link(n, target, l int) {
+	node := h.nodes[n]
+	node.neighbours[l] = append(node.neighbours[l], target)
+	if len(node.neighbours[l]) <= h.maxM(l) {
+		return
+	}
+	candidates := make([]hnswCandidate, len(node.neighbours[l]))
+	for i, neighbour := range node.neighbours[l] {
+		candidates[i] = hnswCandidate{node: neighbour, score: h.score(node.vector, neighbour)}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+	node.neighbours[l] = node.neighbours[l][:h.maxM(l)]
+	for i := range node.neighbours[l] {
+		node.neighbours[l][i] = candidates[i].node
+	}
+}
+
+// score returns the similarity between vector and the vector of node n.
+func (h *HNSWIndex) score(vector []float64, n int) float64 {
+	var dot float64
+	for i, v := range h.nodes[n].vector {
+		dot += v * vector[i]
+	}
+	return dot
+}
+
+// searchLayer performs a greedy best first search of layer l, starting from the entry
+// node, for the ef nodes most similar to vector returning them in descending order of
+// similarity.  If live is true, deleted nodes are traversed but excluded from the
+// results so that up to ef undeleted nodes are returned.
+func (h *HNSWIndex) searchLayer(vector []float64, entry, ef, l int, live bool) []hnswCandidate {
+	visited := map[int]bool{entry: true}
+	start := hnswCandidate{node: entry, score: h.score(vector, entry)}
+	candidates := &hnswHeap{max: true, items: []hnswCandidate{start}}
+	results := &hnswHeap{}
+	if !live || !h.nodes[entry].deleted {
+		results.items = append(results.items, start)
+	}
+
+	for candidates.Len() > 0 {
+		c := heap.Pop(candidates).(hnswCandidate)
+		if results.Len() >= ef && c.score < results.items[0].score {
+			break
+		}
+		for _, neighbour := range h.nodes[c.node].neighbours[l] {
+			if visited[neighbour] {
+				continue
+			}
+			visited[neighbour] = true
+			n := hnswCandidate{node: neighbour, score: h.score(vector, neighbour)}
+			if results.Len() < ef || n.score > results.items[0].score {
+				heap.Push(candidates, n)
+				if live && h.nodes[neighbour].deleted {
+					continue
+				}
+				heap.Push(results, n)
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	sort.Slice(results.items, func(i, j int) bool { return results.items[i].score > results.items[j].score })
+	return results.items
+}
+
+// hnswCandidate is a node considered during a search along with its similarity to the
+// query.
+type hnswCandidate struct {
+	node  int
+	score float64
+}
+
+// hnswHeap is a heap of candidates ordered by score, with the most similar candidate
+// at the root if max is true or the least similar otherwise.
+type hnswHeap struct {
+	items []hnswCandidate
+	max   bool
+}
+
+func (c hnswHeap) Len() int { return len(c.items) }
+
+func (c hnswHeap) Less(i, j int) bool {
+	if c.max {
+		return c.items[i].score > c.items[j].score
+	}
+	return c.items[i].score < c.items[j].score
+}
+
+func (c hnswHeap) Swap(i, j int) { c.items[i], c.items[j] = c.items[j], c.items[i] }
+
+func (c *hnswHeap) Push(x interface{}) { c.items = append(c.items, x.(hnswCandidate)) }
+
+func (c *hnswHeap) Pop() interface{} {
+	old := c.items
+	x := old[len(old)-1]
+	c.items = old[:len(old)-1]
+	return x
+}
+
+// normalise scales vector in place to unit L2 norm.  Zero vectors are left unchanged.
+func normalise(vector []float64) {
+	var norm float64
+	for _, v := range vector {
+		norm += v * v
+	}
+	if norm == 0 {
+		return
+	}
+	norm = math.Sqrt(norm)
+	for i := range vector {
+		vector[i] /= norm
+	}
+}
+
+// hnswHeader is the fixed size portion of a serialised HNSWIndex.
+type hnswHeader struct {
+	M              int64
+	EfConstruction int64
+	EfSearch       int64
+	Metric         int64
+	Dim            int64
+	Nodes          int64
+	Entry          int64
+	MaxLevel       int64
+}
+
+// Save binary serialises the index, including its hyperparameters, vectors and graph,
+// and writes it into w.  This is useful for persisting an index to disk so that it
+// may be loaded (using the Load() method) without re-adding all of the vectors.
+func (h *HNSWIndex) Save(w io.Writer) error {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	header := hnswHeader{
+		M:              int64(h.M),
+		EfConstruction: int64(h.EfConstruction),
+		EfSearch:       int64(h.EfSearch),
+		Metric:         int64(h.Metric),
+		Dim:            int64(h.dim),
+		Nodes:          int64(len(h.nodes)),
+		Entry:          int64(h.entry),
+		MaxLevel:       int64(h.maxLevel),
+	}
+	if err := binary.Write(w, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	for _, node := range h.nodes {
+		if err := writeString(w, node.id); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.LittleEndian, node.deleted); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.LittleEndian, node.vector); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.LittleEndian, int64(len(node.neighbours))); err != nil {
+			return err
+		}
+		for _, neighbours := range node.neighbours {
+			links := make([]int64, len(neighbours))
+			for i, n := range neighbours {
+				links[i] = int64(n)
+			}
+			if err := binary.Write(w, binary.LittleEndian, int64(len(links))); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.LittleEndian, links); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Load binary deserialises an index, previously serialised using Save(), into the
+// receiver replacing any vectors it contains.  Load should only be performed with
+// trusted data.
+func (h *HNSWIndex) Load(r io.Reader) error {
+	var header hnswHeader
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return err
+	}
+
+	nodes := make([]*hnswNode, header.Nodes)
+	ids := make(map[string]int, header.Nodes)
+	for n := range nodes {
+		id, err := readString(r)
+		if err != nil {
+			return err
+		}
+		node := &hnswNode{id: id, vector: make([]float64, header.Dim)}
+		if err := binary.Read(r, binary.LittleEndian, &node.deleted); err != nil {
+			return err
+		}
+		if err := binary.Read(r, binary.LittleEndian, node.vector); err != nil {
+			return err
+		}
+		var levels int64
+		if err := binary.Read(r, binary.LittleEndian, &levels); err != nil {
+			return err
+		}
+		node.neighbours = make([][]int, levels)
+		for l := range node.neighbours {
+			var size int64
+			if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+				return err
+			}
+			links := make([]int64, size)
+			if err := binary.Read(r, binary.LittleEndian, links); err != nil {
+				return err
+			}
+			node.neighbours[l] = make([]int, size)
+			for i, link := range links {
+				if link < 0 || link >= header.Nodes {
+					return fmt.Errorf("nlp: Invalid link to node %d of %d", link, header.Nodes)
+				}
+				node.neighbours[l][i] = int(link)
+			}
+		}
+		nodes[n] = node
+		if !node.deleted {
+			ids[id] = n
+		}
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.M = int(header.M)
+	h.EfConstruction = int(header.EfConstruction)
+	h.EfSearch = int(header.EfSearch)
+	h.Metric = SimilarityMetric(header.Metric)
+	h.dim = int(header.Dim)
+	h.nodes = nodes
+	h.ids = ids
+	h.entry = int(header.Entry)
+	h.maxLevel = int(header.MaxLevel)
+	return nil
+}
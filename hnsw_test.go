@@ -0,0 +1,198 @@
+package nlp
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/james-bowman/sparse"
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestHNSWIndexRecall(t *testing.T) {
+	m := sparse.Random(sparse.DenseFormat, 20, 500, 1.0)
+
+	tests := []struct {
+		metric    SimilarityMetric
+		m         int
+		efSearch  int
+		minRecall float64
+	}{
+		{metric: Cosine, m: 16, efSearch: 50, minRecall: 0.9},
+		{metric: DotProduct, m: 16, efSearch: 50, minRecall: 0.9},
+		{metric: Cosine, m: 4, efSearch: 10, minRecall: 0.5},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		exact := NewLinearIndex(test.metric)
+		index := NewHNSWIndex(test.metric)
+		index.M = test.m
+		index.EfSearch = test.efSearch
+		index.Rnd = rand.New(rand.NewSource(uint64(testRun)))
+		ColDo(m, func(j int, v mat.Vector) {
+			exact.Add(strconv.Itoa(j), v)
+			if err := index.Add(strconv.Itoa(j), v); err != nil {
+				t.Fatalf("Failed to add vector %d because %v", j, err)
+			}
+		})
+		if index.Len() != 500 {
+			t.Errorf("Expected 500 vectors but found %d", index.Len())
+		}
+
+		var found, total int
+		ColDo(m, func(j int, q mat.Vector) {
+			expectedIDs, _ := exact.Search(q, 10)
+			expected := make(map[interface{}]bool)
+			for _, id := range expectedIDs {
+				expected[id] = true
+			}
+			ids, scores := index.Search(q, 10)
+			if len(ids) != 10 || len(scores) != 10 {
+				t.Fatalf("Expected 10 results but found %d ids and %d scores", len(ids), len(scores))
+			}
+			for i, id := range ids {
+				if expected[id] {
+					found++
+				}
+				if i > 0 && scores[i] > scores[i-1] {
+					t.Errorf("Expected scores in descending order but found %v", scores)
+				}
+			}
+			total += len(expectedIDs)
+		})
+
+		recall := float64(found) / float64(total)
+		if recall < test.minRecall {
+			t.Errorf("Expected recall of at least %f but found %f", test.minRecall, recall)
+		}
+	}
+}
+
+func TestHNSWIndexDelete(t *testing.T) {
+	m := sparse.Random(sparse.DenseFormat, 10, 50, 1.0)
+
+	index := NewHNSWIndex(Cosine)
+	ColDo(m, func(j int, v mat.Vector) {
+		index.Add(strconv.Itoa(j), v)
+	})
+
+	q := m.(mat.ColViewer).ColView(0)
+	ids, scores := index.Search(q, 1)
+	if len(ids) != 1 || ids[0] != "0" || math.Abs(scores[0]-1) > 1e-9 {
+		t.Errorf("Expected vector to be most similar to itself but found %v %v", ids, scores)
+	}
+
+	if !index.Delete("0") {
+		t.Errorf("Expected vector to be deleted")
+	}
+	if index.Delete("0") {
+		t.Errorf("Expected deleting an already deleted vector to return false")
+	}
+	if index.Len() != 49 {
+		t.Errorf("Expected 49 vectors after deletion but found %d", index.Len())
+	}
+	ids, _ = index.Search(q, 50)
+	for _, id := range ids {
+		if id == "0" {
+			t.Errorf("Expected deleted vector not to be returned")
+		}
+	}
+	if len(ids) != 49 {
+		t.Errorf("Expected all 49 remaining vectors but found %d", len(ids))
+	}
+
+	// re-adding an existing id replaces the vector
+	index.Add("1", q)
+	ids, _ = index.Search(q, 1)
+	if len(ids) != 1 || ids[0] != "1" {
+		t.Errorf("Expected replaced vector to be returned but found %v", ids)
+	}
+	if index.Len() != 49 {
+		t.Errorf("Expected 49 vectors after replacement but found %d", index.Len())
+	}
+
+	if err := index.Add("short", mat.NewVecDense(3, nil)); err == nil {
+		t.Errorf("Expected error adding vector of the wrong length")
+	}
+}
+
+func TestHNSWIndexSaveLoad(t *testing.T) {
+	m := sparse.Random(sparse.DenseFormat, 10, 100, 1.0)
+
+	index := NewHNSWIndex(DotProduct)
+	index.M = 8
+	index.EfSearch = 20
+	ColDo(m, func(j int, v mat.Vector) {
+		index.Add(strconv.Itoa(j), v)
+	})
+	index.Delete("5")
+
+	var buf bytes.Buffer
+	if err := index.Save(&buf); err != nil {
+		t.Fatalf("Failed to save index because %v", err)
+	}
+	loaded := NewHNSWIndex(Cosine)
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatalf("Failed to load index because %v", err)
+	}
+
+	if loaded.M != 8 || loaded.EfSearch != 20 || loaded.Metric != DotProduct || loaded.Len() != 99 {
+		t.Errorf("Expected loaded index to match saved index but found M %d, EfSearch %d, Metric %d and %d vectors", loaded.M, loaded.EfSearch, loaded.Metric, loaded.Len())
+	}
+	ColDo(m, func(j int, q mat.Vector) {
+		ids, scores := index.Search(q, 5)
+		loadedIDs, loadedScores := loaded.Search(q, 5)
+		if len(ids) != len(loadedIDs) {
+			t.Fatalf("Expected %d results but found %d", len(ids), len(loadedIDs))
+		}
+		for i := range ids {
+			if ids[i] != loadedIDs[i] || scores[i] != loadedScores[i] {
+				t.Errorf("Expected result %d to be %s (%f) but found %s (%f)", i, ids[i], scores[i], loadedIDs[i], loadedScores[i])
+			}
+		}
+	})
+}
+
+func TestHNSWIndexCompact(t *testing.T) {
+	m := sparse.Random(sparse.DenseFormat, 10, 200, 1.0)
+
+	index := NewHNSWIndex(Cosine)
+	index.EfSearch = 10
+	index.Rnd = rand.New(rand.NewSource(uint64(0)))
+	ColDo(m, func(j int, v mat.Vector) {
+		index.Add(strconv.Itoa(j), v)
+	})
+	for j := 0; j < 150; j++ {
+		index.Delete(strconv.Itoa(j))
+	}
+
+	check := func() {
+		ColDo(m, func(j int, q mat.Vector) {
+			ids, _ := index.Search(q, 10)
+			if len(ids) != 10 {
+				t.Errorf("Expected 10 results but found %d", len(ids))
+			}
+			for _, id := range ids {
+				if i, _ := strconv.Atoi(id); i < 150 {
+					t.Errorf("Expected deleted vector %s not to be returned", id)
+				}
+			}
+			if j >= 150 && (len(ids) == 0 || ids[0] != strconv.Itoa(j)) {
+				t.Errorf("Expected vector %d to be most similar to itself but found %v", j, ids)
+			}
+		})
+	}
+
+	// deleted vectors should not count towards the EfSearch candidates
+	check()
+
+	index.Compact()
+	if len(index.nodes) != 50 || index.Len() != 50 {
+		t.Errorf("Expected 50 vectors after compaction but found %d nodes and %d vectors", len(index.nodes), index.Len())
+	}
+	check()
+}
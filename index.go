@@ -2,6 +2,7 @@ package nlp
 
 import (
 	"container/heap"
+	"sort"
 	"sync"
 
 	"github.com/james-bowman/nlp/measures/pairwise"
@@ -94,7 +95,7 @@ func (b *LinearScanIndex) Search(qv mat.Vector, k int) []Match {
 
 	for point = 0; point < k && point < size; point++ {
 		mv := b.signatures[point]
-		match := Match{Distance: b.distance(qv, mv), ID: b.ids[point]}
+		match := Match{Distance: compare(b.distance, qv, mv), ID: b.ids[point]}
 		results.matches = append(results.matches, match)
 	}
 	if len(results.matches) < k {
@@ -104,7 +105,7 @@ func (b *LinearScanIndex) Search(qv mat.Vector, k int) []Match {
 	var dist float64
 	for i := point; i < size; i++ {
 		mv := b.signatures[i]
-		dist = b.distance(qv, mv)
+		dist = compare(b.distance, qv, mv)
 		if dist <= results.matches[0].Distance {
 			heap.Pop(&results)
 			heap.Push(&results, Match{Distance: dist, ID: b.ids[i]})
@@ -114,6 +115,13 @@ func (b *LinearScanIndex) Search(qv mat.Vector, k int) []Match {
 	return results.matches
 }
 
+// compare compares vectors a and b using the distance metric fn.  If the vectors are
+// of different lengths (e.g. output before and after growth of the Vocabulary of a
+// vectoriser), the shorter vector is padded with trailing zeros.
+func compare(fn pairwise.Comparer, a, b mat.Vector) float64 {
+	return fn(padVector(a, b.Len()), padVector(b, a.Len()))
+}
+
 // Remove removes the vector with the specified id from the index.  If no vector
 // is found with the specified id the method will simply do nothing.
 func (b *LinearScanIndex) Remove(id interface{}) {
@@ -135,6 +143,130 @@ func (b *LinearScanIndex) Remove(id interface{}) {
 	}
 }
 
+// SimilarityMetric is the measure of similarity between vectors used by a LinearIndex
+// or HNSWIndex.
+type SimilarityMetric int
+
+const (
+	// Cosine similarity is the dot product of vectors divided by the product of their
+	// L2 norms i.e. the cosine of the angle between them, ignoring their magnitude
+	Cosine SimilarityMetric = iota
+
+	// DotProduct similarity is the (unnormalised) dot product of vectors e.g. for
+	// vectors that are already L2 normalised or where magnitude is significant
+	DotProduct
+)
+
+// LinearIndex supports exact top-k nearest neighbour searches across indexed vectors
+// (e.g. the document vectors output from a Pipeline) by linearly scanning all of the
+// vectors, scoring each against the query vector using the configured Metric.
+// Unlike LinearScanIndex, which ranks matches by a distance metric, LinearIndex ranks
+// matches by similarity score (higher is more similar) and returns them in descending
+// order of score.  Searches are performed in O(n) making it suitable for small to
+// medium sized collections and as an exact baseline against which to measure the
+// recall of Approximate Nearest Neighbour (ANN) indexes like LSHIndex.  Sparse
+// vectors are supported with only their non-zero elements accessed.  Vectors may be
+// added and removed at any time and need not all be the same length: shorter vectors
+// (e.g. output before the Vocabulary of a CountVectoriser was extended by PartialFit())
+// are treated as if padded with trailing zeros.  A LinearIndex is safe for concurrent
+// use.
+type LinearIndex struct {
+	// Metric is the measure of similarity used to score indexed vectors against query
+	// vectors
+	Metric SimilarityMetric
+
+	lock    sync.RWMutex
+	vectors []mat.Vector
+	norms   []float64
+	ids     []interface{}
+}
+
+// NewLinearIndex creates a new empty LinearIndex scoring vectors using the specified
+// similarity metric.
+func NewLinearIndex(metric SimilarityMetric) *LinearIndex {
+	return &LinearIndex{Metric: metric}
+}
+
+// Add adds the vector v with associated id to the index.
+func (x *LinearIndex) Add(id interface{}, v mat.Vector) {
+	norm := sparse.Norm(v, 2)
+	x.lock.Lock()
+	x.vectors = append(x.vectors, v)
+	x.norms = append(x.norms, norm)
+	x.ids = append(x.ids, id)
+	x.lock.Unlock()
+}
+
+// Len returns the number of vectors within the index.
+func (x *LinearIndex) Len() int {
+	x.lock.RLock()
+	defer x.lock.RUnlock()
+	return len(x.ids)
+}
+
+// Search returns the ids and similarity scores of the k indexed vectors most similar
+// to the query vector q in descending order of score.  Vectors with equal scores are
+// returned in the order they were added.  Fewer than k results are returned if the
+// index contains fewer than k vectors.  For Cosine similarity, the score of zero
+// vectors (with a norm of 0) is 0.
+func (x *LinearIndex) Search(q mat.Vector, k int) ([]interface{}, []float64) {
+	x.lock.RLock()
+	defer x.lock.RUnlock()
+
+	qNorm := sparse.Norm(q, 2)
+
+	// the heap is ordered by Distance (the negated score) so that its root is the
+	// lowest scoring match and the ID of each match is the position of the vector
+	var results resultHeap
+	for i, v := range x.vectors {
+		score := sparse.Dot(padVector(q, v.Len()), padVector(v, q.Len()))
+		if x.Metric == Cosine {
+			if qNorm == 0 || x.norms[i] == 0 {
+				score = 0
+			} else {
+				score /= qNorm * x.norms[i]
+			}
+		}
+		if len(results.matches) < k {
+			heap.Push(&results, Match{Distance: -score, ID: i})
+		} else if k > 0 && -score < results.matches[0].Distance {
+			heap.Pop(&results)
+			heap.Push(&results, Match{Distance: -score, ID: i})
+		}
+	}
+
+	sort.Slice(results.matches, func(i, j int) bool {
+		a, b := results.matches[i], results.matches[j]
+		if a.Distance == b.Distance {
+			return a.ID.(int) < b.ID.(int)
+		}
+		return a.Distance < b.Distance
+	})
+	ids := make([]interface{}, len(results.matches))
+	scores := make([]float64, len(results.matches))
+	for i, match := range results.matches {
+		ids[i] = x.ids[match.ID.(int)]
+		scores[i] = -match.Distance
+	}
+	return ids, scores
+}
+
+// Remove removes the vector with the specified id from the index.  If no vector is
+// found with the specified id the method will simply do nothing.
+func (x *LinearIndex) Remove(id interface{}) {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+
+	for i, v := range x.ids {
+		if v == id {
+			x.vectors = append(x.vectors[:i], x.vectors[i+1:]...)
+			x.norms = append(x.norms[:i], x.norms[i+1:]...)
+			x.ids = append(x.ids[:i], x.ids[i+1:]...)
+			return
+		}
+	}
+}
+
 // Hasher interface represents a Locality Sensitive Hashing algorithm whereby
 // the proximity of data points is preserved in the hash space i.e. similar data
 // points will be hashed to values close together in the hash space.
@@ -163,7 +295,10 @@ type LSHScheme interface {
 // Nearest Neighbour (ANN) search in O(log n).  The storage required by the index will
 // depend upon the underlying LSH scheme used but will typically be higher than O(n).
 // In use cases where accurate Nearest Neighbour search is required other types of
-// index should be considered like LinearScanIndex.
+// index should be considered like LinearScanIndex.  Vectors may be indexed and removed
+// at any time.  When used with SimHash, vectors may also grow in length (e.g. as the
+// Vocabulary of a CountVectoriser is extended by PartialFit()) with shorter vectors
+// treated as if padded with trailing zeros.
 type LSHIndex struct {
 	lock       sync.RWMutex
 	isApprox   bool
@@ -194,6 +329,19 @@ func NewLSHIndex(approx bool, hasher Hasher, store LSHScheme, distance pairwise.
 	return &index
 }
 
+// NewCosineLSHIndex creates a new LSHIndex for approximate cosine similarity search
+// over vectors of length dim (e.g. document vectors output from a Pipeline) using
+// random hyperplane hashing (SimHash) with a ClassicLSH scheme of the specified number
+// of hash tables, each keyed by bits hash bits.  Candidates are ranked by their exact
+// CosineDistance from the query.  The number of tables and bits tune the trade-off
+// between recall and speed: more bits per table produce more selective buckets with
+// fewer candidates to compare, increasing speed at the expense of recall, whilst more
+// tables increase recall, by giving similar vectors more chances to collide, at the
+// expense of speed and memory.
+func NewCosineLSHIndex(dim, tables, bits int) *LSHIndex {
+	return NewLSHIndex(false, NewSimHash(tables*bits, dim), NewClassicLSH(bits, tables), pairwise.CosineDistance)
+}
+
 // Index indexes the supplied vector along with its associated ID.
 func (l *LSHIndex) Index(v mat.Vector, id interface{}) {
 	h := l.hasher.Hash(v)
@@ -234,7 +382,7 @@ func (l *LSHIndex) Search(q mat.Vector, k int) []Match {
 
 	for point = 0; point < k && point < size; point++ {
 		mv := l.signatures[candidateIDs[point]]
-		match := Match{Distance: l.distance(qv, mv), ID: candidateIDs[point]}
+		match := Match{Distance: compare(l.distance, qv, mv), ID: candidateIDs[point]}
 		results.matches = append(results.matches, match)
 	}
 	if len(results.matches) < k {
@@ -244,7 +392,7 @@ func (l *LSHIndex) Search(q mat.Vector, k int) []Match {
 	var dist float64
 	for i := point; i < size; i++ {
 		mv := l.signatures[candidateIDs[i]]
-		dist = l.distance(qv, mv)
+		dist = compare(l.distance, qv, mv)
 		if dist <= results.matches[0].Distance {
 			heap.Pop(&results)
 			heap.Push(&results, Match{Distance: dist, ID: candidateIDs[i]})
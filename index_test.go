@@ -1,6 +1,7 @@
 package nlp
 
 import (
+	"math"
 	"sort"
 	"testing"
 
@@ -127,3 +128,176 @@ func TestIndexerRemove(t *testing.T) {
 		})
 	}
 }
+
+func TestLinearIndex(t *testing.T) {
+	m := sparse.Random(sparse.CSCFormat, 50, 20, 0.2)
+
+	tests := []struct {
+		metric SimilarityMetric
+		score  func(a, b mat.Vector) float64
+	}{
+		{metric: Cosine, score: pairwise.CosineSimilarity},
+		{metric: DotProduct, score: sparse.Dot},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		index := NewLinearIndex(test.metric)
+		ColDo(m, func(j int, v mat.Vector) {
+			index.Add(j, v)
+		})
+		if index.Len() != 20 {
+			t.Errorf("Expected 20 vectors but found %d", index.Len())
+		}
+
+		ColDo(m, func(j int, q mat.Vector) {
+			expected := make([]float64, 0, 20)
+			ColDo(m, func(i int, v mat.Vector) {
+				if score := test.score(q, v); !math.IsNaN(score) {
+					expected = append(expected, score)
+				} else {
+					expected = append(expected, 0)
+				}
+			})
+			sort.Sort(sort.Reverse(sort.Float64Slice(expected)))
+
+			ids, scores := index.Search(q, 5)
+			if len(ids) != 5 || len(scores) != 5 {
+				t.Fatalf("Expected 5 results but found %d ids and %d scores", len(ids), len(scores))
+			}
+			for i, score := range scores {
+				if math.Abs(score-expected[i]) > 1e-9 {
+					t.Errorf("Expected score %d to be %f but found %f", i, expected[i], score)
+				}
+				if actual := test.score(q, m.(mat.ColViewer).ColView(ids[i].(int))); !math.IsNaN(actual) && math.Abs(actual-score) > 1e-9 {
+					t.Errorf("Expected score of id %v to be %f but found %f", ids[i], actual, score)
+				}
+			}
+		})
+
+		ids, _ := index.Search(m.(mat.ColViewer).ColView(0), 30)
+		if len(ids) != 20 {
+			t.Errorf("Expected all 20 vectors when k exceeds size but found %d", len(ids))
+		}
+
+		index.Remove(0)
+		ids, _ = index.Search(m.(mat.ColViewer).ColView(0), 20)
+		for _, id := range ids {
+			if id == 0 {
+				t.Errorf("Expected removed vector not to be returned")
+			}
+		}
+		if len(ids) != 19 {
+			t.Errorf("Expected 19 vectors after removal but found %d", len(ids))
+		}
+	}
+}
+
+func TestCosineLSHIndexRecall(t *testing.T) {
+	m := sparse.Random(sparse.DenseFormat, 50, 200, 1.0)
+
+	tests := []struct {
+		tables, bits int
+		minRecall    float64
+	}{
+		{tables: 1, bits: 4, minRecall: 0},
+		{tables: 20, bits: 4, minRecall: 0.8},
+	}
+
+	exact := NewLinearIndex(Cosine)
+	ColDo(m, func(j int, v mat.Vector) {
+		exact.Add(j, v)
+	})
+
+	var previous float64
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		index := NewCosineLSHIndex(50, test.tables, test.bits)
+		ColDo(m, func(j int, v mat.Vector) {
+			index.Index(v, j)
+		})
+
+		var found, total int
+		ColDo(m, func(j int, q mat.Vector) {
+			ids, _ := exact.Search(q, 10)
+			expected := make(map[interface{}]bool)
+			for _, id := range ids {
+				expected[id] = true
+			}
+			for _, match := range index.Search(q, 10) {
+				if expected[match.ID] {
+					found++
+				}
+			}
+			total += len(ids)
+		})
+
+		recall := float64(found) / float64(total)
+		if recall < test.minRecall || recall < previous {
+			t.Errorf("Expected recall of at least %f (and %f with fewer tables) but found %f", test.minRecall, previous, recall)
+		}
+		previous = recall
+	}
+}
+
+func TestIndexVocabularyGrowth(t *testing.T) {
+	vectoriser := NewCountVectoriser()
+	vectoriser.Fit(trainSet[:3]...)
+	before, _ := vectoriser.Transform(trainSet[:3]...)
+	vectoriser.PartialFit(trainSet[3:]...)
+	after, _ := vectoriser.Transform(trainSet...)
+	rows, _ := after.Dims()
+
+	linear := NewLinearIndex(Cosine)
+	lsh := NewCosineLSHIndex(rows-10, 10, 4)
+	scan := NewLinearScanIndex(pairwise.CosineDistance)
+	ColDo(before, func(j int, v mat.Vector) {
+		linear.Add(j, v)
+		lsh.Index(v, j)
+		scan.Index(v, j)
+	})
+	ColDo(after, func(j int, v mat.Vector) {
+		if j >= 3 {
+			linear.Add(j, v)
+			lsh.Index(v, j)
+			scan.Index(v, j)
+		}
+	})
+
+	ColDo(after, func(j int, q mat.Vector) {
+		ids, scores := linear.Search(q, 1)
+		if len(ids) != 1 || ids[0] != j || math.Abs(scores[0]-1) > 1e-9 {
+			t.Errorf("Expected document %d to be most similar to itself but found %v %v", j, ids, scores)
+		}
+		for _, index := range []Indexer{lsh, scan} {
+			var found bool
+			for _, match := range index.Search(q, 3) {
+				if match.ID == j && math.Abs(match.Distance) < 1e-9 {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("Expected document %d to be found at distance 0 from itself", j)
+			}
+		}
+	})
+
+	linear.Remove(0)
+	lsh.Remove(0)
+	var q mat.Vector
+	ColDo(after, func(j int, v mat.Vector) {
+		if j == 0 {
+			q = v
+		}
+	})
+	if ids, _ := linear.Search(q, 6); len(ids) != 5 {
+		t.Errorf("Expected 5 documents after removal but found %v", ids)
+	}
+	for _, match := range lsh.Search(q, 6) {
+		if match.ID == 0 {
+			t.Errorf("Expected removed document not to be returned")
+		}
+	}
+}
@@ -0,0 +1,572 @@
+package nlp
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Posting is an entry within the postings list of a term recording a document
+// containing the term and the frequency with which the term occurs within it.
+type Posting struct {
+	// Doc is the index of the document within the matrix the InvertedIndex was built
+	// from
+	Doc int
+
+	// Freq is the frequency of the term within the document
+	Freq float64
+}
+
+// RankingFunction specifies how documents are scored against queries for ranked
+// retrieval by an InvertedIndex.
+type RankingFunction int
+
+const (
+	// TfidfRanking scores documents by the cosine similarity of the TF-IDF weighted
+	// query and document vectors using smoothed IDF, log((1+n)/(1+df)) + 1, as used by
+	// TfidfTransformer by default
+	TfidfRanking RankingFunction = iota
+
+	// BM25Ranking scores documents by the sum of the Okapi BM25 weights of the query
+	// terms they contain using the K1 and B parameters of the InvertedIndex.  Use a
+	// BM25Scorer to specify BM25 parameters at query time or to score multiple fields.
+	BM25Ranking
+)
+
+// BooleanQuery is a boolean retrieval query for an InvertedIndex.  Each clause is
+// text tokenised with the Tokeniser of the CountVectoriser the index was built from.
+// Must terms not within its Vocabulary match no documents whereas Should and MustNot
+// terms not within its Vocabulary are ignored.
+type BooleanQuery struct {
+	// Must holds terms that matching documents must all contain
+	Must string
+
+	// Should holds terms of which matching documents must contain at least one.  If
+	// empty, this clause is ignored.
+	Should string
+
+	// MustNot holds terms that matching documents must not contain
+	MustNot string
+}
+
+// InvertedIndex is an inverted index, mapping each term within the Vocabulary of a
+// fitted CountVectoriser to a postings list of the documents containing it, supporting
+// both boolean and ranked (TF-IDF or BM25) retrieval.  Only the postings lists of the
+// terms within a query are accessed to answer it so queries are fast, typically
+// requiring time proportional to the number of documents containing the query terms
+// rather than the total number of documents.  Documents may be added to, and removed
+// from, the index after construction without rebuilding it.  An InvertedIndex is safe
+// for concurrent use.
+type InvertedIndex struct {
+	// Ranking is the function used to score documents by Search()
+	Ranking RankingFunction
+
+	// K1 controls term frequency saturation for BM25Ranking
+	K1 float64
+
+	// B controls the degree of document length normalisation, between 0 and 1, for
+	// BM25Ranking
+	B float64
+
+	// GrowVocabulary, if true, causes Add() to extend the Vocabulary of the
+	// CountVectoriser (using its PartialFit() method) with any new terms found within
+	// the added documents so that they may be searched for.  Otherwise terms outside
+	// of the Vocabulary are ignored.  Growth is not supported for vectorisers with
+	// OOVBuckets as extending the Vocabulary would change the rows of the buckets.
+	GrowVocabulary bool
+
+	lock       sync.RWMutex
+	vectoriser *CountVectoriser
+	postings   [][]Posting
+	docTerms   [][]int
+	docLens    []float64
+	totalLen   float64
+	removed    []bool
+	numDocs    int
+	norms      []float64
+}
+
+// NewInvertedIndex creates a new InvertedIndex from a fitted CountVectoriser and the
+// term document matrix output from its Transform() method (either orientation is
+// supported according to the vectoriser's Orientation).  Documents are identified by
+// their index within the matrix.  The index ranks documents using BM25Ranking with a
+// K1 of 1.2 and a B of 0.75 by default.
+func NewInvertedIndex(vectoriser *CountVectoriser, m mat.Matrix) *InvertedIndex {
+	terms, docs := vectoriser.Orientation.index(m.Dims())
+	x := &InvertedIndex{
+		Ranking:    BM25Ranking,
+		K1:         1.2,
+		B:          0.75,
+		vectoriser: vectoriser,
+		postings:   make([][]Posting, terms),
+		docTerms:   make([][]int, docs),
+		docLens:    make([]float64, docs),
+		removed:    make([]bool, docs),
+		numDocs:    docs,
+	}
+
+	// collect the postings in document order, with documents as columns, so each
+	// postings list is sorted by document
+	t := m
+	if vectoriser.Orientation == DocumentsAsRows {
+		t = m.T()
+	}
+	for d := 0; d < docs; d++ {
+		ColNonZeroElemDo(t, d, func(term, d int, v float64) {
+			x.postings[term] = append(x.postings[term], Posting{Doc: d, Freq: v})
+			x.docTerms[d] = append(x.docTerms[d], term)
+			x.docLens[d] += v
+			x.totalLen += v
+		})
+	}
+	x.updateNorms()
+	return x
+}
+
+// Add vectorises and adds the supplied documents to the index returning their
+// indices.  Documents are assigned indices following on from those already within the
+// index.  If GrowVocabulary is true, the Vocabulary of the CountVectoriser is first
+// extended with any new terms within the documents.  As the TF-IDF document norms
+// depend upon the document frequencies of all terms, they are recalculated each time
+// documents are added or removed so adding documents in batches is more efficient
+// than adding them individually.
+func (x *InvertedIndex) Add(docs ...string) []int {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+
+	if x.GrowVocabulary && x.vectoriser.OOVBuckets <= 0 {
+		x.vectoriser.PartialFit(docs...)
+		for len(x.postings) < len(x.vectoriser.Vocabulary) {
+			x.postings = append(x.postings, nil)
+		}
+	}
+
+	indices := make([]int, len(docs))
+	for i, doc := range docs {
+		d := len(x.docLens)
+		indices[i] = d
+
+		vec := x.vectoriser.vectorise(tokenise(x.vectoriser.Tokeniser, doc))
+		terms := make([]int, 0, len(vec))
+		for term := range vec {
+			if term < len(x.postings) {
+				terms = append(terms, term)
+			}
+		}
+		sort.Ints(terms)
+
+		var docLen float64
+		for _, term := range terms {
+			x.postings[term] = append(x.postings[term], Posting{Doc: d, Freq: vec[term]})
+			docLen += vec[term]
+		}
+		x.docTerms = append(x.docTerms, terms)
+		x.docLens = append(x.docLens, docLen)
+		x.removed = append(x.removed, false)
+		x.totalLen += docLen
+		x.numDocs++
+	}
+	x.updateNorms()
+	return indices
+}
+
+// Remove removes the document with the specified index from the index returning true
+// or false if the index contains no such document.  The indices of the other
+// documents are unchanged.
+func (x *InvertedIndex) Remove(doc int) bool {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+
+	if doc < 0 || doc >= len(x.docLens) || x.removed[doc] {
+		return false
+	}
+	for _, term := range x.docTerms[doc] {
+		postings := x.postings[term]
+		i := sort.Search(len(postings), func(i int) bool { return postings[i].Doc >= doc })
+		// copy rather than shifting in place as the slice may be held by callers of
+		// Postings()
+		x.postings[term] = append(postings[:i:i], postings[i+1:]...)
+	}
+	x.totalLen -= x.docLens[doc]
+	x.docTerms[doc] = nil
+	x.docLens[doc] = 0
+	x.removed[doc] = true
+	x.numDocs--
+	x.updateNorms()
+	return true
+}
+
+// updateNorms calculates the L2 norm of the TF-IDF vector of every document for
+// TfidfRanking.
+func (x *InvertedIndex) updateNorms() {
+	x.norms = make([]float64, len(x.docLens))
+	for term := range x.postings {
+		idf := x.idf(term)
+		for _, p := range x.postings[term] {
+			w := p.Freq * idf
+			x.norms[p.Doc] += w * w
+		}
+	}
+	for d := range x.norms {
+		x.norms[d] = math.Sqrt(x.norms[d])
+	}
+}
+
+// idf returns the smoothed inverse document frequency of the term used for
+// TfidfRanking.
+func (x *InvertedIndex) idf(term int) float64 {
+	return SmoothIDF.idf(x.numDocs, len(x.postings[term])) + 1
+}
+
+// Len returns the number of documents within the index.
+func (x *InvertedIndex) Len() int {
+	x.lock.RLock()
+	defer x.lock.RUnlock()
+	return x.numDocs
+}
+
+// Postings returns the postings list of the specified term, ordered by document, or
+// nil if the term is not within the Vocabulary.  The returned slice must not be
+// modified.
+func (x *InvertedIndex) Postings(term string) []Posting {
+	x.lock.RLock()
+	defer x.lock.RUnlock()
+
+	i, exists := x.vectoriser.Vocabulary[term]
+	if !exists || i >= len(x.postings) {
+		return nil
+	}
+	return x.postings[i]
+}
+
+// DocFreq returns the number of documents containing the specified term.
+func (x *InvertedIndex) DocFreq(term string) int {
+	return len(x.Postings(term))
+}
+
+// queryTerms tokenises and vectorises the query text returning the indices of the
+// terms within it, in ascending order, along with their frequencies.  Terms outside
+// of the index are ignored.
+func (x *InvertedIndex) queryTerms(query string) ([]int, map[int]float64) {
+	vec := x.vectoriser.vectorise(tokenise(x.vectoriser.Tokeniser, query))
+	terms := make([]int, 0, len(vec))
+	for term := range vec {
+		if term < len(x.postings) {
+			terms = append(terms, term)
+		}
+	}
+	sort.Ints(terms)
+	return terms, vec
+}
+
+// Match performs boolean retrieval returning the indices of the documents, in
+// ascending order, satisfying all of the clauses of the query.  A query with no Must
+// or Should terms matches no documents.
+func (x *InvertedIndex) Match(q BooleanQuery) []int {
+	x.lock.RLock()
+	defer x.lock.RUnlock()
+
+	// with any Must terms outside of the index, no document can match
+	var must []int
+	seen := make(map[int]bool)
+	for token := range tokenSet(x.vectoriser.Tokeniser, q.Must) {
+		term, exists := x.vectoriser.termIndex(token)
+		if !exists || term >= len(x.postings) {
+			return nil
+		}
+		if !seen[term] {
+			seen[term] = true
+			must = append(must, term)
+		}
+	}
+	should, _ := x.queryTerms(q.Should)
+	mustNot, _ := x.queryTerms(q.MustNot)
+
+	var docs []int
+	switch {
+	case len(must) > 0:
+		// intersect starting from the shortest postings list to minimise the size of
+		// intermediate results
+		sort.Slice(must, func(i, j int) bool { return len(x.postings[must[i]]) < len(x.postings[must[j]]) })
+		docs = postingDocs(x.postings[must[0]])
+		for _, term := range must[1:] {
+			docs = intersect(docs, postingDocs(x.postings[term]))
+		}
+		if len(should) > 0 {
+			docs = intersect(docs, x.union(should))
+		}
+	case len(should) > 0:
+		docs = x.union(should)
+	default:
+		return nil
+	}
+
+	if len(mustNot) > 0 {
+		docs = difference(docs, x.union(mustNot))
+	}
+	return docs
+}
+
+// union returns the indices of the documents, in ascending order, containing any of
+// the specified terms.
+func (x *InvertedIndex) union(terms []int) []int {
+	var docs []int
+	for _, term := range terms {
+		docs = union(docs, postingDocs(x.postings[term]))
+	}
+	return docs
+}
+
+// Search performs ranked retrieval returning the indices and scores of the k
+// documents scoring highest against the query text, using the Ranking function, in
+// descending order of score.  Documents with equal scores are returned in ascending
+// order of index.  Only documents containing at least one of the query terms are
+// returned so fewer than k results may be returned.
+func (x *InvertedIndex) Search(query string, k int) ([]int, []float64) {
+	x.lock.RLock()
+	defer x.lock.RUnlock()
+
+	terms, tf := x.queryTerms(query)
+	scores := make(map[int]float64)
+
+	switch x.Ranking {
+	case TfidfRanking:
+		var qNorm float64
+		for _, term := range terms {
+			idf := x.idf(term)
+			qw := tf[term] * idf
+			qNorm += qw * qw
+			for _, p := range x.postings[term] {
+				scores[p.Doc] += qw * p.Freq * idf
+			}
+		}
+		qNorm = math.Sqrt(qNorm)
+		for d := range scores {
+			scores[d] /= qNorm * x.norms[d]
+		}
+	default:
+		scorer := BM25Scorer{K1: x.K1, B: x.B}
+		scorer.score(x, terms, tf, 1, scores)
+	}
+
+	return topK(scores, k)
+}
+
+// BM25Scorer ranks the documents within InvertedIndexes against text queries using
+// BM25, as described for BM25Transformer, with the parameters specified at query time
+// rather than when the index is built.  This allows the same index to be queried with
+// different parameters e.g. to tune K1 and B against relevance judgements.  Documents
+// may be indexed as multiple fields (e.g. title and body), each with its own
+// InvertedIndex, and scored across all of the fields with the scores of each field
+// boosted according to its importance.  The score of a document is the sum over the
+// fields of the BM25 score of the field multiplied by the boost of the field.
+type BM25Scorer struct {
+	// K1 controls term frequency saturation
+	K1 float64
+
+	// B controls the degree of document length normalisation between 0 (no
+	// normalisation) and 1 (full normalisation)
+	B float64
+
+	// Variant selects the BM25 variant to apply (OkapiBM25 by default)
+	Variant BM25Variant
+
+	// Delta is the lower bound correction applied by the BM25+ and BM25L variants
+	Delta float64
+
+	// Boosts maps field names to the weights by which the scores of each field are
+	// multiplied in SearchFields().  Fields without a boost are given a weight of 1.
+	Boosts map[string]float64
+}
+
+// NewBM25Scorer creates a new BM25Scorer with the commonly used default values of 1.2
+// for K1 and 0.75 for B.
+func NewBM25Scorer() *BM25Scorer {
+	return &BM25Scorer{K1: 1.2, B: 0.75}
+}
+
+// Search returns the indices and BM25 scores of the k documents within the index
+// scoring highest against the query text in descending order of score.  Documents
+// with equal scores are returned in ascending order of index.  Only documents
+// containing at least one of the query terms are returned so fewer than k results may
+// be returned.
+func (s *BM25Scorer) Search(index *InvertedIndex, query string, k int) ([]int, []float64) {
+	index.lock.RLock()
+	defer index.lock.RUnlock()
+
+	terms, tf := index.queryTerms(query)
+	scores := make(map[int]float64)
+	s.score(index, terms, tf, 1, scores)
+	return topK(scores, k)
+}
+
+// SearchFields is equivalent to Search() but scores documents indexed as multiple
+// fields, supplied as a map of field names to the InvertedIndex of each field, summing
+// the scores of each field multiplied by its boost.  The query text is tokenised for
+// each field with the CountVectoriser of the field's index.  Each index must contain
+// the same documents, in the same order, otherwise an error is returned.
+func (s *BM25Scorer) SearchFields(fields map[string]*InvertedIndex, query string, k int) ([]int, []float64, error) {
+	// score fields in a consistent order so the summed scores are deterministic
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	scores := make(map[int]float64)
+	docs := -1
+	for _, name := range names {
+		index := fields[name]
+		boost, exists := s.Boosts[name]
+		if !exists {
+			boost = 1
+		}
+
+		index.lock.RLock()
+		if docs >= 0 && len(index.docLens) != docs {
+			index.lock.RUnlock()
+			return nil, nil, fmt.Errorf("nlp: Field '%s' contains %d documents but other fields contain %d", name, len(index.docLens), docs)
+		}
+		docs = len(index.docLens)
+		terms, tf := index.queryTerms(query)
+		s.score(index, terms, tf, boost, scores)
+		index.lock.RUnlock()
+	}
+
+	ids, values := topK(scores, k)
+	return ids, values, nil
+}
+
+// score adds the BM25 score of each document within the index containing any of the
+// query terms, with the frequencies tf, multiplied by boost to scores.  The caller
+// must hold the read lock of the index.
+func (s *BM25Scorer) score(index *InvertedIndex, terms []int, tf map[int]float64, boost float64, scores map[int]float64) {
+	weighting := BM25Transformer{K1: s.K1, B: s.B, Variant: s.Variant, Delta: s.Delta}
+
+	n := float64(index.numDocs)
+	var avgDocLen float64
+	if n > 0 {
+		avgDocLen = index.totalLen / n
+	}
+	for _, term := range terms {
+		df := float64(len(index.postings[term]))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for _, p := range index.postings[term] {
+			norm := 1 - s.B
+			if avgDocLen > 0 {
+				norm += s.B * index.docLens[p.Doc] / avgDocLen
+			}
+			scores[p.Doc] += boost * tf[term] * weighting.weight(idf, p.Freq, norm)
+		}
+	}
+}
+
+// topK returns the k highest scoring documents, and their scores, in descending order
+// of score with ties ordered by ascending document index.
+func topK(scores map[int]float64, k int) ([]int, []float64) {
+	// the heap is ordered by Distance (the negated score) so that its root is the
+	// lowest scoring match
+	var results resultHeap
+	for d, score := range scores {
+		match := Match{Distance: -score, ID: d}
+		if len(results.matches) < k {
+			heap.Push(&results, match)
+		} else if k > 0 && (match.Distance < results.matches[0].Distance ||
+			match.Distance == results.matches[0].Distance && d < results.matches[0].ID.(int)) {
+			heap.Pop(&results)
+			heap.Push(&results, match)
+		}
+	}
+
+	sort.Slice(results.matches, func(i, j int) bool {
+		a, b := results.matches[i], results.matches[j]
+		if a.Distance == b.Distance {
+			return a.ID.(int) < b.ID.(int)
+		}
+		return a.Distance < b.Distance
+	})
+	docs := make([]int, len(results.matches))
+	scoresOut := make([]float64, len(results.matches))
+	for i, match := range results.matches {
+		docs[i] = match.ID.(int)
+		scoresOut[i] = -match.Distance
+	}
+	return docs, scoresOut
+}
+
+// tokenSet returns the distinct tokens within text according to tokeniser.
+func tokenSet(tokeniser Tokeniser, text string) map[string]bool {
+	set := make(map[string]bool)
+	tokeniser.ForEachIn(text, func(token string) {
+		set[token] = true
+	})
+	return set
+}
+
+// postingDocs returns the document indices of the postings.
+func postingDocs(postings []Posting) []int {
+	docs := make([]int, len(postings))
+	for i, p := range postings {
+		docs[i] = p.Doc
+	}
+	return docs
+}
+
+// intersect returns the elements within both of the ascending ordered slices a and b.
+func intersect(a, b []int) []int {
+	var result []int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			result = append(result, a[i])
+			i++
+			j++
+		}
+	}
+	return result
+}
+
+// union returns the elements within either of the ascending ordered slices a and b.
+func union(a, b []int) []int {
+	result := make([]int, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] < b[j]:
+			result = append(result, a[i])
+			i++
+		case a[i] > b[j]:
+			result = append(result, b[j])
+			j++
+		default:
+			result = append(result, a[i])
+			i++
+			j++
+		}
+	}
+	result = append(result, a[i:]...)
+	return append(result, b[j:]...)
+}
+
+// difference returns the elements within the ascending ordered slice a but not b.
+func difference(a, b []int) []int {
+	var result []int
+	j := 0
+	for _, v := range a {
+		for j < len(b) && b[j] < v {
+			j++
+		}
+		if j == len(b) || b[j] != v {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,277 @@
+package nlp
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestInvertedIndexMatch(t *testing.T) {
+	tests := []struct {
+		query    BooleanQuery
+		expected []int
+	}{
+		{query: BooleanQuery{Must: "dog"}, expected: []int{0, 2, 4}},
+		{query: BooleanQuery{Must: "dog the"}, expected: []int{0, 2, 4}},
+		{query: BooleanQuery{Must: "dog", MustNot: "cow"}, expected: []int{0, 2}},
+		{query: BooleanQuery{Should: "cow cat"}, expected: []int{1, 3, 4}},
+		{query: BooleanQuery{Must: "dog", Should: "cow fox"}, expected: []int{0, 4}},
+		{query: BooleanQuery{Should: "the", MustNot: "dog brown"}, expected: nil},
+		{query: BooleanQuery{Must: "dog unicorn"}, expected: nil},
+		{query: BooleanQuery{Should: "unicorn"}, expected: nil},
+		{query: BooleanQuery{MustNot: "dog"}, expected: nil},
+	}
+
+	for _, orientation := range []Orientation{TermsAsRows, DocumentsAsRows} {
+		vectoriser := NewCountVectoriser()
+		vectoriser.Orientation = orientation
+		m, _ := vectoriser.FitTransform(trainSet...)
+		index := NewInvertedIndex(vectoriser, m)
+
+		if index.Len() != len(trainSet) {
+			t.Errorf("Expected %d documents but found %d", len(trainSet), index.Len())
+		}
+		if df := index.DocFreq("the"); df != 4 {
+			t.Errorf("Expected document frequency of 4 but found %d", df)
+		}
+		if p := index.Postings("the"); !reflect.DeepEqual(p, []Posting{{0, 2}, {1, 2}, {2, 1}, {4, 2}}) {
+			t.Errorf("Expected postings ordered by document but found %v", p)
+		}
+
+		for testRun, test := range tests {
+			t.Logf("**** Test Run %d.\n", testRun+1)
+
+			docs := index.Match(test.query)
+			if !reflect.DeepEqual(docs, test.expected) && (len(docs) > 0 || len(test.expected) > 0) {
+				t.Errorf("Expected %v but found %v", test.expected, docs)
+			}
+		}
+	}
+}
+
+func TestInvertedIndexSearch(t *testing.T) {
+	vectoriser := NewCountVectoriser()
+	m, _ := vectoriser.FitTransform(trainSet...)
+	index := NewInvertedIndex(vectoriser, m)
+
+	// BM25 scores should be the sum of the BM25 weights of the query terms
+	bm25 := NewBM25Transformer()
+	weights, _ := bm25.FitTransform(m)
+	brown, dog := vectoriser.Vocabulary["brown"], vectoriser.Vocabulary["dog"]
+
+	docs, scores := index.Search("brown dog", 10)
+	if len(docs) != 4 {
+		t.Errorf("Expected 4 documents containing the query terms but found %d", len(docs))
+	}
+	for i, d := range docs {
+		expected := weights.At(brown, d) + weights.At(dog, d)
+		if math.Abs(scores[i]-expected) > 1e-9 {
+			t.Errorf("Expected BM25 score of document %d to be %f but found %f", d, expected, scores[i])
+		}
+		if i > 0 && scores[i] > scores[i-1] {
+			t.Errorf("Expected scores in descending order but found %v", scores)
+		}
+	}
+	if docs[0] != 0 {
+		t.Errorf("Expected document containing both terms to rank first but found %v", docs)
+	}
+
+	docs, _ = index.Search("brown dog", 2)
+	if len(docs) != 2 || docs[0] != 0 {
+		t.Errorf("Expected top 2 documents but found %v", docs)
+	}
+	docs, _ = index.Search("unicorn", 10)
+	if len(docs) != 0 {
+		t.Errorf("Expected no documents but found %v", docs)
+	}
+
+	index.Ranking = TfidfRanking
+	for d, doc := range trainSet {
+		docs, scores := index.Search(doc, 3)
+		if docs[0] != d || math.Abs(scores[0]-1) > 1e-9 {
+			t.Errorf("Expected document %d to be most similar to itself with score 1 but found %v %v", d, docs, scores)
+		}
+		for _, score := range scores {
+			if score <= 0 || score > 1+1e-9 {
+				t.Errorf("Expected cosine similarity scores in (0, 1] but found %v", scores)
+			}
+		}
+	}
+}
+
+func TestBM25Scorer(t *testing.T) {
+	vectoriser := NewCountVectoriser()
+	m, _ := vectoriser.FitTransform(trainSet...)
+	index := NewInvertedIndex(vectoriser, m)
+
+	tests := []struct {
+		k1, b, delta float64
+		variant      BM25Variant
+	}{
+		{k1: 1.2, b: 0.75},
+		{k1: 2.0, b: 0},
+		{k1: 1.5, b: 1, delta: 1, variant: BM25Plus},
+		{k1: 1.2, b: 0.5, delta: 0.5, variant: BM25L},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		scorer := NewBM25Scorer()
+		scorer.K1, scorer.B, scorer.Delta, scorer.Variant = test.k1, test.b, test.delta, test.variant
+
+		bm25 := &BM25Transformer{K1: test.k1, B: test.b, Delta: test.delta, Variant: test.variant}
+		weights, _ := bm25.FitTransform(m)
+		the, dog := vectoriser.Vocabulary["the"], vectoriser.Vocabulary["dog"]
+
+		docs, scores := scorer.Search(index, "the dog", 10)
+		if len(docs) != 4 {
+			t.Errorf("Expected 4 documents containing the query terms but found %d", len(docs))
+		}
+		for i, d := range docs {
+			expected := weights.At(the, d) + weights.At(dog, d)
+			if math.Abs(scores[i]-expected) > 1e-9 {
+				t.Errorf("Expected score of document %d to be %f but found %f", d, expected, scores[i])
+			}
+		}
+	}
+}
+
+func TestBM25ScorerSearchFields(t *testing.T) {
+	titles := []string{"fox", "cat", "dog", "cow", "cow", "dish"}
+
+	titleVectoriser := NewCountVectoriser()
+	m, _ := titleVectoriser.FitTransform(titles...)
+	titleIndex := NewInvertedIndex(titleVectoriser, m)
+
+	bodyVectoriser := NewCountVectoriser()
+	m, _ = bodyVectoriser.FitTransform(trainSet...)
+	bodyIndex := NewInvertedIndex(bodyVectoriser, m)
+
+	scorer := NewBM25Scorer()
+	fields := map[string]*InvertedIndex{"title": titleIndex, "body": bodyIndex}
+
+	tests := []struct {
+		boosts map[string]float64
+		first  int
+	}{
+		{boosts: nil, first: 2},
+		{boosts: map[string]float64{"title": 0}, first: 4},
+		{boosts: map[string]float64{"title": 3, "body": 0.5}, first: 2},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		scorer.Boosts = test.boosts
+		docs, scores, err := scorer.SearchFields(fields, "dog", 10)
+		if err != nil {
+			t.Fatalf("Failed to search fields because %v", err)
+		}
+
+		titleDocs, titleScores := scorer.Search(titleIndex, "dog", 10)
+		bodyDocs, bodyScores := scorer.Search(bodyIndex, "dog", 10)
+		expected := make(map[int]float64)
+		for i, d := range titleDocs {
+			boost, ok := test.boosts["title"]
+			if !ok {
+				boost = 1
+			}
+			expected[d] += boost * titleScores[i]
+		}
+		for i, d := range bodyDocs {
+			boost, ok := test.boosts["body"]
+			if !ok {
+				boost = 1
+			}
+			expected[d] += boost * bodyScores[i]
+		}
+
+		if len(docs) == 0 || docs[0] != test.first {
+			t.Errorf("Expected document %d to rank first but found %v", test.first, docs)
+		}
+		for i, d := range docs {
+			if math.Abs(scores[i]-expected[d]) > 1e-9 {
+				t.Errorf("Expected score of document %d to be %f but found %f", d, expected[d], scores[i])
+			}
+		}
+	}
+
+	m, _ = titleVectoriser.Transform(titles[:3]...)
+	fields["title"] = NewInvertedIndex(titleVectoriser, m)
+	if _, _, err := scorer.SearchFields(fields, "dog", 10); err == nil {
+		t.Errorf("Expected error searching fields containing different numbers of documents")
+	}
+}
+
+func TestInvertedIndexAddRemove(t *testing.T) {
+	tests := []struct {
+		grow     bool
+		query    string
+		expected []int
+	}{
+		{grow: true, query: "cow dish", expected: []int{3, 4, 5}},
+		{grow: false, query: "cow dish", expected: nil},
+		{grow: true, query: "the dog", expected: []int{0, 1, 2, 4}},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		vectoriser := NewCountVectoriser()
+		m, _ := vectoriser.FitTransform(trainSet[:3]...)
+		index := NewInvertedIndex(vectoriser, m)
+		index.GrowVocabulary = test.grow
+
+		indices := index.Add(trainSet[3:]...)
+		if !reflect.DeepEqual(indices, []int{3, 4, 5}) || index.Len() != len(trainSet) {
+			t.Errorf("Expected documents to be added with indices [3 4 5] but found %v and %d documents", indices, index.Len())
+		}
+		docs := index.Match(BooleanQuery{Should: test.query})
+		if !reflect.DeepEqual(docs, test.expected) && (len(docs) > 0 || len(test.expected) > 0) {
+			t.Errorf("Expected %v but found %v", test.expected, docs)
+		}
+		if !test.grow {
+			continue
+		}
+
+		// scores should be identical to an index built from all of the documents at once
+		full := NewCountVectoriser()
+		m, _ = full.FitTransform(trainSet...)
+		expected := NewInvertedIndex(full, m)
+		for _, ranking := range []RankingFunction{BM25Ranking, TfidfRanking} {
+			index.Ranking, expected.Ranking = ranking, ranking
+			docs, scores := index.Search(test.query, 10)
+			expectedDocs, expectedScores := expected.Search(test.query, 10)
+			if !reflect.DeepEqual(docs, expectedDocs) {
+				t.Errorf("Expected %v but found %v", expectedDocs, docs)
+			}
+			for i := range scores {
+				if math.Abs(scores[i]-expectedScores[i]) > 1e-9 {
+					t.Errorf("Expected score %f but found %f", expectedScores[i], scores[i])
+				}
+			}
+		}
+
+		// removing the last document should be identical to never having added it
+		if !index.Remove(5) || index.Remove(5) || index.Remove(6) {
+			t.Errorf("Expected only the first removal of an existing document to succeed")
+		}
+		m, _ = full.Transform(trainSet[:5]...)
+		expected = NewInvertedIndex(full, m)
+		expected.Ranking = index.Ranking
+		docs, scores := index.Search(test.query, 10)
+		expectedDocs, expectedScores := expected.Search(test.query, 10)
+		if !reflect.DeepEqual(docs, expectedDocs) || index.Len() != 5 {
+			t.Errorf("Expected %v after removal but found %v", expectedDocs, docs)
+		}
+		for i := range scores {
+			if math.Abs(scores[i]-expectedScores[i]) > 1e-9 {
+				t.Errorf("Expected score %f after removal but found %f", expectedScores[i], scores[i])
+			}
+		}
+		if docs := index.Match(BooleanQuery{Must: "dish"}); len(docs) != 0 {
+			t.Errorf("Expected removed document not to match but found %v", docs)
+		}
+	}
+}
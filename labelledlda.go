@@ -0,0 +1,266 @@
+package nlp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/james-bowman/sparse"
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+)
+
+// LabelledLDA (Labelled Latent Dirichlet Allocation) is a supervised topic model in
+// which each topic corresponds to one of the labels attached to the training
+// documents, as described by Ramage et al in "Labeled LDA: A supervised topic model
+// for credit attribution in multi-labeled corpora"
+// (https://www.aclweb.org/anthology/D09-1026.pdf).  During training, the words of each
+// document may only be assigned to the topics of the document's own labels so that
+// each topic learns the distribution of words associated with its label.  This gives
+// interpretable per-label topic over word distributions (see TopTerms()) and allows
+// new documents to be classified, with the words responsible for each label
+// attributed, by their inferred distribution over labels.
+//
+// This transformer uses collapsed Gibbs sampling and, as a Gibbs sampler, the values
+// of the input matrix are treated as word counts and rounded to the nearest integer.
+// Documents may be given a common label (e.g. "background") in addition to their own
+// to absorb words that are not specific to any label.
+type LabelledLDA struct {
+	// Iterations is the number of Gibbs sampling sweeps through the training data
+	Iterations int
+
+	// TransformationPasses is the number of Gibbs sampling sweeps through each document
+	// when transforming new documents given a previously fitted topic model
+	TransformationPasses int
+
+	// Alpha is the prior of theta (the documents over topics distribution)
+	Alpha float64
+
+	// Eta is the prior of phi (the topics over words distribution)
+	Eta float64
+
+	// Rnd is the random number generator used for sampling
+	Rnd *rand.Rand
+
+	// Labels holds the label corresponding to each topic, in topic order, learnt
+	// during Fit()
+	Labels []string
+
+	w int
+
+	// nPhi is the number of words assigned to each topic by word (topic x word)
+	nPhi [][]int
+
+	// nZ is the number of words assigned to each topic
+	nZ []int
+}
+
+// NewLabelledLDA returns a new LabelledLDA type initialised with default values.
+func NewLabelledLDA() *LabelledLDA {
+	return &LabelledLDA{
+		Iterations:           200,
+		TransformationPasses: 50,
+		Alpha:                0.1,
+		Eta:                  0.01,
+		Rnd:                  rand.New(rand.NewSource(uint64(time.Now().UnixNano()))),
+	}
+}
+
+// K returns the number of topics (distinct labels) learnt during Fit().
+func (l *LabelledLDA) K() int {
+	return len(l.Labels)
+}
+
+// Fit fits the model to the specified matrix m, where each column represents a
+// document, and the labels of each document.  labels must contain an entry for each
+// column of m.  Documents with no labels may be assigned to any topic.  A topic is
+// learnt for each distinct label in the order first encountered.  An error is
+// returned if the number of documents and labels differ or if none of the documents
+// are labelled.
+func (l *LabelledLDA) Fit(m mat.Matrix, labels [][]string) error {
+	_, err := l.FitTransform(m, labels)
+	return err
+}
+
+// FitTransform is approximately equivalent to calling Fit() followed by Transform()
+// on the same matrix except that each document's distribution over topics is
+// constrained to its labels.  The returned matrix contains the document over topic
+// distributions where each element is the probability of the corresponding document
+// being related to the corresponding topic (label).  The returned matrix is a Dense
+// matrix of shape K x C where K is the number of distinct labels and C is the number
+// of columns in the input matrix (representing the documents).
+func (l *LabelledLDA) FitTransform(m mat.Matrix, labels [][]string) (mat.Matrix, error) {
+	if t, isTypeConv := m.(sparse.TypeConverter); isTypeConv {
+		m = t.ToCSC()
+	}
+	var c int
+	l.w, c = m.Dims()
+	if len(labels) != c {
+		return nil, fmt.Errorf("nlp: Expected labels for %d documents but received %d", c, len(labels))
+	}
+
+	l.Labels = nil
+	index := make(map[string]int)
+	allowed := make([][]int, c)
+	for j, docLabels := range labels {
+		for _, label := range docLabels {
+			t, exists := index[label]
+			if !exists {
+				t = len(l.Labels)
+				index[label] = t
+				l.Labels = append(l.Labels, label)
+			}
+			allowed[j] = append(allowed[j], t)
+		}
+	}
+	if l.K() == 0 {
+		return nil, fmt.Errorf("nlp: No labels found for the documents")
+	}
+	topics := make([]int, l.K())
+	for t := range topics {
+		topics[t] = t
+	}
+	for j := range allowed {
+		if len(allowed[j]) == 0 {
+			allowed[j] = topics
+		}
+	}
+
+	l.nZ = make([]int, l.K())
+	l.nPhi = make([][]int, l.K())
+	for t := range l.nPhi {
+		l.nPhi[t] = make([]int, l.w)
+	}
+
+	docs := newHdpDocs(m, l.w)
+	for iter := 0; iter < l.Iterations; iter++ {
+		for j, doc := range docs {
+			l.sampleDoc(doc, allowed[j], true)
+		}
+	}
+
+	return l.theta(docs, allowed), nil
+}
+
+// Transform transforms the input matrix into a matrix representing the distribution
+// of the documents over topics (labels).  Unlike FitTransform(), each document may be
+// assigned to any topic so the distribution can be used to predict the labels of
+// new documents.  The returned matrix contains the document over topic distributions
+// where each element is the probability of the corresponding document being related
+// to the corresponding topic (label).  The returned matrix is a Dense matrix of shape
+// K x C where K is the number of labels and C is the number of columns in the input
+// matrix (representing the documents).  An error is returned if the model has not
+// been fitted with labelled documents.
+func (l *LabelledLDA) Transform(m mat.Matrix) (mat.Matrix, error) {
+	if t, isTypeConv := m.(sparse.TypeConverter); isTypeConv {
+		m = t.ToCSC()
+	}
+	if l.K() == 0 {
+		return nil, fmt.Errorf("nlp: Model must be fitted with labelled documents before Transform()")
+	}
+	_, c := m.Dims()
+
+	topics := make([]int, l.K())
+	for t := range topics {
+		topics[t] = t
+	}
+	allowed := make([][]int, c)
+	for j := range allowed {
+		allowed[j] = topics
+	}
+
+	docs := newHdpDocs(m, l.w)
+	for j, doc := range docs {
+		for pass := 0; pass < l.TransformationPasses; pass++ {
+			l.sampleDoc(doc, allowed[j], false)
+		}
+	}
+	return l.theta(docs, allowed), nil
+}
+
+// sampleDoc performs a single Gibbs sampling sweep through the words of the document,
+// re-assigning each word to one of the allowed topics.  If fit is true, the corpus
+// level topic statistics are updated, otherwise they are treated as fixed.
+func (l *LabelledLDA) sampleDoc(doc *hdpDoc, allowed []int, fit bool) {
+	if doc.nTheta == nil {
+		doc.nTheta = make([]int, l.K())
+	}
+	weightedEta := float64(l.w) * l.Eta
+	p := make([]float64, len(allowed))
+
+	for n, word := range doc.words {
+		if z := doc.z[n]; z >= 0 {
+			doc.nTheta[z]--
+			if fit {
+				l.nPhi[z][word]--
+				l.nZ[z]--
+			}
+		}
+
+		var sum float64
+		for i, t := range allowed {
+			sum += (float64(doc.nTheta[t]) + l.Alpha) * (float64(l.nPhi[t][word]) + l.Eta) / (float64(l.nZ[t]) + weightedEta)
+			p[i] = sum
+		}
+		u := l.Rnd.Float64() * sum
+		z := allowed[len(allowed)-1]
+		for i, cum := range p {
+			if u < cum {
+				z = allowed[i]
+				break
+			}
+		}
+
+		doc.z[n] = z
+		doc.nTheta[z]++
+		if fit {
+			l.nPhi[z][word]++
+			l.nZ[z]++
+		}
+	}
+}
+
+// theta returns the normalised document over topic distributions for docs, each
+// restricted to its allowed topics, as a K x C matrix.
+func (l *LabelledLDA) theta(docs []*hdpDoc, allowed [][]int) mat.Matrix {
+	theta := mat.NewDense(l.K(), len(docs), nil)
+	for j, doc := range docs {
+		sum := float64(len(doc.words)) + float64(len(allowed[j]))*l.Alpha
+		for _, t := range allowed[j] {
+			var n int
+			if doc.nTheta != nil {
+				n = doc.nTheta[t]
+			}
+			theta.Set(t, j, (float64(n)+l.Alpha)/sum)
+		}
+	}
+	return theta
+}
+
+// Components returns the topic over words probability distribution.  The returned
+// matrix is of dimensions K x W where W was the number of rows in the training matrix
+// and each column represents a unique words in the vocabulary and K is the number of
+// topics (labels).
+func (l *LabelledLDA) Components() mat.Matrix {
+	phi := mat.NewDense(l.K(), l.w, nil)
+	weightedEta := float64(l.w) * l.Eta
+	for t := range l.nZ {
+		for w := 0; w < l.w; w++ {
+			phi.Set(t, w, (float64(l.nPhi[t][w])+l.Eta)/(float64(l.nZ[t])+weightedEta))
+		}
+	}
+	return phi
+}
+
+// TopTerms returns the k terms with the highest probability within the topic of the
+// specified label in descending order of probability.  featureNames should contain
+// the name of each term ordered by index (row) in the training matrix e.g. as
+// returned by the GetFeatureNames() method of the vectoriser used to produce it.
+// nil is returned if the label was not present in the training data.
+func (l *LabelledLDA) TopTerms(label string, k int, featureNames []string) []string {
+	for t, topicLabel := range l.Labels {
+		if topicLabel == label {
+			return topTerms(l.Components(), t, false, k, featureNames)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package nlp
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/james-bowman/sparse"
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestLabelledLDA(t *testing.T) {
+	names := []string{"cat", "dog", "pet", "car", "road", "wheel"}
+
+	// 20 documents alternating between 2 blocks of terms with every fifth document
+	// containing the terms of both blocks and labelled with both labels
+	coo := sparse.NewCOO(6, 20, nil, nil, nil)
+	labels := make([][]string, 20)
+	for j := 0; j < 20; j++ {
+		switch {
+		case j%5 == 4:
+			coo.Set(0, j, 2)
+			coo.Set(2, j, 1)
+			coo.Set(4, j, 2)
+			coo.Set(5, j, 1)
+			labels[j] = []string{"vehicles", "animals"}
+		case j%2 == 0:
+			coo.Set(0, j, 3)
+			coo.Set(1, j, 2)
+			coo.Set(2, j, 4)
+			labels[j] = []string{"animals"}
+		default:
+			coo.Set(3, j, 2)
+			coo.Set(4, j, 4)
+			coo.Set(5, j, 3)
+			labels[j] = []string{"vehicles"}
+		}
+	}
+
+	var tests = []struct {
+		m mat.Matrix
+	}{
+		{m: mat.DenseCopyOf(coo)},
+		{m: coo.ToCSR()},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		llda := NewLabelledLDA()
+		// set Rnd to fixed constant seed for deterministic results
+		llda.Rnd = rand.New(rand.NewSource(uint64(0)))
+
+		theta, err := llda.FitTransform(test.m, labels)
+		if err != nil {
+			t.Errorf("Failed to fit Labelled LDA because %v", err)
+		}
+		if !reflect.DeepEqual([]string{"animals", "vehicles"}, llda.Labels) {
+			t.Errorf("Expected labels in order first encountered but found %v", llda.Labels)
+		}
+
+		// single labelled documents must have zero probability of the other label and
+		// multi-labelled documents should attribute words to both labels
+		for j := 0; j < 20; j++ {
+			animals, vehicles := theta.At(0, j), theta.At(1, j)
+			switch {
+			case j%5 == 4:
+				if animals < 0.25 || vehicles < 0.25 {
+					t.Errorf("Expected document %d to be attributed to both labels but theta was %v", j, mat.Col(nil, j, theta))
+				}
+			case j%2 == 0:
+				if vehicles != 0 {
+					t.Errorf("Expected document %d to have zero probability for vehicles but was %f", j, vehicles)
+				}
+			default:
+				if animals != 0 {
+					t.Errorf("Expected document %d to have zero probability for animals but was %f", j, animals)
+				}
+			}
+		}
+
+		for label, expected := range map[string][]string{
+			"animals":  {"cat", "dog", "pet"},
+			"vehicles": {"car", "road", "wheel"},
+		} {
+			terms := llda.TopTerms(label, len(expected), names)
+			sort.Strings(terms)
+			sort.Strings(expected)
+			if !reflect.DeepEqual(expected, terms) {
+				t.Errorf("Expected top terms of label %s to be %v but found %v", label, expected, terms)
+			}
+		}
+		if terms := llda.TopTerms("unknown", 3, names); terms != nil {
+			t.Errorf("Expected no top terms for unknown label but found %v", terms)
+		}
+
+		// new documents should be predominantly assigned to the label of their block
+		unseen := mat.NewDense(6, 2, []float64{
+			2, 0,
+			1, 0,
+			3, 0,
+			0, 3,
+			0, 2,
+			0, 1,
+		})
+		transformed, err := llda.Transform(unseen)
+		if err != nil {
+			t.Errorf("Failed to transform using Labelled LDA because %v", err)
+		}
+		if transformed.At(0, 0) <= transformed.At(1, 0) {
+			t.Errorf("Expected first document to be labelled animals but theta was %v", mat.Col(nil, 0, transformed))
+		}
+		if transformed.At(1, 1) <= transformed.At(0, 1) {
+			t.Errorf("Expected second document to be labelled vehicles but theta was %v", mat.Col(nil, 1, transformed))
+		}
+	}
+}
+
+func TestLabelledLDALabelMismatch(t *testing.T) {
+	llda := NewLabelledLDA()
+	m := mat.NewDense(3, 2, []float64{1, 0, 0, 1, 1, 1})
+	if err := llda.Fit(m, [][]string{{"a"}}); err == nil {
+		t.Errorf("Expected error fitting with fewer labels than documents but received nil")
+	}
+}
+
+func TestLabelledLDANoLabels(t *testing.T) {
+	llda := NewLabelledLDA()
+	m := mat.NewDense(3, 2, []float64{1, 0, 0, 1, 1, 1})
+	if _, err := llda.Transform(m); err == nil {
+		t.Errorf("Expected error transforming with an unfitted model but received nil")
+	}
+	if err := llda.Fit(m, [][]string{nil, nil}); err == nil {
+		t.Errorf("Expected error fitting with no labels but received nil")
+	}
+	if _, err := llda.Transform(m); err == nil {
+		t.Errorf("Expected error transforming with a model fitted without labels but received nil")
+	}
+}
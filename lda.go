@@ -1,6 +1,10 @@
 package nlp
 
 import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
 	"math"
 	"runtime"
 	"sync"
@@ -133,6 +137,11 @@ type LatentDirichletAllocation struct {
 	// concurrent go routines to use during fitting.
 	Processes int
 
+	// Progress, if not nil, is called after each training iteration during Fit() with
+	// the number of iterations completed (stage "lda").  If fitting converges (see
+	// PerplexityTolerance) before completing all Iterations, it is not called again.
+	Progress ProgressFunc
+
 	// nPhi is the topics over words distribution
 	nPhi []float64
 
@@ -144,7 +153,6 @@ type LatentDirichletAllocation struct {
 // with default values for k topics.
 func NewLatentDirichletAllocation(k int) *LatentDirichletAllocation {
 	// TODO:
-	// - Add FitPartial (and FitPartialTransform?) methods
 	// - refactor word counting
 	// - rename and check rhoTheta_t and rhoPhi_t
 	// - Check visibilitiy of member variables
@@ -155,7 +163,6 @@ func NewLatentDirichletAllocation(k int) *LatentDirichletAllocation {
 	//		- other areas
 	// - investigate whetehr can combine/consolidate fitMiniBatch and burnIn
 	// - Check whether nPhi could be sparse
-	// - Add persistence methods
 
 	l := LatentDirichletAllocation{
 		Iterations:                    1000,
@@ -193,6 +200,7 @@ func NewLatentDirichletAllocation(k int) *LatentDirichletAllocation {
 func (l *LatentDirichletAllocation) init(m mat.Matrix) {
 	r, c := m.Dims()
 	l.w, l.d = r, c
+	l.wordsInCorpus = 0
 	l.nPhi = make([]float64, l.K*r)
 	l.nZ = make([]float64, l.K)
 	var v float64
@@ -407,6 +415,15 @@ func (l *LatentDirichletAllocation) perplexity(m mat.Matrix, sum float64, nTheta
 	return perplexity
 }
 
+// FeatureDims returns the number of words expected in input matrices and the number of
+// topics output or -1 if the model has not been fitted.
+func (l *LatentDirichletAllocation) FeatureDims() (in, out int) {
+	if l.nPhi == nil {
+		return -1, -1
+	}
+	return l.w, l.K
+}
+
 // Components returns the topic over words probability distribution.  The returned
 // matrix is of dimensions K x W where w was the number of rows in the training matrix
 // and each column represents a unique words in the vocabulary and K is the number of
@@ -415,6 +432,14 @@ func (l *LatentDirichletAllocation) Components() mat.Matrix {
 	return mat.DenseCopyOf(mat.NewDense(l.w, l.K, l.normalisePhi(l.nPhi, nil)).T())
 }
 
+// TopTerms returns the k terms with the highest probability within the specified topic
+// in descending order of probability.  featureNames should contain the name of each
+// term ordered by index (row) in the training matrix e.g. as returned by the
+// GetFeatureNames() method of the vectoriser used to produce it.
+func (l *LatentDirichletAllocation) TopTerms(topic, k int, featureNames []string) []string {
+	return topTerms(l.Components(), topic, false, k, featureNames)
+}
+
 // unNormalisedTransform performs an unNormalisedTransform - the output
 // needs to be normalised using normaliseTheta before use.
 func (l *LatentDirichletAllocation) unNormalisedTransform(m mat.Matrix) []float64 {
@@ -461,6 +486,13 @@ func (l *LatentDirichletAllocation) Transform(m mat.Matrix) (mat.Matrix, error)
 // of topics and C is the number of columns in the input matrix (representing the
 // documents).
 func (l *LatentDirichletAllocation) FitTransform(m mat.Matrix) (mat.Matrix, error) {
+	return l.FitTransformCtx(context.Background(), m)
+}
+
+// FitTransformCtx is equivalent to FitTransform() except that fitting stops, returning
+// ctx.Err(), if ctx is cancelled or its deadline expires.  The context is checked
+// before each iteration so the model is left partially fitted.
+func (l *LatentDirichletAllocation) FitTransformCtx(ctx context.Context, m mat.Matrix) (mat.Matrix, error) {
 	if t, isTypeConv := m.(sparse.TypeConverter); isTypeConv {
 		m = t.ToCSC()
 	}
@@ -484,48 +516,20 @@ func (l *LatentDirichletAllocation) FitTransform(m mat.Matrix) (mat.Matrix, erro
 	var phiProb []float64
 	var thetaProb []float64
 
-	numMiniBatches := int(math.Ceil(float64(c) / float64(l.BatchSize)))
-	processes := l.Processes
-	if numMiniBatches < l.Processes {
-		processes = numMiniBatches
-	}
-	miniBatches := make([]*ldaMiniBatch, processes)
-	for i := range miniBatches {
-		miniBatches[i] = newLdaMiniBatch(l.K, l.w)
-	}
+	miniBatches := l.newMiniBatches(c)
 
 	l.rhoPhiT = 1
 	var perplexity float64
 	var prevPerplexity float64
 
 	for it := 0; it < l.Iterations; it++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		l.rhoThetaT++
 
-		mb := make(chan int)
-		var wg sync.WaitGroup
-
-		for process := 0; process < processes; process++ {
-			wg.Add(1)
-			go func(miniBatch *ldaMiniBatch) {
-				defer wg.Done()
-				for j := range mb {
-					miniBatch.reset()
-					miniBatch.start = j * l.BatchSize
-					if j < numMiniBatches-1 {
-						miniBatch.end = miniBatch.start + l.BatchSize
-					} else {
-						miniBatch.end = c
-					}
-					l.fitMiniBatch(miniBatch, wc, nTheta, m)
-				}
-			}(miniBatches[process])
-		}
-
-		for j := 0; j < numMiniBatches; j++ {
-			mb <- j
-		}
-		close(mb)
-		wg.Wait()
+		l.fitPass(m, wc, nTheta, miniBatches)
+		l.Progress.report("lda", it+1, l.Iterations)
 
 		if l.PerplexityEvaluationFrequency > 0 && (it+1)%l.PerplexityEvaluationFrequency == 0 {
 			phiProb = l.normalisePhi(l.nPhi, phiProb)
@@ -540,3 +544,211 @@ func (l *LatentDirichletAllocation) FitTransform(m mat.Matrix) (mat.Matrix, erro
 	}
 	return mat.DenseCopyOf(mat.NewDense(c, l.K, l.normaliseTheta(nTheta, thetaProb)).T()), nil
 }
+
+// newMiniBatches allocates the mini batches, one per concurrent process, used to fit
+// c documents.
+func (l *LatentDirichletAllocation) newMiniBatches(c int) []*ldaMiniBatch {
+	numMiniBatches := int(math.Ceil(float64(c) / float64(l.BatchSize)))
+	processes := l.Processes
+	if numMiniBatches < l.Processes {
+		processes = numMiniBatches
+	}
+	miniBatches := make([]*ldaMiniBatch, processes)
+	for i := range miniBatches {
+		miniBatches[i] = newLdaMiniBatch(l.K, l.w)
+	}
+	return miniBatches
+}
+
+// fitPass performs a single pass over all the documents (columns) in m, fitting each
+// mini batch of documents concurrently and updating the global topic statistics.
+func (l *LatentDirichletAllocation) fitPass(m mat.Matrix, wc []float64, nTheta []float64, miniBatches []*ldaMiniBatch) {
+	_, c := m.Dims()
+	numMiniBatches := int(math.Ceil(float64(c) / float64(l.BatchSize)))
+
+	mb := make(chan int)
+	var wg sync.WaitGroup
+
+	for _, miniBatch := range miniBatches {
+		wg.Add(1)
+		go func(miniBatch *ldaMiniBatch) {
+			defer wg.Done()
+			for j := range mb {
+				miniBatch.reset()
+				miniBatch.start = j * l.BatchSize
+				if j < numMiniBatches-1 {
+					miniBatch.end = miniBatch.start + l.BatchSize
+				} else {
+					miniBatch.end = c
+				}
+				l.fitMiniBatch(miniBatch, wc, nTheta, m)
+			}
+		}(miniBatch)
+	}
+
+	for j := 0; j < numMiniBatches; j++ {
+		mb <- j
+	}
+	close(mb)
+	wg.Wait()
+}
+
+// PartialFit extends the model to take account of the documents (columns) within the
+// specified matrix m, performing a single pass over them in mini batches of BatchSize
+// documents.  Unlike the Fit() method, which makes multiple passes over the entire
+// corpus, PartialFit() is designed to be called multiple times to support online
+// learning over corpora streamed in mini batches that would not fit in memory.  The
+// global topic statistics are updated according to the RhoPhi learning schedule,
+// with the learning rate decaying across successive calls, and the size of the
+// corpus is estimated from the total number of words seen so far.  The matrix may
+// contain more terms (rows) than previously seen (e.g. as the vocabulary of a
+// CountVectoriser is extended by its PartialFit() method) in which case the new terms
+// are added to the model.
+func (l *LatentDirichletAllocation) PartialFit(m mat.Matrix) OnlineTransformer {
+	if t, isTypeConv := m.(sparse.TypeConverter); isTypeConv {
+		m = t.ToCSC()
+	}
+	r, c := m.Dims()
+
+	if l.nPhi == nil {
+		l.init(m)
+		l.rhoPhiT = 1
+	} else if r > l.w {
+		// extend the model with randomly initialised statistics for new terms
+		for i := l.w; i < r; i++ {
+			for k := 0; k < l.K; k++ {
+				v := float64((l.Rnd.Int() % (r * l.K))) / float64(r*l.K)
+				l.nPhi = append(l.nPhi, v)
+				l.nZ[k] += v
+			}
+		}
+		l.w = r
+	}
+	l.d += c
+
+	nTheta := make([]float64, l.K*c)
+	for i := range nTheta {
+		nTheta[i] = float64((l.Rnd.Int() % (c * l.K))) / float64(c*l.K)
+	}
+	wc := make([]float64, c)
+	for j := 0; j < c; j++ {
+		ColNonZeroElemDo(m, j, func(i, j int, v float64) {
+			wc[j] += v
+		})
+		l.wordsInCorpus += wc[j]
+	}
+
+	l.rhoThetaT++
+	l.fitPass(m, wc, nTheta, l.newMiniBatches(c))
+
+	return l
+}
+
+// ldaVersion is the version of the serialisation format written by Save().
+const ldaVersion = 1
+
+// ldaHeader is the fixed size portion of a serialised LatentDirichletAllocation.
+type ldaHeader struct {
+	Version                       int64
+	Iterations                    int64
+	PerplexityTolerance           float64
+	PerplexityEvaluationFrequency int64
+	BatchSize                     int64
+	K                             int64
+	BurnInPasses                  int64
+	TransformationPasses          int64
+	MeanChangeTolerance           float64
+	ChangeEvaluationFrequency     int64
+	Alpha                         float64
+	Eta                           float64
+	RhoPhi                        LearningSchedule
+	RhoTheta                      LearningSchedule
+	RhoPhiT                       float64
+	RhoThetaT                     float64
+	WordsInCorpus                 float64
+	W                             int64
+	D                             int64
+	Processes                     int64
+}
+
+// Save binary serialises the model, including its hyperparameters, and writes it into
+// w.  This is useful for persisting a trained model to disk so that it may be loaded
+// (using the Load() method) in another context (e.g. production) for reproducible
+// results.
+func (l *LatentDirichletAllocation) Save(w io.Writer) error {
+	header := ldaHeader{
+		Version:                       ldaVersion,
+		Iterations:                    int64(l.Iterations),
+		PerplexityTolerance:           l.PerplexityTolerance,
+		PerplexityEvaluationFrequency: int64(l.PerplexityEvaluationFrequency),
+		BatchSize:                     int64(l.BatchSize),
+		K:                             int64(l.K),
+		BurnInPasses:                  int64(l.BurnInPasses),
+		TransformationPasses:          int64(l.TransformationPasses),
+		MeanChangeTolerance:           l.MeanChangeTolerance,
+		ChangeEvaluationFrequency:     int64(l.ChangeEvaluationFrequency),
+		Alpha:                         l.Alpha,
+		Eta:                           l.Eta,
+		RhoPhi:                        l.RhoPhi,
+		RhoTheta:                      l.RhoTheta,
+		RhoPhiT:                       l.rhoPhiT,
+		RhoThetaT:                     l.rhoThetaT,
+		WordsInCorpus:                 l.wordsInCorpus,
+		W:                             int64(l.w),
+		D:                             int64(l.d),
+		Processes:                     int64(l.Processes),
+	}
+	if err := binary.Write(w, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, l.nPhi); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, l.nZ)
+}
+
+// Load binary deserialises the previously serialised model into the receiver.  This is
+// useful for loading a previously trained and saved model from another context
+// (e.g. offline training) for use within another context (e.g. production) for
+// reproducible results.  Load should only be performed with trusted data.
+func (l *LatentDirichletAllocation) Load(r io.Reader) error {
+	var header ldaHeader
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return err
+	}
+	if header.Version != ldaVersion {
+		return fmt.Errorf("nlp: Unsupported serialisation version %d", header.Version)
+	}
+	nPhi := make([]float64, header.W*header.K)
+	if err := binary.Read(r, binary.LittleEndian, nPhi); err != nil {
+		return err
+	}
+	nZ := make([]float64, header.K)
+	if err := binary.Read(r, binary.LittleEndian, nZ); err != nil {
+		return err
+	}
+
+	l.Iterations = int(header.Iterations)
+	l.PerplexityTolerance = header.PerplexityTolerance
+	l.PerplexityEvaluationFrequency = int(header.PerplexityEvaluationFrequency)
+	l.BatchSize = int(header.BatchSize)
+	l.K = int(header.K)
+	l.BurnInPasses = int(header.BurnInPasses)
+	l.TransformationPasses = int(header.TransformationPasses)
+	l.MeanChangeTolerance = header.MeanChangeTolerance
+	l.ChangeEvaluationFrequency = int(header.ChangeEvaluationFrequency)
+	l.Alpha = header.Alpha
+	l.Eta = header.Eta
+	l.RhoPhi = header.RhoPhi
+	l.RhoTheta = header.RhoTheta
+	l.rhoPhiT = header.RhoPhiT
+	l.rhoThetaT = header.RhoThetaT
+	l.wordsInCorpus = header.WordsInCorpus
+	l.w = int(header.W)
+	l.d = int(header.D)
+	l.Processes = int(header.Processes)
+	l.nPhi = nPhi
+	l.nZ = nZ
+
+	return nil
+}
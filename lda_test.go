@@ -1,8 +1,11 @@
 package nlp_test
 
 import (
+	"bytes"
 	"fmt"
 	"math"
+	"reflect"
+	"sort"
 	"testing"
 
 	"golang.org/x/exp/rand"
@@ -88,6 +91,94 @@ func TestLDAFit(t *testing.T) {
 	}
 }
 
+func TestLDAPartialFit(t *testing.T) {
+	data := mat.NewDense(9, 9, []float64{
+		3, 3, 3, 0, 0, 0, 0, 0, 0,
+		3, 3, 3, 0, 0, 0, 0, 0, 0,
+		3, 3, 3, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 3, 3, 3, 0, 0, 0,
+		0, 0, 0, 3, 3, 3, 0, 0, 0,
+		0, 0, 0, 3, 3, 3, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 4, 4, 4,
+		0, 0, 0, 0, 0, 0, 4, 4, 4,
+		0, 0, 0, 0, 0, 0, 4, 4, 4,
+	})
+
+	for _, batchSize := range []int{1, 3} {
+		// set Rnd to fixed constant seed for deterministic results
+		lda := nlp.NewLatentDirichletAllocation(3)
+		lda.Rnd = rand.New(rand.NewSource(uint64(0)))
+
+		// stream the documents in mini batches, interleaving the topics across batches
+		var online nlp.OnlineTransformer = lda
+		for epoch := 0; epoch < 100; epoch++ {
+			for start := 0; start < 3; start += batchSize {
+				var batch []int
+				for j := start; j < start+batchSize; j++ {
+					batch = append(batch, j, j+3, j+6)
+				}
+				docs := mat.NewDense(9, len(batch), nil)
+				for k, j := range batch {
+					docs.SetCol(k, mat.Col(nil, j, data))
+				}
+				online = online.PartialFit(docs)
+			}
+		}
+
+		// each topic should be concentrated on the terms of a single block
+		components := lda.Components()
+		seen := make(map[int]bool)
+		for i := 0; i < 3; i++ {
+			var max float64
+			var maxBlock int
+			for block := 0; block < 3; block++ {
+				var sum float64
+				for w := block * 3; w < block*3+3; w++ {
+					sum += components.At(i, w)
+				}
+				if sum > max {
+					max, maxBlock = sum, block
+				}
+			}
+			if max < 0.9 || seen[maxBlock] {
+				t.Errorf("Batch size %d: Expected topic (%d) to be concentrated on a distinct block of terms but found %v\n",
+					batchSize, i, mat.Formatted(components))
+			}
+			seen[maxBlock] = true
+		}
+	}
+}
+
+func TestLDATopTerms(t *testing.T) {
+	data := mat.NewDense(6, 6, []float64{
+		4, 3, 4, 0, 0, 0,
+		1, 1, 2, 0, 0, 0,
+		2, 2, 1, 0, 0, 0,
+		0, 0, 0, 1, 2, 1,
+		0, 0, 0, 4, 3, 5,
+		0, 0, 0, 2, 1, 2,
+	})
+	names := []string{"cat", "dog", "pet", "car", "road", "wheel"}
+
+	// set Rnd to fixed constant seed for deterministic results
+	lda := nlp.NewLatentDirichletAllocation(2)
+	lda.Rnd = rand.New(rand.NewSource(uint64(0)))
+	lda.Fit(data)
+
+	// each topic should be made up of the terms from one of the two blocks
+	blocks := [][]string{{"car", "road", "wheel"}, {"cat", "dog", "pet"}}
+	var found [][]string
+	for topic := 0; topic < 2; topic++ {
+		top := lda.TopTerms(topic, 3, names)
+		sort.Strings(top)
+		found = append(found, top)
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i][0] < found[j][0] })
+	if !reflect.DeepEqual(blocks, found) {
+		t.Errorf("Expected top terms %v but found %v", blocks, found)
+	}
+}
+
 func TestLDAFitTransform(t *testing.T) {
 	tests := []struct {
 		topics       int
@@ -282,3 +373,46 @@ func ExampleLatentDirichletAllocation() {
 		}
 	}
 }
+
+func TestLDASaveLoad(t *testing.T) {
+	data := mat.NewDense(6, 6, []float64{
+		4, 3, 4, 0, 0, 0,
+		1, 1, 2, 0, 0, 0,
+		2, 2, 1, 0, 0, 0,
+		0, 0, 0, 1, 2, 1,
+		0, 0, 0, 4, 3, 5,
+		0, 0, 0, 2, 1, 2,
+	})
+
+	// set Rnd to fixed constant seed for deterministic results
+	wanted := nlp.NewLatentDirichletAllocation(2)
+	wanted.Rnd = rand.New(rand.NewSource(uint64(0)))
+	wanted.Alpha = 0.2
+	wanted.TransformationPasses = 100
+	wanted.Fit(data)
+
+	var buf bytes.Buffer
+	if err := wanted.Save(&buf); err != nil {
+		t.Fatalf("Error encoding: %v\n", err)
+	}
+	loaded := nlp.NewLatentDirichletAllocation(5)
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatalf("Error unencoding: %v\n", err)
+	}
+
+	if wanted.K != loaded.K || wanted.Alpha != loaded.Alpha || wanted.TransformationPasses != loaded.TransformationPasses || wanted.RhoPhi != loaded.RhoPhi {
+		t.Errorf("Expected hyperparameters of loaded model to match those of saved model")
+	}
+	if !mat.Equal(wanted.Components(), loaded.Components()) {
+		t.Errorf("Expected components %v but found %v", mat.Formatted(wanted.Components()), mat.Formatted(loaded.Components()))
+	}
+
+	// both models should produce the same transform given the same random initialisation
+	wanted.Rnd = rand.New(rand.NewSource(uint64(1)))
+	loaded.Rnd = rand.New(rand.NewSource(uint64(1)))
+	expected, _ := wanted.Transform(data)
+	result, _ := loaded.Transform(data)
+	if !mat.Equal(expected, result) {
+		t.Errorf("Expected %v but found %v", mat.Formatted(expected), mat.Formatted(result))
+	}
+}
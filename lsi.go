@@ -0,0 +1,154 @@
+package nlp
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/james-bowman/sparse"
+	"gonum.org/v1/gonum/mat"
+)
+
+// LSIRetriever retrieves documents by their similarity to queries within the latent
+// semantic space of a fitted TruncatedSVD (Latent Semantic Indexing).  Query vectors,
+// vectorised and weighted in the same way as the documents (e.g. by the vectoriser
+// and TF-IDF steps of an LSI Pipeline), are folded into the latent space and compared
+// against the latent document vectors using cosine similarity.  As documents are
+// compared by the latent concepts they share, rather than the terms, documents may be
+// retrieved even if they contain none of the query terms (e.g. synonyms).
+//
+// Retrieval may optionally be improved using pseudo-relevance feedback, whereby the
+// query is expanded using the Rocchio algorithm with the terms of the top ranked
+// documents (assumed to be relevant) and then re-run.  The expanded query is:
+//
+//	Alpha * q + Beta * (1/|R|) * sum(d for d in R)
+//
+// where R is the set of the FeedbackDocs top ranked documents for the query q.
+type LSIRetriever struct {
+	// Alpha is the weight of the original query within the expanded query
+	Alpha float64
+
+	// Beta is the weight of the centroid of the feedback documents within the
+	// expanded query
+	Beta float64
+
+	// FeedbackDocs is the number of top ranked documents assumed to be relevant for
+	// pseudo-relevance feedback
+	FeedbackDocs int
+
+	// ExpansionTerms, if greater than 0, limits the terms added to the expanded
+	// query to the ExpansionTerms highest weighted terms not within the original
+	// query.  Otherwise every term within the feedback documents is added.
+	ExpansionTerms int
+
+	svd   *TruncatedSVD
+	docs  mat.Matrix
+	index *LinearIndex
+}
+
+// NewLSIRetriever creates a new LSIRetriever for the documents within the term
+// document matrix docs (with a column for each document, in the same term space used
+// to fit the TruncatedSVD) using the fitted TruncatedSVD.  Documents are identified
+// by their column index within docs.  The retriever uses an Alpha of 1, a Beta of
+// 0.75, 10 FeedbackDocs and 20 ExpansionTerms for pseudo-relevance feedback by
+// default.  An error is returned if docs does not have the same number of terms (rows)
+// as the TruncatedSVD was fitted with.
+func NewLSIRetriever(svd *TruncatedSVD, docs mat.Matrix) (*LSIRetriever, error) {
+	if in, _ := svd.FeatureDims(); in < 0 {
+		return nil, fmt.Errorf("nlp: TruncatedSVD must be fitted before retrieval")
+	} else if r, _ := docs.Dims(); r != in {
+		return nil, fmt.Errorf("nlp: Matrix has %d terms but the TruncatedSVD was fitted with %d", r, in)
+	}
+	if t, isTypeConv := docs.(sparse.TypeConverter); isTypeConv {
+		docs = t.ToCSC()
+	}
+
+	latent, err := svd.Transform(docs)
+	if err != nil {
+		return nil, err
+	}
+	index := NewLinearIndex(Cosine)
+	ColDo(latent, func(j int, v mat.Vector) {
+		index.Add(j, v)
+	})
+
+	return &LSIRetriever{
+		Alpha:          1,
+		Beta:           0.75,
+		FeedbackDocs:   10,
+		ExpansionTerms: 20,
+		svd:            svd,
+		docs:           docs,
+		index:          index,
+	}, nil
+}
+
+// Search folds the term vector q into the latent space and returns the indices and
+// cosine similarities of the k documents most similar to it in descending order of
+// similarity.
+func (r *LSIRetriever) Search(q mat.Vector, k int) ([]int, []float64) {
+	ids, scores := r.index.Search(r.svd.FoldIn(q), k)
+	docs := make([]int, len(ids))
+	for i, id := range ids {
+		docs[i] = id.(int)
+	}
+	return docs, scores
+}
+
+// Expand expands the term vector q using pseudo-relevance feedback (Rocchio) over the
+// FeedbackDocs documents most similar to q, returning the expanded term vector.  The
+// returned vector has a length equal to the number of terms the TruncatedSVD was
+// fitted with.
+func (r *LSIRetriever) Expand(q mat.Vector) *mat.VecDense {
+	terms, _ := r.docs.Dims()
+	expanded := mat.NewVecDense(terms, nil)
+	original := make(map[int]bool)
+	for i := 0; i < q.Len() && i < terms; i++ {
+		if v := q.AtVec(i); v != 0 {
+			expanded.SetVec(i, r.Alpha*v)
+			original[i] = true
+		}
+	}
+
+	feedback, _ := r.Search(q, r.FeedbackDocs)
+	if len(feedback) == 0 || r.Beta == 0 {
+		return expanded
+	}
+	centroid := make(map[int]float64)
+	for _, d := range feedback {
+		ColNonZeroElemDo(r.docs, d, func(i, j int, v float64) {
+			centroid[i] += v / float64(len(feedback))
+		})
+	}
+
+	// rank candidate expansion terms by weight (ties by index) so that only the
+	// highest weighted new terms are added
+	candidates := make([]int, 0, len(centroid))
+	for i := range centroid {
+		if !original[i] {
+			candidates = append(candidates, i)
+		}
+	}
+	sort.Slice(candidates, func(a, b int) bool {
+		if centroid[candidates[a]] == centroid[candidates[b]] {
+			return candidates[a] < candidates[b]
+		}
+		return centroid[candidates[a]] > centroid[candidates[b]]
+	})
+	if r.ExpansionTerms > 0 && len(candidates) > r.ExpansionTerms {
+		candidates = candidates[:r.ExpansionTerms]
+	}
+
+	for i := range original {
+		expanded.SetVec(i, expanded.AtVec(i)+r.Beta*centroid[i])
+	}
+	for _, i := range candidates {
+		expanded.SetVec(i, r.Beta*centroid[i])
+	}
+	return expanded
+}
+
+// SearchFeedback is equivalent to Search() but first expands the query q using
+// pseudo-relevance feedback (see Expand()).
+func (r *LSIRetriever) SearchFeedback(q mat.Vector, k int) ([]int, []float64) {
+	return r.Search(r.Expand(q), k)
+}
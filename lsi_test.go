@@ -0,0 +1,107 @@
+package nlp
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestTruncatedSVDFoldIn(t *testing.T) {
+	vectoriser := NewCountVectoriser()
+	m, _ := vectoriser.FitTransform(trainSet...)
+	svd := NewTruncatedSVD(3)
+	latent, _ := svd.FitTransform(m)
+
+	ColDo(m, func(j int, q mat.Vector) {
+		folded := svd.FoldIn(q)
+		for c := 0; c < 3; c++ {
+			if math.Abs(folded.AtVec(c)-latent.At(c, j)) > 1e-9 {
+				t.Errorf("Expected folded in document %d to equal transformed document but found %v", j, folded)
+			}
+		}
+
+		// dense vectors and vectors with additional (unseen) terms should be equivalent
+		r := q.Len()
+		dense := mat.NewVecDense(r+2, nil)
+		for i := 0; i < r; i++ {
+			dense.SetVec(i, q.AtVec(i))
+		}
+		dense.SetVec(r, 1)
+		if !mat.EqualApprox(svd.FoldIn(dense), folded, 1e-9) {
+			t.Errorf("Expected dense vector with additional terms to fold in identically")
+		}
+	})
+}
+
+func TestLSIRetriever(t *testing.T) {
+	vectoriser := NewCountVectoriser(stopWords...)
+	tfidf := NewTfidfTransformer()
+	m, _ := vectoriser.FitTransform(trainSet...)
+	m, _ = tfidf.FitTransform(m)
+	svd := NewTruncatedSVD(4)
+	svd.Fit(m)
+
+	retriever, err := NewLSIRetriever(svd, m)
+	if err != nil {
+		t.Fatalf("Failed to create retriever because %v", err)
+	}
+	if _, err := NewLSIRetriever(svd, mat.NewDense(3, 2, nil)); err == nil {
+		t.Errorf("Expected error for matrix with the wrong number of terms")
+	}
+	if _, err := NewLSIRetriever(NewTruncatedSVD(4), m); err == nil {
+		t.Errorf("Expected error for unfitted TruncatedSVD")
+	}
+
+	ColDo(m, func(j int, q mat.Vector) {
+		docs, scores := retriever.Search(q, 3)
+		if len(docs) != 3 || math.Abs(scores[0]-1) > 1e-9 {
+			t.Errorf("Expected document %d to be most similar to itself but found %v %v", j, docs, scores)
+		}
+	})
+
+	tests := []struct {
+		query          string
+		beta           float64
+		feedbackDocs   int
+		expansionTerms int
+		maxTerms       int
+	}{
+		{query: "dog", beta: 0, feedbackDocs: 2, expansionTerms: 0, maxTerms: 1},
+		{query: "dog", beta: 0.75, feedbackDocs: 2, expansionTerms: 3, maxTerms: 4},
+		{query: "cow", beta: 0.75, feedbackDocs: 1, expansionTerms: 0, maxTerms: 26},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		retriever.Beta, retriever.FeedbackDocs, retriever.ExpansionTerms = test.beta, test.feedbackDocs, test.expansionTerms
+
+		qm, _ := vectoriser.Transform(test.query)
+		qm, _ = tfidf.Transform(qm)
+		var q mat.Vector
+		ColDo(qm, func(j int, v mat.Vector) {
+			q = v
+		})
+		term := vectoriser.Vocabulary[test.query]
+
+		expanded := retriever.Expand(q)
+		var nonZero int
+		for i := 0; i < expanded.Len(); i++ {
+			if expanded.AtVec(i) != 0 {
+				nonZero++
+			}
+		}
+		if nonZero > test.maxTerms || (test.beta > 0 && nonZero < 2) {
+			t.Errorf("Expected between 2 and %d terms in expanded query but found %d", test.maxTerms, nonZero)
+		}
+		if expanded.AtVec(term) < q.AtVec(term) {
+			t.Errorf("Expected original query term to be retained with at least its original weight")
+		}
+
+		feedbackDocs, _ := retriever.SearchFeedback(q, 6)
+		if len(feedbackDocs) != 6 {
+			t.Errorf("Expected all 6 documents but found %v", feedbackDocs)
+		}
+	}
+}
@@ -17,15 +17,154 @@ type Comparer func(a, b mat.Vector) float64
 func CosineSimilarity(a, b mat.Vector) float64 {
 	// Cosine angle between two vectors is equal to their dot product divided by
 	// the product of their L2 norms
-	dotProduct := sparse.Dot(a, b)
+	return cosine(sparse.Dot(a, b), sparse.Norm(a, 2.0), sparse.Norm(b, 2.0))
+}
+
+// CosineSimilarities calculates the cosine similarity between vector a and each of
+// the rows of matrix m, e.g. to compare a query vector against every document within
+// a corpus, returning the similarities in row order.  If m is a sparse matrix, it is
+// converted to CSR format (if not already) and only its non-zero elements are
+// accessed, avoiding the cost of dense dot products over mostly zero vectors.  The
+// length of a must equal the number of columns in m.  As with CosineSimilarity, NaN is
+// returned for rows containing only 0s or for every row if a contains only 0s.  As
+// vectorisers output matrices with documents as columns by default, such matrices
+// should be transposed first e.g. using m.T().
+func CosineSimilarities(a mat.Vector, m mat.Matrix) []float64 {
+	r, c := m.Dims()
+	if a.Len() != c {
+		panic(mat.ErrShape)
+	}
+
+	query := make([]float64, c)
+	if sv, isSparse := a.(*sparse.Vector); isSparse {
+		data, ind := sv.RawVector()
+		for i, v := range data {
+			query[ind[i]] = v
+		}
+	} else {
+		for i := range query {
+			query[i] = a.AtVec(i)
+		}
+	}
 	norma := sparse.Norm(a, 2.0)
-	normb := sparse.Norm(b, 2.0)
 
+	similarities := make([]float64, r)
+	if t, isTypeConv := m.(sparse.TypeConverter); isTypeConv {
+		raw := t.ToCSR().RawMatrix()
+		for i := range similarities {
+			var dotProduct, norm float64
+			for k := raw.Indptr[i]; k < raw.Indptr[i+1]; k++ {
+				v := raw.Data[k]
+				dotProduct += v * query[raw.Ind[k]]
+				norm += v * v
+			}
+			similarities[i] = cosine(dotProduct, norma, math.Sqrt(norm))
+		}
+		return similarities
+	}
+
+	for i := range similarities {
+		var dotProduct, norm float64
+		for j, q := range query {
+			v := m.At(i, j)
+			dotProduct += v * q
+			norm += v * v
+		}
+		similarities[i] = cosine(dotProduct, norma, math.Sqrt(norm))
+	}
+	return similarities
+}
+
+// cosine returns the cosine similarity of two vectors given their dot product and L2
+// norms or NaN if either norm is 0.
+func cosine(dotProduct, norma, normb float64) float64 {
 	if norma == 0 || normb == 0 {
 		return math.NaN()
 	}
+	return dotProduct / (norma * normb)
+}
+
+// SoftCosineSimilarity calculates the soft cosine similarity between vectors a and b
+// as described by Sidorov et al. in "Soft Similarity and Soft Cosine Measure:
+// Similarity of Features in Vector Space Model".  Unlike CosineSimilarity, which only
+// credits terms shared by both vectors, soft cosine also credits pairs of different
+// but similar terms (e.g. "car" and "automobile") according to the term-term
+// similarity matrix s, where element i, j is the similarity between terms i and j
+// (e.g. the cosine similarity of their word embeddings or PPMI vectors):
+//
+//	a^T s b / (sqrt(a^T s a) * sqrt(b^T s b))
+//
+// The diagonal of s is taken to be 1 (each term is identical to itself) regardless of
+// its values so matrices omitting the diagonal, such as those output from
+// SparsePairwiseCosine, may be used directly.  Only the non-zero elements of sparse
+// vectors are accessed so s is best supplied as a sparse matrix, retaining only the
+// similarities between the most similar terms.  With s as the identity matrix, the
+// result is equal to CosineSimilarity.  NaN is returned if either vector contains only
+// 0s.
+func SoftCosineSimilarity(a, b mat.Vector, s mat.Matrix) float64 {
+	aInd, aData := nonZeros(a)
+	bInd, bData := nonZeros(b)
+	return cosine(
+		softDot(aInd, aData, bInd, bData, s),
+		math.Sqrt(softDot(aInd, aData, aInd, aData, s)),
+		math.Sqrt(softDot(bInd, bData, bInd, bData, s)),
+	)
+}
+
+// SoftCosine returns a Comparer calculating the soft cosine similarity between vectors
+// using the term-term similarity matrix s (see SoftCosineSimilarity).
+func SoftCosine(s mat.Matrix) Comparer {
+	return func(a, b mat.Vector) float64 {
+		return SoftCosineSimilarity(a, b, s)
+	}
+}
+
+// SoftCosineDistance returns a Comparer calculating the complement of the soft cosine
+// similarity between vectors (1-SoftCosineSimilarity) using the term-term similarity
+// matrix s e.g. for ranking by distance within an index.
+func SoftCosineDistance(s mat.Matrix) Comparer {
+	return func(a, b mat.Vector) float64 {
+		return 1.0 - SoftCosineSimilarity(a, b, s)
+	}
+}
+
+// softDot returns a^T s b for the vectors a and b, represented by the indices and
+// values of their non-zero elements, treating the diagonal of s as 1.
+func softDot(aInd []int, aData []float64, bInd []int, bData []float64, s mat.Matrix) float64 {
+	var sum float64
+	for i, ai := range aInd {
+		for j, bj := range bInd {
+			if ai == bj {
+				sum += aData[i] * bData[j]
+			} else if sim := s.At(ai, bj); sim != 0 {
+				sum += aData[i] * sim * bData[j]
+			}
+		}
+	}
+	return sum
+}
 
-	return (dotProduct / (norma * normb))
+// nonZeros returns the indices and values of the non-zero elements of v.
+func nonZeros(v mat.Vector) ([]int, []float64) {
+	var ind []int
+	var data []float64
+	if s, isSparse := v.(*sparse.Vector); isSparse {
+		raw, rawInd := s.RawVector()
+		for i, val := range raw {
+			if val != 0 {
+				ind = append(ind, rawInd[i])
+				data = append(data, val)
+			}
+		}
+		return ind, data
+	}
+	for i := 0; i < v.Len(); i++ {
+		if val := v.AtVec(i); val != 0 {
+			ind = append(ind, i)
+			data = append(data, val)
+		}
+	}
+	return ind, data
 }
 
 // CosineDistance is the complement of CosineSimilarity in the positive space.
@@ -91,6 +230,156 @@ func HammingSimilarity(a, b mat.Vector) float64 {
 	return 1.0 - HammingDistance(a, b)
 }
 
+// JaccardSimilarity calculates the Jaccard similarity coefficient between vectors a
+// and b treating each as the set of the indices of its non-zero elements e.g. binary
+// term presence vectors or shingle vectors.  The coefficient is the size of the
+// intersection of the sets divided by the size of their union:
+//
+//	|A ∩ B| / |A ∪ B|
+//
+// Only non-zero elements are accessed for sparse.BinaryVec and sparse.Vector types.
+// NaN is returned if both vectors contain only 0s.
+func JaccardSimilarity(a, b mat.Vector) float64 {
+	intersection, na, nb := setSizes(a, b)
+	return intersection / (na + nb - intersection)
+}
+
+// JaccardDistance is the complement of JaccardSimilarity (1-JaccardSimilarity).
+// Unlike CosineDistance, JaccardDistance is a valid distance metric.
+func JaccardDistance(a, b mat.Vector) float64 {
+	return 1.0 - JaccardSimilarity(a, b)
+}
+
+// DiceSimilarity calculates the Sørensen–Dice coefficient between vectors a and b
+// treating each as the set of the indices of its non-zero elements.  The coefficient
+// is twice the size of the intersection of the sets divided by the sum of their sizes:
+//
+//	2 * |A ∩ B| / (|A| + |B|)
+//
+// giving more weight to shared elements than JaccardSimilarity.  NaN is returned if
+// both vectors contain only 0s.
+func DiceSimilarity(a, b mat.Vector) float64 {
+	intersection, na, nb := setSizes(a, b)
+	return 2 * intersection / (na + nb)
+}
+
+// OverlapSimilarity calculates the overlap (Szymkiewicz–Simpson) coefficient between
+// vectors a and b treating each as the set of the indices of its non-zero elements.
+// The coefficient is the size of the intersection of the sets divided by the size of
+// the smaller set:
+//
+//	|A ∩ B| / min(|A|, |B|)
+//
+// so is 1 if either set is a subset of the other e.g. to match short texts contained
+// within longer ones.  NaN is returned if either vector contains only 0s.
+func OverlapSimilarity(a, b mat.Vector) float64 {
+	intersection, na, nb := setSizes(a, b)
+	return intersection / math.Min(na, nb)
+}
+
+// setSizes returns the number of indices at which both a and b are non-zero along
+// with the number of non-zero elements in each of a and b.
+func setSizes(a, b mat.Vector) (intersection, na, nb float64) {
+	if ba, aok := a.(*sparse.BinaryVec); aok {
+		if bb, bok := b.(*sparse.BinaryVec); bok {
+			na, nb = float64(ba.NNZ()), float64(bb.NNZ())
+			// the Hamming distance counts the elements in exactly one of the sets
+			return (na + nb - float64(ba.DistanceFrom(bb))) / 2, na, nb
+		}
+	}
+	sa, aok := a.(*sparse.Vector)
+	sb, bok := b.(*sparse.Vector)
+	if aok && bok {
+		aData, aInd := sa.RawVector()
+		bData, bInd := sb.RawVector()
+		i, j := 0, 0
+		for i < len(aInd) || j < len(bInd) {
+			switch {
+			case j == len(bInd) || i < len(aInd) && aInd[i] < bInd[j]:
+				if aData[i] != 0 {
+					na++
+				}
+				i++
+			case i == len(aInd) || bInd[j] < aInd[i]:
+				if bData[j] != 0 {
+					nb++
+				}
+				j++
+			default:
+				if aData[i] != 0 {
+					na++
+				}
+				if bData[j] != 0 {
+					nb++
+				}
+				if aData[i] != 0 && bData[j] != 0 {
+					intersection++
+				}
+				i++
+				j++
+			}
+		}
+		return intersection, na, nb
+	}
+
+	for i := 0; i < a.Len(); i++ {
+		va, vb := a.AtVec(i) != 0, b.AtVec(i) != 0
+		if va {
+			na++
+		}
+		if vb {
+			nb++
+		}
+		if va && vb {
+			intersection++
+		}
+	}
+	return intersection, na, nb
+}
+
+// TokenJaccardSimilarity calculates the Jaccard similarity coefficient, as
+// JaccardSimilarity, between the sets of distinct tokens within a and b e.g. the words
+// or shingles of 2 short texts.  NaN is returned if both a and b are empty.
+func TokenJaccardSimilarity(a, b []string) float64 {
+	intersection, na, nb := tokenSetSizes(a, b)
+	return intersection / (na + nb - intersection)
+}
+
+// TokenDiceSimilarity calculates the Sørensen–Dice coefficient, as DiceSimilarity,
+// between the sets of distinct tokens within a and b.  NaN is returned if both a and
+// b are empty.
+func TokenDiceSimilarity(a, b []string) float64 {
+	intersection, na, nb := tokenSetSizes(a, b)
+	return 2 * intersection / (na + nb)
+}
+
+// TokenOverlapSimilarity calculates the overlap coefficient, as OverlapSimilarity,
+// between the sets of distinct tokens within a and b.  NaN is returned if either a or
+// b is empty.
+func TokenOverlapSimilarity(a, b []string) float64 {
+	intersection, na, nb := tokenSetSizes(a, b)
+	return intersection / math.Min(na, nb)
+}
+
+// tokenSetSizes returns the number of distinct tokens within both a and b along with
+// the number of distinct tokens within each of a and b.
+func tokenSetSizes(a, b []string) (intersection, na, nb float64) {
+	setA := make(map[string]bool, len(a))
+	for _, token := range a {
+		setA[token] = true
+	}
+	setB := make(map[string]bool, len(b))
+	for _, token := range b {
+		setB[token] = true
+	}
+	for token := range setB {
+		if setA[token] {
+			intersection++
+		}
+	}
+	return intersection, float64(len(setA)), float64(len(setB))
+}
+
 // EuclideanDistance calculates the Euclidean distance
 // (l2 distance) between vectors a and b or more specifically
 // \sqrt{\sum_{i=1}^n (a_i - b_i)^2}
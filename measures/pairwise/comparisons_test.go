@@ -0,0 +1,225 @@
+package pairwise
+
+import (
+	"math"
+	"testing"
+
+	"github.com/james-bowman/sparse"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestCosineSimilarities(t *testing.T) {
+	data := []float64{
+		1, 0, 2, 0,
+		0, 0, 0, 0,
+		0, 3, 0, 1,
+		2, 0, 4, 0,
+		1, 1, 1, 1,
+	}
+	dense := mat.NewDense(5, 4, data)
+
+	var tests = []struct {
+		a mat.Vector
+		m mat.Matrix
+	}{
+		{a: mat.NewVecDense(4, []float64{1, 0, 2, 0}), m: dense},
+		{a: mat.NewVecDense(4, []float64{1, 0, 2, 0}), m: sparse.NewCSR(5, 4, []int{0, 2, 2, 4, 6, 10}, []int{0, 2, 1, 3, 0, 2, 0, 1, 2, 3}, []float64{1, 2, 3, 1, 2, 4, 1, 1, 1, 1})},
+		{a: sparse.NewVector(4, []int{1, 3}, []float64{3, 1}), m: sparse.NewDOK(5, 4)},
+		{a: sparse.NewVector(4, []int{1, 3}, []float64{3, 1}), m: dense.T().T()},
+		{a: mat.NewVecDense(4, []float64{0, 0, 0, 0}), m: dense},
+	}
+	dok := tests[2].m.(*sparse.DOK)
+	for i := 0; i < 5; i++ {
+		for j := 0; j < 4; j++ {
+			if v := dense.At(i, j); v != 0 {
+				dok.Set(i, j, v)
+			}
+		}
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		result := CosineSimilarities(test.a, test.m)
+		if len(result) != 5 {
+			t.Fatalf("Expected 5 similarities but found %d", len(result))
+		}
+		for i, sim := range result {
+			expected := CosineSimilarity(test.a, dense.RowView(i))
+			if math.IsNaN(expected) != math.IsNaN(sim) || (!math.IsNaN(expected) && math.Abs(expected-sim) > 1e-12) {
+				t.Errorf("Expected similarity of row %d to be %f but found %f", i, expected, sim)
+			}
+		}
+	}
+}
+
+func TestSetSimilarities(t *testing.T) {
+	binary := func(n int, bits ...int) *sparse.BinaryVec {
+		v := sparse.NewBinaryVec(n)
+		for _, bit := range bits {
+			v.SetBit(bit)
+		}
+		return v
+	}
+
+	var tests = []struct {
+		a, b                   mat.Vector
+		jaccard, dice, overlap float64
+	}{
+		{
+			a:       mat.NewVecDense(6, []float64{1, 1, 1, 0, 0, 0}),
+			b:       mat.NewVecDense(6, []float64{0, 2, 1, 1, 0, 0}),
+			jaccard: 2.0 / 4, dice: 4.0 / 6, overlap: 2.0 / 3,
+		},
+		{
+			a:       sparse.NewVector(6, []int{0, 1, 2}, []float64{1, 1, 1}),
+			b:       sparse.NewVector(6, []int{1, 2, 3, 5}, []float64{2, 1, 1, 0}),
+			jaccard: 2.0 / 4, dice: 4.0 / 6, overlap: 2.0 / 3,
+		},
+		{
+			a:       binary(70, 0, 1, 2, 65),
+			b:       binary(70, 1, 2, 3, 65),
+			jaccard: 3.0 / 5, dice: 6.0 / 8, overlap: 3.0 / 4,
+		},
+		{
+			a:       sparse.NewVector(6, []int{1, 2}, []float64{1, 1}),
+			b:       mat.NewVecDense(6, []float64{0, 1, 1, 1, 1, 0}),
+			jaccard: 2.0 / 4, dice: 4.0 / 6, overlap: 1,
+		},
+		{
+			a:       mat.NewVecDense(3, []float64{1, 0, 0}),
+			b:       mat.NewVecDense(3, []float64{0, 1, 0}),
+			jaccard: 0, dice: 0, overlap: 0,
+		},
+		{
+			a:       mat.NewVecDense(3, nil),
+			b:       mat.NewVecDense(3, nil),
+			jaccard: math.NaN(), dice: math.NaN(), overlap: math.NaN(),
+		},
+	}
+
+	equal := func(a, b float64) bool {
+		return math.IsNaN(a) && math.IsNaN(b) || math.Abs(a-b) < 1e-9
+	}
+
+	for ti, test := range tests {
+		t.Logf("**** Test Run %d.\n", ti+1)
+
+		if s := JaccardSimilarity(test.a, test.b); !equal(s, test.jaccard) {
+			t.Errorf("Expected Jaccard similarity %f but found %f", test.jaccard, s)
+		}
+		if d := JaccardDistance(test.a, test.b); !equal(d, 1-test.jaccard) {
+			t.Errorf("Expected Jaccard distance %f but found %f", 1-test.jaccard, d)
+		}
+		if s := DiceSimilarity(test.a, test.b); !equal(s, test.dice) {
+			t.Errorf("Expected Dice similarity %f but found %f", test.dice, s)
+		}
+		if s := OverlapSimilarity(test.a, test.b); !equal(s, test.overlap) {
+			t.Errorf("Expected overlap similarity %f but found %f", test.overlap, s)
+		}
+	}
+}
+
+func TestTokenSetSimilarities(t *testing.T) {
+	var tests = []struct {
+		a, b                   []string
+		jaccard, dice, overlap float64
+	}{
+		{
+			a:       []string{"the", "quick", "brown", "fox", "the"},
+			b:       []string{"the", "brown", "dog"},
+			jaccard: 2.0 / 5, dice: 4.0 / 7, overlap: 2.0 / 3,
+		},
+		{
+			a:       []string{"brown", "fox"},
+			b:       []string{"the", "quick", "brown", "fox"},
+			jaccard: 2.0 / 4, dice: 4.0 / 6, overlap: 1,
+		},
+		{
+			a:       []string{"cat"},
+			b:       []string{"dog"},
+			jaccard: 0, dice: 0, overlap: 0,
+		},
+	}
+
+	for ti, test := range tests {
+		t.Logf("**** Test Run %d.\n", ti+1)
+
+		if s := TokenJaccardSimilarity(test.a, test.b); math.Abs(s-test.jaccard) > 1e-9 {
+			t.Errorf("Expected Jaccard similarity %f but found %f", test.jaccard, s)
+		}
+		if s := TokenDiceSimilarity(test.a, test.b); math.Abs(s-test.dice) > 1e-9 {
+			t.Errorf("Expected Dice similarity %f but found %f", test.dice, s)
+		}
+		if s := TokenOverlapSimilarity(test.a, test.b); math.Abs(s-test.overlap) > 1e-9 {
+			t.Errorf("Expected overlap similarity %f but found %f", test.overlap, s)
+		}
+	}
+
+	if s := TokenJaccardSimilarity(nil, nil); !math.IsNaN(s) {
+		t.Errorf("Expected NaN for empty token sets but found %f", s)
+	}
+}
+
+func TestSoftCosineSimilarity(t *testing.T) {
+	// terms: 0 car, 1 automobile, 2 red, 3 fruit
+	s := mat.NewDense(4, 4, []float64{
+		0, 0.8, 0, 0,
+		0.8, 0, 0, 0,
+		0, 0, 0, 0.1,
+		0, 0, 0.1, 0,
+	})
+	identity := mat.NewDiagDense(4, []float64{1, 1, 1, 1})
+
+	var tests = []struct {
+		a, b     mat.Vector
+		s        mat.Matrix
+		expected float64
+	}{
+		{
+			a:        mat.NewVecDense(4, []float64{1, 0, 1, 0}),
+			b:        mat.NewVecDense(4, []float64{0, 1, 1, 0}),
+			s:        s,
+			expected: 1.8 / 2,
+		},
+		{
+			a:        sparse.NewVector(4, []int{0}, []float64{1}),
+			b:        sparse.NewVector(4, []int{1}, []float64{2}),
+			s:        s,
+			expected: 0.8,
+		},
+		{
+			a:        sparse.NewVector(4, []int{0}, []float64{1}),
+			b:        sparse.NewVector(4, []int{1}, []float64{2}),
+			s:        identity,
+			expected: 0,
+		},
+		{
+			a:        mat.NewVecDense(4, []float64{1, 2, 0, 3}),
+			b:        mat.NewVecDense(4, []float64{2, 0, 1, 1}),
+			s:        identity,
+			expected: CosineSimilarity(mat.NewVecDense(4, []float64{1, 2, 0, 3}), mat.NewVecDense(4, []float64{2, 0, 1, 1})),
+		},
+		{
+			a:        mat.NewVecDense(4, nil),
+			b:        mat.NewVecDense(4, []float64{2, 0, 1, 1}),
+			s:        s,
+			expected: math.NaN(),
+		},
+	}
+
+	for ti, test := range tests {
+		t.Logf("**** Test Run %d.\n", ti+1)
+
+		sim := SoftCosineSimilarity(test.a, test.b, test.s)
+		if !(math.IsNaN(sim) && math.IsNaN(test.expected)) && math.Abs(sim-test.expected) > 1e-9 {
+			t.Errorf("Expected soft cosine similarity %f but found %f", test.expected, sim)
+		}
+		if d := SoftCosineDistance(test.s)(test.a, test.b); !math.IsNaN(sim) && math.Abs(d-(1-sim)) > 1e-9 {
+			t.Errorf("Expected soft cosine distance %f but found %f", 1-sim, d)
+		}
+		if c := SoftCosine(test.s)(test.b, test.a); !math.IsNaN(sim) && math.Abs(c-sim) > 1e-9 {
+			t.Errorf("Expected symmetric similarity %f but found %f", sim, c)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package pairwise
+
+import (
+	"math"
+	"sort"
+
+	"github.com/james-bowman/sparse"
+	"gonum.org/v1/gonum/mat"
+)
+
+// PairwiseCosine calculates the cosine similarity between every pair of rows within
+// matrix m returning an r x r dense matrix where element i, j is the similarity
+// between rows i and j.  The similarities are calculated by multiplying the L2
+// normalised rows of m with their transpose, accessing only the non-zero elements of
+// m, one row of the result at a time.  Unlike CosineSimilarity, the similarity of
+// rows containing only 0s is 0 rather than NaN.  As the result is dense, requiring
+// memory proportional to r^2, SparsePairwiseCosine should be used for large matrices.
+// As vectorisers output matrices with documents as columns by default, such matrices
+// should be transposed first e.g. using m.T().
+func PairwiseCosine(m mat.Matrix) *mat.Dense {
+	r, _ := m.Dims()
+	similarities := mat.NewDense(r, r, nil)
+	pairwiseCosineDo(m, func(i int, row []float64, nonZero []int) {
+		for _, j := range nonZero {
+			similarities.Set(i, j, row[j])
+		}
+	})
+	return similarities
+}
+
+// SparsePairwiseCosine calculates the cosine similarity between every pair of rows
+// within matrix m, as PairwiseCosine, but returns an r x r sparse CSR matrix retaining
+// only the similarities of at least threshold and, if k is greater than 0, only the k
+// highest similarities within each row.  The similarity of each row with itself is
+// omitted so that, for example, with documents as rows, row i of the result holds the
+// k most similar other documents to document i.  Rows of the result are calculated
+// one at a time so, unlike PairwiseCosine, memory usage is bounded by the size of
+// the result, proportional to r * k if k is greater than 0, rather than r^2.
+func SparsePairwiseCosine(m mat.Matrix, threshold float64, k int) *sparse.CSR {
+	r, _ := m.Dims()
+	indptr := make([]int, r+1)
+	var ind []int
+	var data []float64
+
+	var selected []int
+	pairwiseCosineDo(m, func(i int, row []float64, nonZero []int) {
+		selected = selected[:0]
+		for _, j := range nonZero {
+			if j != i && row[j] >= threshold {
+				selected = append(selected, j)
+			}
+		}
+		if k > 0 && len(selected) > k {
+			sort.Slice(selected, func(a, b int) bool {
+				if row[selected[a]] == row[selected[b]] {
+					return selected[a] < selected[b]
+				}
+				return row[selected[a]] > row[selected[b]]
+			})
+			selected = selected[:k]
+		}
+		sort.Ints(selected)
+		for _, j := range selected {
+			ind = append(ind, j)
+			data = append(data, row[j])
+		}
+		indptr[i+1] = len(ind)
+	})
+	return sparse.NewCSR(r, r, indptr, ind, data)
+}
+
+// pairwiseCosineDo calculates the cosine similarities between each row of m and every
+// other row, in row order, calling fn for each row i with the similarities of row i
+// and the indices of the rows with non-zero similarities.  Similarities are
+// accumulated in a single dense row, reused between calls to fn, by multiplying each
+// L2 normalised row of m with the transpose of the normalised matrix.
+func pairwiseCosineDo(m mat.Matrix, fn func(i int, row []float64, nonZero []int)) {
+	var csr *sparse.CSR
+	if t, isTypeConv := m.(sparse.TypeConverter); isTypeConv {
+		csr = t.ToCSR()
+	} else {
+		r, c := m.Dims()
+		dok := sparse.NewDOK(r, c)
+		for i := 0; i < r; i++ {
+			for j := 0; j < c; j++ {
+				if v := m.At(i, j); v != 0 {
+					dok.Set(i, j, v)
+				}
+			}
+		}
+		csr = dok.ToCSR()
+	}
+	r, c := csr.Dims()
+	raw := csr.RawMatrix()
+
+	// normalise each row to unit L2 norm so dot products are cosine similarities
+	data := make([]float64, len(raw.Data))
+	for i := 0; i < r; i++ {
+		var norm float64
+		for k := raw.Indptr[i]; k < raw.Indptr[i+1]; k++ {
+			norm += raw.Data[k] * raw.Data[k]
+		}
+		if norm == 0 {
+			continue
+		}
+		norm = math.Sqrt(norm)
+		for k := raw.Indptr[i]; k < raw.Indptr[i+1]; k++ {
+			data[k] = raw.Data[k] / norm
+		}
+	}
+	normalised := sparse.NewCSR(r, c, raw.Indptr, raw.Ind, data)
+
+	// the transpose, as a CSR, indexes the rows containing each column (e.g. the
+	// documents containing each term)
+	transposed := normalised.T().(sparse.TypeConverter).ToCSR().RawMatrix()
+
+	row := make([]float64, r)
+	seen := make([]bool, r)
+	var nonZero []int
+	for i := 0; i < r; i++ {
+		for _, j := range nonZero {
+			row[j] = 0
+			seen[j] = false
+		}
+		nonZero = nonZero[:0]
+
+		for k := raw.Indptr[i]; k < raw.Indptr[i+1]; k++ {
+			col, v := raw.Ind[k], data[k]
+			for t := transposed.Indptr[col]; t < transposed.Indptr[col+1]; t++ {
+				j := transposed.Ind[t]
+				if !seen[j] {
+					seen[j] = true
+					nonZero = append(nonZero, j)
+				}
+				row[j] += v * transposed.Data[t]
+			}
+		}
+		fn(i, row, nonZero)
+	}
+}
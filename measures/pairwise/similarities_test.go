@@ -0,0 +1,98 @@
+package pairwise
+
+import (
+	"math"
+	"testing"
+
+	"github.com/james-bowman/sparse"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestPairwiseCosine(t *testing.T) {
+	dense := mat.NewDense(5, 4, []float64{
+		1, 0, 2, 0,
+		0, 0, 0, 0,
+		0, 3, 0, 1,
+		2, 0, 4, 0,
+		1, 1, 1, 1,
+	})
+	dok := sparse.NewDOK(5, 4)
+	for i := 0; i < 5; i++ {
+		for j := 0; j < 4; j++ {
+			if v := dense.At(i, j); v != 0 {
+				dok.Set(i, j, v)
+			}
+		}
+	}
+
+	var tests = []struct {
+		m mat.Matrix
+	}{
+		{m: dense},
+		{m: dok.ToCSR()},
+		{m: dok.ToCSC()},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		result := PairwiseCosine(test.m)
+		for i := 0; i < 5; i++ {
+			for j := 0; j < 5; j++ {
+				expected := CosineSimilarity(dense.RowView(i), dense.RowView(j))
+				if math.IsNaN(expected) {
+					expected = 0
+				}
+				if math.Abs(expected-result.At(i, j)) > 1e-12 {
+					t.Errorf("Expected similarity of rows %d and %d to be %f but found %f", i, j, expected, result.At(i, j))
+				}
+			}
+		}
+	}
+}
+
+func TestSparsePairwiseCosine(t *testing.T) {
+	m := mat.NewDense(5, 4, []float64{
+		1, 0, 2, 0,
+		0, 0, 0, 0,
+		0, 3, 0, 1,
+		2, 0, 4, 0,
+		1, 1, 1, 1,
+	})
+	full := PairwiseCosine(m)
+
+	var tests = []struct {
+		threshold float64
+		k         int
+		expected  [][]int
+	}{
+		{threshold: 0, k: 0, expected: [][]int{{3, 4}, {}, {4}, {0, 4}, {0, 2, 3}}},
+		{threshold: 0.7, k: 0, expected: [][]int{{3}, {}, {}, {0}, {}}},
+		{threshold: 0, k: 1, expected: [][]int{{3}, {}, {4}, {0}, {0}}},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		result := SparsePairwiseCosine(m, test.threshold, test.k)
+		for i, expected := range test.expected {
+			var found []int
+			result.DoRowNonZero(i, func(i, j int, v float64) {
+				found = append(found, j)
+				if math.Abs(v-full.At(i, j)) > 1e-12 {
+					t.Errorf("Expected similarity of rows %d and %d to be %f but found %f", i, j, full.At(i, j), v)
+				}
+			})
+			if len(found) != len(expected) {
+				t.Errorf("Expected row %d to contain %v but found %v", i, expected, found)
+				continue
+			}
+			for n := range found {
+				if found[n] != expected[n] {
+					t.Errorf("Expected row %d to contain %v but found %v", i, expected, found)
+					break
+				}
+			}
+		}
+	}
+}
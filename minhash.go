@@ -0,0 +1,383 @@
+package nlp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
+	"strings"
+
+	"github.com/spaolacci/murmur3"
+	"golang.org/x/exp/rand"
+)
+
+// mersennePrime is the prime (2^61 - 1) used as the modulus for the universal hash
+// functions simulating random permutations for MinHash.
+const mersennePrime = (1 << 61) - 1
+
+// MinHash generates MinHash signatures for documents from the set of their token
+// shingles (contiguous sequences of ShingleSize tokens) as described by Andrei Broder.
+// The probability that 2 signatures agree at any position is equal to the Jaccard
+// similarity of the documents' shingle sets so the proportion of positions at which
+// signatures agree (see MinHashSimilarity()) is an unbiased estimate of their Jaccard
+// similarity.  Signatures may be indexed using MinHashLSH to efficiently find
+// near-duplicate documents.  Signatures are only comparable if generated using the
+// same NumHashes and Seed.
+//
+// Broder, Andrei Z. "On the resemblance and containment of documents" in Proceedings.
+// Compression and Complexity of SEQUENCES 1997, p. 21.
+// https://www.cs.princeton.edu/courses/archive/spring13/cos598C/broder97resemblance.pdf
+type MinHash struct {
+	// NumHashes is the number of hash functions and hence the length of the signatures
+	NumHashes int
+
+	// ShingleSize is the number of contiguous tokens within each shingle.  Documents
+	// containing fewer tokens than ShingleSize are represented by a single shingle
+	// containing all their tokens.
+	ShingleSize int
+
+	// Seed is the seed used to generate the hash functions
+	Seed uint64
+
+	// Tokeniser is used to tokenise input text into tokens
+	Tokeniser Tokeniser
+
+	a, b []uint64
+	seed uint64
+}
+
+// NewMinHash creates a new MinHash generating signatures of numHashes length from
+// shingles of shingleSize tokens with a default Seed of 1.  If stopWords is not an
+// empty slice then stop words will be removed.
+func NewMinHash(numHashes, shingleSize int, stopWords ...string) *MinHash {
+	return &MinHash{
+		NumHashes:   numHashes,
+		ShingleSize: shingleSize,
+		Seed:        1,
+		Tokeniser:   NewTokeniser(stopWords...),
+	}
+}
+
+// Signatures returns the MinHash signature of each of the specified documents.
+func (m *MinHash) Signatures(docs ...string) [][]uint64 {
+	sigs := make([][]uint64, len(docs))
+	for i, doc := range docs {
+		sigs[i] = m.Signature(doc)
+	}
+	return sigs
+}
+
+// Signature returns the MinHash signature of the specified document.  The signature of
+// a document containing no tokens has every position set to math.MaxUint64.
+func (m *MinHash) Signature(doc string) []uint64 {
+	return m.signature(m.shingles(doc))
+}
+
+// signature returns the MinHash signature of the set of shingle hashes.
+func (m *MinHash) signature(shingles []uint64) []uint64 {
+	m.initHashFunctions()
+
+	sig := make([]uint64, m.NumHashes)
+	for i := range sig {
+		sig[i] = math.MaxUint64
+	}
+	for _, shingle := range shingles {
+		for i := range sig {
+			if h := permute(m.a[i], m.b[i], shingle); h < sig[i] {
+				sig[i] = h
+			}
+		}
+	}
+	return sig
+}
+
+// initHashFunctions generates the coefficients of the NumHashes universal hash
+// functions used to simulate random permutations of the shingles if they have not
+// already been generated for the current NumHashes and Seed.
+func (m *MinHash) initHashFunctions() {
+	if len(m.a) == m.NumHashes && m.seed == m.Seed {
+		return
+	}
+	rnd := rand.New(rand.NewSource(m.Seed))
+	m.a = make([]uint64, m.NumHashes)
+	m.b = make([]uint64, m.NumHashes)
+	for i := range m.a {
+		m.a[i] = 1 + rnd.Uint64()%(mersennePrime-1)
+		m.b[i] = rnd.Uint64() % mersennePrime
+	}
+	m.seed = m.Seed
+}
+
+// shingles returns the 64 bit hashes of each of the token shingles within doc.
+func (m *MinHash) shingles(doc string) []uint64 {
+	var tokens []string
+	tokenise(m.Tokeniser, doc)(func(token string) {
+		tokens = append(tokens, token)
+	})
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	n := len(tokens) - m.ShingleSize + 1
+	if n < 1 {
+		n = 1
+	}
+	hashes := make([]uint64, n)
+	for i := range hashes {
+		end := i + m.ShingleSize
+		if end > len(tokens) {
+			end = len(tokens)
+		}
+		hashes[i] = murmur3.Sum64([]byte(strings.Join(tokens[i:end], " ")))
+	}
+	return hashes
+}
+
+// permute applies the universal hash function (a*x + b) mod (2^61 - 1) to x.
+func permute(a, b, x uint64) uint64 {
+	return (mulMod(a, x%mersennePrime) + b) % mersennePrime
+}
+
+// mulMod returns (a * b) mod (2^61 - 1) for a and b less than 2^61 - 1 without
+// overflowing.
+func mulMod(a, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	// split the 122 bit product into the low 61 bits and the remaining high bits
+	r := (lo & mersennePrime) + (lo >> 61) + (hi << 3)
+	for r >= mersennePrime {
+		r -= mersennePrime
+	}
+	return r
+}
+
+// MinHashSimilarity returns the estimated Jaccard similarity of the documents
+// represented by the MinHash signatures a and b i.e. the proportion of positions at
+// which the signatures agree.  The method panics if the signatures are of different
+// lengths.
+func MinHashSimilarity(a, b []uint64) float64 {
+	if len(a) != len(b) {
+		panic("nlp: Signatures must be the same length")
+	}
+	if len(a) == 0 {
+		return 0
+	}
+	var matches int
+	for i, v := range a {
+		if v == b[i] {
+			matches++
+		}
+	}
+	return float64(matches) / float64(len(a))
+}
+
+// CandidatePair is a pair of near-duplicate items found by MinHashLSH along with their
+// estimated Jaccard similarity.
+type CandidatePair struct {
+	A, B       interface{}
+	Similarity float64
+}
+
+// MinHashLSH is a Locality Sensitive Hashing index of MinHash signatures using the
+// banding technique for efficiently finding near-duplicate items.  Signatures are
+// divided into b bands of r rows and each band is hashed into a separate hash table
+// so that items sharing an identical band with another item are candidate
+// near-duplicates.  The probability of 2 items with Jaccard similarity s becoming
+// candidates is 1 - (1 - s^r)^b, an S-curve with a threshold of approximately
+// (1/b)^(1/r).  Candidates are then filtered to those whose estimated Jaccard
+// similarity is at least Threshold.
+//
+// Leskovec, Jure, Rajaraman, Anand and Ullman, Jeffrey D. "Mining of Massive
+// Datasets", chapter 3. Cambridge University Press, 2014.
+// http://infolab.stanford.edu/~ullman/mmds/ch3.pdf
+type MinHashLSH struct {
+	// Threshold is the minimum estimated Jaccard similarity of items returned by
+	// Query() and CandidatePairs()
+	Threshold float64
+
+	bands, rows int
+	tables      []lshTable
+	signatures  map[interface{}][]uint64
+	ids         []interface{}
+}
+
+// NewMinHashLSH creates a new MinHashLSH index for MinHash signatures of numHashes
+// length returning near-duplicates with an estimated Jaccard similarity of at least
+// threshold.  The number of bands and rows are chosen to minimise the (equally
+// weighted) probabilities of false positives and false negatives at the threshold.
+func NewMinHashLSH(numHashes int, threshold float64) *MinHashLSH {
+	bands, rows := optimalBands(numHashes, threshold)
+	lsh := NewMinHashLSHBands(bands, rows)
+	lsh.Threshold = threshold
+	return lsh
+}
+
+// NewMinHashLSHBands creates a new MinHashLSH index with the specified number of
+// bands and rows per band.  Signatures must be at least bands * rows in length.  The
+// Threshold is 0 so all candidates sharing a band are returned unless it is set.
+func NewMinHashLSHBands(bands, rows int) *MinHashLSH {
+	tables := make([]lshTable, bands)
+	for i := range tables {
+		tables[i] = make(lshTable)
+	}
+	return &MinHashLSH{
+		bands:      bands,
+		rows:       rows,
+		tables:     tables,
+		signatures: make(map[interface{}][]uint64),
+	}
+}
+
+// Bands returns the number of bands and rows per band the signatures are divided into.
+func (l *MinHashLSH) Bands() (bands, rows int) {
+	return l.bands, l.rows
+}
+
+// Put stores the specified MinHash signature and associated ID in the index.  If the
+// ID is already present within the index, it is replaced.  The method panics if the
+// signature is shorter than bands * rows.
+func (l *MinHashLSH) Put(id interface{}, signature []uint64) {
+	if _, exists := l.signatures[id]; exists {
+		l.Remove(id)
+	}
+	for i, key := range l.bandKeys(signature) {
+		l.tables[i][key] = append(l.tables[i][key], id)
+	}
+	l.signatures[id] = signature
+	l.ids = append(l.ids, id)
+}
+
+// Remove removes the specified item from the index.
+func (l *MinHashLSH) Remove(id interface{}) {
+	signature, exists := l.signatures[id]
+	if !exists {
+		return
+	}
+	for i, key := range l.bandKeys(signature) {
+		bucket := l.tables[i][key]
+		for j, indexedID := range bucket {
+			if indexedID == id {
+				bucket = append(bucket[:j], bucket[j+1:]...)
+				break
+			}
+		}
+		if len(bucket) == 0 {
+			delete(l.tables[i], key)
+		} else {
+			l.tables[i][key] = bucket
+		}
+	}
+	delete(l.signatures, id)
+	for i, indexedID := range l.ids {
+		if indexedID == id {
+			l.ids = append(l.ids[:i], l.ids[i+1:]...)
+			break
+		}
+	}
+}
+
+// Query returns the IDs of items within the index that are near-duplicates of the
+// item with the specified signature i.e. that share at least one band with the
+// signature and have an estimated Jaccard similarity of at least Threshold.  The
+// method panics if the signature is shorter than bands * rows.
+func (l *MinHashLSH) Query(signature []uint64) []interface{} {
+	var ids []interface{}
+	for _, id := range l.candidates(signature) {
+		if MinHashSimilarity(signature, l.signatures[id]) >= l.Threshold {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// CandidatePairs returns all pairs of items within the index that are near-duplicates
+// of each other i.e. that share at least one band and have an estimated Jaccard
+// similarity of at least Threshold.  Each pair is returned once, with A the item put
+// into the index first.
+func (l *MinHashLSH) CandidatePairs() []CandidatePair {
+	position := make(map[interface{}]int, len(l.ids))
+	for i, id := range l.ids {
+		position[id] = i
+	}
+
+	var pairs []CandidatePair
+	for i, id := range l.ids {
+		signature := l.signatures[id]
+		for _, other := range l.candidates(signature) {
+			if position[other] <= i {
+				continue
+			}
+			if s := MinHashSimilarity(signature, l.signatures[other]); s >= l.Threshold {
+				pairs = append(pairs, CandidatePair{A: id, B: other, Similarity: s})
+			}
+		}
+	}
+	return pairs
+}
+
+// candidates returns the IDs of items sharing at least one band with signature in
+// the order they were put into the index.
+func (l *MinHashLSH) candidates(signature []uint64) []interface{} {
+	seen := make(map[interface{}]struct{})
+	for i, key := range l.bandKeys(signature) {
+		for _, id := range l.tables[i][key] {
+			seen[id] = struct{}{}
+		}
+	}
+
+	ids := make([]interface{}, 0, len(seen))
+	for _, id := range l.ids {
+		if _, ok := seen[id]; ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// bandKeys returns the hash of each band of the signature.  The method panics if the
+// signature is shorter than bands * rows.
+func (l *MinHashLSH) bandKeys(signature []uint64) []uint64 {
+	if len(signature) < l.bands*l.rows {
+		panic(fmt.Sprintf("nlp: Specified signature is not the correct length.  Needed %d but received %d", l.bands*l.rows, len(signature)))
+	}
+	keys := make([]uint64, l.bands)
+	buf := make([]byte, 8*l.rows)
+	for i := range keys {
+		for r, v := range signature[i*l.rows : (i+1)*l.rows] {
+			binary.LittleEndian.PutUint64(buf[r*8:], v)
+		}
+		keys[i] = murmur3.Sum64(buf)
+	}
+	return keys
+}
+
+// optimalBands returns the number of bands and rows per band, using at most numHashes
+// hashes, that minimise the sum of the probabilities of false positives (items with
+// Jaccard similarity below threshold becoming candidates) and false negatives (items
+// with Jaccard similarity above threshold not becoming candidates).
+func optimalBands(numHashes int, threshold float64) (bands, rows int) {
+	candidate := func(s float64, b, r int) float64 {
+		return 1 - math.Pow(1-math.Pow(s, float64(r)), float64(b))
+	}
+	integrate := func(fn func(float64) float64, a, b float64) float64 {
+		const steps = 100
+		h := (b - a) / steps
+		sum := (fn(a) + fn(b)) / 2
+		for i := 1; i < steps; i++ {
+			sum += fn(a + float64(i)*h)
+		}
+		return sum * h
+	}
+
+	bands, rows = 1, numHashes
+	minError := math.Inf(1)
+	for b := 1; b <= numHashes; b++ {
+		for r := 1; b*r <= numHashes; r++ {
+			falsePositive := integrate(func(s float64) float64 { return candidate(s, b, r) }, 0, threshold)
+			falseNegative := integrate(func(s float64) float64 { return 1 - candidate(s, b, r) }, threshold, 1)
+			if err := falsePositive + falseNegative; err < minError {
+				minError, bands, rows = err, b, r
+			}
+		}
+	}
+	return bands, rows
+}
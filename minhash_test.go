@@ -0,0 +1,119 @@
+package nlp
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestMinHashSimilarity(t *testing.T) {
+	var tests = []struct {
+		a, b    string
+		jaccard float64
+	}{
+		// identical
+		{
+			a:       "the quick brown fox jumped over the lazy dog",
+			b:       "the quick brown fox jumped over the lazy dog",
+			jaccard: 1,
+		},
+		// 8 and 7 unique bigrams sharing 5
+		{
+			a:       "the quick brown fox jumped over the lazy dog",
+			b:       "the quick brown fox jumped over a dog",
+			jaccard: 5.0 / 10.0,
+		},
+		// no shared bigrams
+		{
+			a:       "the quick brown fox jumped over the lazy dog",
+			b:       "stock markets fell sharply on inflation fears",
+			jaccard: 0,
+		},
+	}
+
+	minhash := NewMinHash(256, 2)
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		sigs := minhash.Signatures(test.a, test.b)
+		if len(sigs[0]) != 256 {
+			t.Errorf("Expected signature of length 256 but found %d", len(sigs[0]))
+		}
+		estimate := MinHashSimilarity(sigs[0], sigs[1])
+		if math.Abs(estimate-test.jaccard) > 0.1 {
+			t.Errorf("Expected estimated Jaccard similarity of %f but found %f", test.jaccard, estimate)
+		}
+	}
+
+	// signatures should be reproducible across MinHash instances with the same seed
+	other := NewMinHash(256, 2)
+	if !reflect.DeepEqual(minhash.Signature(tests[0].a), other.Signature(tests[0].a)) {
+		t.Errorf("Expected signatures generated with the same seed to match")
+	}
+}
+
+func TestMinHashLSH(t *testing.T) {
+	docs := []string{
+		"the quick brown fox jumped over the lazy dog and ran into the woods to find a place to sleep",
+		"stock markets fell sharply today as investors worried about rising interest rates and inflation",
+		"the quick brown fox jumped over the lazy dog and ran into the forest to find a place to sleep",
+		"the home team won the match in the final minute with a spectacular goal from the halfway line",
+		"stock markets fell sharply today as investors worried about rising interest rates and unemployment",
+		"the quick brown fox jumped over the lazy dog and ran into the woods to find a place to sleep",
+	}
+
+	var tests = []struct {
+		threshold float64
+		expected  [][2]int
+	}{
+		{threshold: 0.5, expected: [][2]int{{0, 2}, {0, 5}, {1, 4}, {2, 5}}},
+		{threshold: 0.95, expected: [][2]int{{0, 5}}},
+	}
+
+	minhash := NewMinHash(128, 3)
+	sigs := minhash.Signatures(docs...)
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		lsh := NewMinHashLSH(128, test.threshold)
+		if b, r := lsh.Bands(); b*r > 128 {
+			t.Errorf("Expected at most 128 hashes to be used but found %d bands of %d rows", b, r)
+		}
+		for i, sig := range sigs {
+			lsh.Put(i, sig)
+		}
+
+		var pairs [][2]int
+		for _, pair := range lsh.CandidatePairs() {
+			if pair.Similarity < test.threshold {
+				t.Errorf("Expected similarity of pair %v-%v to be at least %f but found %f", pair.A, pair.B, test.threshold, pair.Similarity)
+			}
+			pairs = append(pairs, [2]int{pair.A.(int), pair.B.(int)})
+		}
+		if !reflect.DeepEqual(test.expected, pairs) {
+			t.Errorf("Expected candidate pairs %v but found %v", test.expected, pairs)
+		}
+
+		for _, pair := range test.expected {
+			found := false
+			for _, id := range lsh.Query(sigs[pair[0]]) {
+				if id == pair[1] {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("Expected query for document %d to return document %d", pair[0], pair[1])
+			}
+		}
+
+		// removed items should no longer be returned
+		lsh.Remove(5)
+		for _, pair := range lsh.CandidatePairs() {
+			if pair.A == 5 || pair.B == 5 {
+				t.Errorf("Expected removed document 5 not to be returned but found pair %v-%v", pair.A, pair.B)
+			}
+		}
+	}
+}
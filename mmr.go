@@ -0,0 +1,89 @@
+package nlp
+
+import (
+	"math"
+
+	"github.com/james-bowman/nlp/measures/pairwise"
+	"gonum.org/v1/gonum/mat"
+)
+
+// MMR reranks search results using Maximal Marginal Relevance as described by
+// Carbonell and Goldstein in "The use of MMR, diversity-based reranking for reordering
+// documents and producing summaries".  Results are selected greedily, each time
+// choosing the result maximising:
+//
+//	Lambda * relevance - (1 - Lambda) * max(similarity to already selected results)
+//
+// so that results near-duplicating those already selected are demoted in favour of
+// relevant results covering different content.  This is useful for diversifying
+// search results and, with sentences as results scored by their similarity to the
+// whole document, for extractive summarisation avoiding redundant sentences.
+type MMR struct {
+	// Lambda controls the trade-off between relevance and diversity from 0 (maximum
+	// diversity) to 1 (ranking by relevance alone)
+	Lambda float64
+
+	// Similarity is the measure of similarity between the vectors of results.  NaN
+	// similarities (e.g. cosine similarity with vectors containing only 0s) are
+	// treated as 0.
+	Similarity pairwise.Comparer
+}
+
+// NewMMR creates a new MMR reranker with the specified Lambda measuring the similarity
+// between results using cosine similarity.
+func NewMMR(lambda float64) *MMR {
+	return &MMR{Lambda: lambda, Similarity: pairwise.CosineSimilarity}
+}
+
+// Rerank reorders search results, with the relevance scores and vectors of each
+// result supplied in the same order in scores and vectors, returning the indices of
+// the first k results (or all results if k is less than 1 or greater than the number
+// of results) in reranked order.  The scores are typically those returned by the
+// Search() method of an index e.g. similarities to the query.  The method will panic
+// if scores and vectors are of different lengths.
+func (m *MMR) Rerank(scores []float64, vectors []mat.Vector, k int) []int {
+	if len(scores) != len(vectors) {
+		panic("nlp: The number of scores and vectors must be equal")
+	}
+	if k < 1 || k > len(scores) {
+		k = len(scores)
+	}
+
+	// maxSim holds the maximum similarity of each result to the selected results
+	maxSim := make([]float64, len(scores))
+	selected := make([]bool, len(scores))
+	order := make([]int, 0, k)
+
+	for len(order) < k {
+		best := -1
+		var bestScore float64
+		for i, relevance := range scores {
+			if selected[i] {
+				continue
+			}
+			score := m.Lambda * relevance
+			if len(order) > 0 {
+				score -= (1 - m.Lambda) * maxSim[i]
+			}
+			if best < 0 || score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+
+		selected[best] = true
+		order = append(order, best)
+		for i := range scores {
+			if selected[i] {
+				continue
+			}
+			sim := m.Similarity(vectors[i], vectors[best])
+			if math.IsNaN(sim) {
+				sim = 0
+			}
+			if len(order) == 1 || sim > maxSim[i] {
+				maxSim[i] = sim
+			}
+		}
+	}
+	return order
+}
@@ -0,0 +1,46 @@
+package nlp
+
+import (
+	"reflect"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestMMRRerank(t *testing.T) {
+	vectors := []mat.Vector{
+		mat.NewVecDense(2, []float64{1, 0}),
+		mat.NewVecDense(2, []float64{1, 0.01}),
+		mat.NewVecDense(2, []float64{0, 1}),
+		mat.NewVecDense(2, []float64{0.7, 0.7}),
+		mat.NewVecDense(2, nil),
+	}
+	scores := []float64{0.9, 0.89, 0.5, 0.6, 0.1}
+
+	tests := []struct {
+		lambda   float64
+		k        int
+		expected []int
+	}{
+		{lambda: 1, k: 0, expected: []int{0, 1, 3, 2, 4}},
+		{lambda: 0.5, k: 0, expected: []int{0, 2, 4, 3, 1}},
+		{lambda: 0.5, k: 2, expected: []int{0, 2}},
+		{lambda: 0.7, k: 3, expected: []int{0, 2, 1}},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		order := NewMMR(test.lambda).Rerank(scores, vectors, test.k)
+		if !reflect.DeepEqual(order, test.expected) {
+			t.Errorf("Expected %v but found %v", test.expected, order)
+		}
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected panic for mismatched scores and vectors")
+		}
+	}()
+	NewMMR(0.5).Rerank(scores[:2], vectors, 0)
+}
@@ -0,0 +1,260 @@
+package nlp
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"golang.org/x/exp/rand"
+)
+
+// Params is a set of hyperparameter values keyed by name e.g. {"k": 100, "k1": 1.2}.
+type Params map[string]interface{}
+
+// ParamGrid maps the names of hyperparameters to the candidate values to search over.
+// The configurations searched are the Cartesian product of the candidate values.
+type ParamGrid map[string][]interface{}
+
+// ScoreFunc scores a model, fitted to the training documents of a cross-validation
+// fold, against the held out validation documents of the fold.  indices contains the
+// index of each of the validation documents within the documents supplied to
+// GridSearch.Fit() e.g. so that the labels of the documents may be looked up for
+// supervised scoring.  Higher scores are better so measures such as perplexity or
+// reconstruction error should be negated.
+type ScoreFunc func(model Vectoriser, docs []string, indices []int) (float64, error)
+
+// SearchResult is the result of evaluating a single hyperparameter configuration.
+type SearchResult struct {
+	// Params are the hyperparameter values evaluated
+	Params Params
+
+	// Scores holds the score of each cross-validation fold
+	Scores []float64
+
+	// Mean and StdDev are the mean and standard deviation of Scores
+	Mean, StdDev float64
+}
+
+// GridSearch selects the best hyperparameters for a model (typically a Pipeline) by
+// evaluating candidate configurations using k-fold cross-validation.  For each
+// configuration, a new model is constructed using Build, fitted to the training
+// documents of each fold and then scored against the held out documents of the fold
+// using Score.  The configuration with the highest mean score is selected e.g. to
+// choose the number of dimensions for LSA and the BM25 parameters:
+//
+//	search := NewGridSearch(
+//		func(p Params) Vectoriser {
+//			bm25 := NewBM25Transformer()
+//			bm25.K1, bm25.B = p["k1"].(float64), p["b"].(float64)
+//			return NewPipeline(NewCountVectoriser(), bm25, NewTruncatedSVD(p["k"].(int)))
+//		},
+//		ParamGrid{
+//			"k":  {50, 100, 200},
+//			"k1": {1.2, 1.5, 2.0},
+//			"b":  {0.5, 0.75},
+//		},
+//		score,
+//	)
+//	best, err := search.Fit(corpus...)
+//
+// By default every configuration within the Grid is evaluated (grid search).  If
+// Samples is greater than 0, only Samples configurations, sampled at random from the
+// Grid without replacement, are evaluated (random search) which is often as effective
+// for a fraction of the cost when there are many hyperparameters.
+type GridSearch struct {
+	// Build constructs a new, unfitted, model configured with the supplied
+	// hyperparameters.  Build is called for every fold of every configuration so must
+	// return a new instance each time.
+	Build func(params Params) Vectoriser
+
+	// Grid holds the candidate values of each hyperparameter
+	Grid ParamGrid
+
+	// Score scores fitted models against held out documents
+	Score ScoreFunc
+
+	// Folds is the number of cross-validation folds.  Each configuration is fitted
+	// Folds times, each time holding out a different fold of the documents for
+	// scoring.
+	Folds int
+
+	// Samples, if greater than 0, is the number of configurations sampled at random
+	// from the Grid to evaluate rather than evaluating all of them.
+	Samples int
+
+	// Shuffle, if true, randomly assigns documents to folds rather than assigning
+	// contiguous runs of documents to each fold.
+	Shuffle bool
+
+	// Processes is the number of configurations to evaluate concurrently.  Values
+	// less than 2 evaluate configurations sequentially.  If greater than 1, Build and
+	// Score must be safe for concurrent use.
+	Processes int
+
+	// Rnd is the random number generator used to sample configurations and shuffle
+	// documents
+	Rnd *rand.Rand
+
+	// Results holds the result of each configuration evaluated during Fit() in the
+	// order evaluated
+	Results []SearchResult
+
+	// Best is the model constructed with the best configuration found during Fit()
+	// and fitted to all of the documents
+	Best Vectoriser
+}
+
+// NewGridSearch creates a new GridSearch evaluating every configuration of the
+// hyperparameters within grid using 3 fold cross-validation.
+func NewGridSearch(build func(params Params) Vectoriser, grid ParamGrid, score ScoreFunc) *GridSearch {
+	return &GridSearch{
+		Build: build,
+		Grid:  grid,
+		Score: score,
+		Folds: 3,
+		Rnd:   rand.New(rand.NewSource(uint64(time.Now().UnixNano()))),
+	}
+}
+
+// NewRandomSearch creates a new GridSearch evaluating samples configurations sampled
+// at random from grid using 3 fold cross-validation.
+func NewRandomSearch(build func(params Params) Vectoriser, grid ParamGrid, score ScoreFunc, samples int) *GridSearch {
+	search := NewGridSearch(build, grid, score)
+	search.Samples = samples
+	return search
+}
+
+// Fit evaluates the candidate configurations against the supplied documents using
+// cross-validation, recording the result of each in Results, and returns the result
+// of the configuration with the highest mean score.  The model built with the best
+// configuration is then fitted to all of the documents and stored in Best.  An error
+// is returned if there are fewer documents than Folds or if fitting or scoring any
+// of the models fails.
+func (g *GridSearch) Fit(docs ...string) (SearchResult, error) {
+	if g.Folds < 2 || len(docs) < g.Folds {
+		return SearchResult{}, fmt.Errorf("nlp: Cross-validation requires at least 2 folds and at least as many documents as folds but found %d folds and %d documents", g.Folds, len(docs))
+	}
+	configs := g.configs()
+	if len(configs) == 0 {
+		return SearchResult{}, fmt.Errorf("nlp: No hyperparameter configurations to search")
+	}
+	folds := kFolds(len(docs), g.Folds, g.Shuffle, g.Rnd)
+
+	results := make([]SearchResult, len(configs))
+	errs := make([]error, len(configs))
+	parallelChunks(len(configs), numChunks(len(configs), g.Processes), func(chunk, start, end int) {
+		for i := start; i < end; i++ {
+			results[i], errs[i] = g.evaluate(configs[i], docs, folds)
+		}
+	})
+	for _, err := range errs {
+		if err != nil {
+			return SearchResult{}, err
+		}
+	}
+	g.Results = results
+
+	var best int
+	for i, result := range results {
+		if result.Mean > results[best].Mean {
+			best = i
+		}
+	}
+	g.Best = g.Build(results[best].Params)
+	if _, err := g.Best.FitTransform(docs...); err != nil {
+		return SearchResult{}, fmt.Errorf("nlp: Failed to fit best configuration %v because %w", results[best].Params, err)
+	}
+	return results[best], nil
+}
+
+// evaluate returns the cross-validation scores of the configuration params over the
+// documents divided into folds.
+func (g *GridSearch) evaluate(params Params, docs []string, folds [][]int) (SearchResult, error) {
+	result := SearchResult{Params: params, Scores: make([]float64, len(folds))}
+	for f, fold := range folds {
+		var train []string
+		for o, other := range folds {
+			if o == f {
+				continue
+			}
+			for _, d := range other {
+				train = append(train, docs[d])
+			}
+		}
+		validation := make([]string, len(fold))
+		for i, d := range fold {
+			validation[i] = docs[d]
+		}
+
+		model := g.Build(params)
+		if _, err := model.FitTransform(train...); err != nil {
+			return result, fmt.Errorf("nlp: Failed to fit configuration %v because %w", params, err)
+		}
+		score, err := g.Score(model, validation, fold)
+		if err != nil {
+			return result, fmt.Errorf("nlp: Failed to score configuration %v because %w", params, err)
+		}
+		result.Scores[f] = score
+		result.Mean += score
+	}
+	result.Mean /= float64(len(folds))
+	for _, score := range result.Scores {
+		result.StdDev += (score - result.Mean) * (score - result.Mean)
+	}
+	result.StdDev = math.Sqrt(result.StdDev / float64(len(folds)))
+
+	return result, nil
+}
+
+// configs returns the configurations of hyperparameters to evaluate, either every
+// configuration in the Grid or, if Samples is greater than 0, a random sample of
+// them.  Configurations are enumerated in a deterministic order with the values of
+// hyperparameters, ordered lexicographically by name, varying fastest for the last.
+func (g *GridSearch) configs() []Params {
+	names := make([]string, 0, len(g.Grid))
+	total := 1
+	for name, values := range g.Grid {
+		names = append(names, name)
+		total *= len(values)
+	}
+	sort.Strings(names)
+
+	indices := make([]int, total)
+	for i := range indices {
+		indices[i] = i
+	}
+	if g.Samples > 0 && g.Samples < total {
+		g.Rnd.Shuffle(total, func(i, j int) { indices[i], indices[j] = indices[j], indices[i] })
+		indices = indices[:g.Samples]
+	}
+
+	configs := make([]Params, len(indices))
+	for c, index := range indices {
+		configs[c] = make(Params, len(names))
+		for n := len(names) - 1; n >= 0; n-- {
+			values := g.Grid[names[n]]
+			configs[c][names[n]] = values[index%len(values)]
+			index /= len(values)
+		}
+	}
+	return configs
+}
+
+// kFolds divides the indices [0, n) into k folds of approximately equal size.  If
+// shuffle is true, indices are randomly assigned to folds using rnd otherwise each
+// fold contains a contiguous run of indices.
+func kFolds(n, k int, shuffle bool, rnd *rand.Rand) [][]int {
+	indices := make([]int, n)
+	for i := range indices {
+		indices[i] = i
+	}
+	if shuffle {
+		rnd.Shuffle(n, func(i, j int) { indices[i], indices[j] = indices[j], indices[i] })
+	}
+	folds := make([][]int, k)
+	for f := range folds {
+		folds[f] = indices[f*n/k : (f+1)*n/k]
+	}
+	return folds
+}
@@ -0,0 +1,132 @@
+package nlp
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"golang.org/x/exp/rand"
+)
+
+func TestGridSearch(t *testing.T) {
+	build := func(p Params) Vectoriser {
+		vectoriser := NewCountVectoriser()
+		vectoriser.MaxDF = p["maxdf"].(float64)
+		return NewPipeline(vectoriser, NewTruncatedSVD(p["k"].(int)))
+	}
+	grid := ParamGrid{
+		"k":     {1, 2, 3},
+		"maxdf": {0.0, 0.5},
+	}
+
+	var tests = []struct {
+		samples   int
+		processes int
+		shuffle   bool
+		results   int
+	}{
+		{samples: 0, processes: 1, results: 6},
+		{samples: 0, processes: 4, shuffle: true, results: 6},
+		{samples: 3, processes: 1, results: 3},
+		{samples: 10, processes: 2, results: 6},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		var lock sync.Mutex
+		scored := make(map[string][]int)
+		score := func(model Vectoriser, docs []string, indices []int) (float64, error) {
+			if len(docs) != len(indices) {
+				return 0, fmt.Errorf("expected %d docs but found %d", len(indices), len(docs))
+			}
+			for i, d := range indices {
+				if trainSet[d] != docs[i] {
+					return 0, fmt.Errorf("expected doc %d to be '%s' but found '%s'", d, trainSet[d], docs[i])
+				}
+			}
+			p := model.(*Pipeline)
+			lock.Lock()
+			key := fmt.Sprintf("%d-%g", p.Transformers[0].(*TruncatedSVD).K, p.Vectoriser.(*CountVectoriser).MaxDF)
+			scored[key] = append(scored[key], indices...)
+			lock.Unlock()
+
+			// prefer k = 2 and no maximum document frequency
+			k := float64(p.Transformers[0].(*TruncatedSVD).K)
+			return -(k-2)*(k-2) - p.Vectoriser.(*CountVectoriser).MaxDF, nil
+		}
+
+		search := NewRandomSearch(build, grid, score, test.samples)
+		search.Processes = test.processes
+		search.Shuffle = test.shuffle
+		search.Rnd = rand.New(rand.NewSource(uint64(testRun)))
+
+		best, err := search.Fit(trainSet...)
+		if err != nil {
+			t.Errorf("Failed to search because %v", err)
+			continue
+		}
+
+		if len(search.Results) != test.results || len(scored) != test.results {
+			t.Errorf("Expected %d configurations to be evaluated but found %d (%d scored)", test.results, len(search.Results), len(scored))
+		}
+		for key, indices := range scored {
+			seen := make(map[int]bool)
+			for _, d := range indices {
+				seen[d] = true
+			}
+			if len(indices) != len(trainSet) || len(seen) != len(trainSet) {
+				t.Errorf("Expected every document to be validated exactly once for %s but found %v", key, indices)
+			}
+		}
+		for _, result := range search.Results {
+			if len(result.Scores) != search.Folds {
+				t.Errorf("Expected %d fold scores but found %d", search.Folds, len(result.Scores))
+			}
+			if result.Mean > best.Mean {
+				t.Errorf("Expected best mean score %f to be highest but found %f for %v", best.Mean, result.Mean, result.Params)
+			}
+		}
+		if test.results == 6 && (best.Params["k"] != 2 || best.Params["maxdf"] != 0.0) {
+			t.Errorf("Expected best configuration k=2, maxdf=0 but found %v", best.Params)
+		}
+
+		svd := search.Best.(*Pipeline).Transformers[0].(*TruncatedSVD)
+		if svd.Components == nil || svd.K != best.Params["k"] {
+			t.Errorf("Expected best model to be fitted with k=%v", best.Params["k"])
+		}
+	}
+}
+
+func TestGridSearchErrors(t *testing.T) {
+	build := func(p Params) Vectoriser {
+		return NewCountVectoriser()
+	}
+	failure := fmt.Errorf("failed")
+
+	var tests = []struct {
+		grid  ParamGrid
+		folds int
+		score ScoreFunc
+	}{
+		{grid: ParamGrid{"a": {1}}, folds: len(trainSet) + 1},
+		{grid: ParamGrid{"a": {}}, folds: 3},
+		{
+			grid:  ParamGrid{"a": {1, 2}},
+			folds: 3,
+			score: func(model Vectoriser, docs []string, indices []int) (float64, error) {
+				return 0, failure
+			},
+		},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		search := NewGridSearch(build, test.grid, test.score)
+		search.Folds = test.folds
+		if _, err := search.Fit(trainSet...); err == nil {
+			t.Errorf("Expected error but found none")
+		}
+	}
+}
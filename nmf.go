@@ -0,0 +1,289 @@
+package nlp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/james-bowman/sparse"
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+)
+
+// nmfEpsilon is added to the denominators of the multiplicative updates to prevent
+// division by zero.
+const nmfEpsilon = 1e-10
+
+// NMF (Non-negative Matrix Factorisation) factorises a non-negative term document
+// matrix V into the product of two non-negative matrices W and H (V ≈ WH) of rank K.
+// W (terms x K) represents the topics as weighted combinations of terms and H (K x
+// documents) represents the documents as weighted combinations of topics.  As all
+// values are non-negative, topics can only be additively combined and so, unlike
+// TruncatedSVD, NMF tends to produce sparse, interpretable, topics.
+//
+// This transformer uses the multiplicative update rules of Lee and Seung
+// (https://papers.nips.cc/paper/1861-algorithms-for-non-negative-matrix-factorization.pdf)
+// minimising the Frobenius norm of the difference between V and WH.  Only the non-zero
+// elements of V are accessed so sparse matrices are never converted to dense.
+type NMF struct {
+	// K is the number of components (topics)
+	K int
+
+	// MaxIterations is the maximum number of update iterations performed during Fit()
+	// and Transform()
+	MaxIterations int
+
+	// Tolerance is the tolerance of the relative improvement in reconstruction error
+	// between iterations below which fitting will stop iterating and complete without
+	// necessarily completing MaxIterations iterations.
+	Tolerance float64
+
+	// Rnd is the random number generator used to generate the initial values of W and H
+	Rnd *rand.Rand
+
+	w   *mat.Dense
+	h   *mat.Dense
+	err float64
+}
+
+// NewNMF creates a new NMF transformer with K components (topics) and default values
+// of 200 for MaxIterations and 1e-4 for Tolerance.
+func NewNMF(k int) *NMF {
+	return &NMF{
+		K:             k,
+		MaxIterations: 200,
+		Tolerance:     1e-4,
+		Rnd:           rand.New(rand.NewSource(uint64(time.Now().UnixNano()))),
+	}
+}
+
+// Fit factorises the input training data matrix m, learning the topics (W) to use
+// when transforming matrices in subsequent calls to Transform().
+func (n *NMF) Fit(m mat.Matrix) Transformer {
+	n.FitTransform(m)
+	return n
+}
+
+// Transform transforms the input matrix into a matrix representing the documents as
+// weighted combinations of the topics (W) learnt during Fit() i.e. finding H such that
+// m ≈ WH with W held fixed.  The returned matrix is a dense matrix of shape K x C where
+// C is the number of columns in the input matrix (representing the documents).
+func (n *NMF) Transform(m mat.Matrix) (mat.Matrix, error) {
+	if t, isTypeConv := m.(sparse.TypeConverter); isTypeConv {
+		m = t.ToCSR()
+	}
+	ht := n.init(m, false)
+	n.factorise(m, n.w, ht, false)
+
+	return mat.DenseCopyOf(ht.T()), nil
+}
+
+// FitTransform is approximately equivalent to calling Fit() followed by Transform()
+// on the same matrix.  This is a useful shortcut where separate training data is not
+// being used to fit the model i.e. the model is fitted on the fly to the test data.
+// The returned matrix is a dense matrix of shape K x C where C is the number of columns
+// in the input matrix (representing the documents).
+func (n *NMF) FitTransform(m mat.Matrix) (mat.Matrix, error) {
+	if t, isTypeConv := m.(sparse.TypeConverter); isTypeConv {
+		m = t.ToCSR()
+	}
+	n.w = n.init(m, true)
+	ht := n.init(m, false)
+	n.err = n.factorise(m, n.w, ht, true)
+	n.h = mat.DenseCopyOf(ht.T())
+
+	return mat.DenseCopyOf(n.h), nil
+}
+
+// FeatureDims returns the number of features (terms) expected in input matrices and
+// the number of components output or -1 if the model has not been fitted.
+func (n *NMF) FeatureDims() (in, out int) {
+	if n.w == nil {
+		return -1, -1
+	}
+	return n.w.Dims()
+}
+
+// Components returns the topics learnt during Fit() as a matrix of dimensions K x R
+// where R was the number of rows in the training matrix (representing the terms).
+// Each row represents a topic as the weights of each term within it.
+func (n *NMF) Components() mat.Matrix {
+	return mat.DenseCopyOf(n.w.T())
+}
+
+// TopTerms returns the k terms with the highest weight within the specified topic in
+// descending order of weight.  featureNames should contain the name of each term
+// ordered by index (row) in the training matrix e.g. as returned by the
+// GetFeatureNames() method of the vectoriser used to produce it.
+func (n *NMF) TopTerms(topic, k int, featureNames []string) []string {
+	return topTerms(n.w, topic, true, k, featureNames)
+}
+
+// Factors returns the factor matrices W (R x K) and H (K x C) learnt during Fit()
+// such that the training matrix V (R x C) ≈ WH.
+func (n *NMF) Factors() (w, h mat.Matrix) {
+	return mat.DenseCopyOf(n.w), mat.DenseCopyOf(n.h)
+}
+
+// ReconstructionErr returns the Frobenius norm of the difference between the training
+// matrix and its approximation from the factors learnt during Fit().
+func (n *NMF) ReconstructionErr() float64 {
+	return n.err
+}
+
+// nmfVersion is the version of the serialisation format written by Save().
+const nmfVersion = 1
+
+// nmfHeader is the fixed size portion of a serialised NMF.
+type nmfHeader struct {
+	Version       int64
+	K             int64
+	MaxIterations int64
+	Tolerance     float64
+	Err           float64
+}
+
+// Save binary serialises the model, including its hyperparameters, and writes it into
+// w.  This is useful for persisting a trained model to disk so that it may be loaded
+// (using the Load() method) in another context (e.g. production) for reproducible
+// results.
+func (n NMF) Save(w io.Writer) error {
+	header := nmfHeader{
+		Version:       nmfVersion,
+		K:             int64(n.K),
+		MaxIterations: int64(n.MaxIterations),
+		Tolerance:     n.Tolerance,
+		Err:           n.err,
+	}
+	if err := binary.Write(w, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	if _, err := n.w.MarshalBinaryTo(w); err != nil {
+		return err
+	}
+	_, err := n.h.MarshalBinaryTo(w)
+
+	return err
+}
+
+// Load binary deserialises the previously serialised model into the receiver.  This is
+// useful for loading a previously trained and saved model from another context
+// (e.g. offline training) for use within another context (e.g. production) for
+// reproducible results.  Load should only be performed with trusted data.
+func (n *NMF) Load(r io.Reader) error {
+	var header nmfHeader
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return err
+	}
+	if header.Version != nmfVersion {
+		return fmt.Errorf("nlp: Unsupported serialisation version %d", header.Version)
+	}
+	var w, h mat.Dense
+	if _, err := w.UnmarshalBinaryFrom(r); err != nil {
+		return err
+	}
+	if _, err := h.UnmarshalBinaryFrom(r); err != nil {
+		return err
+	}
+
+	n.K = int(header.K)
+	n.MaxIterations = int(header.MaxIterations)
+	n.Tolerance = header.Tolerance
+	n.err = header.Err
+	n.w = &w
+	n.h = &h
+
+	return nil
+}
+
+// init returns a matrix of random initial values for W (R x K), if w is true, or
+// for the transpose of H (C x K) for the matrix m.  Values are scaled according to
+// the mean of m so that the initial approximation is of the correct magnitude.
+func (n *NMF) init(m mat.Matrix, w bool) *mat.Dense {
+	r, c := m.Dims()
+	var sum float64
+	nonZeroDo(m, func(i, j int, v float64) {
+		sum += v
+	})
+	scale := math.Sqrt(sum / float64(r*c) / float64(n.K))
+
+	rows := c
+	if w {
+		rows = r
+	}
+	factor := mat.NewDense(rows, n.K, nil)
+	factor.Apply(func(i, j int, v float64) float64 {
+		return n.Rnd.Float64() * scale
+	}, factor)
+
+	return factor
+}
+
+// factorise iteratively updates ht (the transpose of H) and, if updateW is true, w
+// using multiplicative updates until the relative improvement in reconstruction error
+// falls below Tolerance or MaxIterations is reached.  The final reconstruction error
+// is returned.
+func (n *NMF) factorise(m mat.Matrix, w, ht *mat.Dense, updateW bool) float64 {
+	var norm float64
+	nonZeroDo(m, func(i, j int, v float64) {
+		norm += v * v
+	})
+
+	initialErr := n.reconstructionErr(m, norm, w, ht)
+	prevErr := initialErr
+	var gram mat.Dense
+	for iter := 0; iter < n.MaxIterations; iter++ {
+		// H^T = H^T .* (V^T W) ./ (H^T W^T W)
+		gram.Mul(w.T(), w)
+		multiplicativeUpdate(ht, mulSparse(m, w, true), &gram)
+
+		if updateW {
+			// W = W .* (V H^T) ./ (W H H^T)
+			gram.Mul(ht.T(), ht)
+			multiplicativeUpdate(w, mulSparse(m, ht, false), &gram)
+		}
+
+		if n.Tolerance > 0 && initialErr > 0 {
+			err := n.reconstructionErr(m, norm, w, ht)
+			if (prevErr-err)/initialErr < n.Tolerance {
+				return err
+			}
+			prevErr = err
+		}
+	}
+
+	return n.reconstructionErr(m, norm, w, ht)
+}
+
+// multiplicativeUpdate updates factor in place as factor .* numerator ./ (factor * gram).
+func multiplicativeUpdate(factor, numerator, gram *mat.Dense) {
+	var denominator mat.Dense
+	denominator.Mul(factor, gram)
+	factor.Apply(func(i, j int, v float64) float64 {
+		return v * numerator.At(i, j) / (denominator.At(i, j) + nmfEpsilon)
+	}, factor)
+}
+
+// reconstructionErr calculates the Frobenius norm of V - WH without constructing WH
+// as ||V||^2 - 2 tr(W^T V H^T) + tr(W^T W H H^T) where norm is ||V||^2.
+func (n *NMF) reconstructionErr(m mat.Matrix, norm float64, w, ht *mat.Dense) float64 {
+	var cross float64
+	nonZeroDo(m, func(i, j int, v float64) {
+		cross += v * mat.Dot(w.RowView(i), ht.RowView(j))
+	})
+
+	var wtw, hht mat.Dense
+	wtw.Mul(w.T(), w)
+	hht.Mul(ht.T(), ht)
+	var approx float64
+	r, c := wtw.Dims()
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			approx += wtw.At(i, j) * hht.At(i, j)
+		}
+	}
+
+	return math.Sqrt(math.Max(norm-2*cross+approx, 0))
+}
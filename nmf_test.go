@@ -0,0 +1,164 @@
+package nlp
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/james-bowman/sparse"
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestNMFTopTerms(t *testing.T) {
+	input := mat.NewDense(6, 4, []float64{
+		3, 2, 0, 0,
+		1, 2, 0, 0,
+		4, 3, 0, 0,
+		0, 0, 2, 1,
+		0, 0, 5, 4,
+		0, 0, 1, 1,
+	})
+	names := []string{"cat", "dog", "pet", "car", "road", "wheel"}
+
+	nmf := NewNMF(2)
+	// set Rnd to fixed constant seed for deterministic results
+	nmf.Rnd = rand.New(rand.NewSource(uint64(0)))
+	nmf.Fit(input)
+
+	expected := map[string][]string{
+		"pet":  {"pet", "cat"},
+		"road": {"road", "car"},
+	}
+	for topic := 0; topic < 2; topic++ {
+		top := nmf.TopTerms(topic, 2, names)
+		if !reflect.DeepEqual(expected[top[0]], top) {
+			t.Errorf("Expected top terms to be one of %v but found %v", expected, top)
+		}
+		delete(expected, top[0])
+	}
+
+	if top := nmf.TopTerms(0, 10, names); len(top) != len(names) {
+		t.Errorf("Expected all %d terms but found %v", len(names), top)
+	}
+}
+
+func TestNMFFitTransform(t *testing.T) {
+	// terms 0-2 relate to topic 1 and terms 3-5 relate to topic 2
+	input := sparse.NewCSR(6, 5, []int{0, 2, 4, 6, 8, 11, 13}, []int{0, 1, 0, 1, 1, 4, 2, 3, 2, 3, 4, 3, 4}, []float64{
+		2, 1,
+		4, 2,
+		1, 1,
+		3, 1,
+		1, 2, 1,
+		2, 2,
+	})
+
+	var tests = []struct {
+		k           int
+		maxRelError float64
+	}{
+		{k: 2, maxRelError: 0.5},
+		{k: 5, maxRelError: 0.05},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+		nmf := NewNMF(test.k)
+		nmf.MaxIterations = 2000
+		nmf.Tolerance = 1e-8
+		// set Rnd to fixed constant seed for deterministic results
+		nmf.Rnd = rand.New(rand.NewSource(uint64(0)))
+
+		result, err := nmf.FitTransform(input)
+		if err != nil {
+			t.Errorf("Failed NMF transform caused by %v", err)
+		}
+		if r, c := result.Dims(); r != test.k || c != 5 {
+			t.Errorf("Expected result of dimensions %d x %d but found %d x %d", test.k, 5, r, c)
+		}
+		if r, c := nmf.Components().Dims(); r != test.k || c != 6 {
+			t.Errorf("Expected components of dimensions %d x %d but found %d x %d", test.k, 6, r, c)
+		}
+
+		w, h := nmf.Factors()
+		if !mat.Equal(result, h) {
+			t.Errorf("Expected H: \n%v\n but found: \n%v\n", mat.Formatted(result), mat.Formatted(h))
+		}
+		for _, factor := range []mat.Matrix{w, h} {
+			if mat.Min(factor) < 0 {
+				t.Errorf("Expected non-negative factor but found: \n%v\n", mat.Formatted(factor))
+			}
+		}
+
+		var approx, diff mat.Dense
+		approx.Mul(w, h)
+		diff.Sub(input, &approx)
+		norm := mat.Norm(input, 2)
+		if relErr := nmf.ReconstructionErr() / norm; relErr > test.maxRelError {
+			t.Errorf("Expected relative reconstruction error below %f but found %f", test.maxRelError, relErr)
+		}
+		if e := mat.Norm(&diff, 2); e-nmf.ReconstructionErr() > 1e-6 || nmf.ReconstructionErr()-e > 1e-6 {
+			t.Errorf("Expected reconstruction error %f but found %f", e, nmf.ReconstructionErr())
+		}
+
+		// transforming the training data should produce a similar approximation
+		transformed, err := nmf.Transform(input)
+		if err != nil {
+			t.Errorf("Failed NMF transform caused by %v", err)
+		}
+		approx.Mul(w, transformed)
+		diff.Sub(input, &approx)
+		if relErr := mat.Norm(&diff, 2) / norm; relErr > test.maxRelError {
+			t.Errorf("Expected relative transformation error below %f but found %f", test.maxRelError, relErr)
+		}
+	}
+}
+
+func TestNMFSaveLoad(t *testing.T) {
+	input := mat.NewDense(6, 4, []float64{
+		3, 2, 0, 0,
+		1, 2, 0, 0,
+		4, 3, 0, 0,
+		0, 0, 2, 1,
+		0, 0, 5, 4,
+		0, 0, 1, 1,
+	})
+
+	wanted := NewNMF(2)
+	wanted.Rnd = rand.New(rand.NewSource(uint64(0)))
+	wanted.MaxIterations = 150
+	wanted.Tolerance = 1e-5
+	wanted.Fit(input)
+
+	var buf bytes.Buffer
+	if err := wanted.Save(&buf); err != nil {
+		t.Fatalf("Error encoding: %v\n", err)
+	}
+	loaded := NewNMF(5)
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatalf("Error unencoding: %v\n", err)
+	}
+
+	if wanted.K != loaded.K || wanted.MaxIterations != loaded.MaxIterations || wanted.Tolerance != loaded.Tolerance {
+		t.Errorf("Expected hyperparameters %d, %d, %f but found %d, %d, %f",
+			wanted.K, wanted.MaxIterations, wanted.Tolerance, loaded.K, loaded.MaxIterations, loaded.Tolerance)
+	}
+	if wanted.ReconstructionErr() != loaded.ReconstructionErr() {
+		t.Errorf("Expected reconstruction error %f but found %f", wanted.ReconstructionErr(), loaded.ReconstructionErr())
+	}
+	ww, wh := wanted.Factors()
+	lw, lh := loaded.Factors()
+	if !mat.Equal(ww, lw) || !mat.Equal(wh, lh) {
+		t.Errorf("Expected factors of loaded model to match those of saved model")
+	}
+
+	// both models should produce the same transform given the same random initialisation
+	wanted.Rnd = rand.New(rand.NewSource(uint64(1)))
+	loaded.Rnd = rand.New(rand.NewSource(uint64(1)))
+	expected, _ := wanted.Transform(input)
+	result, _ := loaded.Transform(input)
+	if !mat.Equal(expected, result) {
+		t.Errorf("Expected %v but found %v", mat.Formatted(expected), mat.Formatted(result))
+	}
+}
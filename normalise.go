@@ -0,0 +1,117 @@
+package nlp
+
+import (
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalisingTokeniser is a Tokeniser that pre-processes text, applying Unicode
+// normalisation, case folding and/or accent stripping, before tokenising it using
+// the wrapped Tokeniser.  This allows different representations of the same text
+// e.g. "Café" (composed or decomposed) and "cafe" to be collapsed into the same
+// tokens and so the same features within the vectorisers.
+type NormalisingTokeniser struct {
+	// Tokeniser is the underlying tokeniser used to tokenise the text after it has
+	// been normalised.
+	Tokeniser Tokeniser
+
+	form         *norm.Form
+	caseFold     bool
+	stripAccents bool
+}
+
+// NormalisationOption is a functional option for configuring the pre-processing
+// applied by a NormalisingTokeniser.
+type NormalisationOption func(*NormalisingTokeniser)
+
+// WithNFC configures the NormalisingTokeniser to normalise text into Unicode
+// Normalization Form C (canonical composition).
+func WithNFC() NormalisationOption {
+	return withForm(norm.NFC)
+}
+
+// WithNFKC configures the NormalisingTokeniser to normalise text into Unicode
+// Normalization Form KC (compatibility composition).  In addition to composing
+// characters, this folds compatibility characters, such as ligatures and full
+// width forms, into their canonical equivalents e.g. "ﬁ" becomes "fi".
+func WithNFKC() NormalisationOption {
+	return withForm(norm.NFKC)
+}
+
+func withForm(form norm.Form) NormalisationOption {
+	return func(t *NormalisingTokeniser) {
+		t.form = &form
+	}
+}
+
+// WithCaseFolding configures the NormalisingTokeniser to apply full Unicode case
+// folding to text.  Unlike simple lower casing, case folding maps characters with
+// multi-character case mappings e.g. "ß" is folded to "ss".
+func WithCaseFolding() NormalisationOption {
+	return func(t *NormalisingTokeniser) {
+		t.caseFold = true
+	}
+}
+
+// WithAccentStripping configures the NormalisingTokeniser to remove accents and
+// other diacritical marks (Unicode non-spacing marks) from text e.g. "Café"
+// becomes "Cafe".
+func WithAccentStripping() NormalisationOption {
+	return func(t *NormalisingTokeniser) {
+		t.stripAccents = true
+	}
+}
+
+// NewNormalisingTokeniser creates a new NormalisingTokeniser wrapping the specified
+// tokeniser and applying the pre-processing specified by opts.  Pre-processing is
+// applied in the order of Unicode normalisation, case folding and then accent
+// stripping regardless of the order opts are specified.
+func NewNormalisingTokeniser(tokeniser Tokeniser, opts ...NormalisationOption) *NormalisingTokeniser {
+	t := &NormalisingTokeniser{Tokeniser: tokeniser}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Normalise applies the configured pre-processing to text and returns the result.
+func (t *NormalisingTokeniser) Normalise(text string) string {
+	// transformers are stateful and so are created for each call to allow
+	// concurrent use of the tokeniser
+	var transformers []transform.Transformer
+	if t.form != nil {
+		transformers = append(transformers, *t.form)
+	}
+	if t.caseFold {
+		transformers = append(transformers, cases.Fold())
+	}
+	if t.stripAccents {
+		transformers = append(transformers, norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	}
+	if len(transformers) == 0 {
+		return text
+	}
+
+	result, _, err := transform.String(transform.Chain(transformers...), text)
+	if err != nil {
+		return text
+	}
+	return result
+}
+
+// ForEachIn normalises text and then iterates over each token within it, as
+// produced by the wrapped Tokeniser, invoking function f with the token as
+// parameter.
+func (t *NormalisingTokeniser) ForEachIn(text string, f func(token string)) {
+	t.Tokeniser.ForEachIn(t.Normalise(text), f)
+}
+
+// Tokenise normalises text and returns a slice of all the tokens contained within
+// it, as produced by the wrapped Tokeniser.
+func (t *NormalisingTokeniser) Tokenise(text string) []string {
+	return t.Tokeniser.Tokenise(t.Normalise(text))
+}
@@ -0,0 +1,76 @@
+package nlp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalisingTokeniser(t *testing.T) {
+	var tests = []struct {
+		opts     []NormalisationOption
+		text     string
+		expected []string
+	}{
+		{
+			opts:     nil,
+			text:     "Cafe\u0301 Straße",
+			expected: []string{"cafe", "straße"},
+		},
+		{
+			opts:     []NormalisationOption{WithNFC()},
+			text:     "Cafe\u0301 Straße",
+			expected: []string{"café", "straße"},
+		},
+		{
+			opts:     []NormalisationOption{WithNFKC()},
+			text:     "ﬁne ｗｉｄｅ",
+			expected: []string{"fine", "wide"},
+		},
+		{
+			opts:     []NormalisationOption{WithCaseFolding()},
+			text:     "STRASSE Straße",
+			expected: []string{"strasse", "strasse"},
+		},
+		{
+			opts:     []NormalisationOption{WithAccentStripping()},
+			text:     "Café Cafe\u0301 naïve résumé",
+			expected: []string{"cafe", "cafe", "naive", "resume"},
+		},
+		{
+			opts:     []NormalisationOption{WithAccentStripping(), WithCaseFolding(), WithNFKC()},
+			text:     "CAFÉ ﬁancée",
+			expected: []string{"cafe", "fiancee"},
+		},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+		tokeniser := NewNormalisingTokeniser(NewTokeniser(), test.opts...)
+
+		tokens := tokeniser.Tokenise(test.text)
+		if !reflect.DeepEqual(test.expected, tokens) {
+			t.Errorf("Expected tokens %v but found %v", test.expected, tokens)
+		}
+
+		var forEach []string
+		tokeniser.ForEachIn(test.text, func(token string) {
+			forEach = append(forEach, token)
+		})
+		if !reflect.DeepEqual(test.expected, forEach) {
+			t.Errorf("Expected tokens %v but found %v", test.expected, forEach)
+		}
+	}
+}
+
+func TestNormalisingTokeniserVectoriser(t *testing.T) {
+	vectoriser := NewCountVectoriser()
+	vectoriser.Tokeniser = NewNormalisingTokeniser(vectoriser.Tokeniser, WithNFC(), WithAccentStripping())
+
+	vectoriser.Fit("Café", "cafe", "Cafe\u0301")
+	if len(vectoriser.Vocabulary) != 1 {
+		t.Errorf("Expected vocabulary of 1 term but found %v", vectoriser.Vocabulary)
+	}
+	if _, exists := vectoriser.Vocabulary["cafe"]; !exists {
+		t.Errorf("Expected 'cafe' in vocabulary but found %v", vectoriser.Vocabulary)
+	}
+}
@@ -0,0 +1,1094 @@
+package nlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/james-bowman/sparse"
+	"github.com/spaolacci/murmur3"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Pipeline is a mechanism for composing processing pipelines out of vectorisers
+// and transformation steps.  For example to compose a classic LSA/LSI pipeline
+// (vectorisation -> TFIDF transformation -> Truncated SVD) one could use a
+// Pipeline as follows:
+//
+//	lsaPipeline := NewPipeline(NewCountVectoriser(), NewTfidfTransformer(), NewTruncatedSVD(100))
+//
+// Each step of the pipeline is named so that individual fitted steps can be
+// retrieved (using GetStep()) for inspection or replaced (using SetStep()) after
+// training e.g.
+//
+//	lsaPipeline.Fit(corpus...)
+//	tfidf, _ := lsaPipeline.GetStep("tfidftransformer")
+type Pipeline struct {
+	// Vectoriser is the first stage of the pipeline converting documents into a
+	// matrix of features
+	Vectoriser Vectoriser
+
+	// Transformers are applied in order to the output of the Vectoriser with the
+	// output of each forming the input of the next
+	Transformers []Transformer
+
+	// Names optionally holds the name of each step within the pipeline, the
+	// Vectoriser followed by each of the Transformers in order.  Steps without a
+	// name (or with an empty name) are named after their type in lower case e.g.
+	// "tfidftransformer" with duplicate names suffixed by the index of the step
+	// e.g. "tfidftransformer-2".
+	Names []string
+
+	// Cache, if not nil, memoises the output of each step during FitTransform() so
+	// that repeated calls with the same documents, e.g. while sweeping the
+	// hyperparameters of later steps, do not repeat earlier steps.  See
+	// PipelineCache for details.
+	Cache *PipelineCache
+
+	// Progress, if not nil, is called during Fit() and FitTransform() as each step
+	// completes with the name of the step as the stage and the number of steps
+	// completed out of the total number of steps.  Progress within individual steps
+	// may be reported by setting the Progress field of the steps themselves.
+	Progress ProgressFunc
+}
+
+// PipelineStep is a named step within a Pipeline.  Step is either the Vectoriser
+// of the pipeline or one of its Transformers.
+type PipelineStep struct {
+	Name string
+	Step interface{}
+}
+
+// StepError is the error returned when a step of a Pipeline fails.  It identifies the
+// failing step along with the dimensions of the matrix input to it, to help diagnose
+// errors such as dimension mismatches between steps, and wraps the error returned by
+// the step so that it may be inspected using errors.Is() and errors.As().
+type StepError struct {
+	// Step is the name of the failing step
+	Step string
+
+	// Index is the position of the failing step within the pipeline where 0 is the
+	// Vectoriser and 1 the first Transformer
+	Index int
+
+	// Docs is the number of documents input to the pipeline, if known
+	Docs int
+
+	// Rows and Cols are the dimensions of the matrix input to the failing step.  Both
+	// are 0 if the failing step is the Vectoriser.
+	Rows, Cols int
+
+	// Err is the error returned by the step
+	Err error
+}
+
+// Error returns a description of the error including the name of the step.
+func (e *StepError) Error() string {
+	if e.Index == 0 {
+		return fmt.Sprintf("nlp: Step '%s' failed on %d documents: %v", e.Step, e.Docs, e.Err)
+	}
+	return fmt.Sprintf("nlp: Step '%s' failed on %dx%d input matrix: %v", e.Step, e.Rows, e.Cols, e.Err)
+}
+
+// Unwrap returns the error returned by the step.
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// NewPipeline constructs a new processing pipeline with the supplied Vectoriser
+// and one or more transformers
+func NewPipeline(vectoriser Vectoriser, transformers ...Transformer) *Pipeline {
+	pipeline := Pipeline{
+		Vectoriser:   vectoriser,
+		Transformers: transformers,
+	}
+
+	return &pipeline
+}
+
+// Fit fits the model(s) to the supplied training data
+func (p *Pipeline) Fit(docs ...string) Vectoriser {
+	if _, err := p.FitTransform(docs...); err != nil {
+		panic("nlp: Failed to Fit pipeline because " + err.Error())
+	}
+
+	return p
+}
+
+// FitCtx is equivalent to Fit() except that fitting may be cancelled, or given a
+// deadline, using ctx.  Rather than panicking, any error fitting the pipeline is
+// returned.  See FitTransformCtx() for details.
+func (p *Pipeline) FitCtx(ctx context.Context, docs ...string) error {
+	_, err := p.FitTransformCtx(ctx, docs...)
+	return err
+}
+
+// Transform transforms the supplied documents into a matrix representation
+// of numerical feature vectors using a model(s) previously fitted to supplied
+// training data.
+func (p *Pipeline) Transform(docs ...string) (mat.Matrix, error) {
+	return p.TransformCtx(context.Background(), docs...)
+}
+
+// TransformCtx is equivalent to Transform() except that, if ctx is cancelled or its
+// deadline expires, the transformation stops before the next step and ctx.Err() is
+// returned.
+func (p *Pipeline) TransformCtx(ctx context.Context, docs ...string) (mat.Matrix, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	matrix, err := p.Vectoriser.Transform(docs...)
+	if err != nil {
+		return nil, p.stepError(0, len(docs), nil, err)
+	}
+	for i, t := range p.Transformers {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		output, err := t.Transform(matrix)
+		if err != nil {
+			return nil, p.stepError(i+1, len(docs), matrix, err)
+		}
+		matrix = output
+	}
+	return matrix, nil
+}
+
+// FitTransform transforms the supplied documents into a matrix representation
+// of numerical feature vectors fitting the model to the supplied data in the
+// process.
+func (p *Pipeline) FitTransform(docs ...string) (mat.Matrix, error) {
+	return p.FitTransformCtx(context.Background(), docs...)
+}
+
+// FitTransformCtx is equivalent to FitTransform() except that fitting may be
+// cancelled, or given a deadline, using ctx e.g. when fitting large corpora within
+// request scoped services.  The context is checked before each step and passed to
+// any steps implementing ContextTransformer (e.g. LatentDirichletAllocation) so
+// that long running steps may also stop part way through.  If ctx is done, ctx.Err()
+// is returned and the pipeline may be left partially fitted.
+func (p *Pipeline) FitTransformCtx(ctx context.Context, docs ...string) (mat.Matrix, error) {
+	if p.Cache != nil {
+		return p.Cache.fitTransform(ctx, p, docs)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	completed := p.progress()
+	matrix, err := p.Vectoriser.FitTransform(docs...)
+	if err != nil {
+		return nil, p.stepError(0, len(docs), nil, err)
+	}
+	completed()
+	for i, t := range p.Transformers {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		output, err := fitTransformCtx(ctx, t, matrix)
+		if err != nil {
+			return nil, p.stepError(i+1, len(docs), matrix, err)
+		}
+		matrix = output
+		completed()
+	}
+	return matrix, nil
+}
+
+// stepError returns err wrapped in a StepError identifying step i of the pipeline and
+// the matrix input to it (nil for the Vectoriser).
+func (p *Pipeline) stepError(i, docs int, input mat.Matrix, err error) error {
+	e := &StepError{Step: p.Steps()[i].Name, Index: i, Docs: docs, Err: err}
+	if input != nil {
+		e.Rows, e.Cols = input.Dims()
+	}
+	return e
+}
+
+// progress returns a function to be called on completion of each step in turn to
+// report progress.
+func (p *Pipeline) progress() func() {
+	if p.Progress == nil {
+		return func() {}
+	}
+	steps := p.Steps()
+	var done int
+	return func() {
+		p.Progress(steps[done].Name, done+1, len(steps))
+		done++
+	}
+}
+
+// fitTransformCtx fits t to matrix, passing ctx to t if it is a ContextTransformer,
+// and returns the transformed matrix.
+func fitTransformCtx(ctx context.Context, t Transformer, matrix mat.Matrix) (mat.Matrix, error) {
+	if ct, ok := t.(ContextTransformer); ok {
+		return ct.FitTransformCtx(ctx, matrix)
+	}
+	return t.FitTransform(matrix)
+}
+
+// InverseTransform maps the supplied matrix, as output from Transform(), back through
+// each of the Transformers in reverse order returning a matrix in the space output by
+// the Vectoriser e.g. mapping reduced dimensional document vectors back towards term
+// space.  Depending upon the Transformers, the result may be an approximation.  An
+// error is returned if any of the Transformers do not implement
+// InvertibleTransformer.
+func (p *Pipeline) InverseTransform(matrix mat.Matrix) (mat.Matrix, error) {
+	steps := p.Steps()
+	for i := len(p.Transformers) - 1; i >= 0; i-- {
+		t, ok := p.Transformers[i].(InvertibleTransformer)
+		if !ok {
+			return nil, fmt.Errorf("nlp: Step '%s' of type %T is not invertible", steps[i+1].Name, p.Transformers[i])
+		}
+		output, err := t.InverseTransform(matrix)
+		if err != nil {
+			return nil, p.stepError(i+1, 0, matrix, err)
+		}
+		matrix = output
+	}
+	return matrix, nil
+}
+
+// Validate checks that the steps of the pipeline are compatible with one another
+// without transforming any documents.  The number of features output by each step
+// is propagated through the pipeline, using the dimensions declared by fitted steps
+// implementing Dimensioner, and compared with the number of features expected by the
+// following step.  This allows incompatibilities, for example a Transformer fitted to
+// the output of a different Vectoriser, to be reported upfront rather than part way
+// through a transformation.  Steps that do not implement Dimensioner, or are not
+// fitted, are not checked.  An error is returned describing the first incompatibility
+// found.
+func (p *Pipeline) Validate() error {
+	_, err := p.features()
+	return err
+}
+
+// FeatureDims returns the number of features output by the final step of the pipeline
+// or -1 if unknown.  The input is documents so the number of input features is always
+// -1.
+func (p *Pipeline) FeatureDims() (in, out int) {
+	out, err := p.features()
+	if err != nil {
+		return -1, -1
+	}
+	return -1, out
+}
+
+// features propagates the number of features through the steps of the pipeline and
+// returns the number output by the final step, or -1 if unknown, along with an error
+// if any step does not accept the number of features output by the step before it.
+func (p *Pipeline) features() (int, error) {
+	features := -1
+	var previous string
+	for i, step := range p.Steps() {
+		in, out := -1, -1
+		if d, ok := step.Step.(Dimensioner); ok {
+			in, out = d.FeatureDims()
+		}
+		if i > 0 && in >= 0 && features >= 0 && in != features {
+			return -1, fmt.Errorf("nlp: Step '%s' expects %d input features but step '%s' outputs %d", step.Name, in, previous, features)
+		}
+		features = out
+		previous = step.Name
+	}
+	return features, nil
+}
+
+// Steps returns the named steps of the pipeline, the Vectoriser followed by each of
+// the Transformers, in order.
+func (p *Pipeline) Steps() []PipelineStep {
+	steps := make([]interface{}, 0, len(p.Transformers)+1)
+	steps = append(steps, p.Vectoriser)
+	for _, t := range p.Transformers {
+		steps = append(steps, t)
+	}
+	return namedSteps(steps, p.Names)
+}
+
+// GetStep returns the step with the specified name along with true or nil and false
+// if the pipeline contains no step with that name.  The returned step is either a
+// Vectoriser (for the first step) or a Transformer and may be type asserted to its
+// concrete type for inspection e.g.
+//
+//	step, _ := pipeline.GetStep("tfidftransformer")
+//	tfidf := step.(*TfidfTransformer)
+func (p *Pipeline) GetStep(name string) (interface{}, bool) {
+	for _, step := range p.Steps() {
+		if step.Name == name {
+			return step.Step, true
+		}
+	}
+	return nil, false
+}
+
+// SetStep replaces the step with the specified name with step.  The first step of
+// the pipeline must be replaced with a Vectoriser and subsequent steps with
+// Transformers.  The replacement step retains the name of the step it replaces.  An
+// error is returned if the pipeline contains no step with the specified name or the
+// replacement is of the wrong kind.
+func (p *Pipeline) SetStep(name string, step interface{}) error {
+	for i, s := range p.Steps() {
+		if s.Name != name {
+			continue
+		}
+		if i == 0 {
+			v, ok := step.(Vectoriser)
+			if !ok {
+				return fmt.Errorf("nlp: Step '%s' must be a Vectoriser but was %T", name, step)
+			}
+			p.Vectoriser = v
+		} else {
+			t, ok := step.(Transformer)
+			if !ok {
+				return fmt.Errorf("nlp: Step '%s' must be a Transformer but was %T", name, step)
+			}
+			p.Transformers[i-1] = t
+		}
+		return nil
+	}
+	return fmt.Errorf("nlp: No step named '%s' in pipeline", name)
+}
+
+// Save binary serialises the Vectoriser and every Transformer within the pipeline,
+// including their fitted state, hyperparameters and step names, into a single
+// stream written into w.  This is useful for persisting a trained pipeline to disk
+// so that it may be loaded (using the Load() method) in another context (e.g.
+// production) as a single artifact.  An error is returned if any of the steps do not
+// support serialisation (do not implement Save() and Load() methods).
+func (p *Pipeline) Save(w io.Writer) error {
+	return saveSteps(w, p.Steps())
+}
+
+// Load binary deserialises a pipeline, previously serialised using Save(), into the
+// receiver.  The receiver must be composed of steps of the same types, in the same
+// order, as the serialised pipeline e.g. constructed with the same call to
+// NewPipeline() as was used for training, and each step is loaded in place.  The
+// names of the steps are restored from the serialised pipeline.  An error is
+// returned if the serialised steps do not match those of the receiver.  Load should
+// only be performed with trusted data.
+func (p *Pipeline) Load(r io.Reader) error {
+	names, err := loadSteps(r, p.Steps())
+	if err != nil {
+		return err
+	}
+	p.Names = names
+	return nil
+}
+
+// StreamingPipeline is a Pipeline composed of an OnlineVectoriser and
+// OnlineTransformers supporting online learning.  In addition to fitting the whole
+// pipeline at once, as with a Pipeline, each step can be incrementally updated with
+// successive batches of documents using PartialFit() (or FitReader() to stream
+// batches from an io.Reader) e.g. to train over corpora too large to fit in memory or
+// to track a stream of documents:
+//
+//	streaming := NewStreamingPipeline(NewCountVectoriser(), NewTfidfTransformer(), NewLatentDirichletAllocation(10))
+//	for batch := range batches {
+//		streaming.PartialFit(batch...)
+//	}
+//
+// Each batch of documents updates the Vectoriser (e.g. extending its Vocabulary) and
+// is then passed through each of the Transformers in turn, updating each with the
+// output of the step before.
+type StreamingPipeline struct {
+	// Vectoriser is the first stage of the pipeline converting documents into a
+	// matrix of features
+	Vectoriser OnlineVectoriser
+
+	// Transformers are applied in order to the output of the Vectoriser with the
+	// output of each forming the input of the next
+	Transformers []OnlineTransformer
+}
+
+// NewStreamingPipeline constructs a new online processing pipeline with the supplied
+// OnlineVectoriser and one or more OnlineTransformers.
+func NewStreamingPipeline(vectoriser OnlineVectoriser, transformers ...OnlineTransformer) *StreamingPipeline {
+	return &StreamingPipeline{
+		Vectoriser:   vectoriser,
+		Transformers: transformers,
+	}
+}
+
+// pipeline returns an equivalent Pipeline composed of the same steps.
+func (p *StreamingPipeline) pipeline() *Pipeline {
+	transformers := make([]Transformer, len(p.Transformers))
+	for i, t := range p.Transformers {
+		transformers[i] = t
+	}
+	return NewPipeline(p.Vectoriser, transformers...)
+}
+
+// Fit fits the model(s) to the supplied training data, re-training each step from
+// scratch.
+func (p *StreamingPipeline) Fit(docs ...string) Vectoriser {
+	p.pipeline().Fit(docs...)
+	return p
+}
+
+// Transform transforms the supplied documents into a matrix representation of
+// numerical feature vectors using the model(s) previously fitted to supplied training
+// data.
+func (p *StreamingPipeline) Transform(docs ...string) (mat.Matrix, error) {
+	return p.pipeline().Transform(docs...)
+}
+
+// FitTransform transforms the supplied documents into a matrix representation of
+// numerical feature vectors, re-training each step from scratch, fitting the model to
+// the supplied data in the process.
+func (p *StreamingPipeline) FitTransform(docs ...string) (mat.Matrix, error) {
+	return p.pipeline().FitTransform(docs...)
+}
+
+// PartialFit incrementally updates each step of the pipeline with the supplied batch
+// of documents.  Unlike Fit(), which re-trains each step from scratch, PartialFit() is
+// designed to be called multiple times with successive batches of documents.
+func (p *StreamingPipeline) PartialFit(docs ...string) OnlineVectoriser {
+	if err := p.partialFit(docs); err != nil {
+		panic("nlp: Failed to PartialFit pipeline because " + err.Error())
+	}
+	return p
+}
+
+// FitReader incrementally updates each step of the pipeline with documents read from
+// r, delimited by delim, in batches of batchSize documents as if PartialFit() were
+// called with each batch.  This allows the pipeline to be trained over a corpus
+// without loading it all into memory.  Any error reading from r or transforming a
+// batch is returned.
+func (p *StreamingPipeline) FitReader(r io.Reader, delim byte, batchSize int) error {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	batch := make([]string, 0, batchSize)
+	var err error
+	if readErr := readDocs(r, delim, func(doc string) {
+		if err != nil {
+			return
+		}
+		batch = append(batch, doc)
+		if len(batch) == batchSize {
+			err = p.partialFit(batch)
+			batch = batch[:0]
+		}
+	}); readErr != nil {
+		return readErr
+	}
+	if err != nil {
+		return err
+	}
+	if len(batch) > 0 {
+		return p.partialFit(batch)
+	}
+	return nil
+}
+
+// partialFit updates each step of the pipeline with the batch of documents.
+func (p *StreamingPipeline) partialFit(docs []string) error {
+	p.Vectoriser.PartialFit(docs...)
+	matrix, err := p.Vectoriser.Transform(docs...)
+	if err != nil {
+		return p.pipeline().stepError(0, len(docs), nil, err)
+	}
+	for i, t := range p.Transformers {
+		t.PartialFit(matrix)
+		output, err := t.Transform(matrix)
+		if err != nil {
+			return p.pipeline().stepError(i+1, len(docs), matrix, err)
+		}
+		matrix = output
+	}
+	return nil
+}
+
+// PipelineCache memoises the outputs of the steps of one or more Pipelines during
+// FitTransform().  Outputs are keyed by a hash of the content of the input documents
+// and the identity of each step along with the steps preceding it so that, for
+// example, when sweeping over the hyperparameters of the final step of a pipeline
+// (replacing it using SetStep()), the expensive vectorisation and weighting of the
+// documents by earlier steps is only performed once:
+//
+//	pipeline := NewPipeline(NewCountVectoriser(), NewTfidfTransformer(), NewTruncatedSVD(50))
+//	pipeline.Cache = NewPipelineCache()
+//	for k := 50; k <= 500; k += 50 {
+//		pipeline.SetStep("truncatedsvd", NewTruncatedSVD(k))
+//		lsi, err := pipeline.FitTransform(corpus...)
+//		...
+//	}
+//
+// A cached output is only reused if the step was last fitted, through the cache, to
+// the same input so that the fitted state of the step remains consistent with the
+// output.  Steps are identified by pointer so steps that are not pointers are never
+// cached.  If the hyperparameters of a step are modified in place or the step is
+// fitted outside of the pipeline, Reset() must be called (or the step replaced with
+// a new instance) to avoid stale outputs.  Cached matrices are shared between calls
+// and must not be modified.  A PipelineCache is safe for concurrent use.
+type PipelineCache struct {
+	lock    sync.Mutex
+	outputs map[uint64]mat.Matrix
+	fitted  map[interface{}]uint64
+}
+
+// NewPipelineCache creates a new, empty, PipelineCache.
+func NewPipelineCache() *PipelineCache {
+	return &PipelineCache{
+		outputs: make(map[uint64]mat.Matrix),
+		fitted:  make(map[interface{}]uint64),
+	}
+}
+
+// Len returns the number of step outputs held within the cache.
+func (c *PipelineCache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.outputs)
+}
+
+// Reset empties the cache.
+func (c *PipelineCache) Reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.outputs = make(map[uint64]mat.Matrix)
+	c.fitted = make(map[interface{}]uint64)
+}
+
+// fitTransform is equivalent to p.FitTransformCtx(ctx, docs...) except that the
+// output of each step is reused from, or stored into, the cache.
+func (c *PipelineCache) fitTransform(ctx context.Context, p *Pipeline, docs []string) (mat.Matrix, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	h := murmur3.New64()
+	for _, doc := range docs {
+		binary.Write(h, binary.LittleEndian, int64(len(doc)))
+		io.WriteString(h, doc)
+	}
+	completed := p.progress()
+	key, cacheable := c.key(h.Sum64(), p.Vectoriser)
+	matrix, hit := c.lookup(key, p.Vectoriser, cacheable)
+	if !hit {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var err error
+		if matrix, err = p.Vectoriser.FitTransform(docs...); err != nil {
+			return nil, p.stepError(0, len(docs), nil, err)
+		}
+		c.store(key, p.Vectoriser, matrix, cacheable)
+	}
+	completed()
+
+	for i, t := range p.Transformers {
+		var stepCacheable bool
+		key, stepCacheable = c.key(key, t)
+		cacheable = cacheable && stepCacheable
+		output, hit := c.lookup(key, t, cacheable)
+		if !hit {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			var err error
+			if output, err = fitTransformCtx(ctx, t, matrix); err != nil {
+				return nil, p.stepError(i+1, len(docs), matrix, err)
+			}
+			c.store(key, t, output, cacheable)
+		}
+		matrix = output
+		completed()
+	}
+	return matrix, nil
+}
+
+// key returns the cache key for the output of step given the key of its input along
+// with true or false if the step cannot be cached.
+func (c *PipelineCache) key(input uint64, step interface{}) (uint64, bool) {
+	v := reflect.ValueOf(step)
+	if v.Kind() != reflect.Ptr {
+		return 0, false
+	}
+	var buf [16]byte
+	binary.LittleEndian.PutUint64(buf[:8], input)
+	binary.LittleEndian.PutUint64(buf[8:], uint64(v.Pointer()))
+	return murmur3.Sum64(buf[:]), true
+}
+
+// lookup returns the cached output for key along with true if present and step was
+// last fitted to the same input.
+func (c *PipelineCache) lookup(key uint64, step interface{}, cacheable bool) (mat.Matrix, bool) {
+	if !cacheable {
+		return nil, false
+	}
+	if fitted, ok := c.fitted[step]; !ok || fitted != key {
+		return nil, false
+	}
+	m, ok := c.outputs[key]
+	return m, ok
+}
+
+// store records output as the output of step for key.
+func (c *PipelineCache) store(key uint64, step interface{}, output mat.Matrix, cacheable bool) {
+	if !cacheable {
+		return
+	}
+	c.outputs[key] = output
+	c.fitted[step] = key
+}
+
+// FeatureUnion concatenates the feature spaces of several vectorisers applied to the
+// same documents into a single matrix.  This allows different representations of
+// documents to be combined e.g. word n-gram counts, character n-gram counts and
+// averaged word embeddings.  As a Pipeline is itself a Vectoriser, transformers may
+// be applied to the output of individual vectorisers by supplying Pipelines e.g.
+//
+//	union := NewFeatureUnion(
+//		NewPipeline(NewCountVectoriser(), NewTfidfTransformer()),
+//		NewCharNGramVectoriser(3, 5, true),
+//	)
+//
+// The features of each vectoriser are stacked in the order the vectorisers are
+// supplied so that features output by the first vectoriser occupy the first rows
+// (or columns if Orientation is DocumentsAsRows) of the output matrix followed by
+// those of the second vectoriser and so on.
+type FeatureUnion struct {
+	// Vectorisers are each applied to the input documents and their outputs
+	// concatenated
+	Vectorisers []Vectoriser
+
+	// Orientation specifies the layout of matrices output from the Vectorisers and
+	// so the direction in which they are stacked.  By default (TermsAsRows) matrices
+	// are stacked vertically with features as rows.  If DocumentsAsRows, matrices are
+	// stacked horizontally with features as columns.  All Vectorisers must output
+	// matrices with the same orientation.
+	Orientation Orientation
+
+	// Processes is the degree of parallelisation, or more specifically, the maximum
+	// number of Vectorisers to run concurrently during Fit() and Transform().  Values
+	// less than 2 run the Vectorisers sequentially.  The results are identical
+	// regardless of the degree of parallelisation as the outputs are always stacked
+	// in the order of the Vectorisers.  If greater than 1, the Vectorisers must not
+	// share state e.g. the same Tokeniser must be safe for concurrent use.
+	Processes int
+}
+
+// NewFeatureUnion creates a new FeatureUnion concatenating the features of the
+// supplied vectorisers.
+func NewFeatureUnion(vectorisers ...Vectoriser) *FeatureUnion {
+	return &FeatureUnion{
+		Vectorisers: vectorisers,
+	}
+}
+
+// Fit fits each of the vectorisers to the supplied training data.
+func (f *FeatureUnion) Fit(docs ...string) Vectoriser {
+	f.parallelDo(func(i int, v Vectoriser) error {
+		v.Fit(docs...)
+		return nil
+	})
+	return f
+}
+
+// Transform applies each of the vectorisers to the supplied documents and returns
+// their outputs stacked into a single sparse matrix.  An error is returned if any of
+// the vectorisers fail or return a different number of documents.
+func (f *FeatureUnion) Transform(docs ...string) (mat.Matrix, error) {
+	return f.transform(docs, false)
+}
+
+// FitTransform fits each of the vectorisers to the supplied documents and returns
+// their outputs stacked into a single sparse matrix.  An error is returned if any of
+// the vectorisers fail or return a different number of documents.
+func (f *FeatureUnion) FitTransform(docs ...string) (mat.Matrix, error) {
+	return f.transform(docs, true)
+}
+
+// transform applies each of the vectorisers to the documents, first fitting them if
+// fit is true, and stacks the results.
+func (f *FeatureUnion) transform(docs []string, fit bool) (mat.Matrix, error) {
+	matrices := make([]mat.Matrix, len(f.Vectorisers))
+	err := f.parallelDo(func(i int, v Vectoriser) error {
+		var err error
+		if fit {
+			matrices[i], err = v.FitTransform(docs...)
+		} else {
+			matrices[i], err = v.Transform(docs...)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stackFeatures(matrices, f.Orientation)
+}
+
+// parallelDo invokes fn for each of the Vectorisers, concurrently across Processes
+// go routines, returning the error from the first Vectoriser (in order) that
+// failed, if any.
+func (f *FeatureUnion) parallelDo(fn func(i int, v Vectoriser) error) error {
+	n := len(f.Vectorisers)
+	errs := make([]error, n)
+	parallelChunks(n, numChunks(n, f.Processes), func(chunk, start, end int) {
+		for i := start; i < end; i++ {
+			errs[i] = fn(i, f.Vectorisers[i])
+		}
+	})
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stackFeatures concatenates the features of the supplied matrices, laid out
+// according to orientation o, returning a sparse CSR matrix.  An error is returned
+// if the matrices do not all represent the same number of documents.
+func stackFeatures(matrices []mat.Matrix, o Orientation) (mat.Matrix, error) {
+	if len(matrices) == 0 {
+		return nil, fmt.Errorf("nlp: No matrices to stack")
+	}
+
+	var features, docs int
+	for i, m := range matrices {
+		f, d := o.index(m.Dims())
+		if i == 0 {
+			docs = d
+		} else if d != docs {
+			return nil, fmt.Errorf("nlp: Expected %d documents from each matrix but matrix %d contained %d", docs, i, d)
+		}
+		features += f
+	}
+
+	r, c := o.index(features, docs)
+	stacked := sparse.NewCOO(r, c, nil, nil, nil)
+	var offset int
+	for _, m := range matrices {
+		nonZeroDo(m, func(i, j int, v float64) {
+			si, sj := o.index(o.term(i, j)+offset, o.doc(i, j))
+			stacked.Set(si, sj, v)
+		})
+		f, _ := o.index(m.Dims())
+		offset += f
+	}
+	return stacked.ToCSR(), nil
+}
+
+// FeatureDims returns the total number of features output by the Vectorisers or -1 if
+// unknown for any of them.  The input is documents so the number of input features is
+// always -1.
+func (f *FeatureUnion) FeatureDims() (in, out int) {
+	for _, v := range f.Vectorisers {
+		d, ok := v.(Dimensioner)
+		if !ok {
+			return -1, -1
+		}
+		_, features := d.FeatureDims()
+		if features < 0 {
+			return -1, -1
+		}
+		out += features
+	}
+	return -1, out
+}
+
+// Save binary serialises each of the Vectorisers within the union, including their
+// fitted state and hyperparameters, into a single stream written into w.  An error
+// is returned if any of the Vectorisers do not support serialisation.
+func (f *FeatureUnion) Save(w io.Writer) error {
+	if err := saveSteps(w, f.steps()); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, int64(f.Orientation))
+}
+
+// Load binary deserialises a union, previously serialised using Save(), into the
+// receiver.  The receiver must be composed of Vectorisers of the same types, in the
+// same order, as the serialised union and each Vectoriser is loaded in place.  Load
+// should only be performed with trusted data.
+func (f *FeatureUnion) Load(r io.Reader) error {
+	if _, err := loadSteps(r, f.steps()); err != nil {
+		return err
+	}
+	var orientation int64
+	if err := binary.Read(r, binary.LittleEndian, &orientation); err != nil {
+		return err
+	}
+	f.Orientation = Orientation(orientation)
+	return nil
+}
+
+// steps returns the Vectorisers of the union as named steps.
+func (f *FeatureUnion) steps() []PipelineStep {
+	steps := make([]interface{}, len(f.Vectorisers))
+	for i, v := range f.Vectorisers {
+		steps[i] = v
+	}
+	return namedSteps(steps, nil)
+}
+
+// namedSteps pairs each of the steps with the corresponding name from names or, if
+// no name is supplied, a name derived from the type of the step.
+func namedSteps(steps []interface{}, names []string) []PipelineStep {
+	named := make([]PipelineStep, len(steps))
+	seen := make(map[string]bool, len(steps))
+	for i, step := range steps {
+		var name string
+		if i < len(names) {
+			name = names[i]
+		}
+		if name == "" {
+			name = fmt.Sprintf("%T", step)
+			name = strings.ToLower(name[strings.LastIndex(name, ".")+1:])
+			if seen[name] {
+				name = fmt.Sprintf("%s-%d", name, i)
+			}
+		}
+		seen[name] = true
+		named[i] = PipelineStep{Name: name, Step: step}
+	}
+	return named
+}
+
+// persistable is implemented by models supporting binary serialisation.
+type persistable interface {
+	Save(w io.Writer) error
+	Load(r io.Reader) error
+}
+
+// pipelineVersion is the version of the serialisation format written by saveSteps().
+// Version 1 did not include the names of steps.
+const pipelineVersion = 2
+
+// pipelineHeader is the fixed size portion of a serialised sequence of steps.
+type pipelineHeader struct {
+	Version int64
+	Steps   int64
+}
+
+// saveSteps binary serialises each of the steps, preceded by a versioned header,
+// into w.  Each step is written as its name and type name followed by its length
+// prefixed serialised state.
+func saveSteps(w io.Writer, steps []PipelineStep) error {
+	header := pipelineHeader{Version: pipelineVersion, Steps: int64(len(steps))}
+	if err := binary.Write(w, binary.LittleEndian, header); err != nil {
+		return err
+	}
+
+	for i, step := range steps {
+		p, ok := step.Step.(persistable)
+		if !ok {
+			return fmt.Errorf("nlp: Step %d of type %T does not support serialisation", i, step.Step)
+		}
+		var buf bytes.Buffer
+		if err := p.Save(&buf); err != nil {
+			return err
+		}
+		if err := writeString(w, step.Name); err != nil {
+			return err
+		}
+		if err := writeString(w, fmt.Sprintf("%T", step.Step)); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.LittleEndian, int64(buf.Len())); err != nil {
+			return err
+		}
+		if _, err := buf.WriteTo(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadSteps binary deserialises steps, previously serialised using saveSteps(), from
+// r into the supplied steps in place and returns the serialised names of the steps.
+// Steps serialised with version 1 of the format retain the names of the supplied
+// steps.  An error is returned if the version is not supported or the serialised
+// steps do not match the types of the supplied steps.
+func loadSteps(r io.Reader, steps []PipelineStep) ([]string, error) {
+	var header pipelineHeader
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return nil, err
+	}
+	if header.Version < 1 || header.Version > pipelineVersion {
+		return nil, fmt.Errorf("nlp: Unsupported serialisation version %d", header.Version)
+	}
+	if int(header.Steps) != len(steps) {
+		return nil, fmt.Errorf("nlp: Expected %d steps but found %d serialised", len(steps), header.Steps)
+	}
+
+	names := make([]string, len(steps))
+	for i, step := range steps {
+		names[i] = step.Name
+		if header.Version > 1 {
+			name, err := readString(r)
+			if err != nil {
+				return nil, err
+			}
+			names[i] = name
+		}
+		typeName, err := readString(r)
+		if err != nil {
+			return nil, err
+		}
+		if expected := fmt.Sprintf("%T", step.Step); typeName != expected {
+			return nil, fmt.Errorf("nlp: Expected step %d to be of type %s but found %s", i, expected, typeName)
+		}
+		p, ok := step.Step.(persistable)
+		if !ok {
+			return nil, fmt.Errorf("nlp: Step %d of type %T does not support serialisation", i, step.Step)
+		}
+
+		var n int64
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return nil, err
+		}
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return nil, err
+		}
+		if err := p.Load(bytes.NewReader(payload)); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+// Document is a structured document comprising named text fields (e.g. title and
+// body) and numeric metadata (e.g. number of views or flags) for use with a
+// ColumnTransformer.
+type Document struct {
+	// Fields maps field names to the text of the field
+	Fields map[string]string
+
+	// Metadata maps feature names to numeric values
+	Metadata map[string]float64
+}
+
+// Column routes a single named text field of structured documents to a Vectoriser.
+type Column struct {
+	// Field is the name of the field to vectorise.  Documents without the field are
+	// treated as if the field were empty.
+	Field string
+
+	// Vectoriser is used to vectorise the text of the field.  Transformers may be
+	// applied to the output by supplying a Pipeline.
+	Vectoriser Vectoriser
+}
+
+// ColumnTransformer vectorises structured documents comprising multiple fields by
+// routing each field to a different Vectoriser and concatenating the results into a
+// single matrix.  This allows each field to be processed differently, for example
+// vectorising the title of documents using character n-grams and the body using
+// TF-IDF weighted word counts, along with numeric metadata:
+//
+//	ct := NewColumnTransformer(
+//		Column{Field: "title", Vectoriser: NewCharNGramVectoriser(3, 5, true)},
+//		Column{Field: "body", Vectoriser: NewTfidfVectoriser()},
+//	)
+//	ct.Metadata = NewDictVectoriser()
+//
+// The features of each Column are stacked in the order the Columns are supplied
+// followed by the features of the metadata.  In contrast, FieldWeightedVectoriser
+// vectorises all fields using a single shared vocabulary.
+type ColumnTransformer struct {
+	// Columns route the fields of documents to Vectorisers
+	Columns []Column
+
+	// Metadata, if not nil, vectorises the Metadata of documents with the resulting
+	// features appended after those of the Columns
+	Metadata *DictVectoriser
+
+	// Orientation specifies the layout of matrices output from the Vectorisers and
+	// so the direction in which they are stacked.  By default (TermsAsRows) matrices
+	// are stacked vertically with features as rows.  If DocumentsAsRows, matrices are
+	// stacked horizontally with features as columns.  All Vectorisers must output
+	// matrices with the same orientation.
+	Orientation Orientation
+}
+
+// NewColumnTransformer creates a new ColumnTransformer routing fields of documents
+// as specified by the supplied columns.
+func NewColumnTransformer(columns ...Column) *ColumnTransformer {
+	return &ColumnTransformer{
+		Columns: columns,
+	}
+}
+
+// Fit fits the Vectoriser of each Column to the corresponding field of the supplied
+// training documents and the Metadata vectoriser (if not nil) to their metadata.
+func (c *ColumnTransformer) Fit(train ...Document) *ColumnTransformer {
+	for _, col := range c.Columns {
+		col.Vectoriser.Fit(c.field(col.Field, train)...)
+	}
+	if c.Metadata != nil {
+		c.Metadata.Fit(c.metadata(train)...)
+	}
+	return c
+}
+
+// Transform vectorises each field of the supplied documents using the Vectoriser of
+// the corresponding Column, and the metadata using the Metadata vectoriser (if not
+// nil), returning the results stacked into a single sparse matrix.
+func (c *ColumnTransformer) Transform(docs ...Document) (mat.Matrix, error) {
+	return c.transform(docs, false)
+}
+
+// FitTransform is equivalent to calling Fit() followed by Transform() on the same
+// documents.  The returned matrix is a sparse matrix type.
+func (c *ColumnTransformer) FitTransform(docs ...Document) (mat.Matrix, error) {
+	return c.transform(docs, true)
+}
+
+// transform vectorises the documents, first fitting each vectoriser if fit is true,
+// and stacks the results.
+func (c *ColumnTransformer) transform(docs []Document, fit bool) (mat.Matrix, error) {
+	matrices := make([]mat.Matrix, 0, len(c.Columns)+1)
+	for _, col := range c.Columns {
+		texts := c.field(col.Field, docs)
+		var m mat.Matrix
+		var err error
+		if fit {
+			m, err = col.Vectoriser.FitTransform(texts...)
+		} else {
+			m, err = col.Vectoriser.Transform(texts...)
+		}
+		if err != nil {
+			return nil, err
+		}
+		matrices = append(matrices, m)
+	}
+
+	if c.Metadata != nil {
+		metadata := c.metadata(docs)
+		if fit {
+			c.Metadata.Fit(metadata...)
+		}
+		m, err := c.Metadata.Transform(metadata...)
+		if err != nil {
+			return nil, err
+		}
+		matrices = append(matrices, m)
+	}
+
+	return stackFeatures(matrices, c.Orientation)
+}
+
+// field returns the text of the named field of each of the documents.
+func (c *ColumnTransformer) field(name string, docs []Document) []string {
+	texts := make([]string, len(docs))
+	for i, doc := range docs {
+		texts[i] = doc.Fields[name]
+	}
+	return texts
+}
+
+// metadata returns the metadata of each of the documents.
+func (c *ColumnTransformer) metadata(docs []Document) []map[string]float64 {
+	metadata := make([]map[string]float64, len(docs))
+	for i, doc := range docs {
+		metadata[i] = doc.Metadata
+	}
+	return metadata
+}
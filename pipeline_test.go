@@ -0,0 +1,956 @@
+package nlp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestPipeline(t *testing.T) {
+	var tests = []struct {
+		k     int
+		train []string
+		test  []string
+	}{
+		{k: 2, train: trainSet, test: testSet},
+		{k: 3, train: trainSet, test: trainSet},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		vectoriser := NewCountVectoriser()
+		tfidf := NewTfidfTransformer()
+		svd := NewTruncatedSVD(test.k)
+		pipeline := NewPipeline(vectoriser, tfidf, svd)
+
+		result, err := pipeline.FitTransform(test.train...)
+		if err != nil {
+			t.Errorf("Failed to fit pipeline because %v", err)
+		}
+
+		// the output of the pipeline should match applying each stage in turn
+		m, _ := NewCountVectoriser().FitTransform(test.train...)
+		m, _ = NewTfidfTransformer().FitTransform(m)
+		expected, _ := NewTruncatedSVD(test.k).FitTransform(m)
+		if !mat.Equal(expected, result) {
+			t.Errorf("Expected matrix:\n%v\nbut found:\n%v", mat.Formatted(expected), mat.Formatted(result))
+		}
+
+		// Transform should chain the fitted stages
+		result, err = pipeline.Transform(test.test...)
+		if err != nil {
+			t.Errorf("Failed to transform using pipeline because %v", err)
+		}
+		m, _ = vectoriser.Transform(test.test...)
+		m, _ = tfidf.Transform(m)
+		expected, _ = svd.Transform(m)
+		if !mat.Equal(expected, result) {
+			t.Errorf("Expected matrix:\n%v\nbut found:\n%v", mat.Formatted(expected), mat.Formatted(result))
+		}
+	}
+}
+
+func TestFeatureUnion(t *testing.T) {
+	for testRun, orientation := range []Orientation{TermsAsRows, DocumentsAsRows} {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		words := NewCountVectoriser()
+		words.Orientation = orientation
+		chars := NewCharNGramVectoriser(2, 3, true)
+		chars.Orientation = orientation
+		union := NewFeatureUnion(words, chars)
+		union.Orientation = orientation
+
+		for _, stage := range []struct {
+			docs []string
+			fit  bool
+		}{{docs: trainSet, fit: true}, {docs: testSet}} {
+			docs := stage.docs
+			var result mat.Matrix
+			var err error
+			if stage.fit {
+				result, err = union.FitTransform(docs...)
+			} else {
+				result, err = union.Transform(docs...)
+			}
+			if err != nil {
+				t.Errorf("Failed to transform using feature union because %v", err)
+			}
+
+			wordFeatures, _ := words.Transform(docs...)
+			charFeatures, _ := chars.Transform(docs...)
+			w, _ := orientation.index(wordFeatures.Dims())
+			c, _ := orientation.index(charFeatures.Dims())
+
+			r, cols := result.Dims()
+			if er, ec := orientation.index(w+c, len(docs)); r != er || cols != ec {
+				t.Errorf("Expected dimensions %dx%d but found %dx%d", er, ec, r, cols)
+				continue
+			}
+			for d := range docs {
+				for f := 0; f < w+c; f++ {
+					var expected float64
+					if f < w {
+						expected = wordFeatures.At(orientation.index(f, d))
+					} else {
+						expected = charFeatures.At(orientation.index(f-w, d))
+					}
+					if v := result.At(orientation.index(f, d)); v != expected {
+						t.Errorf("Expected feature %d of document %d to be %f but found %f", f, d, expected, v)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestFeatureUnionDocumentMismatch(t *testing.T) {
+	matrices := []mat.Matrix{
+		mat.NewDense(2, 3, nil),
+		mat.NewDense(2, 2, nil),
+	}
+	if _, err := stackFeatures(matrices, TermsAsRows); err == nil {
+		t.Errorf("Expected error stacking matrices with different numbers of documents but received nil")
+	}
+}
+
+func TestPipelineSaveLoad(t *testing.T) {
+	var tests = []struct {
+		vectoriser   func() Vectoriser
+		transformers func() []Transformer
+	}{
+		{
+			vectoriser: func() Vectoriser { return NewCountVectoriser(stopWords...) },
+			transformers: func() []Transformer {
+				return []Transformer{NewTfidfTransformer(), NewTruncatedSVD(2)}
+			},
+		},
+		{
+			vectoriser:   func() Vectoriser { return NewTfidfVectoriser() },
+			transformers: func() []Transformer { return []Transformer{NewTruncatedSVD(3)} },
+		},
+		{
+			vectoriser: func() Vectoriser {
+				return NewFeatureUnion(
+					NewPipeline(NewCountVectoriser(), NewTfidfTransformer()),
+					NewHashingVectoriser(100),
+				)
+			},
+			transformers: func() []Transformer { return []Transformer{NewTruncatedSVD(2)} },
+		},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		trained := NewPipeline(test.vectoriser(), test.transformers()...)
+		trained.Fit(trainSet...)
+		expected, err := trained.Transform(testSet...)
+		if err != nil {
+			t.Errorf("Failed to transform using pipeline because %v", err)
+		}
+
+		var buf bytes.Buffer
+		if err := trained.Save(&buf); err != nil {
+			t.Errorf("Failed to save pipeline because %v", err)
+		}
+
+		loaded := NewPipeline(test.vectoriser(), test.transformers()...)
+		if err := loaded.Load(&buf); err != nil {
+			t.Errorf("Failed to load pipeline because %v", err)
+		}
+		result, err := loaded.Transform(testSet...)
+		if err != nil {
+			t.Errorf("Failed to transform using loaded pipeline because %v", err)
+		}
+		if !mat.EqualApprox(expected, result, 1e-12) {
+			t.Errorf("Expected matrix:\n%v\nbut found:\n%v", mat.Formatted(expected), mat.Formatted(result))
+		}
+	}
+}
+
+func TestPipelineLoadMismatch(t *testing.T) {
+	trained := NewPipeline(NewCountVectoriser(), NewTfidfTransformer())
+	trained.Fit(trainSet...)
+	var buf bytes.Buffer
+	if err := trained.Save(&buf); err != nil {
+		t.Errorf("Failed to save pipeline because %v", err)
+	}
+	saved := buf.Bytes()
+
+	var tests = []struct {
+		pipeline *Pipeline
+	}{
+		{pipeline: NewPipeline(NewCountVectoriser(), NewBM25Transformer())},
+		{pipeline: NewPipeline(NewCountVectoriser())},
+		{pipeline: NewPipeline(NewCountVectoriser(), NewTfidfTransformer(), NewTruncatedSVD(2))},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		if err := test.pipeline.Load(bytes.NewReader(saved)); err == nil {
+			t.Errorf("Expected error loading into mismatched pipeline but received nil")
+		}
+	}
+
+	if err := NewPipeline(NewCountVectoriser(), NewTransposeTransformer()).Save(&buf); err == nil {
+		t.Errorf("Expected error saving pipeline containing step not supporting serialisation but received nil")
+	}
+}
+
+func TestPipelineSteps(t *testing.T) {
+	var tests = []struct {
+		pipeline *Pipeline
+		names    []string
+	}{
+		{
+			pipeline: NewPipeline(NewCountVectoriser(), NewTfidfTransformer(), NewTruncatedSVD(2)),
+			names:    []string{"countvectoriser", "tfidftransformer", "truncatedsvd"},
+		},
+		{
+			pipeline: NewPipeline(NewCountVectoriser(), NewTfidfTransformer(), NewTfidfTransformer()),
+			names:    []string{"countvectoriser", "tfidftransformer", "tfidftransformer-2"},
+		},
+		{
+			pipeline: &Pipeline{
+				Vectoriser:   NewCountVectoriser(),
+				Transformers: []Transformer{NewTfidfTransformer(), NewTruncatedSVD(2)},
+				Names:        []string{"counts", "", "lsa"},
+			},
+			names: []string{"counts", "tfidftransformer", "lsa"},
+		},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		steps := test.pipeline.Steps()
+		if len(steps) != len(test.names) {
+			t.Errorf("Expected %d steps but found %d", len(test.names), len(steps))
+			continue
+		}
+		for i, step := range steps {
+			if step.Name != test.names[i] {
+				t.Errorf("Expected step %d to be named %s but was %s", i, test.names[i], step.Name)
+			}
+			if s, ok := test.pipeline.GetStep(test.names[i]); !ok || s != step.Step {
+				t.Errorf("Expected GetStep(%s) to return step %d but returned %v", test.names[i], i, s)
+			}
+		}
+		if _, ok := test.pipeline.GetStep("missing"); ok {
+			t.Errorf("Expected GetStep() to return false for missing step")
+		}
+	}
+}
+
+func TestPipelineSetStep(t *testing.T) {
+	pipeline := NewPipeline(NewCountVectoriser(), NewTfidfTransformer())
+
+	bm25 := NewBM25Transformer()
+	if err := pipeline.SetStep("tfidftransformer", bm25); err != nil {
+		t.Errorf("Failed to set step because %v", err)
+	}
+	if step, _ := pipeline.GetStep("bm25transformer"); step != bm25 {
+		t.Errorf("Expected replaced step to be retrievable by the name of its type")
+	}
+	if _, err := pipeline.FitTransform(trainSet...); err != nil {
+		t.Errorf("Failed to fit pipeline with replaced step because %v", err)
+	}
+
+	if err := pipeline.SetStep("countvectoriser", bm25); err == nil {
+		t.Errorf("Expected error replacing vectoriser with transformer but received nil")
+	}
+	if err := pipeline.SetStep("bm25transformer", NewCountVectoriser()); err == nil {
+		t.Errorf("Expected error replacing transformer with vectoriser but received nil")
+	}
+	if err := pipeline.SetStep("missing", bm25); err == nil {
+		t.Errorf("Expected error replacing missing step but received nil")
+	}
+}
+
+func TestPipelineSaveLoadNames(t *testing.T) {
+	trained := NewPipeline(NewCountVectoriser(), NewTfidfTransformer())
+	trained.Names = []string{"counts", "weights"}
+	trained.Fit(trainSet...)
+
+	var buf bytes.Buffer
+	if err := trained.Save(&buf); err != nil {
+		t.Errorf("Failed to save pipeline because %v", err)
+	}
+
+	loaded := NewPipeline(NewCountVectoriser(), NewTfidfTransformer())
+	if err := loaded.Load(&buf); err != nil {
+		t.Errorf("Failed to load pipeline because %v", err)
+	}
+	if step, ok := loaded.GetStep("weights"); !ok || step != loaded.Transformers[0] {
+		t.Errorf("Expected step names to be restored on Load but found %v", loaded.Steps())
+	}
+}
+
+func TestPipelineSaveLoadHyperparameters(t *testing.T) {
+	var tests = []struct {
+		orientation Orientation
+		tfidf       func(*TfidfTransformer)
+	}{
+		{
+			orientation: TermsAsRows,
+			tfidf: func(tfidf *TfidfTransformer) {
+				tfidf.SetIDFScheme(ProbabilisticIDF)
+				tfidf.SetTFScheme(AugmentedTF)
+				tfidf.SetWeightPadding(1)
+				tfidf.SetL2Normalization(PivotedNormalization)
+				tfidf.SetPivot(0.25, 0)
+			},
+		},
+		{
+			orientation: DocumentsAsRows,
+			tfidf: func(tfidf *TfidfTransformer) {
+				tfidf.SetOrientation(DocumentsAsRows)
+				tfidf.SetSmoothIDF(false)
+				tfidf.SetTFScheme(BinaryTF)
+				tfidf.SetL2Normalization(RowBasedL2Normalization)
+			},
+		},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		vectoriser := NewCountVectoriser(stopWords...)
+		vectoriser.Orientation = test.orientation
+		tfidf := NewTfidfTransformer()
+		test.tfidf(tfidf)
+		trained := NewPipeline(vectoriser, tfidf)
+		trained.Fit(trainSet...)
+		expected, err := trained.Transform(testSet...)
+		if err != nil {
+			t.Errorf("Failed to transform using pipeline because %v", err)
+		}
+
+		var buf bytes.Buffer
+		if err := trained.Save(&buf); err != nil {
+			t.Errorf("Failed to save pipeline because %v", err)
+		}
+
+		// the loaded pipeline is constructed with default hyperparameters which
+		// should be restored from the serialised pipeline
+		loaded := NewPipeline(NewCountVectoriser(), NewTfidfTransformer())
+		if err := loaded.Load(&buf); err != nil {
+			t.Errorf("Failed to load pipeline because %v", err)
+		}
+		result, err := loaded.Transform(testSet...)
+		if err != nil {
+			t.Errorf("Failed to transform using loaded pipeline because %v", err)
+		}
+		if !mat.EqualApprox(expected, result, 1e-12) {
+			t.Errorf("Expected matrix:\n%v\nbut found:\n%v", mat.Formatted(expected), mat.Formatted(result))
+		}
+	}
+}
+
+// countingVectoriser counts the number of times FitTransform() is called
+type countingVectoriser struct {
+	*CountVectoriser
+	fits int
+}
+
+func (c *countingVectoriser) FitTransform(docs ...string) (mat.Matrix, error) {
+	c.fits++
+	return c.CountVectoriser.FitTransform(docs...)
+}
+
+// countingTransformer counts the number of times FitTransform() is called
+type countingTransformer struct {
+	*TfidfTransformer
+	fits int
+}
+
+func (c *countingTransformer) FitTransform(m mat.Matrix) (mat.Matrix, error) {
+	c.fits++
+	return c.TfidfTransformer.FitTransform(m)
+}
+
+func TestPipelineCache(t *testing.T) {
+	vectoriser := &countingVectoriser{CountVectoriser: NewCountVectoriser()}
+	tfidf := &countingTransformer{TfidfTransformer: NewTfidfTransformer()}
+	pipeline := NewPipeline(vectoriser, tfidf, NewTruncatedSVD(2))
+	pipeline.Names = []string{"counts", "tfidf", "svd"}
+	pipeline.Cache = NewPipelineCache()
+
+	var tests = []struct {
+		docs  []string
+		k     int
+		reset bool
+		fits  int
+	}{
+		{docs: trainSet, k: 2, fits: 1},
+		{docs: trainSet, k: 2, fits: 1},
+		{docs: trainSet, k: 3, fits: 1},
+		{docs: testSet, k: 3, fits: 2},
+		{docs: trainSet, k: 3, fits: 3},
+		{docs: trainSet, k: 3, reset: true, fits: 4},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		if test.reset {
+			pipeline.Cache.Reset()
+		}
+		if err := pipeline.SetStep("svd", NewTruncatedSVD(test.k)); err != nil {
+			t.Errorf("Failed to set step because %v", err)
+		}
+		result, err := pipeline.FitTransform(test.docs...)
+		if err != nil {
+			t.Errorf("Failed to fit pipeline because %v", err)
+		}
+
+		if vectoriser.fits != test.fits || tfidf.fits != test.fits {
+			t.Errorf("Expected %d fits but vectoriser fitted %d times and transformer %d times", test.fits, vectoriser.fits, tfidf.fits)
+		}
+
+		// the output and fitted state should match an uncached pipeline
+		uncached := NewPipeline(NewCountVectoriser(), NewTfidfTransformer(), NewTruncatedSVD(test.k))
+		expected, _ := uncached.FitTransform(test.docs...)
+		if !mat.Equal(expected, result) {
+			t.Errorf("Expected matrix:\n%v\nbut found:\n%v", mat.Formatted(expected), mat.Formatted(result))
+		}
+		expected, _ = uncached.Transform(testSet...)
+		result, _ = pipeline.Transform(testSet...)
+		if !mat.Equal(expected, result) {
+			t.Errorf("Expected transformed matrix:\n%v\nbut found:\n%v", mat.Formatted(expected), mat.Formatted(result))
+		}
+	}
+}
+
+func TestColumnTransformer(t *testing.T) {
+	docs := []Document{
+		{
+			Fields:   map[string]string{"title": "Dogs", "body": "the quick brown fox jumped over the lazy dog"},
+			Metadata: map[string]float64{"views": 10},
+		},
+		{
+			Fields:   map[string]string{"title": "Cats", "body": "the brown cat sat on the mat"},
+			Metadata: map[string]float64{"views": 3, "featured": 1},
+		},
+		{
+			Fields: map[string]string{"body": "the dog ate the cat"},
+		},
+	}
+
+	for testRun, orientation := range []Orientation{TermsAsRows, DocumentsAsRows} {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		title := NewCountVectoriser()
+		title.Orientation = orientation
+		body := NewCountVectoriser()
+		body.Orientation = orientation
+		ct := NewColumnTransformer(
+			Column{Field: "title", Vectoriser: title},
+			Column{Field: "body", Vectoriser: body},
+		)
+		ct.Metadata = NewDictVectoriser()
+		ct.Metadata.Orientation = orientation
+		ct.Orientation = orientation
+
+		result, err := ct.FitTransform(docs...)
+		if err != nil {
+			t.Errorf("Failed to transform using column transformer because %v", err)
+		}
+		if transformed, _ := ct.Fit(docs...).Transform(docs...); !mat.Equal(result, transformed) {
+			t.Errorf("Expected Fit() followed by Transform() to match FitTransform()")
+		}
+
+		// features should be stacked in the order of the columns followed by metadata
+		var offset int
+		for _, part := range []struct {
+			names  []string
+			values func(doc Document, feature string) float64
+		}{
+			{
+				names: title.GetFeatureNames(),
+				values: func(doc Document, feature string) float64 {
+					if strings.ToLower(doc.Fields["title"]) == feature {
+						return 1
+					}
+					return 0
+				},
+			},
+			{
+				names: body.GetFeatureNames(),
+				values: func(doc Document, feature string) float64 {
+					return float64(strings.Count(" "+doc.Fields["body"]+" ", " "+feature+" "))
+				},
+			},
+			{
+				names: ct.Metadata.GetFeatureNames(),
+				values: func(doc Document, feature string) float64 {
+					return doc.Metadata[feature]
+				},
+			},
+		} {
+			for f, name := range part.names {
+				for d, doc := range docs {
+					if v, expected := result.At(orientation.index(offset+f, d)), part.values(doc, name); v != expected {
+						t.Errorf("Expected feature '%s' of document %d to be %f but found %f", name, d, expected, v)
+					}
+				}
+			}
+			offset += len(part.names)
+		}
+		if r, c := result.Dims(); r*c != offset*len(docs) {
+			t.Errorf("Expected %d features but found matrix of %dx%d", offset, r, c)
+		}
+	}
+}
+
+// failingVectoriser returns an error from Transform() and FitTransform()
+type failingVectoriser struct {
+	*CountVectoriser
+	err error
+}
+
+func (f *failingVectoriser) Transform(docs ...string) (mat.Matrix, error) {
+	return nil, f.err
+}
+
+func (f *failingVectoriser) FitTransform(docs ...string) (mat.Matrix, error) {
+	return nil, f.err
+}
+
+func TestFeatureUnionParallel(t *testing.T) {
+	newUnion := func(processes int) *FeatureUnion {
+		union := NewFeatureUnion(
+			NewCountVectoriser(),
+			NewCharNGramVectoriser(2, 3, true),
+			NewPipeline(NewCountVectoriser(stopWords...), NewTfidfTransformer()),
+			NewHashingVectoriser(50),
+		)
+		union.Processes = processes
+		return union
+	}
+
+	sequential := newUnion(1)
+	expectedFit, _ := sequential.FitTransform(trainSet...)
+	expected, _ := sequential.Transform(testSet...)
+
+	for testRun, processes := range []int{2, 3, 4, 8} {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		union := newUnion(processes)
+		result, err := union.FitTransform(trainSet...)
+		if err != nil {
+			t.Errorf("Failed to fit feature union because %v", err)
+		}
+		if !mat.Equal(expectedFit, result) {
+			t.Errorf("Expected parallel FitTransform() to match sequential for %d processes", processes)
+		}
+		result, err = union.Fit(trainSet...).Transform(testSet...)
+		if err != nil {
+			t.Errorf("Failed to transform using feature union because %v", err)
+		}
+		if !mat.Equal(expected, result) {
+			t.Errorf("Expected parallel Transform() to match sequential for %d processes", processes)
+		}
+
+		// the error returned should be that of the first failing vectoriser
+		failing := NewFeatureUnion(
+			NewCountVectoriser(),
+			&failingVectoriser{CountVectoriser: NewCountVectoriser(), err: fmt.Errorf("first")},
+			&failingVectoriser{CountVectoriser: NewCountVectoriser(), err: fmt.Errorf("second")},
+		)
+		failing.Processes = processes
+		if _, err := failing.FitTransform(trainSet...); err == nil || err.Error() != "first" {
+			t.Errorf("Expected error from first failing vectoriser but received %v", err)
+		}
+	}
+}
+
+func TestPipelineInverseTransform(t *testing.T) {
+	var tests = []struct {
+		transformers []Transformer
+		invertible   bool
+	}{
+		{transformers: []Transformer{NewTfidfTransformer(), NewMaxAbsScaler()}, invertible: true},
+		{transformers: []Transformer{NewTfidfTransformer(), NewTruncatedSVD(100)}, invertible: true},
+		{transformers: []Transformer{NewTransposeTransformer(), &StandardScaler{Orientation: DocumentsAsRows, Scale: true}}, invertible: true},
+		{transformers: []Transformer{NewTfidfTransformer(), NewNMF(2)}, invertible: false},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		vectoriser := NewCountVectoriser()
+		pipeline := NewPipeline(vectoriser, test.transformers...)
+		transformed, err := pipeline.FitTransform(trainSet...)
+		if err != nil {
+			t.Errorf("Failed to fit pipeline because %v", err)
+		}
+
+		result, err := pipeline.InverseTransform(transformed)
+		if !test.invertible {
+			if err == nil {
+				t.Errorf("Expected error inverse transforming with non invertible step but received nil")
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Failed to inverse transform because %v", err)
+		}
+		expected, _ := vectoriser.Transform(trainSet...)
+		if !mat.EqualApprox(expected, result, 1e-9) {
+			t.Errorf("Expected matrix:\n%v\nbut found:\n%v", mat.Formatted(expected), mat.Formatted(result))
+		}
+	}
+}
+
+func TestStreamingPipeline(t *testing.T) {
+	var tests = []struct {
+		batchSize int
+	}{
+		{batchSize: 1},
+		{batchSize: 2},
+		{batchSize: 4},
+		{batchSize: len(trainSet)},
+	}
+
+	// fitting the vocabulary incrementally and IDF over the whole corpus should be
+	// equivalent to streaming batches through both
+	vectoriser := NewCountVectoriser()
+	vectoriser.PartialFit(trainSet...)
+	batch := NewPipeline(vectoriser, NewTfidfTransformer())
+	m, _ := vectoriser.Transform(trainSet...)
+	batch.Transformers[0].Fit(m)
+	expected, _ := batch.Transform(testSet...)
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		streaming := NewStreamingPipeline(NewCountVectoriser(), NewTfidfTransformer())
+		for start := 0; start < len(trainSet); start += test.batchSize {
+			end := start + test.batchSize
+			if end > len(trainSet) {
+				end = len(trainSet)
+			}
+			streaming.PartialFit(trainSet[start:end]...)
+		}
+		result, err := streaming.Transform(testSet...)
+		if err != nil {
+			t.Errorf("Failed to transform using streaming pipeline because %v", err)
+		}
+		if !mat.EqualApprox(expected, result, 1e-12) {
+			t.Errorf("Expected matrix:\n%v\nbut found:\n%v", mat.Formatted(expected), mat.Formatted(result))
+		}
+
+		reader := NewStreamingPipeline(NewCountVectoriser(), NewTfidfTransformer())
+		if err := reader.FitReader(strings.NewReader(strings.Join(trainSet, "\n")), '\n', test.batchSize); err != nil {
+			t.Errorf("Failed to fit streaming pipeline from reader because %v", err)
+		}
+		result, err = reader.Transform(testSet...)
+		if err != nil {
+			t.Errorf("Failed to transform using streaming pipeline because %v", err)
+		}
+		if !mat.EqualApprox(expected, result, 1e-12) {
+			t.Errorf("Expected matrix from reader:\n%v\nbut found:\n%v", mat.Formatted(expected), mat.Formatted(result))
+		}
+	}
+}
+
+func TestStreamingPipelineLDA(t *testing.T) {
+	lda := NewLatentDirichletAllocation(2)
+	lda.Rnd = rand.New(rand.NewSource(uint64(0)))
+	streaming := NewStreamingPipeline(NewHashingVectoriser(100), lda)
+	for _, doc := range trainSet {
+		streaming.PartialFit(doc)
+	}
+
+	result, err := streaming.Transform(testSet...)
+	if err != nil {
+		t.Errorf("Failed to transform using streaming pipeline because %v", err)
+	}
+	if r, c := result.Dims(); r != 2 || c != len(testSet) {
+		t.Errorf("Expected dimensions 2x%d but found %dx%d", len(testSet), r, c)
+	}
+}
+
+// cancellingTransformer cancels a context when fitted
+type cancellingTransformer struct {
+	*TfidfTransformer
+	cancel context.CancelFunc
+}
+
+func (c *cancellingTransformer) FitTransform(m mat.Matrix) (mat.Matrix, error) {
+	c.cancel()
+	return c.TfidfTransformer.FitTransform(m)
+}
+
+func TestPipelineContext(t *testing.T) {
+	var tests = []struct {
+		cancel   bool
+		cache    bool
+		expected error
+	}{
+		{cancel: false, expected: nil},
+		{cancel: true, expected: context.Canceled},
+		{cancel: true, cache: true, expected: context.Canceled},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		stop := func() {}
+		if test.cancel {
+			stop = cancel
+		}
+		counting := &countingTransformer{TfidfTransformer: NewTfidfTransformer()}
+		pipeline := NewPipeline(
+			NewCountVectoriser(),
+			&cancellingTransformer{TfidfTransformer: NewTfidfTransformer(), cancel: stop},
+			counting,
+		)
+		if test.cache {
+			pipeline.Cache = NewPipelineCache()
+		}
+
+		err := pipeline.FitCtx(ctx, trainSet...)
+		if err != test.expected {
+			t.Errorf("Expected error %v but found %v", test.expected, err)
+		}
+		if test.expected != nil && counting.fits != 0 {
+			t.Errorf("Expected steps after cancellation not to be fitted but fitted %d times", counting.fits)
+		}
+
+		_, err = pipeline.TransformCtx(ctx, testSet...)
+		if err != test.expected {
+			t.Errorf("Expected error transforming %v but found %v", test.expected, err)
+		}
+		cancel()
+	}
+}
+
+func TestContextTransformers(t *testing.T) {
+	var tests = []struct {
+		transformer ContextTransformer
+	}{
+		{transformer: NewLatentDirichletAllocation(2)},
+		{transformer: NewHierarchicalDirichletProcess()},
+	}
+
+	vectoriser := NewCountVectoriser()
+	m, _ := vectoriser.FitTransform(trainSet...)
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		if _, err := test.transformer.FitTransformCtx(ctx, m); err != nil {
+			t.Errorf("Expected %T to fit without error but found %v", test.transformer, err)
+		}
+
+		cancel()
+		if _, err := test.transformer.FitTransformCtx(ctx, m); err != context.Canceled {
+			t.Errorf("Expected %T to return %v once cancelled but found %v", test.transformer, context.Canceled, err)
+		}
+	}
+}
+
+// progressRecorder records calls to a ProgressFunc by stage
+type progressRecorder struct {
+	lock   sync.Mutex
+	stages []string
+	done   map[string][]int
+	total  map[string]int
+}
+
+func (p *progressRecorder) progress(stage string, done, total int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.done == nil {
+		p.done = make(map[string][]int)
+		p.total = make(map[string]int)
+	}
+	p.stages = append(p.stages, stage)
+	p.done[stage] = append(p.done[stage], done)
+	p.total[stage] = total
+}
+
+func TestPipelineProgress(t *testing.T) {
+	var tests = []struct {
+		processes  int
+		randomised bool
+	}{
+		{processes: 1, randomised: false},
+		{processes: 3, randomised: true},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		steps := &progressRecorder{}
+		vectoriser := NewCountVectoriser()
+		vectoriser.Processes = test.processes
+		vectoriser.Progress = steps.progress
+		svd := NewTruncatedSVD(3)
+		svd.Randomised = test.randomised
+		svd.Progress = steps.progress
+		lda := NewLatentDirichletAllocation(2)
+		lda.Iterations = 5
+		lda.PerplexityEvaluationFrequency = 0
+		lda.Progress = steps.progress
+
+		pipeline := NewPipeline(vectoriser, NewTfidfTransformer(), svd)
+		pipeline.Names = []string{"counts", "tfidf", "svd"}
+		pipelineSteps := &progressRecorder{}
+		pipeline.Progress = pipelineSteps.progress
+		pipeline.Fit(trainSet...)
+		m, _ := vectoriser.Transform(trainSet...)
+		lda.Fit(m)
+
+		if expected := []string{"counts", "tfidf", "svd"}; !reflect.DeepEqual(expected, pipelineSteps.stages) {
+			t.Errorf("Expected pipeline stages %v but found %v", expected, pipelineSteps.stages)
+		}
+		for i, stage := range pipelineSteps.stages {
+			if done := pipelineSteps.done[stage]; done[0] != i+1 || pipelineSteps.total[stage] != 3 {
+				t.Errorf("Expected stage '%s' to report %d of 3 but found %v of %d", stage, i+1, done, pipelineSteps.total[stage])
+			}
+		}
+
+		for _, stage := range []string{"count", "svd", "lda"} {
+			done := steps.done[stage]
+			if len(done) == 0 {
+				t.Errorf("Expected progress to be reported for stage '%s'", stage)
+				continue
+			}
+			for i := 1; i < len(done); i++ {
+				if done[i] <= done[i-1] {
+					t.Errorf("Expected progress of stage '%s' to increase but found %v", stage, done)
+				}
+			}
+			if last := done[len(done)-1]; last != steps.total[stage] {
+				t.Errorf("Expected stage '%s' to complete %d but completed %d", stage, steps.total[stage], last)
+			}
+		}
+		if total := steps.total["count"]; total != len(trainSet) {
+			t.Errorf("Expected %d documents to be counted but found %d", len(trainSet), total)
+		}
+	}
+}
+
+// failingTransformer returns an error from Transform() and FitTransform()
+type failingTransformer struct {
+	*TfidfTransformer
+	err error
+}
+
+func (f *failingTransformer) Transform(m mat.Matrix) (mat.Matrix, error) {
+	return nil, f.err
+}
+
+func (f *failingTransformer) FitTransform(m mat.Matrix) (mat.Matrix, error) {
+	return nil, f.err
+}
+
+func TestPipelineStepError(t *testing.T) {
+	failure := fmt.Errorf("failed")
+	var tests = []struct {
+		pipeline   *Pipeline
+		step       string
+		index      int
+		rows, cols int
+	}{
+		{
+			pipeline: NewPipeline(&failingVectoriser{CountVectoriser: NewCountVectoriser(), err: failure}, NewTfidfTransformer()),
+			step:     "failingvectoriser",
+			index:    0,
+		},
+		{
+			pipeline: NewPipeline(NewCountVectoriser(), NewTfidfTransformer(), &failingTransformer{TfidfTransformer: NewTfidfTransformer(), err: failure}),
+			step:     "failingtransformer",
+			index:    2,
+			rows:     26, cols: len(trainSet),
+		},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		_, err := test.pipeline.FitTransform(trainSet...)
+		if !errors.Is(err, failure) {
+			t.Errorf("Expected error to wrap '%v' but found '%v'", failure, err)
+		}
+		var stepErr *StepError
+		if !errors.As(err, &stepErr) {
+			t.Fatalf("Expected *StepError but found %T", err)
+		}
+		if stepErr.Step != test.step || stepErr.Index != test.index || stepErr.Docs != len(trainSet) {
+			t.Errorf("Expected step '%s' (%d) of %d docs but found '%s' (%d) of %d docs", test.step, test.index, len(trainSet), stepErr.Step, stepErr.Index, stepErr.Docs)
+		}
+		if stepErr.Rows != test.rows || stepErr.Cols != test.cols {
+			t.Errorf("Expected input dimensions %dx%d but found %dx%d", test.rows, test.cols, stepErr.Rows, stepErr.Cols)
+		}
+		if !strings.Contains(err.Error(), test.step) {
+			t.Errorf("Expected error message to contain step name '%s' but found '%s'", test.step, err.Error())
+		}
+	}
+}
+
+func TestPipelineValidate(t *testing.T) {
+	fitted := NewPipeline(NewCountVectoriser(), NewTfidfTransformer(), NewTruncatedSVD(2))
+	fitted.Names = []string{"counts", "tfidf", "svd"}
+	fitted.Fit(trainSet...)
+
+	other := NewCountVectoriser()
+	other.Fit(testSet[:2]...)
+
+	lda := NewLatentDirichletAllocation(2)
+	m, _ := NewHashingVectoriser(20).FitTransform(trainSet...)
+	lda.Fit(m)
+
+	var tests = []struct {
+		pipeline *Pipeline
+		features int
+		err      string
+	}{
+		{pipeline: NewPipeline(NewCountVectoriser(), NewTfidfTransformer(), NewTruncatedSVD(2)), features: -1},
+		{pipeline: fitted, features: 2},
+		{
+			pipeline: &Pipeline{Vectoriser: other, Transformers: fitted.Transformers, Names: fitted.Names},
+			features: -1,
+			err:      "nlp: Step 'tfidf' expects 26 input features but step 'counts' outputs 6",
+		},
+		{pipeline: NewPipeline(NewHashingVectoriser(20), lda), features: 2},
+		{
+			pipeline: NewPipeline(NewHashingVectoriser(30), NewTransposeTransformer(), lda),
+			features: 2,
+		},
+		{
+			pipeline: NewPipeline(NewHashingVectoriser(30), lda),
+			features: -1,
+			err:      "nlp: Step 'latentdirichletallocation' expects 20 input features but step 'hashingvectoriser' outputs 30",
+		},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		err := test.pipeline.Validate()
+		if (err == nil && test.err != "") || (err != nil && err.Error() != test.err) {
+			t.Errorf("Expected error '%s' but found '%v'", test.err, err)
+		}
+		if _, out := test.pipeline.FeatureDims(); out != test.features {
+			t.Errorf("Expected %d output features but found %d", test.features, out)
+		}
+	}
+}
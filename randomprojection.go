@@ -1,6 +1,9 @@
 package nlp
 
 import (
+	"encoding/binary"
+	"fmt"
+	"io"
 	"math"
 	"time"
 
@@ -86,6 +89,68 @@ func (s *SignRandomProjection) FitTransform(m mat.Matrix) (mat.Matrix, error) {
 	return s.Fit(m).Transform(m)
 }
 
+// signRandomProjectionVersion is the version of the serialisation format written by
+// Save().
+const signRandomProjectionVersion = 1
+
+// signRandomProjectionHeader is the fixed size portion of a serialised
+// SignRandomProjection.
+type signRandomProjectionHeader struct {
+	Version int64
+	Bits    int64
+	Dims    int64
+}
+
+// Save binary serialises the model, including its hyperparameters, and writes it into
+// w.  This is useful for persisting a trained model to disk so that it may be loaded
+// (using the Load() method) in another context (e.g. production) for reproducible
+// results.
+func (s SignRandomProjection) Save(w io.Writer) error {
+	header := signRandomProjectionHeader{
+		Version: signRandomProjectionVersion,
+		Bits:    int64(s.Bits),
+	}
+	if len(s.simHash.hyperplanes) > 0 {
+		header.Dims = int64(s.simHash.hyperplanes[0].Len())
+	}
+	if err := binary.Write(w, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	for _, hyperplane := range s.simHash.hyperplanes {
+		if err := binary.Write(w, binary.LittleEndian, hyperplane.RawVector().Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load binary deserialises the previously serialised model into the receiver.  This is
+// useful for loading a previously trained and saved model from another context
+// (e.g. offline training) for use within another context (e.g. production) for
+// reproducible results.  Load should only be performed with trusted data.
+func (s *SignRandomProjection) Load(r io.Reader) error {
+	var header signRandomProjectionHeader
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return err
+	}
+	if header.Version != signRandomProjectionVersion {
+		return fmt.Errorf("nlp: Unsupported serialisation version %d", header.Version)
+	}
+	hyperplanes := make([]*mat.VecDense, header.Bits)
+	for i := range hyperplanes {
+		data := make([]float64, header.Dims)
+		if err := binary.Read(r, binary.LittleEndian, data); err != nil {
+			return err
+		}
+		hyperplanes[i] = mat.NewVecDense(len(data), data)
+	}
+
+	s.Bits = int(header.Bits)
+	s.simHash = &SimHash{hyperplanes: hyperplanes}
+
+	return nil
+}
+
 // RandomProjection is a method of dimensionality reduction based upon
 // the Johnson–Lindenstrauss lemma stating that a small set of points
 // in a high-dimensional space can be embedded into a space of much
@@ -109,7 +174,12 @@ type RandomProjection struct {
 // NewRandomProjection creates and returns a new RandomProjection
 // transformer.  The RandomProjection will use a specially generated
 // random matrix of the specified density and dimensionality k to
-// perform the transform to k dimensional space.
+// perform the transform to k dimensional space.  If density is 0, the
+// density is automatically set to 1/sqrt(d) during Fit(), where d is
+// the original dimensionality (number of rows) of the training matrix,
+// as recommended by Li et al. (Very Sparse Random Projections).  This
+// is significantly faster than a dense Gaussian projection and keeps
+// products sparse whilst preserving pairwise distances almost as well.
 func NewRandomProjection(k int, density float64) *RandomProjection {
 	r := RandomProjection{
 		K:       k,
@@ -123,7 +193,11 @@ func NewRandomProjection(k int, density float64) *RandomProjection {
 // input matrices into the new reduced dimensional subspace.
 func (r *RandomProjection) Fit(m mat.Matrix) Transformer {
 	rows, _ := m.Dims()
-	r.projections = CreateRandomProjectionTransform(r.K, rows, r.Density, r.rnd)
+	density := r.Density
+	if density <= 0 {
+		density = 1 / math.Sqrt(float64(rows))
+	}
+	r.projections = CreateRandomProjectionTransform(r.K, rows, density, r.rnd)
 	return r
 }
 
@@ -153,6 +227,211 @@ func (r *RandomProjection) FitTransform(m mat.Matrix) (mat.Matrix, error) {
 	return r.Fit(m).Transform(m)
 }
 
+// randomProjectionVersion is the version of the serialisation format written by Save().
+const randomProjectionVersion = 1
+
+// randomProjectionHeader is the fixed size portion of a serialised RandomProjection.
+type randomProjectionHeader struct {
+	Version int64
+	K       int64
+	Density float64
+}
+
+// Save binary serialises the model, including its hyperparameters, and writes it into
+// w.  This is useful for persisting a trained model to disk so that it may be loaded
+// (using the Load() method) in another context (e.g. production) for reproducible
+// results.
+func (r RandomProjection) Save(w io.Writer) error {
+	header := randomProjectionHeader{
+		Version: randomProjectionVersion,
+		K:       int64(r.K),
+		Density: r.Density,
+	}
+	if err := binary.Write(w, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	_, err := r.projections.(*sparse.CSR).MarshalBinaryTo(w)
+
+	return err
+}
+
+// Load binary deserialises the previously serialised model into the receiver.  This is
+// useful for loading a previously trained and saved model from another context
+// (e.g. offline training) for use within another context (e.g. production) for
+// reproducible results.  Load should only be performed with trusted data.
+func (r *RandomProjection) Load(rd io.Reader) error {
+	var header randomProjectionHeader
+	if err := binary.Read(rd, binary.LittleEndian, &header); err != nil {
+		return err
+	}
+	if header.Version != randomProjectionVersion {
+		return fmt.Errorf("nlp: Unsupported serialisation version %d", header.Version)
+	}
+	var projections sparse.CSR
+	if _, err := projections.UnmarshalBinaryFrom(rd); err != nil {
+		return err
+	}
+
+	r.K = int(header.K)
+	r.Density = header.Density
+	r.projections = &projections
+
+	return nil
+}
+
+// GaussianRandomProjection is a method of dimensionality reduction that projects the
+// original matrix onto a random subspace using a dense projection matrix with elements
+// drawn from a Gaussian distribution N(0, 1/k).  By the Johnson-Lindenstrauss lemma,
+// the pairwise distances between vectors are approximately preserved provided k is
+// sufficiently large relative to the number of vectors.  Whilst more expensive than
+// the sparse projections used by RandomProjection, Gaussian projections offer the
+// strongest distance preservation guarantees and are commonly used for fast
+// dimensionality reduction before clustering.
+type GaussianRandomProjection struct {
+	// K is the number of dimensions to project onto.  If K is 0, the number of
+	// dimensions is automatically selected during Fit() as the minimum number
+	// guaranteeing distances are preserved to within a factor of Eps (see
+	// JohnsonLindenstraussMinDim).
+	K int
+
+	// Eps is the maximum distortion of pairwise distances used to select the number of
+	// dimensions when K is 0.
+	Eps float64
+
+	rnd         *rand.Rand
+	projections *mat.Dense
+}
+
+// NewGaussianRandomProjection creates and returns a new GaussianRandomProjection
+// transformer projecting onto k dimensions.  If k is 0, the number of dimensions is
+// automatically selected during Fit() according to Eps, which defaults to 0.1.
+func NewGaussianRandomProjection(k int) *GaussianRandomProjection {
+	return &GaussianRandomProjection{
+		K:   k,
+		Eps: 0.1,
+	}
+}
+
+// JohnsonLindenstraussMinDim returns the minimum number of dimensions a random
+// projection must project onto to preserve the pairwise distances between the
+// specified number of samples (vectors) to within a factor of eps (0 < eps < 1)
+// with high probability according to the Johnson-Lindenstrauss lemma:
+//
+//	k >= 4 * ln(samples) / (eps^2 / 2 - eps^3 / 3)
+//
+// Notably, the number of dimensions is independent of the original dimensionality.
+func JohnsonLindenstraussMinDim(samples int, eps float64) int {
+	denominator := eps*eps/2 - eps*eps*eps/3
+	return int(math.Ceil(4 * math.Log(float64(samples)) / denominator))
+}
+
+// Fit creates the random Gaussian matrix used to project input matrices into the new
+// reduced dimensional subspace.  If K is 0, the number of dimensions is selected
+// according to the number of columns (documents) in m and Eps, projecting onto at
+// least 1 dimension.
+func (g *GaussianRandomProjection) Fit(m mat.Matrix) Transformer {
+	rows, cols := m.Dims()
+	k := g.K
+	if k <= 0 {
+		// fewer than 2 documents have no pairwise distances to preserve
+		k = 1
+		if cols > 1 {
+			k = JohnsonLindenstraussMinDim(cols, g.Eps)
+		}
+	}
+
+	rnd := g.rnd
+	if rnd == nil {
+		rnd = rand.New(rand.NewSource(uint64(time.Now().UnixNano())))
+	}
+	dist := distuv.Normal{
+		Mu:    0,
+		Sigma: 1 / math.Sqrt(float64(k)),
+		Src:   rnd,
+	}
+	data := make([]float64, k*rows)
+	for i := range data {
+		data[i] = dist.Rand()
+	}
+	// store the transpose of the k x rows projection matrix for efficient
+	// multiplication with sparse matrices
+	g.projections = mat.NewDense(rows, k, data)
+
+	return g
+}
+
+// Transform applies the transformation, projecting the input matrix into the reduced
+// dimensional subspace.  The transformed matrix will be a dense matrix of shape k x c.
+func (g *GaussianRandomProjection) Transform(m mat.Matrix) (mat.Matrix, error) {
+	if t, isTypeConv := m.(sparse.TypeConverter); isTypeConv {
+		m = t.ToCSR()
+	}
+	// multiply only the non-zero elements of m as (m^T * projections^T)^T
+	return mat.DenseCopyOf(mulSparse(m, g.projections, true).T()), nil
+}
+
+// FitTransform is approximately equivalent to calling Fit() followed by Transform()
+// on the same matrix.  This is a useful shortcut where separate training data is not being
+// used to fit the model i.e. the model is fitted on the fly to the test data.
+// The returned matrix is a dense matrix of shape k x c.
+func (g *GaussianRandomProjection) FitTransform(m mat.Matrix) (mat.Matrix, error) {
+	return g.Fit(m).Transform(m)
+}
+
+// gaussianRandomProjectionVersion is the version of the serialisation format written by
+// Save().
+const gaussianRandomProjectionVersion = 1
+
+// gaussianRandomProjectionHeader is the fixed size portion of a serialised
+// GaussianRandomProjection.
+type gaussianRandomProjectionHeader struct {
+	Version int64
+	K       int64
+	Eps     float64
+}
+
+// Save binary serialises the model, including its hyperparameters, and writes it into
+// w.  This is useful for persisting a trained model to disk so that it may be loaded
+// (using the Load() method) in another context (e.g. production) for reproducible
+// results.
+func (g GaussianRandomProjection) Save(w io.Writer) error {
+	header := gaussianRandomProjectionHeader{
+		Version: gaussianRandomProjectionVersion,
+		K:       int64(g.K),
+		Eps:     g.Eps,
+	}
+	if err := binary.Write(w, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	_, err := g.projections.MarshalBinaryTo(w)
+
+	return err
+}
+
+// Load binary deserialises the previously serialised model into the receiver.  This is
+// useful for loading a previously trained and saved model from another context
+// (e.g. offline training) for use within another context (e.g. production) for
+// reproducible results.  Load should only be performed with trusted data.
+func (g *GaussianRandomProjection) Load(r io.Reader) error {
+	var header gaussianRandomProjectionHeader
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return err
+	}
+	if header.Version != gaussianRandomProjectionVersion {
+		return fmt.Errorf("nlp: Unsupported serialisation version %d", header.Version)
+	}
+	var projections mat.Dense
+	if _, err := projections.UnmarshalBinaryFrom(r); err != nil {
+		return err
+	}
+
+	g.K = int(header.K)
+	g.Eps = header.Eps
+	g.projections = &projections
+
+	return nil
+}
+
 // RRIBasis represents the initial basis for the index/elemental vectors
 // used for Random Reflective Indexing
 type RRIBasis int
@@ -230,7 +509,10 @@ func NewRandomIndexing(k int, density float64) *RandomIndexing {
 // whether the initial, random index/elemental vectors should represent
 // documents (columns) or terms (rows).
 // reflections is the number of additional training cycles to apply
-// to build the elemental vectors.
+// to build the elemental vectors.  The vectors are normalised to unit
+// length before each cycle as described by Cohen et al. in "Reflective
+// Random Indexing and indirect inference: A scalable method for discovery
+// of implied connections" (https://doi.org/10.1016/j.jbi.2009.09.003).
 // Specifying basis == DocBasedRRI and reflections == 0 is equivalent
 // to conventional Random Indexing.
 func NewReflectiveRandomIndexing(k int, basis RRIBasis, reflections int, density float64) *RandomIndexing {
@@ -297,9 +579,11 @@ func (r *RandomIndexing) Fit(m mat.Matrix) Transformer {
 		idxVecs = r.contextualise(m.T(), idxVecs)
 	}
 
+	// normalise the vectors before each reflective training cycle so that each
+	// term/document contributes equally to the next cycle regardless of frequency
 	for i := 0; i < r.Reflections; i++ {
-		idxVecs = r.contextualise(m, idxVecs)
-		idxVecs = r.contextualise(m.T(), idxVecs)
+		idxVecs = r.contextualise(m, normaliseColumns(idxVecs))
+		idxVecs = r.contextualise(m.T(), normaliseColumns(idxVecs))
 	}
 
 	r.components = idxVecs
@@ -324,6 +608,64 @@ func (r *RandomIndexing) Transform(m mat.Matrix) (mat.Matrix, error) {
 	return r.contextualise(m, r.components), nil
 }
 
+// randomIndexingVersion is the version of the serialisation format written by Save().
+const randomIndexingVersion = 1
+
+// randomIndexingHeader is the fixed size portion of a serialised RandomIndexing.
+type randomIndexingHeader struct {
+	Version     int64
+	K           int64
+	Density     float64
+	Type        int64
+	Reflections int64
+}
+
+// Save binary serialises the model, including its hyperparameters, and writes it into
+// w.  This is useful for persisting a trained model to disk so that it may be loaded
+// (using the Load() method) in another context (e.g. production) for reproducible
+// results.
+func (r RandomIndexing) Save(w io.Writer) error {
+	header := randomIndexingHeader{
+		Version:     randomIndexingVersion,
+		K:           int64(r.K),
+		Density:     r.Density,
+		Type:        int64(r.Type),
+		Reflections: int64(r.Reflections),
+	}
+	if err := binary.Write(w, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	_, err := r.components.(*sparse.CSR).MarshalBinaryTo(w)
+
+	return err
+}
+
+// Load binary deserialises the previously serialised model into the receiver.  This is
+// useful for loading a previously trained and saved model from another context
+// (e.g. offline training) for use within another context (e.g. production) for
+// reproducible results.  Load should only be performed with trusted data.
+func (r *RandomIndexing) Load(rd io.Reader) error {
+	var header randomIndexingHeader
+	if err := binary.Read(rd, binary.LittleEndian, &header); err != nil {
+		return err
+	}
+	if header.Version != randomIndexingVersion {
+		return fmt.Errorf("nlp: Unsupported serialisation version %d", header.Version)
+	}
+	var components sparse.CSR
+	if _, err := components.UnmarshalBinaryFrom(rd); err != nil {
+		return err
+	}
+
+	r.K = int(header.K)
+	r.Density = header.Density
+	r.Type = RRIBasis(header.Type)
+	r.Reflections = int(header.Reflections)
+	r.components = &components
+
+	return nil
+}
+
 // contextualise accumulates the vectors vectors for each column in matrix m weighting
 // each row vector in vectors by its corresponding value in column of the matrix
 func (r *RandomIndexing) contextualise(m mat.Matrix, vectors mat.Matrix) mat.Matrix {
@@ -334,6 +676,25 @@ func (r *RandomIndexing) contextualise(m mat.Matrix, vectors mat.Matrix) mat.Mat
 	return &product
 }
 
+// normaliseColumns returns a copy of m with each column scaled to unit (L2) length.
+// Columns of all zeros are left unchanged.
+func normaliseColumns(m mat.Matrix) mat.Matrix {
+	var normalised sparse.CSR
+	normalised.Clone(m.(sparse.TypeConverter).ToCSR())
+	raw := normalised.RawMatrix()
+
+	norms := make([]float64, raw.J)
+	for k, j := range raw.Ind {
+		norms[j] += raw.Data[k] * raw.Data[k]
+	}
+	for k, j := range raw.Ind {
+		if norms[j] != 0 {
+			raw.Data[k] /= math.Sqrt(norms[j])
+		}
+	}
+	return &normalised
+}
+
 // CreateRandomProjectionTransform returns a new random matrix for
 // Random Projections of shape newDims x origDims.  The matrix will
 // be randomly populated using probability distributions where density
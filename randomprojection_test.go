@@ -1,6 +1,8 @@
 package nlp
 
 import (
+	"bytes"
+	"io"
 	"math"
 	"testing"
 
@@ -75,6 +77,68 @@ func TestSignRandomProjection(t *testing.T) {
 	}
 }
 
+func TestJohnsonLindenstraussMinDim(t *testing.T) {
+	tests := []struct {
+		samples  int
+		eps      float64
+		expected int
+	}{
+		{samples: 1000000, eps: 0.5, expected: 664},
+		{samples: 1000000, eps: 0.1, expected: 11842},
+		{samples: 100, eps: 0.1, expected: 3948},
+	}
+
+	for ti, test := range tests {
+		t.Logf("**** Test Run %d.\n", ti+1)
+		if k := JohnsonLindenstraussMinDim(test.samples, test.eps); k != test.expected {
+			t.Errorf("Test %d: Expected %d dimensions but found %d", ti, test.expected, k)
+		}
+	}
+}
+
+func TestGaussianRandomProjection(t *testing.T) {
+	tests := []struct {
+		k        int
+		eps      float64
+		rows     int
+		cols     int
+		expected int
+	}{
+		{k: 300, rows: 1000, cols: 50, expected: 300},
+		{k: 0, eps: 0.5, rows: 1000, cols: 50, expected: 188},
+		{k: 0, eps: 0.5, rows: 1000, cols: 1, expected: 1},
+	}
+
+	for ti, test := range tests {
+		t.Logf("**** Test Run %d.\n", ti+1)
+		matrix := sparse.Random(sparse.CSRFormat, test.rows, test.cols, 0.1).(sparse.TypeConverter).ToCSR()
+
+		transformer := NewGaussianRandomProjection(test.k)
+		if test.eps != 0 {
+			transformer.Eps = test.eps
+		}
+		transformer.rnd = rand.New(rand.NewSource(uint64(0)))
+		reduced, err := transformer.FitTransform(matrix)
+		if err != nil {
+			t.Errorf("Failed to transform matrix because %v\n", err)
+		}
+		if r, c := reduced.Dims(); r != test.expected || c != test.cols {
+			t.Errorf("Test %d: Expected dimensions %d x %d but found %d x %d", ti, test.expected, test.cols, r, c)
+		}
+
+		// pairwise distances should be approximately preserved
+		for j := 1; j < test.cols; j++ {
+			var orig, proj mat.VecDense
+			orig.SubVec(matrix.ToCSC().ColView(0), matrix.ToCSC().ColView(j))
+			proj.SubVec(reduced.(mat.ColViewer).ColView(0), reduced.(mat.ColViewer).ColView(j))
+			ratio := mat.Norm(&proj, 2) / mat.Norm(&orig, 2)
+			if math.Abs(1-ratio) > 0.5 {
+				t.Errorf("Test %d: Expected distance between columns 0 and %d to be preserved but ratio was %f", ti, j, ratio)
+			}
+		}
+	}
+}
+
 func TestRandomProjection(t *testing.T) {
 	tests := []struct {
 		k       int
@@ -137,6 +201,32 @@ func TestRandomProjection(t *testing.T) {
 	}
 }
 
+func TestRandomProjectionAutoDensity(t *testing.T) {
+	rows, k := 900, 200
+	matrix := sparse.Random(sparse.CSRFormat, rows, 50, 0.05).(sparse.TypeConverter).ToCSR()
+
+	transformer := NewRandomProjection(k, 0)
+	transformer.rnd = rand.New(rand.NewSource(uint64(0)))
+	transformer.Fit(matrix)
+
+	// density should be 1/sqrt(rows) with elements of +/- sqrt(sqrt(rows)/k)
+	projections := transformer.projections.(*sparse.CSR)
+	expectedNNZ := float64(k*rows) / math.Sqrt(float64(rows))
+	if nnz := float64(projections.NNZ()); math.Abs(nnz-expectedNNZ)/expectedNNZ > 0.1 {
+		t.Errorf("Expected approximately %f non-zero elements but found %f", expectedNNZ, nnz)
+	}
+	expectedValue := math.Sqrt(math.Sqrt(float64(rows)) / float64(k))
+	projections.DoNonZero(func(i, j int, v float64) {
+		if math.Abs(math.Abs(v)-expectedValue) > 1e-12 {
+			t.Errorf("Expected value of +/-%f but found %f", expectedValue, v)
+		}
+	})
+
+	if r, c := transformer.projections.Dims(); r != k || c != rows {
+		t.Errorf("Expected projection matrix of %d x %d but found %d x %d", k, rows, r, c)
+	}
+}
+
 func TestRandomIndexingFit(t *testing.T) {
 	tests := []struct {
 		k       int
@@ -326,3 +416,109 @@ func TestReflectiveRandomIndexing(t *testing.T) {
 		}
 	}
 }
+
+func TestReflectiveRandomIndexingIndirectInference(t *testing.T) {
+	// terms 0 and 1 never appear together in a document but both appear with term 2
+	// whilst terms 3 and 4 appear in separate documents of their own
+	matrix := mat.NewDense(5, 30, nil)
+	for j := 0; j < 10; j++ {
+		matrix.Set(0, j, 1)
+		matrix.Set(2, j, 1)
+		matrix.Set(1, j+10, 1)
+		matrix.Set(2, j+10, 1)
+		matrix.Set(3, j+20, 1)
+		matrix.Set(4, j+20, 1)
+	}
+
+	tests := []struct {
+		basis       RRIBasis
+		reflections int
+		minSim      float64
+		maxSim      float64
+	}{
+		// conventional random indexing does not capture the indirect association
+		{basis: DocBasedRRI, reflections: 0, minSim: -1, maxSim: 0.3},
+		{basis: TermBasedRRI, reflections: 0, minSim: -1, maxSim: 0.3},
+		// each reflection strengthens the indirect association
+		{basis: DocBasedRRI, reflections: 1, minSim: 0.6, maxSim: 1},
+		{basis: TermBasedRRI, reflections: 1, minSim: 0.4, maxSim: 1},
+		{basis: TermBasedRRI, reflections: 3, minSim: 0.7, maxSim: 1},
+	}
+
+	for ti, test := range tests {
+		t.Logf("**** Test Run %d.\n", ti+1)
+
+		transformer := NewReflectiveRandomIndexing(200, test.basis, test.reflections, 0.05)
+		transformer.rnd = rand.New(rand.NewSource(uint64(0)))
+		transformer.Fit(matrix)
+		terms := transformer.Components().(sparse.TypeConverter).ToCSR()
+
+		indirect := pairwise.CosineSimilarity(terms.RowView(0), terms.RowView(1))
+		if indirect < test.minSim || indirect > test.maxSim {
+			t.Errorf("Test %d: Expected similarity of indirectly associated terms in range %f-%f but found %f", ti, test.minSim, test.maxSim, indirect)
+		}
+		unrelated := pairwise.CosineSimilarity(terms.RowView(0), terms.RowView(3))
+		if unrelated > 0.3 {
+			t.Errorf("Test %d: Expected unrelated terms to be dissimilar but found similarity %f", ti, unrelated)
+		}
+	}
+}
+
+func TestRandomProjectionSaveLoad(t *testing.T) {
+	matrix := mat.NewDense(50, 10, nil)
+	rnd := rand.New(rand.NewSource(uint64(0)))
+	for i := 0; i < 50; i++ {
+		for j := 0; j < 10; j++ {
+			matrix.Set(i, j, rnd.Float64())
+		}
+	}
+
+	type model interface {
+		Transformer
+		Save(io.Writer) error
+		Load(io.Reader) error
+	}
+
+	var tests = []struct {
+		wanted model
+		loaded model
+	}{
+		{wanted: NewSignRandomProjection(16), loaded: &SignRandomProjection{}},
+		{wanted: NewRandomProjection(8, 0.3), loaded: &RandomProjection{}},
+		{wanted: NewRandomProjection(8, 0), loaded: &RandomProjection{}},
+		{wanted: NewGaussianRandomProjection(8), loaded: &GaussianRandomProjection{}},
+		{wanted: NewRandomIndexing(8, 0.3), loaded: &RandomIndexing{}},
+		{wanted: NewReflectiveRandomIndexing(8, TermBasedRRI, 2, 0.3), loaded: &RandomIndexing{}},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		test.wanted.Fit(matrix)
+		var buf bytes.Buffer
+		if err := test.wanted.Save(&buf); err != nil {
+			t.Errorf("Error encoding: %v\n", err)
+			continue
+		}
+		if err := test.loaded.Load(&buf); err != nil {
+			t.Errorf("Error unencoding: %v\n", err)
+			continue
+		}
+
+		wanted, _ := test.wanted.Transform(matrix)
+		result, _ := test.loaded.Transform(matrix)
+		if !mat.Equal(wanted, result) {
+			t.Errorf("Expected loaded model to transform matrix to %v but found %v", mat.Formatted(wanted), mat.Formatted(result))
+		}
+	}
+
+	wanted := NewReflectiveRandomIndexing(8, TermBasedRRI, 2, 0.3)
+	wanted.Fit(matrix)
+	var buf bytes.Buffer
+	wanted.Save(&buf)
+	var loaded RandomIndexing
+	loaded.Load(&buf)
+	if wanted.K != loaded.K || wanted.Density != loaded.Density || wanted.Type != loaded.Type || wanted.Reflections != loaded.Reflections {
+		t.Errorf("Expected hyperparameters %v but found %v", *wanted, loaded)
+	}
+}
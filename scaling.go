@@ -0,0 +1,485 @@
+package nlp
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/james-bowman/sparse"
+	"gonum.org/v1/gonum/mat"
+)
+
+// MaxAbsScaler scales each feature (term) by its maximum absolute value across the
+// training matrix so that all values lie within the range [-1, 1].  As scaling does
+// not shift or centre the data, zero values remain zero and so sparsity is preserved
+// making MaxAbsScaler suitable for scaling sparse term document matrices e.g. before
+// training gradient based models that require bounded feature ranges.
+type MaxAbsScaler struct {
+	// Orientation specifies whether features (terms) are represented by the rows
+	// (the default) or the columns of the matrices supplied to Fit() and Transform().
+	Orientation Orientation
+
+	maxAbs []float64
+}
+
+// NewMaxAbsScaler constructs a new MaxAbsScaler.
+func NewMaxAbsScaler() *MaxAbsScaler {
+	return &MaxAbsScaler{}
+}
+
+// Fit calculates the maximum absolute value of each feature within matrix for use
+// in subsequent calls to Transform().
+func (s *MaxAbsScaler) Fit(matrix mat.Matrix) Transformer {
+	if t, isTypeConv := matrix.(sparse.TypeConverter); isTypeConv {
+		matrix = t.ToCSR()
+	}
+
+	s.maxAbs = make([]float64, numFeatures(matrix, s.Orientation))
+	nonZeroDo(matrix, func(i, j int, v float64) {
+		f := s.Orientation.term(i, j)
+		s.maxAbs[f] = math.Max(s.maxAbs[f], math.Abs(v))
+	})
+
+	return s
+}
+
+// Transform divides each value within matrix by the maximum absolute value of its
+// feature learnt during Fit().  Features that were zero throughout the training
+// matrix are left unscaled.  The returned matrix is a sparse matrix type.
+func (s *MaxAbsScaler) Transform(matrix mat.Matrix) (mat.Matrix, error) {
+	if t, isTypeConv := matrix.(sparse.TypeConverter); isTypeConv {
+		matrix = t.ToCSR()
+	}
+	if f := numFeatures(matrix, s.Orientation); f != len(s.maxAbs) {
+		return nil, fmt.Errorf("nlp: Matrix has %d features but the scaler was fitted with %d", f, len(s.maxAbs))
+	}
+
+	return scaleNonZero(matrix, func(i, j int, v float64) float64 {
+		if maxAbs := s.maxAbs[s.Orientation.term(i, j)]; maxAbs != 0 {
+			return v / maxAbs
+		}
+		return v
+	}), nil
+}
+
+// FeatureDims returns the number of features the scaler was fitted with, which is both
+// the number expected in input matrices and output, or -1 if it has not been fitted.
+func (s *MaxAbsScaler) FeatureDims() (in, out int) {
+	if s.maxAbs == nil {
+		return -1, -1
+	}
+	return len(s.maxAbs), len(s.maxAbs)
+}
+
+// FitTransform is exactly equivalent to calling Fit() followed by Transform() on the
+// same matrix.  This is a convenience where separate training data is not being
+// used to fit the model i.e. the model is fitted on the fly to the test data.
+// The returned matrix is a sparse matrix type.
+func (s *MaxAbsScaler) FitTransform(matrix mat.Matrix) (mat.Matrix, error) {
+	if t, isTypeConv := matrix.(sparse.TypeConverter); isTypeConv {
+		matrix = t.ToCSR()
+	}
+	return s.Fit(matrix).Transform(matrix)
+}
+
+// InverseTransform reverses the scaling of the supplied matrix, multiplying each value
+// by the maximum absolute value of its feature learnt during Fit(), mapping scaled
+// values back to their original values.  The returned matrix is a sparse matrix type.
+func (s *MaxAbsScaler) InverseTransform(matrix mat.Matrix) (mat.Matrix, error) {
+	if f := numFeatures(matrix, s.Orientation); f != len(s.maxAbs) {
+		return nil, fmt.Errorf("nlp: Matrix has %d features but the scaler was fitted with %d", f, len(s.maxAbs))
+	}
+
+	return scaleNonZero(matrix, func(i, j int, v float64) float64 {
+		if maxAbs := s.maxAbs[s.Orientation.term(i, j)]; maxAbs != 0 {
+			return v * maxAbs
+		}
+		return v
+	}), nil
+}
+
+// MinMaxScaler scales each feature (term) to the range [0, 1] according to the
+// minimum and maximum values of the feature across the training matrix i.e. each
+// value v is transformed to (v - min) / (max - min).  Implicit zero values within
+// sparse matrices are taken into account when calculating the minimum and maximum
+// values.  Where the minimum value of any feature is 0, as is typically the case for
+// sparse term frequency matrices, zero values remain zero and the output is sparse.
+// Otherwise zero values are shifted and the output is dense.
+type MinMaxScaler struct {
+	// Orientation specifies whether features (terms) are represented by the rows
+	// (the default) or the columns of the matrices supplied to Fit() and Transform().
+	Orientation Orientation
+
+	min []float64
+	max []float64
+}
+
+// NewMinMaxScaler constructs a new MinMaxScaler.
+func NewMinMaxScaler() *MinMaxScaler {
+	return &MinMaxScaler{}
+}
+
+// Fit calculates the minimum and maximum values of each feature within matrix for
+// use in subsequent calls to Transform().
+func (s *MinMaxScaler) Fit(matrix mat.Matrix) Transformer {
+	if t, isTypeConv := matrix.(sparse.TypeConverter); isTypeConv {
+		matrix = t.ToCSR()
+	}
+	features := numFeatures(matrix, s.Orientation)
+	r, c := matrix.Dims()
+	samples := c
+	if s.Orientation == DocumentsAsRows {
+		samples = r
+	}
+
+	s.min = make([]float64, features)
+	s.max = make([]float64, features)
+	nnz := make([]int, features)
+	for f := range s.min {
+		s.min[f] = math.Inf(1)
+		s.max[f] = math.Inf(-1)
+	}
+	nonZeroDo(matrix, func(i, j int, v float64) {
+		f := s.Orientation.term(i, j)
+		s.min[f] = math.Min(s.min[f], v)
+		s.max[f] = math.Max(s.max[f], v)
+		nnz[f]++
+	})
+	for f := range s.min {
+		// account for implicit zero values
+		if nnz[f] < samples || nnz[f] == 0 {
+			s.min[f] = math.Min(s.min[f], 0)
+			s.max[f] = math.Max(s.max[f], 0)
+		}
+	}
+
+	return s
+}
+
+// Transform scales each value within matrix according to the minimum and maximum
+// values of its feature learnt during Fit().  Features that were constant throughout
+// the training matrix are shifted but not scaled.  The returned matrix is a sparse
+// matrix type if the minimum value of every feature is 0 otherwise it is dense.
+func (s *MinMaxScaler) Transform(matrix mat.Matrix) (mat.Matrix, error) {
+	if t, isTypeConv := matrix.(sparse.TypeConverter); isTypeConv {
+		matrix = t.ToCSR()
+	}
+	if f := numFeatures(matrix, s.Orientation); f != len(s.min) {
+		return nil, fmt.Errorf("nlp: Matrix has %d features but the scaler was fitted with %d", f, len(s.min))
+	}
+
+	scale := func(i, j int, v float64) float64 {
+		f := s.Orientation.term(i, j)
+		if rng := s.max[f] - s.min[f]; rng != 0 {
+			return (v - s.min[f]) / rng
+		}
+		return v - s.min[f]
+	}
+
+	for _, lower := range s.min {
+		if lower != 0 {
+			// zero values will be shifted so the result is dense
+			r, c := matrix.Dims()
+			dense := mat.NewDense(r, c, nil)
+			dense.Apply(func(i, j int, v float64) float64 {
+				return scale(i, j, matrix.At(i, j))
+			}, dense)
+			return dense, nil
+		}
+	}
+
+	return scaleNonZero(matrix, scale), nil
+}
+
+// FeatureDims returns the number of features the scaler was fitted with, which is both
+// the number expected in input matrices and output, or -1 if it has not been fitted.
+func (s *MinMaxScaler) FeatureDims() (in, out int) {
+	if s.min == nil {
+		return -1, -1
+	}
+	return len(s.min), len(s.min)
+}
+
+// FitTransform is exactly equivalent to calling Fit() followed by Transform() on the
+// same matrix.  This is a convenience where separate training data is not being
+// used to fit the model i.e. the model is fitted on the fly to the test data.
+func (s *MinMaxScaler) FitTransform(matrix mat.Matrix) (mat.Matrix, error) {
+	if t, isTypeConv := matrix.(sparse.TypeConverter); isTypeConv {
+		matrix = t.ToCSR()
+	}
+	return s.Fit(matrix).Transform(matrix)
+}
+
+// InverseTransform reverses the scaling of the supplied matrix according to the
+// minimum and maximum values of each feature learnt during Fit(), mapping scaled
+// values back to their original values.  The returned matrix is a sparse matrix type
+// if the minimum value of every feature is 0 otherwise it is dense.
+func (s *MinMaxScaler) InverseTransform(matrix mat.Matrix) (mat.Matrix, error) {
+	if f := numFeatures(matrix, s.Orientation); f != len(s.min) {
+		return nil, fmt.Errorf("nlp: Matrix has %d features but the scaler was fitted with %d", f, len(s.min))
+	}
+
+	unscale := func(i, j int, v float64) float64 {
+		f := s.Orientation.term(i, j)
+		if rng := s.max[f] - s.min[f]; rng != 0 {
+			return v*rng + s.min[f]
+		}
+		return v + s.min[f]
+	}
+
+	for _, lower := range s.min {
+		if lower != 0 {
+			// zero values will be shifted so the result is dense
+			r, c := matrix.Dims()
+			dense := mat.NewDense(r, c, nil)
+			dense.Apply(func(i, j int, v float64) float64 {
+				return unscale(i, j, matrix.At(i, j))
+			}, dense)
+			return dense, nil
+		}
+	}
+
+	return scaleNonZero(matrix, unscale), nil
+}
+
+// numFeatures returns the number of features (terms) represented within matrix
+// for the specified orientation.
+func numFeatures(matrix mat.Matrix, o Orientation) int {
+	r, c := matrix.Dims()
+	if o == DocumentsAsRows {
+		return c
+	}
+	return r
+}
+
+// scaleNonZero returns a sparse CSR matrix containing the result of applying fn to
+// each non-zero element of matrix.  Zero elements remain zero.
+func scaleNonZero(matrix mat.Matrix, fn func(i, j int, v float64) float64) *sparse.CSR {
+	r, c := matrix.Dims()
+	var rows, cols []int
+	var data []float64
+	nonZeroDo(matrix, func(i, j int, v float64) {
+		rows = append(rows, i)
+		cols = append(cols, j)
+		data = append(data, fn(i, j, v))
+	})
+
+	return sparse.NewCOO(r, c, rows, cols, data).ToCSR()
+}
+
+// StandardScaler standardises each feature (term) by removing the mean and/or scaling
+// to unit variance according to the mean and standard deviation of the feature across
+// the training matrix i.e. each value v is transformed to (v - mean) / std.  Implicit
+// zero values within sparse matrices are taken into account when calculating the mean
+// and standard deviation.  As centring shifts zero values and so would destroy the
+// sparsity of term document matrices, it is disabled by default and scaling is
+// performed without converting sparse matrices to dense.
+type StandardScaler struct {
+	// Orientation specifies whether features (terms) are represented by the rows
+	// (the default) or the columns of the matrices supplied to Fit() and Transform().
+	Orientation Orientation
+
+	// Centre, if true, subtracts the mean of each feature from its values.  When
+	// enabled, the output of Transform() is a dense matrix.
+	Centre bool
+
+	// Scale, if true, divides the values of each feature by its standard deviation.
+	Scale bool
+
+	mean []float64
+	std  []float64
+}
+
+// NewStandardScaler constructs a new StandardScaler that scales features to unit
+// variance without centring them so that sparsity is preserved.
+func NewStandardScaler() *StandardScaler {
+	return &StandardScaler{Scale: true}
+}
+
+// Fit calculates the mean and standard deviation of each feature within matrix for use
+// in subsequent calls to Transform().
+func (s *StandardScaler) Fit(matrix mat.Matrix) Transformer {
+	if t, isTypeConv := matrix.(sparse.TypeConverter); isTypeConv {
+		matrix = t.ToCSR()
+	}
+	features := numFeatures(matrix, s.Orientation)
+	r, c := matrix.Dims()
+	samples := c
+	if s.Orientation == DocumentsAsRows {
+		samples = r
+	}
+
+	s.mean = make([]float64, features)
+	s.std = make([]float64, features)
+	nonZeroDo(matrix, func(i, j int, v float64) {
+		f := s.Orientation.term(i, j)
+		s.mean[f] += v
+		s.std[f] += v * v
+	})
+	for f := range s.mean {
+		if samples == 0 {
+			s.std[f] = 1
+			continue
+		}
+		s.mean[f] /= float64(samples)
+		variance := s.std[f]/float64(samples) - s.mean[f]*s.mean[f]
+		s.std[f] = math.Sqrt(math.Max(variance, 0))
+		if s.std[f] == 0 {
+			// leave constant features unscaled
+			s.std[f] = 1
+		}
+	}
+
+	return s
+}
+
+// Transform standardises each value within matrix according to the mean and standard
+// deviation of its feature learnt during Fit().  The returned matrix is a dense matrix
+// if Centre is true otherwise it is a sparse matrix type.
+func (s *StandardScaler) Transform(matrix mat.Matrix) (mat.Matrix, error) {
+	if t, isTypeConv := matrix.(sparse.TypeConverter); isTypeConv {
+		matrix = t.ToCSR()
+	}
+	if f := numFeatures(matrix, s.Orientation); f != len(s.mean) {
+		return nil, fmt.Errorf("nlp: Matrix has %d features but the scaler was fitted with %d", f, len(s.mean))
+	}
+
+	standardise := func(i, j int, v float64) float64 {
+		f := s.Orientation.term(i, j)
+		if s.Centre {
+			v -= s.mean[f]
+		}
+		if s.Scale {
+			v /= s.std[f]
+		}
+		return v
+	}
+
+	if s.Centre {
+		r, c := matrix.Dims()
+		dense := mat.NewDense(r, c, nil)
+		dense.Apply(func(i, j int, v float64) float64 {
+			return standardise(i, j, matrix.At(i, j))
+		}, dense)
+		return dense, nil
+	}
+
+	return scaleNonZero(matrix, standardise), nil
+}
+
+// FeatureDims returns the number of features the scaler was fitted with, which is both
+// the number expected in input matrices and output, or -1 if it has not been fitted.
+func (s *StandardScaler) FeatureDims() (in, out int) {
+	if s.mean == nil {
+		return -1, -1
+	}
+	return len(s.mean), len(s.mean)
+}
+
+// FitTransform is exactly equivalent to calling Fit() followed by Transform() on the
+// same matrix.  This is a convenience where separate training data is not being
+// used to fit the model i.e. the model is fitted on the fly to the test data.
+func (s *StandardScaler) FitTransform(matrix mat.Matrix) (mat.Matrix, error) {
+	if t, isTypeConv := matrix.(sparse.TypeConverter); isTypeConv {
+		matrix = t.ToCSR()
+	}
+	return s.Fit(matrix).Transform(matrix)
+}
+
+// InverseTransform reverses the standardisation of the supplied matrix according to
+// the mean and standard deviation of each feature learnt during Fit(), mapping
+// standardised values back to their original values.  The returned matrix is a dense
+// matrix if Centre is true otherwise it is a sparse matrix type.
+func (s *StandardScaler) InverseTransform(matrix mat.Matrix) (mat.Matrix, error) {
+	if f := numFeatures(matrix, s.Orientation); f != len(s.mean) {
+		return nil, fmt.Errorf("nlp: Matrix has %d features but the scaler was fitted with %d", f, len(s.mean))
+	}
+
+	unstandardise := func(i, j int, v float64) float64 {
+		f := s.Orientation.term(i, j)
+		if s.Scale {
+			v *= s.std[f]
+		}
+		if s.Centre {
+			v += s.mean[f]
+		}
+		return v
+	}
+
+	if s.Centre {
+		r, c := matrix.Dims()
+		dense := mat.NewDense(r, c, nil)
+		dense.Apply(func(i, j int, v float64) float64 {
+			return unstandardise(i, j, matrix.At(i, j))
+		}, dense)
+		return dense, nil
+	}
+
+	return scaleNonZero(matrix, unstandardise), nil
+}
+
+// ClippingTransformer post-processes weighted matrices (e.g. the output of a
+// TfidfTransformer or BM25Transformer within a Pipeline) by flooring tiny weights to
+// zero, removing them from the sparse matrix, and/or clipping weights to a [Min, Max]
+// range.  Flooring re-sparsifies matrices so that noise from terms with near zero
+// weights does not bloat downstream models.  Only non-zero weights are clipped as
+// zero values represent terms absent from a document and so remain zero.
+type ClippingTransformer struct {
+	// Min and Max specify the range to clip weights to.  Clipping is only applied if
+	// Max is greater than Min.
+	Min float64
+	Max float64
+
+	// Threshold specifies the absolute value below which weights are floored to zero.
+	// Flooring is applied before clipping.
+	Threshold float64
+}
+
+// NewClippingTransformer creates a new ClippingTransformer clipping weights to the
+// range [min, max] and flooring weights with an absolute value below threshold to
+// zero.
+func NewClippingTransformer(min, max, threshold float64) *ClippingTransformer {
+	return &ClippingTransformer{
+		Min:       min,
+		Max:       max,
+		Threshold: threshold,
+	}
+}
+
+// Fit is not required for the ClippingTransformer, which requires no training, and so
+// this method does nothing.  It is included for compatibility with other transformers.
+func (c *ClippingTransformer) Fit(matrix mat.Matrix) Transformer {
+	return c
+}
+
+// Transform floors and clips each non-zero value within matrix.  The returned matrix
+// is a sparse matrix type.
+func (c *ClippingTransformer) Transform(matrix mat.Matrix) (mat.Matrix, error) {
+	if t, isTypeConv := matrix.(sparse.TypeConverter); isTypeConv {
+		matrix = t.ToCSR()
+	}
+
+	r, cols := matrix.Dims()
+	var rowInd, colInd []int
+	var data []float64
+	nonZeroDo(matrix, func(i, j int, v float64) {
+		if math.Abs(v) < c.Threshold {
+			return
+		}
+		if c.Max > c.Min {
+			v = math.Max(c.Min, math.Min(c.Max, v))
+		}
+		if v != 0 {
+			rowInd = append(rowInd, i)
+			colInd = append(colInd, j)
+			data = append(data, v)
+		}
+	})
+
+	return sparse.NewCOO(r, cols, rowInd, colInd, data).ToCSR(), nil
+}
+
+// FitTransform is exactly equivalent to calling Fit() followed by Transform() on the
+// same matrix.  The returned matrix is a sparse matrix type.
+func (c *ClippingTransformer) FitTransform(matrix mat.Matrix) (mat.Matrix, error) {
+	return c.Transform(matrix)
+}
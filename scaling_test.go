@@ -0,0 +1,312 @@
+package nlp
+
+import (
+	"math"
+	"testing"
+
+	"github.com/james-bowman/sparse"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestMaxAbsScaler(t *testing.T) {
+	var tests = []struct {
+		orientation Orientation
+		input       mat.Matrix
+		expected    mat.Matrix
+	}{
+		{
+			orientation: TermsAsRows,
+			input:       sparse.NewCOO(3, 3, []int{0, 0, 1, 1, 2}, []int{0, 1, 0, 2, 1}, []float64{2, -4, 3, 1, 5}),
+			expected: mat.NewDense(3, 3, []float64{
+				0.5, -1, 0,
+				1, 0, 1.0 / 3,
+				0, 1, 0,
+			}),
+		},
+		{
+			orientation: DocumentsAsRows,
+			input: mat.NewDense(3, 3, []float64{
+				2, -4, 0,
+				3, 0, 1,
+				0, 5, 0,
+			}),
+			expected: mat.NewDense(3, 3, []float64{
+				2.0 / 3, -0.8, 0,
+				1, 0, 1,
+				0, 1, 0,
+			}),
+		},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+		scaler := NewMaxAbsScaler()
+		scaler.Orientation = test.orientation
+
+		result, err := scaler.FitTransform(test.input)
+		if err != nil {
+			t.Errorf("Failed to transform matrix because %v", err)
+		}
+		if _, isSparse := result.(*sparse.CSR); !isSparse {
+			t.Errorf("Expected sparse CSR result but found %T", result)
+		}
+		if !mat.EqualApprox(test.expected, result, 1e-12) {
+			t.Errorf("Expected result %v but found %v", mat.Formatted(test.expected), mat.Formatted(result))
+		}
+	}
+
+	scaler := NewMaxAbsScaler()
+	scaler.Fit(mat.NewDense(2, 2, nil))
+	if _, err := scaler.Transform(mat.NewDense(3, 2, nil)); err == nil {
+		t.Errorf("Expected error transforming matrix with mismatched features")
+	}
+}
+
+func TestMinMaxScaler(t *testing.T) {
+	var tests = []struct {
+		orientation Orientation
+		input       mat.Matrix
+		test        mat.Matrix
+		expected    mat.Matrix
+		sparse      bool
+	}{
+		{
+			orientation: TermsAsRows,
+			input:       sparse.NewCOO(3, 3, []int{0, 0, 1, 1, 2, 2, 2}, []int{0, 1, 0, 2, 0, 1, 2}, []float64{2, 4, 3, 1, 5, 5, 5}),
+			test: mat.NewDense(3, 2, []float64{
+				1, 0,
+				3, 0,
+				5, 6,
+			}),
+			expected: mat.NewDense(3, 2, []float64{
+				0.25, 0,
+				1, 0,
+				0, 1,
+			}),
+			sparse: false,
+		},
+		{
+			orientation: DocumentsAsRows,
+			input: mat.NewDense(3, 2, []float64{
+				2, 0,
+				4, 1,
+				0, 3,
+			}),
+			test: mat.NewDense(2, 2, []float64{
+				1, 6,
+				0, 0,
+			}),
+			expected: mat.NewDense(2, 2, []float64{
+				0.25, 2,
+				0, 0,
+			}),
+			sparse: true,
+		},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+		scaler := NewMinMaxScaler()
+		scaler.Orientation = test.orientation
+		scaler.Fit(test.input)
+
+		// the training data should be scaled to [0, 1]
+		scaled, err := scaler.Transform(test.input)
+		if err != nil {
+			t.Errorf("Failed to transform matrix because %v", err)
+		}
+		r, c := scaled.Dims()
+		for i := 0; i < r; i++ {
+			for j := 0; j < c; j++ {
+				if v := scaled.At(i, j); v < 0 || v > 1 || math.IsNaN(v) {
+					t.Errorf("Expected value in range [0, 1] at (%d, %d) but found %f", i, j, v)
+				}
+			}
+		}
+
+		result, err := scaler.Transform(test.test)
+		if err != nil {
+			t.Errorf("Failed to transform matrix because %v", err)
+		}
+		if _, isSparse := result.(*sparse.CSR); isSparse != test.sparse {
+			t.Errorf("Expected sparse result: %t but found %T", test.sparse, result)
+		}
+		if !mat.EqualApprox(test.expected, result, 1e-12) {
+			t.Errorf("Expected result %v but found %v", mat.Formatted(test.expected), mat.Formatted(result))
+		}
+	}
+}
+
+func TestStandardScaler(t *testing.T) {
+	input := mat.NewDense(3, 4, []float64{
+		1, 3, 0, 0,
+		2, 2, 2, 2,
+		0, 0, 0, 4,
+	})
+
+	var tests = []struct {
+		centre bool
+		scale  bool
+		sparse bool
+	}{
+		{centre: false, scale: true, sparse: true},
+		{centre: true, scale: false, sparse: false},
+		{centre: true, scale: true, sparse: false},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+		scaler := NewStandardScaler()
+		scaler.Centre = test.centre
+		scaler.Scale = test.scale
+
+		result, err := scaler.FitTransform(sparse.NewCSR(3, 4, []int{0, 2, 6, 7}, []int{0, 1, 0, 1, 2, 3, 3}, []float64{1, 3, 2, 2, 2, 2, 4}))
+		if err != nil {
+			t.Errorf("Failed to transform matrix because %v", err)
+		}
+		if _, isSparse := result.(*sparse.CSR); isSparse != test.sparse {
+			t.Errorf("Expected sparse result: %t but found %T", test.sparse, result)
+		}
+
+		r, c := input.Dims()
+		for i := 0; i < r; i++ {
+			row := mat.Row(nil, i, input)
+			var mean, variance float64
+			for _, v := range row {
+				mean += v
+			}
+			mean /= float64(c)
+			for _, v := range row {
+				variance += (v - mean) * (v - mean)
+			}
+			std := math.Sqrt(variance / float64(c))
+			if std == 0 {
+				std = 1
+			}
+
+			for j, v := range row {
+				e := v
+				if test.centre {
+					e -= mean
+				}
+				if test.scale {
+					e /= std
+				}
+				if a := result.At(i, j); math.Abs(a-e) > 1e-12 {
+					t.Errorf("Expected %f at (%d, %d) but found %f", e, i, j, a)
+				}
+			}
+		}
+	}
+}
+
+func TestClippingTransformer(t *testing.T) {
+	input := mat.NewDense(3, 3, []float64{
+		0.001, 3, 0,
+		-0.5, 0, 1.5,
+		0, -2, 0.2,
+	})
+
+	var tests = []struct {
+		min       float64
+		max       float64
+		threshold float64
+		expected  []float64
+		nnz       int
+	}{
+		{
+			threshold: 0.01,
+			expected: []float64{
+				0, 3, 0,
+				-0.5, 0, 1.5,
+				0, -2, 0.2,
+			},
+			nnz: 5,
+		},
+		{
+			min: -1,
+			max: 1,
+			expected: []float64{
+				0.001, 1, 0,
+				-0.5, 0, 1,
+				0, -1, 0.2,
+			},
+			nnz: 6,
+		},
+		{
+			min:       0,
+			max:       1,
+			threshold: 0.3,
+			expected: []float64{
+				0, 1, 0,
+				0, 0, 1,
+				0, 0, 0,
+			},
+			nnz: 2,
+		},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+		clipper := NewClippingTransformer(test.min, test.max, test.threshold)
+
+		result, err := clipper.FitTransform(input)
+		if err != nil {
+			t.Errorf("Failed to transform matrix because %v", err)
+		}
+		expected := mat.NewDense(3, 3, test.expected)
+		if !mat.Equal(expected, result) {
+			t.Errorf("Expected result %v but found %v", mat.Formatted(expected), mat.Formatted(result))
+		}
+		if nnz := result.(*sparse.CSR).NNZ(); nnz != test.nnz {
+			t.Errorf("Expected %d non-zero values but found %d", test.nnz, nnz)
+		}
+	}
+}
+
+func TestScalerInverseTransform(t *testing.T) {
+	input := mat.NewDense(3, 4, []float64{
+		1, 0, 4, 2,
+		0, 0, 3, 0,
+		-2, 6, 0, 1,
+	})
+	shifted := mat.NewDense(3, 4, []float64{
+		1, 2, 4, 2,
+		3, 5, 3, 4,
+		-2, 6, 1, 1,
+	})
+
+	var tests = []struct {
+		scaler InvertibleTransformer
+		input  mat.Matrix
+	}{
+		{scaler: NewMaxAbsScaler(), input: input},
+		{scaler: &MaxAbsScaler{Orientation: DocumentsAsRows}, input: input},
+		{scaler: NewMinMaxScaler(), input: input},
+		{scaler: NewMinMaxScaler(), input: shifted},
+		{scaler: &MinMaxScaler{Orientation: DocumentsAsRows}, input: shifted},
+		{scaler: NewStandardScaler(), input: input},
+		{scaler: &StandardScaler{Centre: true, Scale: true}, input: shifted},
+		{scaler: &StandardScaler{Orientation: DocumentsAsRows, Centre: true}, input: input},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		scaled, err := test.scaler.FitTransform(test.input)
+		if err != nil {
+			t.Errorf("Failed to fit scaler because %v", err)
+		}
+		result, err := test.scaler.InverseTransform(scaled)
+		if err != nil {
+			t.Errorf("Failed to inverse transform because %v", err)
+		}
+		if !mat.EqualApprox(test.input, result, 1e-12) {
+			t.Errorf("Expected matrix:\n%v\nbut found:\n%v", mat.Formatted(test.input), mat.Formatted(result))
+		}
+
+		if _, err := test.scaler.InverseTransform(mat.NewDense(2, 2, nil)); err == nil {
+			t.Errorf("Expected error inverse transforming matrix with wrong number of features but received nil")
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package nlp
+
+import (
+	"fmt"
+	"sort"
+)
+
+// stopWordLists contains the built in stop word lists keyed by ISO 639-1 language code.
+var stopWordLists = map[string][]string{
+	// Danish
+	"da": {
+		"og", "i", "jeg", "det", "at", "en", "den", "til", "er", "som", "på", "de", "med",
+		"han", "af", "for", "ikke", "der", "var", "mig", "sig", "men", "et", "har", "om", "vi",
+		"min", "havde", "ham", "hun", "nu", "over", "da", "fra", "du", "ud", "sin", "dem",
+		"os", "op", "man", "hans", "hvor", "eller", "hvad", "skal", "selv", "her", "alle",
+		"vil", "blev", "kunne", "ind", "når", "være", "dog", "noget", "ville", "jo", "deres",
+		"efter", "ned", "skulle", "denne", "end", "dette", "mit", "også", "under", "have",
+		"dig", "anden", "hende", "mine", "alt", "meget", "sit", "sine", "vor", "mod", "disse",
+		"hvis", "din", "nogle", "hos", "blive", "mange", "ad", "bliver", "hendes", "været",
+		"thi", "jer", "sådan",
+	},
+	// German
+	"de": {
+		"aber", "alle", "allem", "allen", "aller", "alles", "als", "also", "am", "an", "ander",
+		"andere", "anderem", "anderen", "anderer", "anderes", "anderm", "andern", "anderr",
+		"anders", "auch", "auf", "aus", "bei", "bin", "bis", "bist", "da", "damit", "dann",
+		"der", "den", "des", "dem", "die", "das", "dass", "daß", "derselbe", "derselben",
+		"denselben", "desselben", "demselben", "dieselbe", "dieselben", "dasselbe", "dazu",
+		"dein", "deine", "deinem", "deinen", "deiner", "deines", "denn", "derer", "dessen",
+		"dich", "dir", "du", "dies", "diese", "diesem", "diesen", "dieser", "dieses", "doch",
+		"dort", "durch", "ein", "eine", "einem", "einen", "einer", "eines", "einig", "einige",
+		"einigem", "einigen", "einiger", "einiges", "einmal", "er", "ihn", "ihm", "es",
+		"etwas", "euer", "eure", "eurem", "euren", "eurer", "eures", "für", "gegen", "gewesen",
+		"hab", "habe", "haben", "hat", "hatte", "hatten", "hier", "hin", "hinter", "ich",
+		"mich", "mir", "ihr", "ihre", "ihrem", "ihren", "ihrer", "ihres", "euch", "im", "in",
+		"indem", "ins", "ist", "jede", "jedem", "jeden", "jeder", "jedes", "jene", "jenem",
+		"jenen", "jener", "jenes", "jetzt", "kann", "kein", "keine", "keinem", "keinen",
+		"keiner", "keines", "können", "könnte", "machen", "man", "manche", "manchem",
+		"manchen", "mancher", "manches", "mein", "meine", "meinem", "meinen", "meiner",
+		"meines", "mit", "muss", "musste", "nach", "nicht", "nichts", "noch", "nun", "nur",
+		"ob", "oder", "ohne", "sehr", "sein", "seine", "seinem", "seinen", "seiner", "seines",
+		"selbst", "sich", "sie", "ihnen", "sind", "so", "solche", "solchem", "solchen",
+		"solcher", "solches", "soll", "sollte", "sondern", "sonst", "über", "um", "und", "uns",
+		"unsere", "unserem", "unseren", "unser", "unseres", "unter", "viel", "vom", "von",
+		"vor", "während", "war", "waren", "warst", "was", "weg", "weil", "weiter", "welche",
+		"welchem", "welchen", "welcher", "welches", "wenn", "werde", "werden", "wie", "wieder",
+		"will", "wir", "wird", "wirst", "wo", "wollen", "wollte", "würde", "würden", "zu",
+		"zum", "zur", "zwar", "zwischen",
+	},
+	// English
+	"en": {
+		"a", "about", "above", "across", "after", "afterwards", "again", "against", "all",
+		"almost", "alone", "along", "already", "also", "although", "always", "am", "among",
+		"amongst", "amoungst", "amount", "an", "and", "another", "any", "anyhow", "anyone",
+		"anything", "anyway", "anywhere", "are", "around", "as", "at", "back", "be", "became",
+		"because", "become", "becomes", "becoming", "been", "before", "beforehand", "behind",
+		"being", "below", "beside", "besides", "between", "beyond", "bill", "both", "bottom",
+		"but", "by", "call", "can", "cannot", "cant", "co", "con", "could", "couldnt", "cry",
+		"de", "describe", "detail", "do", "done", "down", "due", "during", "each", "eg",
+		"eight", "either", "eleven", "else", "elsewhere", "empty", "enough", "etc", "even",
+		"ever", "every", "everyone", "everything", "everywhere", "except", "few", "fifteen",
+		"fify", "fill", "find", "fire", "first", "five", "for", "former", "formerly", "forty",
+		"found", "four", "from", "front", "full", "further", "get", "give", "go", "had", "has",
+		"hasnt", "have", "he", "hence", "her", "here", "hereafter", "hereby", "herein",
+		"hereupon", "hers", "herself", "him", "himself", "his", "how", "however", "hundred",
+		"ie", "if", "in", "inc", "indeed", "interest", "into", "is", "it", "its", "itself",
+		"keep", "last", "latter", "latterly", "least", "less", "ltd", "made", "many", "may",
+		"me", "meanwhile", "might", "mill", "mine", "more", "moreover", "most", "mostly",
+		"move", "much", "must", "my", "myself", "name", "namely", "neither", "never",
+		"nevertheless", "next", "nine", "no", "nobody", "none", "noone", "nor", "not",
+		"nothing", "now", "nowhere", "of", "off", "often", "on", "once", "one", "only", "onto",
+		"or", "other", "others", "otherwise", "our", "ours", "ourselves", "out", "over", "own",
+		"part", "per", "perhaps", "please", "put", "rather", "re", "same", "see", "seem",
+		"seemed", "seeming", "seems", "serious", "several", "she", "should", "show", "side",
+		"since", "sincere", "six", "sixty", "so", "some", "somehow", "someone", "something",
+		"sometime", "sometimes", "somewhere", "still", "such", "system", "take", "ten", "than",
+		"that", "the", "their", "them", "themselves", "then", "thence", "there", "thereafter",
+		"thereby", "therefore", "therein", "thereupon", "these", "they", "thickv", "thin",
+		"third", "this", "those", "though", "three", "through", "throughout", "thru", "thus",
+		"to", "together", "too", "top", "toward", "towards", "twelve", "twenty", "two", "un",
+		"under", "until", "up", "upon", "us", "very", "via", "was", "we", "well", "were",
+		"what", "whatever", "when", "whence", "whenever", "where", "whereafter", "whereas",
+		"whereby", "wherein", "whereupon", "wherever", "whether", "which", "while", "whither",
+		"who", "whoever", "whole", "whom", "whose", "why", "will", "with", "within", "without",
+		"would", "yet", "you", "your", "yours", "yourself", "yourselves",
+	},
+	// Spanish
+	"es": {
+		"de", "la", "que", "el", "en", "y", "a", "los", "del", "se", "las", "por", "un",
+		"para", "con", "no", "una", "su", "al", "lo", "como", "más", "pero", "sus", "le", "ya",
+		"o", "este", "sí", "porque", "esta", "entre", "cuando", "muy", "sin", "sobre",
+		"también", "me", "hasta", "hay", "donde", "quien", "desde", "todo", "nos", "durante",
+		"todos", "uno", "les", "ni", "contra", "otros", "ese", "eso", "ante", "ellos", "e",
+		"esto", "mí", "antes", "algunos", "qué", "unos", "yo", "otro", "otras", "otra", "él",
+		"tanto", "esa", "estos", "mucho", "quienes", "nada", "muchos", "cual", "poco", "ella",
+		"estar", "estas", "algunas", "algo", "nosotros", "mi", "mis", "tú", "te", "ti", "tu",
+		"tus", "ellas", "nosotras", "vosotros", "vosotras", "os", "mío", "mía", "míos", "mías",
+		"tuyo", "tuya", "tuyos", "tuyas", "suyo", "suya", "suyos", "suyas", "nuestro",
+		"nuestra", "nuestros", "nuestras", "vuestro", "vuestra", "vuestros", "vuestras",
+		"esos", "esas", "estoy", "estás", "está", "estamos", "estáis", "están", "esté",
+		"estés", "estemos", "estéis", "estén", "estaré", "estarás", "estará", "estaremos",
+		"estaréis", "estarán", "estaba", "estabas", "estábamos", "estabais", "estaban",
+		"estuve", "estuviste", "estuvo", "estuvimos", "estuvisteis", "estuvieron", "he", "has",
+		"ha", "hemos", "habéis", "han", "haya", "hayas", "hayamos", "hayáis", "hayan", "habré",
+		"habrás", "habrá", "habremos", "habréis", "habrán", "había", "habías", "habíamos",
+		"habíais", "habían", "hube", "hubo", "soy", "eres", "es", "somos", "sois", "son",
+		"sea", "seas", "seamos", "seáis", "sean", "seré", "serás", "será", "seremos", "seréis",
+		"serán", "era", "eras", "éramos", "erais", "eran", "fui", "fuiste", "fue", "fuimos",
+		"fuisteis", "fueron", "tengo", "tienes", "tiene", "tenemos", "tenéis", "tienen",
+		"tenga", "tengas", "tengamos", "tengáis", "tengan", "tenía", "tenías", "teníamos",
+		"teníais", "tenían", "tuve", "tuvo",
+	},
+	// Finnish
+	"fi": {
+		"olla", "olen", "olet", "on", "olemme", "olette", "ovat", "ole", "oli", "olisi",
+		"olisit", "olisin", "olisimme", "olisitte", "olisivat", "olit", "olin", "olimme",
+		"olitte", "olivat", "ollut", "olleet", "en", "et", "ei", "emme", "ette", "eivät",
+		"minä", "minun", "minut", "minua", "minussa", "minusta", "minuun", "minulla",
+		"minulta", "minulle", "sinä", "sinun", "sinut", "sinua", "sinussa", "sinusta",
+		"sinuun", "sinulla", "sinulta", "sinulle", "hän", "hänen", "hänet", "häntä", "hänessä",
+		"hänestä", "häneen", "hänellä", "häneltä", "hänelle", "me", "meidän", "meidät",
+		"meitä", "meissä", "meistä", "meihin", "meillä", "meiltä", "meille", "te", "teidän",
+		"teidät", "teitä", "teissä", "teistä", "teihin", "teillä", "teiltä", "teille", "he",
+		"heidän", "heidät", "heitä", "heissä", "heistä", "heihin", "heillä", "heiltä",
+		"heille", "tämä", "tämän", "tätä", "tässä", "tästä", "tähän", "tällä", "tältä",
+		"tälle", "tänä", "täksi", "tuo", "tuon", "tuota", "tuossa", "tuosta", "tuohon",
+		"tuolla", "tuolta", "tuolle", "tuona", "tuoksi", "se", "sen", "sitä", "siinä", "siitä",
+		"siihen", "sillä", "siltä", "sille", "siksi", "nämä", "näiden", "näitä", "näissä",
+		"näistä", "näihin", "näillä", "näiltä", "näille", "ninä", "niiksi", "mikä", "mitä",
+		"joka", "jotka", "että", "ja", "jos", "koska", "kuin", "mutta", "niin", "sekä", "tai",
+		"vaan", "vai", "vaikka", "kanssa", "mukaan", "noin", "poikki", "yli", "kun", "nyt",
+		"itse",
+	},
+	// French
+	"fr": {
+		"au", "aux", "avec", "ce", "ces", "dans", "de", "des", "du", "elle", "en", "et", "eux",
+		"il", "ils", "je", "la", "le", "les", "leur", "lui", "ma", "mais", "me", "même", "mes",
+		"moi", "mon", "ne", "nos", "notre", "nous", "on", "ou", "par", "pas", "pour", "qu",
+		"que", "qui", "sa", "se", "ses", "son", "sur", "ta", "te", "tes", "toi", "ton", "tu",
+		"un", "une", "vos", "votre", "vous", "c", "d", "j", "l", "à", "m", "n", "s", "t", "y",
+		"été", "étée", "étées", "étés", "étant", "étante", "étants", "étantes", "suis", "es",
+		"est", "sommes", "êtes", "sont", "serai", "seras", "sera", "serons", "serez", "seront",
+		"serais", "serait", "serions", "seriez", "seraient", "étais", "était", "étions",
+		"étiez", "étaient", "fus", "fut", "fûmes", "fûtes", "furent", "sois", "soit", "soyons",
+		"soyez", "soient", "fusse", "fusses", "fût", "fussions", "fussiez", "fussent", "ayant",
+		"ayante", "ayantes", "ayants", "eu", "eue", "eues", "eus", "ai", "as", "avons", "avez",
+		"ont", "aurai", "auras", "aura", "aurons", "aurez", "auront", "aurais", "aurait",
+		"aurions", "auriez", "auraient", "avais", "avait", "avions", "aviez", "avaient", "eut",
+		"eûmes", "eûtes", "eurent", "aie", "aies", "ait", "ayons", "ayez", "aient", "eusse",
+		"eusses", "eût", "eussions", "eussiez", "eussent",
+	},
+	// Italian
+	"it": {
+		"ad", "al", "allo", "ai", "agli", "all", "agl", "alla", "alle", "con", "col", "coi",
+		"da", "dal", "dallo", "dai", "dagli", "dall", "dagl", "dalla", "dalle", "di", "del",
+		"dello", "dei", "degli", "dell", "degl", "della", "delle", "in", "nel", "nello", "nei",
+		"negli", "nell", "negl", "nella", "nelle", "su", "sul", "sullo", "sui", "sugli",
+		"sull", "sugl", "sulla", "sulle", "per", "tra", "contro", "io", "tu", "lui", "lei",
+		"noi", "voi", "loro", "mio", "mia", "miei", "mie", "tuo", "tua", "tuoi", "tue", "suo",
+		"sua", "suoi", "sue", "nostro", "nostra", "nostri", "nostre", "vostro", "vostra",
+		"vostri", "vostre", "mi", "ti", "ci", "vi", "lo", "la", "li", "le", "gli", "ne", "il",
+		"un", "uno", "una", "ma", "ed", "se", "perché", "anche", "come", "dov", "dove", "che",
+		"chi", "cui", "non", "più", "quale", "quanto", "quanti", "quanta", "quante", "quello",
+		"quelli", "quella", "quelle", "questo", "questi", "questa", "queste", "si", "tutto",
+		"tutti", "a", "c", "e", "i", "l", "o", "ho", "hai", "ha", "abbiamo", "avete", "hanno",
+		"abbia", "abbiate", "abbiano", "avrò", "avrai", "avrà", "avremo", "avrete", "avranno",
+		"avrei", "avresti", "avrebbe", "avremmo", "avreste", "avrebbero", "avevo", "avevi",
+		"aveva", "avevamo", "avevate", "avevano", "ebbi", "avesti", "ebbe", "avemmo", "aveste",
+		"ebbero", "sono", "sei", "è", "siamo", "siete", "sia", "siate", "siano", "sarò",
+		"sarai", "sarà", "saremo", "sarete", "saranno", "sarei", "saresti", "sarebbe",
+		"saremmo", "sareste", "sarebbero", "ero", "eri", "era", "eravamo", "eravate", "erano",
+		"fui", "fosti", "fu", "fummo", "foste", "furono", "fossi", "fosse", "fossimo",
+		"fossero", "essendo", "stato", "stata", "stati", "state",
+	},
+	// Dutch
+	"nl": {
+		"de", "en", "van", "ik", "te", "dat", "die", "in", "een", "hij", "het", "niet", "zijn",
+		"is", "was", "op", "aan", "met", "als", "voor", "had", "er", "maar", "om", "hem",
+		"dan", "zou", "of", "wat", "mijn", "men", "dit", "zo", "door", "over", "ze", "zich",
+		"bij", "ook", "tot", "je", "mij", "uit", "der", "daar", "haar", "naar", "heb", "hoe",
+		"heeft", "hebben", "deze", "u", "want", "nog", "zal", "me", "zij", "nu", "ge", "geen",
+		"omdat", "iets", "worden", "toch", "al", "waren", "veel", "meer", "doen", "toen",
+		"moet", "ben", "zonder", "kan", "hun", "dus", "alles", "onder", "ja", "eens", "hier",
+		"wie", "werd", "altijd", "doch", "wordt", "wezen", "kunnen", "ons", "zelf", "tegen",
+		"na", "reeds", "wil", "kon", "niets", "uw", "iemand", "geweest", "andere",
+	},
+	// Norwegian
+	"no": {
+		"og", "i", "jeg", "det", "at", "en", "et", "den", "til", "er", "som", "på", "de",
+		"med", "han", "av", "ikke", "der", "så", "var", "meg", "seg", "men", "ett", "har",
+		"om", "vi", "min", "mitt", "ha", "hadde", "hun", "nå", "over", "da", "ved", "fra",
+		"du", "ut", "sin", "dem", "oss", "opp", "man", "kan", "hans", "hvor", "eller", "hva",
+		"skal", "selv", "sjøl", "her", "alle", "vil", "bli", "ble", "blitt", "kunne", "inn",
+		"når", "være", "kom", "noen", "noe", "ville", "dere", "deres", "kun", "ja", "etter",
+		"ned", "skulle", "denne", "for", "deg", "si", "sine", "sitt", "mot", "å", "meget",
+		"hvorfor", "dette", "disse", "uten", "hvordan", "ingen", "din", "ditt", "blir",
+		"samme", "hvilken", "hvilke", "sånn", "inni", "mellom", "vår", "hver", "hvem", "vors",
+		"hvis", "både", "bare", "enn", "fordi", "før", "mange", "også", "slik", "vært",
+		"begge", "siden",
+	},
+	// Portuguese
+	"pt": {
+		"a", "à", "ao", "aos", "aquela", "aquelas", "aquele", "aqueles", "aquilo", "as", "às",
+		"até", "com", "como", "da", "das", "de", "dela", "delas", "dele", "deles", "depois",
+		"do", "dos", "e", "é", "ela", "elas", "ele", "eles", "em", "entre", "era", "eram",
+		"essa", "essas", "esse", "esses", "esta", "está", "estão", "estas", "estava",
+		"estavam", "este", "estes", "eu", "foi", "fomos", "for", "foram", "fosse", "fossem",
+		"fui", "há", "isso", "isto", "já", "lhe", "lhes", "mais", "mas", "me", "mesmo", "meu",
+		"meus", "minha", "minhas", "muito", "na", "não", "nas", "nem", "no", "nos", "nós",
+		"nossa", "nossas", "nosso", "nossos", "num", "numa", "o", "os", "ou", "para", "pela",
+		"pelas", "pelo", "pelos", "por", "qual", "quando", "que", "quem", "se", "seja", "sem",
+		"ser", "será", "seu", "seus", "só", "sua", "suas", "também", "te", "tem", "têm",
+		"tinha", "tu", "tua", "tuas", "um", "uma", "você", "vocês", "vos",
+	},
+	// Swedish
+	"sv": {
+		"och", "det", "att", "i", "en", "jag", "hon", "som", "han", "på", "den", "med", "var",
+		"sig", "för", "så", "till", "är", "men", "ett", "om", "hade", "de", "av", "icke",
+		"mig", "du", "henne", "då", "sin", "nu", "har", "inte", "hans", "honom", "skulle",
+		"hennes", "där", "min", "man", "ej", "vid", "kunde", "något", "från", "ut", "när",
+		"efter", "upp", "vi", "dem", "vara", "vad", "över", "än", "dig", "kan", "sina", "här",
+		"ha", "mot", "alla", "under", "någon", "eller", "allt", "mycket", "sedan", "ju",
+		"denna", "själv", "detta", "åt", "utan", "varit", "hur", "ingen", "mitt", "ni", "bli",
+		"blev", "oss", "din", "dessa", "några", "deras", "blir", "mina", "samma", "vilken",
+		"er", "sådan", "vår", "blivit", "dess", "inom", "mellan", "sådant", "varför", "varje",
+		"vilka", "ditt", "vem", "vilket", "sitta", "sådana", "vart", "dina", "vars", "vårt",
+		"våra", "ert", "era", "vilkas",
+	},
+}
+
+// StopWords returns the built in list of stop words for the language identified by
+// the ISO 639-1 code lang (e.g. "en", "fr" or "de") merged with any additional,
+// user supplied, words.  The returned slice may be passed to the constructors of
+// vectorisers and tokenisers e.g.
+//
+//	stop, err := StopWords("fr", "voilà")
+//	vectoriser := NewCountVectoriser(stop...)
+//
+// An error is returned if there is no stop word list available for the specified
+// language.  The languages available can be found by calling StopWordLanguages().
+func StopWords(lang string, additional ...string) ([]string, error) {
+	words, ok := stopWordLists[lang]
+	if !ok {
+		return nil, fmt.Errorf("nlp: No stop words available for language '%s'", lang)
+	}
+
+	merged := make([]string, len(words), len(words)+len(additional))
+	copy(merged, words)
+	return append(merged, additional...), nil
+}
+
+// StopWordLanguages returns the ISO 639-1 codes of all the languages for which
+// built in stop word lists are available, in alphabetical order.
+func StopWordLanguages() []string {
+	langs := make([]string, 0, len(stopWordLists))
+	for lang := range stopWordLists {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
@@ -0,0 +1,77 @@
+package nlp
+
+import (
+	"testing"
+)
+
+func TestStopWords(t *testing.T) {
+	var tests = []struct {
+		lang       string
+		additional []string
+		contains   []string
+		err        bool
+	}{
+		{lang: "en", contains: []string{"the", "and", "yourselves"}},
+		{lang: "fr", contains: []string{"le", "été"}},
+		{lang: "de", additional: []string{"bitte"}, contains: []string{"und", "über", "bitte"}},
+		{lang: "xx", err: true},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		words, err := StopWords(test.lang, test.additional...)
+		if test.err {
+			if err == nil {
+				t.Errorf("Expected error for language %q but received none", test.lang)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Failed to retrieve stop words for %q because %v", test.lang, err)
+			continue
+		}
+
+		set := make(map[string]bool)
+		for _, word := range words {
+			set[word] = true
+		}
+		for _, word := range test.contains {
+			if !set[word] {
+				t.Errorf("Expected stop words for %q to contain %q", test.lang, word)
+			}
+		}
+	}
+}
+
+func TestStopWordsVectoriser(t *testing.T) {
+	stop, err := StopWords("fr", "chat")
+	if err != nil {
+		t.Fatalf("Failed to retrieve stop words because %v", err)
+	}
+
+	vectoriser := NewCountVectoriser(stop...)
+	vectoriser.Fit("Le chat est sur la table", "Nous avons mangé à la maison")
+
+	for _, word := range []string{"le", "est", "sur", "la", "nous", "avons", "à", "chat"} {
+		if _, exists := vectoriser.Vocabulary[word]; exists {
+			t.Errorf("Expected stop word %q to be removed but found in vocabulary %v", word, vectoriser.Vocabulary)
+		}
+	}
+	if len(vectoriser.Vocabulary) != 3 {
+		t.Errorf("Expected vocabulary of size 3 but found %v", vectoriser.Vocabulary)
+	}
+}
+
+func TestStopWordLanguages(t *testing.T) {
+	langs := StopWordLanguages()
+
+	if len(langs) != len(stopWordLists) {
+		t.Errorf("Expected %d languages but found %v", len(stopWordLists), langs)
+	}
+	for _, lang := range langs {
+		if len(stopWordLists[lang]) == 0 {
+			t.Errorf("Expected stop words for language %q", lang)
+		}
+	}
+}
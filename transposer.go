@@ -37,3 +37,9 @@ func (t *TransposeTransformer) FitTransform(matrix mat.Matrix) (mat.Matrix, erro
 	}
 	return t.Fit(matrix).Transform(matrix)
 }
+
+// InverseTransform transposes the matrix back and returns the result.  As transposition
+// is its own inverse, this is exactly equivalent to Transform().
+func (t *TransposeTransformer) InverseTransform(matrix mat.Matrix) (mat.Matrix, error) {
+	return t.Transform(matrix)
+}
@@ -0,0 +1,425 @@
+package nlp
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+)
+
+// TSNE implements t-distributed Stochastic Neighbour Embedding (t-SNE) for visualising
+// high dimensional data, such as document vectors or topic distributions, by projecting
+// them into 2 or 3 dimensions suitable for plotting.  Points that are close together
+// in the original space are placed close together in the projection.  The
+// implementation uses the Barnes-Hut approximation described in van der Maaten,
+// "Accelerating t-SNE using Tree-Based Algorithms" (http://jmlr.org/papers/v15/vandermaaten14a.html)
+// to compute the gradients in O(N log N) time rather than O(N^2).  Unlike the other
+// dimensionality reduction transformers, t-SNE does not learn a mapping that can be
+// applied to new data so only FitTransform() is provided.
+type TSNE struct {
+	// K is the number of dimensions of the projection (typically 2 or 3)
+	K int
+
+	// Perplexity is the effective number of nearest neighbours considered for each
+	// point.  Larger values emphasise the global structure of the data at the expense
+	// of the local structure.  Perplexity must be less than the number of points.
+	Perplexity float64
+
+	// Iterations is the number of gradient descent iterations
+	Iterations int
+
+	// LearningRate is the gradient descent learning rate
+	LearningRate float64
+
+	// EarlyExaggeration is the factor the affinities between points in the input space
+	// are multiplied by during the first ExaggerationIterations iterations, encouraging
+	// the formation of tight, well separated, clusters
+	EarlyExaggeration      float64
+	ExaggerationIterations int
+
+	// Theta controls the accuracy of the Barnes-Hut approximation.  Smaller values are
+	// more accurate but slower.  If Theta is 0 then the gradients are computed exactly.
+	Theta float64
+
+	// Rnd is the random number generator used to initialise the projection
+	Rnd *rand.Rand
+}
+
+// NewTSNE creates a new TSNE transformer projecting into k dimensions with default
+// values of 30 for Perplexity, 1000 Iterations, a LearningRate of 200, an
+// EarlyExaggeration of 12 for the first 250 iterations and a Theta of 0.5.
+func NewTSNE(k int) *TSNE {
+	return &TSNE{
+		K:                      k,
+		Perplexity:             30,
+		Iterations:             1000,
+		LearningRate:           200,
+		EarlyExaggeration:      12,
+		ExaggerationIterations: 250,
+		Theta:                  0.5,
+		Rnd:                    rand.New(rand.NewSource(uint64(time.Now().UnixNano()))),
+	}
+}
+
+// FitTransform projects the points represented by the columns of matrix m into K
+// dimensions.  The returned matrix is a dense matrix of shape K x C where C is the
+// number of columns (points) in m.
+func (t *TSNE) FitTransform(m mat.Matrix) (mat.Matrix, error) {
+	_, n := m.Dims()
+	if t.Perplexity >= float64(n) {
+		return nil, fmt.Errorf("nlp: Perplexity (%f) must be less than the number of points (%d)", t.Perplexity, n)
+	}
+
+	p := t.affinities(m)
+
+	y := make([]float64, n*t.K)
+	for i := range y {
+		y[i] = t.Rnd.NormFloat64() * 1e-4
+	}
+	update := make([]float64, len(y))
+	gains := make([]float64, len(y))
+	for i := range gains {
+		gains[i] = 1
+	}
+	grad := make([]float64, len(y))
+
+	for iter := 0; iter < t.Iterations; iter++ {
+		exaggeration, momentum := 1.0, 0.8
+		if iter < t.ExaggerationIterations {
+			exaggeration, momentum = t.EarlyExaggeration, 0.5
+		}
+
+		t.gradient(p, y, exaggeration, grad)
+
+		for i := range y {
+			if (grad[i] > 0) != (update[i] > 0) {
+				gains[i] += 0.2
+			} else {
+				gains[i] *= 0.8
+			}
+			if gains[i] < 0.01 {
+				gains[i] = 0.01
+			}
+			update[i] = momentum*update[i] - t.LearningRate*gains[i]*grad[i]
+			y[i] += update[i]
+		}
+		t.centre(y)
+	}
+
+	r := mat.NewDense(t.K, n, nil)
+	for i := 0; i < n; i++ {
+		for d := 0; d < t.K; d++ {
+			r.Set(d, i, y[i*t.K+d])
+		}
+	}
+	return r, nil
+}
+
+// tsneNeighbour is a non-zero, symmetric, affinity between 2 points in the input space.
+type tsneNeighbour struct {
+	index int
+	p     float64
+}
+
+// affinities returns the joint probabilities of points picking each other as
+// neighbours in the input space.  Probabilities are only computed for the
+// 3 * Perplexity nearest neighbours of each point, all others being treated as 0.
+func (t *TSNE) affinities(m mat.Matrix) [][]tsneNeighbour {
+	_, n := m.Dims()
+	x := mat.DenseCopyOf(m.T())
+
+	k := int(3 * t.Perplexity)
+	if k > n-1 {
+		k = n - 1
+	}
+
+	neighbours := make([][]int, n)
+	cond := make([]map[int]float64, n)
+	dists := make([]float64, n)
+	order := make([]int, n)
+	for i := 0; i < n; i++ {
+		xi := x.RawRowView(i)
+		for j := 0; j < n; j++ {
+			var d float64
+			for f, v := range x.RawRowView(j) {
+				d += (xi[f] - v) * (xi[f] - v)
+			}
+			dists[j] = d
+			order[j] = j
+		}
+		dists[i] = math.Inf(1)
+		sort.Slice(order, func(a, b int) bool { return dists[order[a]] < dists[order[b]] })
+
+		neighbours[i] = append([]int(nil), order[:k]...)
+		probs := t.conditional(dists, neighbours[i])
+		cond[i] = make(map[int]float64, k)
+		for a, j := range neighbours[i] {
+			cond[i][j] = probs[a]
+		}
+	}
+
+	// symmetrise the conditional probabilities p(j|i) into joint probabilities
+	p := make([][]tsneNeighbour, n)
+	for i := range cond {
+		for _, j := range neighbours[i] {
+			pji := cond[i][j]
+			pij, reverse := cond[j][i]
+			if reverse && j < i {
+				// already added when processing j
+				continue
+			}
+			v := (pji + pij) / float64(2*n)
+			p[i] = append(p[i], tsneNeighbour{index: j, p: v})
+			p[j] = append(p[j], tsneNeighbour{index: i, p: v})
+		}
+	}
+	return p
+}
+
+// conditional returns the conditional probabilities p(j|i) of a point picking each of
+// its neighbours, given their squared distances, using a Gaussian kernel whose
+// precision is found by binary search such that the perplexity of the distribution
+// matches Perplexity.
+func (t *TSNE) conditional(dists []float64, neighbours []int) []float64 {
+	probs := make([]float64, len(neighbours))
+	target := math.Log(t.Perplexity)
+	beta, lo, hi := 1.0, 0.0, math.Inf(1)
+
+	for iter := 0; iter < 200; iter++ {
+		var sum, weighted float64
+		for a, j := range neighbours {
+			probs[a] = math.Exp(-beta * dists[j])
+			sum += probs[a]
+			weighted += dists[j] * probs[a]
+		}
+		if sum == 0 {
+			sum = math.SmallestNonzeroFloat64
+		}
+		entropy := math.Log(sum) + beta*weighted/sum
+		for a := range probs {
+			probs[a] /= sum
+		}
+
+		diff := entropy - target
+		if math.Abs(diff) < 1e-5 {
+			break
+		}
+		if diff > 0 {
+			lo = beta
+			if math.IsInf(hi, 1) {
+				beta *= 2
+			} else {
+				beta = (beta + hi) / 2
+			}
+		} else {
+			hi = beta
+			beta = (beta + lo) / 2
+		}
+	}
+	return probs
+}
+
+// gradient computes the gradient of the Kullback-Leibler divergence between the joint
+// probabilities p in the input space and those of the projection y into grad.
+func (t *TSNE) gradient(p [][]tsneNeighbour, y []float64, exaggeration float64, grad []float64) {
+	n := len(p)
+	neg := make([]float64, len(y))
+
+	// repulsive forces between all points, approximated using Barnes-Hut
+	var sumQ float64
+	if t.Theta > 0 {
+		tree := newSPTree(y, t.K)
+		for i := 0; i < n; i++ {
+			sumQ += tree.repulse(y[i*t.K:(i+1)*t.K], i, t.Theta, neg[i*t.K:(i+1)*t.K])
+		}
+	} else {
+		for i := 0; i < n; i++ {
+			yi := y[i*t.K : (i+1)*t.K]
+			for j := 0; j < n; j++ {
+				if i == j {
+					continue
+				}
+				yj := y[j*t.K : (j+1)*t.K]
+				q := 1 / (1 + sqDist(yi, yj))
+				sumQ += q
+				for d := range yi {
+					neg[i*t.K+d] += q * q * (yi[d] - yj[d])
+				}
+			}
+		}
+	}
+
+	// attractive forces between neighbouring points
+	for i := 0; i < n; i++ {
+		yi := y[i*t.K : (i+1)*t.K]
+		gi := grad[i*t.K : (i+1)*t.K]
+		for d := range gi {
+			gi[d] = -neg[i*t.K+d] / sumQ
+		}
+		for _, nb := range p[i] {
+			yj := y[nb.index*t.K : (nb.index+1)*t.K]
+			mult := exaggeration * nb.p / (1 + sqDist(yi, yj))
+			for d := range gi {
+				gi[d] += mult * (yi[d] - yj[d])
+			}
+		}
+		for d := range gi {
+			gi[d] *= 4
+		}
+	}
+}
+
+// centre translates the projection so that its mean is at the origin.
+func (t *TSNE) centre(y []float64) {
+	n := len(y) / t.K
+	for d := 0; d < t.K; d++ {
+		var mean float64
+		for i := 0; i < n; i++ {
+			mean += y[i*t.K+d]
+		}
+		mean /= float64(n)
+		for i := 0; i < n; i++ {
+			y[i*t.K+d] -= mean
+		}
+	}
+}
+
+// sqDist returns the squared Euclidean distance between a and b.
+func sqDist(a, b []float64) float64 {
+	var d float64
+	for i, v := range a {
+		d += (v - b[i]) * (v - b[i])
+	}
+	return d
+}
+
+// spTree is a space partitioning tree (a quadtree in 2 dimensions, an octree in 3)
+// used to summarise the points within each cell by their centre of mass for the
+// Barnes-Hut approximation.
+type spTree struct {
+	centre   []float64
+	width    []float64
+	com      []float64
+	count    int
+	points   []int
+	children []*spTree
+}
+
+// newSPTree builds a new space partitioning tree over the points y of dims dimensions.
+func newSPTree(y []float64, dims int) *spTree {
+	lo, hi := make([]float64, dims), make([]float64, dims)
+	for d := range lo {
+		lo[d], hi[d] = math.Inf(1), math.Inf(-1)
+	}
+	for i := 0; i < len(y); i += dims {
+		for d := 0; d < dims; d++ {
+			lo[d] = math.Min(lo[d], y[i+d])
+			hi[d] = math.Max(hi[d], y[i+d])
+		}
+	}
+	centre, width := make([]float64, dims), make([]float64, dims)
+	for d := range centre {
+		centre[d] = (lo[d] + hi[d]) / 2
+		width[d] = (hi[d]-lo[d])/2 + 1e-5
+	}
+
+	tree := &spTree{centre: centre, width: width, com: make([]float64, dims)}
+	for i := 0; i < len(y)/dims; i++ {
+		tree.insert(y, dims, i)
+	}
+	return tree
+}
+
+// insert adds point i from y into the tree.
+func (s *spTree) insert(y []float64, dims, i int) {
+	point := y[i*dims : (i+1)*dims]
+	s.count++
+	for d, v := range point {
+		s.com[d] += (v - s.com[d]) / float64(s.count)
+	}
+
+	if s.children == nil {
+		if len(s.points) == 0 || sqDist(point, y[s.points[0]*dims:(s.points[0]+1)*dims]) == 0 {
+			// empty leaf or a duplicate point
+			s.points = append(s.points, i)
+			return
+		}
+		s.subdivide(dims)
+		for _, p := range s.points {
+			s.child(y[p*dims:(p+1)*dims]).insert(y, dims, p)
+		}
+		s.points = nil
+	}
+	s.child(point).insert(y, dims, i)
+}
+
+// subdivide splits the cell into 2^dims equally sized child cells.
+func (s *spTree) subdivide(dims int) {
+	s.children = make([]*spTree, 1<<uint(dims))
+	for c := range s.children {
+		centre, width := make([]float64, dims), make([]float64, dims)
+		for d := range centre {
+			width[d] = s.width[d] / 2
+			if c&(1<<uint(d)) != 0 {
+				centre[d] = s.centre[d] + width[d]
+			} else {
+				centre[d] = s.centre[d] - width[d]
+			}
+		}
+		s.children[c] = &spTree{centre: centre, width: width, com: make([]float64, dims)}
+	}
+}
+
+// child returns the child cell containing the specified point.
+func (s *spTree) child(point []float64) *spTree {
+	var c int
+	for d, v := range point {
+		if v > s.centre[d] {
+			c |= 1 << uint(d)
+		}
+	}
+	return s.children[c]
+}
+
+// repulse accumulates the (unnormalised) repulsive forces acting on point i, located
+// at point, into neg returning its contribution to the normalisation term.  Cells
+// small enough relative to their distance from the point, as determined by theta, are
+// summarised by their centre of mass.
+func (s *spTree) repulse(point []float64, i int, theta float64, neg []float64) float64 {
+	count := s.count
+	if s.children == nil {
+		for _, p := range s.points {
+			if p == i {
+				count--
+			}
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+
+	dist := sqDist(point, s.com)
+	var maxWidth float64
+	for _, w := range s.width {
+		maxWidth = math.Max(maxWidth, 2*w)
+	}
+	if s.children == nil || maxWidth < theta*math.Sqrt(dist) {
+		q := 1 / (1 + dist)
+		mult := float64(count) * q
+		sumQ := mult
+		mult *= q
+		for d, v := range point {
+			neg[d] += mult * (v - s.com[d])
+		}
+		return sumQ
+	}
+
+	var sumQ float64
+	for _, c := range s.children {
+		sumQ += c.repulse(point, i, theta, neg)
+	}
+	return sumQ
+}
@@ -0,0 +1,77 @@
+package nlp
+
+import (
+	"math"
+	"testing"
+
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestTSNE(t *testing.T) {
+	// 3 well separated clusters of 15 points in 10 dimensional space
+	rnd := rand.New(rand.NewSource(uint64(0)))
+	input := mat.NewDense(10, 45, nil)
+	for j := 0; j < 45; j++ {
+		for i := 0; i < 10; i++ {
+			input.Set(i, j, rnd.NormFloat64())
+		}
+		input.Set(j/15, j, input.At(j/15, j)+20)
+	}
+
+	var tests = []struct {
+		k     int
+		theta float64
+	}{
+		{k: 2, theta: 0.5},
+		{k: 2, theta: 0},
+		{k: 3, theta: 0.5},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		tsne := NewTSNE(test.k)
+		// set Rnd to fixed constant seed for deterministic results
+		tsne.Rnd = rand.New(rand.NewSource(uint64(0)))
+		tsne.Perplexity = 10
+		tsne.LearningRate = 50
+		tsne.Theta = test.theta
+
+		result, err := tsne.FitTransform(input)
+		if err != nil {
+			t.Errorf("Failed to project using t-SNE because %v", err)
+			continue
+		}
+		r, c := result.Dims()
+		if r != test.k || c != 45 {
+			t.Errorf("Expected dims %dx45 but found %dx%d", test.k, r, c)
+			continue
+		}
+
+		// the nearest neighbour of each point within the projection should belong
+		// to the same cluster
+		for i := 0; i < c; i++ {
+			nearest, nearestDist := -1, math.Inf(1)
+			for j := 0; j < c; j++ {
+				if i == j {
+					continue
+				}
+				d := sqDist(mat.Col(nil, i, result), mat.Col(nil, j, result))
+				if d < nearestDist {
+					nearest, nearestDist = j, d
+				}
+			}
+			if nearest/15 != i/15 {
+				t.Errorf("Expected nearest neighbour of point %d to be in cluster %d but was %d in cluster %d", i, i/15, nearest, nearest/15)
+			}
+		}
+	}
+}
+
+func TestTSNEPerplexityTooLarge(t *testing.T) {
+	tsne := NewTSNE(2)
+	if _, err := tsne.FitTransform(mat.NewDense(3, 10, nil)); err == nil {
+		t.Errorf("Expected error for perplexity greater than number of points but found none")
+	}
+}
@@ -1,6 +1,11 @@
 package nlp
 
 import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"sort"
+
 	"github.com/james-bowman/sparse"
 	"gonum.org/v1/gonum/mat"
 )
@@ -39,6 +44,25 @@ func ColDo(m mat.Matrix, fn func(j int, vec mat.Vector)) {
 	}
 }
 
+// padVector returns v extended with trailing zeros to length n, e.g. so that vectors
+// output before the Vocabulary of a vectoriser grew (via PartialFit()) may be compared
+// with vectors output afterwards.  If v already has a length of at least n, v is
+// returned unchanged.  Sparse vectors remain sparse, sharing the elements of v.
+func padVector(v mat.Vector, n int) mat.Vector {
+	if v.Len() >= n {
+		return v
+	}
+	if s, isSparse := v.(*sparse.Vector); isSparse {
+		data, ind := s.RawVector()
+		return sparse.NewVector(n, ind, data)
+	}
+	padded := mat.NewVecDense(n, nil)
+	for i := 0; i < v.Len(); i++ {
+		padded.SetVec(i, v.AtVec(i))
+	}
+	return padded
+}
+
 // ColNonZeroElemDo executes fn for each non-zero element in column j of matrix m.
 // If m implements mat.ColNonZeroDoer then this interface will be used to perform
 // the iteration.
@@ -57,3 +81,90 @@ func ColNonZeroElemDo(m mat.Matrix, j int, fn func(i, j int, v float64)) {
 		}
 	}
 }
+
+// nonZeroDo executes fn for each non-zero element in matrix m.  If m implements
+// mat.NonZeroDoer then this interface will be used to perform the iteration.
+func nonZeroDo(m mat.Matrix, fn func(i, j int, v float64)) {
+	if nz, isSparse := m.(mat.NonZeroDoer); isSparse {
+		nz.DoNonZero(fn)
+		return
+	}
+
+	r, c := m.Dims()
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			if v := m.At(i, j); v != 0 {
+				fn(i, j, v)
+			}
+		}
+	}
+}
+
+// topTerms returns the names of the k terms with the highest weights within the
+// specified row (or column if col is true) of components, in descending order of
+// weight.  Names are taken from featureNames which should be ordered by term index.
+func topTerms(components mat.Matrix, index int, col bool, k int, featureNames []string) []string {
+	terms := make([]int, len(featureNames))
+	weights := make([]float64, len(featureNames))
+	for i := range terms {
+		terms[i] = i
+		if col {
+			weights[i] = components.At(i, index)
+		} else {
+			weights[i] = components.At(index, i)
+		}
+	}
+	sort.SliceStable(terms, func(i, j int) bool {
+		return weights[terms[i]] > weights[terms[j]]
+	})
+	if k > len(terms) {
+		k = len(terms)
+	}
+
+	top := make([]string, k)
+	for i := range top {
+		top[i] = featureNames[terms[i]]
+	}
+	return top
+}
+
+// writeString binary serialises s, prefixed with its length, and writes it into w.
+func writeString(w io.Writer, s string) error {
+	if err := binary.Write(w, binary.LittleEndian, int64(len(s))); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, s)
+	return err
+}
+
+// readString reads a string, previously serialised using writeString(), from r.
+func readString(r io.Reader) (string, error) {
+	var n int64
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return "", err
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// modelMagic prefixes the versioned headers of serialised models to distinguish them
+// from the unversioned formats written by earlier releases, which began with a matrix
+// dimension or number of components that could never equal it.
+var modelMagic = [8]byte{'n', 'l', 'p', 'm', 'o', 'd', 'e', 'l'}
+
+// readMagic reads the magic number prefixing versioned serialised models from r,
+// returning true if it was found.  If it was not found, the returned reader yields the
+// bytes consumed followed by the remainder of r so that a legacy format may be read.
+func readMagic(r io.Reader) (io.Reader, bool, error) {
+	var magic [8]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return r, false, err
+	}
+	if magic == modelMagic {
+		return r, true, nil
+	}
+	return io.MultiReader(bytes.NewReader(magic[:]), r), false, nil
+}
@@ -1,8 +1,17 @@
 package nlp
 
 import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
+	"unicode"
 
 	"github.com/james-bowman/sparse"
 	"github.com/spaolacci/murmur3"
@@ -38,6 +47,115 @@ type OnlineTransformer interface {
 	PartialFit(mat.Matrix) OnlineTransformer
 }
 
+// InvertibleTransformer is a Transformer that can map matrices it has transformed back,
+// either exactly or approximately, into the space of the matrices input to it e.g. to
+// map reduced dimensional document vectors back towards term space.
+type InvertibleTransformer interface {
+	Transformer
+	InverseTransform(mat.Matrix) (mat.Matrix, error)
+}
+
+// ContextTransformer is an extension to the Transformer interface for long running
+// (e.g. iterative) transformers whose fitting may be cancelled, or given a deadline,
+// using a context.Context.  The context is checked periodically during fitting and,
+// once it is done, fitting stops and the context's error is returned.
+type ContextTransformer interface {
+	Transformer
+	FitTransformCtx(ctx context.Context, mat mat.Matrix) (mat.Matrix, error)
+}
+
+// Dimensioner is implemented by vectorisers and transformers that can report the
+// number of features (e.g. terms or topics) within the matrices they accept as input
+// and produce as output once fitted.  This allows the compatibility of the steps of a
+// Pipeline to be checked (see Pipeline.Validate()) without transforming any
+// documents.  Negative values indicate the number of features is unknown e.g. because
+// the step has not been fitted or, for the input of vectorisers, is not applicable.
+type Dimensioner interface {
+	FeatureDims() (in, out int)
+}
+
+// ProgressFunc is called periodically by long running operations (e.g. fitting
+// models to large corpora) to report their progress, for example to display a
+// progress bar or estimate the time remaining.  stage describes the operation in
+// progress and done is the number of units of work completed of the total for the
+// stage.  Calls for the same stage are made with increasing values of done.
+type ProgressFunc func(stage string, done, total int)
+
+// report calls f, if not nil, with the progress of stage.
+func (f ProgressFunc) report(stage string, done, total int) {
+	if f != nil {
+		f(stage, done, total)
+	}
+}
+
+// counter returns a function, safe for concurrent use, to be called on completion of
+// each of total units of work of stage.  To limit the overhead for large numbers of
+// units, f is called on completion of approximately every 1% of the units and of the
+// last unit.
+func (f ProgressFunc) counter(stage string, total int) func() {
+	if f == nil {
+		return func() {}
+	}
+	step := total / 100
+	if step < 1 {
+		step = 1
+	}
+	var lock sync.Mutex
+	var done int
+	return func() {
+		lock.Lock()
+		defer lock.Unlock()
+		done++
+		if done%step == 0 || done == total {
+			f(stage, done, total)
+		}
+	}
+}
+
+// Orientation specifies the layout of the matrices output by vectorisers i.e.
+// whether each row represents a term (feature) or a document (sample).
+type Orientation int
+
+const (
+	// TermsAsRows lays out matrices with a row for each term and a column for each
+	// document (a term document matrix).  This is the default and is the layout
+	// expected by the transformers within this package.
+	TermsAsRows Orientation = iota
+
+	// DocumentsAsRows lays out matrices with a row for each document and a column
+	// for each term (a document term matrix).  This is the layout typically
+	// expected by classifiers and other machine learning libraries that treat
+	// samples as rows.
+	DocumentsAsRows
+)
+
+// index returns the row and column indices of the element representing the
+// specified term and document for the orientation.
+func (o Orientation) index(term, doc int) (i, j int) {
+	if o == DocumentsAsRows {
+		return doc, term
+	}
+	return term, doc
+}
+
+// term returns the index of the term represented by the element at row i and
+// column j for the orientation.
+func (o Orientation) term(i, j int) int {
+	if o == DocumentsAsRows {
+		return j
+	}
+	return i
+}
+
+// doc returns the index of the document represented by the element at row i and
+// column j for the orientation.
+func (o Orientation) doc(i, j int) int {
+	if o == DocumentsAsRows {
+		return i
+	}
+	return j
+}
+
 // Tokeniser interface for tokenisers allowing substitution of different
 // tokenisation strategies e.g. Regexp and also supporting different
 // different token types n-grams and languages.
@@ -51,6 +169,46 @@ type Tokeniser interface {
 	Tokenise(text string) []string
 }
 
+// TokeniserFunc is an adapter allowing an ordinary function to be used as a
+// Tokeniser.  This allows custom, domain specific, tokenisation logic (e.g. for
+// code identifiers or hashtags) to be supplied to vectorisers by setting their
+// Tokeniser field e.g.
+//
+//	vectoriser.Tokeniser = TokeniserFunc(strings.Fields)
+type TokeniserFunc func(text string) []string
+
+// ForEachIn iterates over each token returned by calling f(text) and invokes
+// function fn with the token as parameter.
+func (f TokeniserFunc) ForEachIn(text string, fn func(token string)) {
+	for _, token := range f(text) {
+		fn(token)
+	}
+}
+
+// Tokenise returns the result of calling f(text).
+func (f TokeniserFunc) Tokenise(text string) []string {
+	return f(text)
+}
+
+// tokenIterator iterates over the tokens of a single document invoking f for each.
+type tokenIterator func(f func(token string))
+
+// tokenise returns a tokenIterator over the tokens of doc as produced by tokeniser.
+func tokenise(tokeniser Tokeniser, doc string) tokenIterator {
+	return func(f func(token string)) {
+		tokeniser.ForEachIn(doc, f)
+	}
+}
+
+// sliceTokens returns a tokenIterator over the supplied pre-tokenised tokens.
+func sliceTokens(tokens []string) tokenIterator {
+	return func(f func(token string)) {
+		for _, token := range tokens {
+			f(token)
+		}
+	}
+}
+
 // RegExpTokeniser implements Tokeniser interface using a basic RegExp
 // pattern for unary-gram word tokeniser supporting optional stop word
 // removal
@@ -110,6 +268,48 @@ func (t *RegExpTokeniser) Tokenise(text string) []string {
 	return words
 }
 
+// Token represents a single token within a document along with its location.
+// Start and End are the byte offsets within the original document of the first
+// byte of the token and the byte following the last byte of the token
+// respectively i.e. text[Start:End] is the original text of the token.
+type Token struct {
+	Text  string
+	Start int
+	End   int
+}
+
+// TokeniseWithOffsets returns a slice of all the tokens contained in string text
+// along with their byte offsets within text.  The Text of each token is identical
+// to the corresponding token returned by Tokenise() (i.e. lower case) whilst the
+// offsets refer to the original text.  This allows tokens (and so features) to be
+// mapped back to their source text e.g. for highlighting search results.  If
+// StopWords is not nil then any tokens present in StopWords will be omitted.
+func (t *RegExpTokeniser) TokeniseWithOffsets(text string) []Token {
+	// convert content to lower case, recording the offset within the original text
+	// of each byte as lower casing may change the length of the encoding of runes
+	var c strings.Builder
+	offsets := make([]int, 0, len(text)+1)
+	for i, r := range text {
+		n, _ := c.WriteRune(unicode.ToLower(r))
+		for k := 0; k < n; k++ {
+			offsets = append(offsets, i)
+		}
+	}
+	offsets = append(offsets, len(text))
+	lower := c.String()
+
+	var tokens []Token
+	for _, loc := range t.RegExp.FindAllStringIndex(lower, -1) {
+		word := lower[loc[0]:loc[1]]
+		if t.StopWords != nil && t.StopWords[word] {
+			continue
+		}
+		tokens = append(tokens, Token{Text: word, Start: offsets[loc[0]], End: offsets[loc[1]]})
+	}
+
+	return tokens
+}
+
 // tokenise returns a slice of all the tokens contained in string
 // text.
 func (t *RegExpTokeniser) tokenise(text string) []string {
@@ -122,6 +322,157 @@ func (t *RegExpTokeniser) tokenise(text string) []string {
 	return words
 }
 
+// NGramTokeniser implements the Tokeniser interface producing word n-grams from
+// the tokens output by an underlying Tokeniser.  All n-grams with lengths between
+// MinN and MaxN (inclusive) are produced so, for example, with MinN = 1 and MaxN = 2
+// the text "machine learning" would produce the tokens "machine", "learning" and
+// "machine learning".  The words making up each n-gram are joined with a single
+// space.
+type NGramTokeniser struct {
+	// Tokeniser is the underlying Tokeniser used to split text into the individual
+	// words from which the n-grams are constructed.
+	Tokeniser Tokeniser
+
+	// MinN is the smallest number of words to include in each n-gram
+	MinN int
+
+	// MaxN is the largest number of words to include in each n-gram
+	MaxN int
+
+	// Skip is the maximum total number of words that may be skipped between the words
+	// of each n-gram.  If greater than 0, k-skip-n-grams are produced in addition to
+	// contiguous n-grams so, for example, with Skip = 1 the text "new shiny york" would
+	// produce the bigram "new york" in addition to "new shiny" and "shiny york".  This
+	// can be useful for noisy text where related words are not always adjacent.
+	Skip int
+}
+
+// NewNGramTokeniser returns a new NGramTokeniser producing n-grams with lengths
+// between minN and maxN (inclusive) from words tokenised with the default
+// Tokeniser implementation.  stopWords is a potentially empty slice of words that
+// should be removed before the n-grams are constructed.
+func NewNGramTokeniser(minN, maxN int, stopWords ...string) *NGramTokeniser {
+	return &NGramTokeniser{
+		Tokeniser: NewTokeniser(stopWords...),
+		MinN:      minN,
+		MaxN:      maxN,
+	}
+}
+
+// ForEachIn iterates over each n-gram within text and invokes function
+// f with the n-gram as parameter.  All n-grams of length MinN are produced first
+// followed by all n-grams of length MinN+1 and so on up to MaxN.  N-grams of the
+// same length are produced in order of their first word and then, if Skip is
+// greater than 0, the number of words skipped (contiguous n-grams first).
+func (t *NGramTokeniser) ForEachIn(text string, f func(token string)) {
+	words := t.Tokeniser.Tokenise(text)
+
+	minN := t.MinN
+	if minN < 1 {
+		minN = 1
+	}
+	gram := make([]string, 0, t.MaxN)
+	for n := minN; n <= t.MaxN; n++ {
+		for i := range words {
+			t.extend(words, i, n-1, t.Skip, append(gram, words[i]), f)
+		}
+	}
+}
+
+// extend recursively extends gram, the last word of which is words[last], with a
+// further remaining words, skipping up to skips words in total, and invokes f with
+// each resulting n-gram.
+func (t *NGramTokeniser) extend(words []string, last, remaining, skips int, gram []string, f func(token string)) {
+	if remaining == 0 {
+		if len(gram) == 1 {
+			f(gram[0])
+			return
+		}
+		f(strings.Join(gram, " "))
+		return
+	}
+	for s := 0; s <= skips && last+1+s < len(words); s++ {
+		next := last + 1 + s
+		t.extend(words, next, remaining-1, skips-s, append(gram, words[next]), f)
+	}
+}
+
+// Tokenise returns a slice of all the n-grams contained in string text.
+func (t *NGramTokeniser) Tokenise(text string) []string {
+	var ngrams []string
+	t.ForEachIn(text, func(ngram string) {
+		ngrams = append(ngrams, ngram)
+	})
+	return ngrams
+}
+
+// CharNGramTokeniser implements the Tokeniser interface producing character n-grams
+// of lengths between MinN and MaxN (inclusive) from the input text.  Character
+// n-grams are robust to misspellings and inflections and do not rely upon words
+// being delimited by whitespace.  Text is converted to lower case and runs of
+// whitespace are collapsed into a single space before the n-grams are produced.
+type CharNGramTokeniser struct {
+	// MinN is the smallest number of characters to include in each n-gram
+	MinN int
+
+	// MaxN is the largest number of characters to include in each n-gram
+	MaxN int
+
+	// WordBoundaries, if true, restricts n-grams to characters within the same
+	// whitespace delimited word with each word padded with a space at either end so
+	// that n-grams at the start and end of words may be distinguished from those in
+	// the middle.  If false, n-grams are produced across the whole text and may span
+	// multiple words.
+	WordBoundaries bool
+}
+
+// NewCharNGramTokeniser returns a new CharNGramTokeniser producing character n-grams
+// with lengths between minN and maxN (inclusive).  If wordBoundaries is true then
+// n-grams are only produced from characters within the same, space padded word.
+func NewCharNGramTokeniser(minN, maxN int, wordBoundaries bool) *CharNGramTokeniser {
+	return &CharNGramTokeniser{
+		MinN:           minN,
+		MaxN:           maxN,
+		WordBoundaries: wordBoundaries,
+	}
+}
+
+// ForEachIn iterates over each character n-gram within text and invokes function
+// f with the n-gram as parameter.
+func (t *CharNGramTokeniser) ForEachIn(text string, f func(token string)) {
+	words := strings.Fields(strings.ToLower(text))
+
+	if t.WordBoundaries {
+		for _, word := range words {
+			t.ngrams([]rune(" "+word+" "), f)
+		}
+		return
+	}
+	t.ngrams([]rune(strings.Join(words, " ")), f)
+}
+
+// ngrams invokes function f with each n-gram within the supplied characters.
+func (t *CharNGramTokeniser) ngrams(chars []rune, f func(token string)) {
+	minN := t.MinN
+	if minN < 1 {
+		minN = 1
+	}
+	for n := minN; n <= t.MaxN; n++ {
+		for i := 0; i+n <= len(chars); i++ {
+			f(string(chars[i : i+n]))
+		}
+	}
+}
+
+// Tokenise returns a slice of all the character n-grams contained in string text.
+func (t *CharNGramTokeniser) Tokenise(text string) []string {
+	var ngrams []string
+	t.ForEachIn(text, func(ngram string) {
+		ngrams = append(ngrams, ngram)
+	})
+	return ngrams
+}
+
 // CountVectoriser can be used to encode one or more text documents into a term document
 // matrix where each column represents a document within the corpus and each row represents
 // a term present in the training data set.  Each element represents the frequency the
@@ -137,8 +488,70 @@ type CountVectoriser struct {
 	// and will be ignored.
 	Vocabulary map[string]int
 
-	// Tokeniser is used to tokenise input text into features.
+	// Tokeniser is used to tokenise input text into features.  To include n-grams
+	// (e.g. bigrams and trigrams) as features alongside unigrams, set Tokeniser
+	// to an NGramTokeniser.
 	Tokeniser Tokeniser
+
+	// MinDF is the minimum document frequency a term must have to be included in the
+	// Vocabulary during Fit().  Terms occurring in fewer documents are ignored.
+	// Values less than 1 are interpreted as a proportion of the training documents
+	// and values of 1 or greater as an absolute number of documents.
+	MinDF float64
+
+	// MaxDF is the maximum document frequency a term may have to be included in the
+	// Vocabulary during Fit().  Terms occurring in more documents are ignored.  Values
+	// less than 1 are interpreted as a proportion of the training documents and values
+	// of 1 or greater as an absolute number of documents.  A value of 0 means no
+	// maximum is applied.
+	MaxDF float64
+
+	// MaxFeatures, if greater than 0, limits the size of the Vocabulary learnt during
+	// Fit() to the MaxFeatures most frequently occurring terms across the training
+	// documents.  Terms with equal frequencies are selected in lexicographical order.
+	MaxFeatures int
+
+	// Binary, if true, causes Transform() to output a matrix of binary presence
+	// indicators (1 if the term occurs within the document or 0 if it does not)
+	// rather than the frequency with which each term occurs.
+	Binary bool
+
+	// MaxTF, if greater than 0, caps the frequency output by Transform() for each term
+	// within a document at MaxTF.  This guards against pathological documents, that
+	// repeat the same term many times, distorting subsequent weighting (e.g. TF-IDF).
+	MaxTF int
+
+	// Processes is the degree of parallelisation, or more specifically, the number of
+	// concurrent go routines to use to tokenise and count documents during Fit() and
+	// Transform().  Values less than 2 process documents sequentially.  The results
+	// are identical regardless of the degree of parallelisation.  If greater than 1,
+	// Tokeniser must be safe for concurrent use.
+	Processes int
+
+	// OOVBuckets is the number of out-of-vocabulary (OOV) buckets.  If greater than 0,
+	// terms encountered within Transform() that are not present in the Vocabulary are
+	// hashed into one of OOVBuckets additional rows, appended after the rows for the
+	// Vocabulary, rather than being ignored.  This preserves some signal for unseen
+	// terms at the expense of potential collisions between them.
+	OOVBuckets int
+
+	// Orientation specifies the layout of matrices output from Transform().  By
+	// default (TermsAsRows) each row represents a term and each column a document.
+	// If set to DocumentsAsRows, the output matrices are transposed so that each
+	// row represents a document.
+	Orientation Orientation
+
+	// Progress, if not nil, is called periodically during Fit() with the number of
+	// training documents counted (stage "count")
+	Progress ProgressFunc
+
+	// docFreqs holds the number of training documents in which each term in the
+	// Vocabulary occurred.
+	docFreqs map[string]int
+
+	// termFreqs holds the total number of occurrences of each term in the Vocabulary
+	// across all training documents.
+	termFreqs map[string]int
 }
 
 // NewCountVectoriser creates a new CountVectoriser.
@@ -150,52 +563,396 @@ func NewCountVectoriser(stopWords ...string) *CountVectoriser {
 	}
 }
 
+// NewCharNGramVectoriser creates a new CountVectoriser that builds its vocabulary
+// from character n-grams, rather than words, with lengths between minN and maxN
+// (inclusive).  If wordBoundaries is true, n-grams are only produced from characters
+// within the same word with each word padded with a space at either end.
+func NewCharNGramVectoriser(minN, maxN int, wordBoundaries bool) *CountVectoriser {
+	return &CountVectoriser{
+		Vocabulary: make(map[string]int),
+		Tokeniser:  NewCharNGramTokeniser(minN, maxN, wordBoundaries),
+	}
+}
+
 // Fit processes the supplied training data (a variable number of strings representing
 // documents).  Each word appearing inside the training data will be added to the
 // Vocabulary.  The Fit() method is intended to be called once to train the model
 // in a batch context.  Calling the Fit() method a sceond time have the effect of
 // re-training the model from scratch (discarding the previously learnt vocabulary).
 func (v *CountVectoriser) Fit(train ...string) Vectoriser {
+	v.fit(len(train), func(d int) tokenIterator {
+		return tokenise(v.Tokeniser, train[d])
+	})
+	return v
+}
+
+// FitTokens is equivalent to Fit() but accepts pre-tokenised training documents,
+// each represented as a slice of tokens, rather than raw text.  The Tokeniser is not
+// used.  This allows tokens produced by an external tokeniser or tagger to be used
+// directly.
+func (v *CountVectoriser) FitTokens(train ...[]string) *CountVectoriser {
+	v.fit(len(train), func(d int) tokenIterator {
+		return sliceTokens(train[d])
+	})
+	return v
+}
+
+// fit learns the Vocabulary from n training documents, the tokens of which are
+// iterated over by the iterators returned from doc for each document index.
+func (v *CountVectoriser) fit(n int, doc func(d int) tokenIterator) {
 	i := 0
-	if len(v.Vocabulary) != 0 {
+	if len(v.Vocabulary) != 0 || v.Vocabulary == nil {
 		v.Vocabulary = make(map[string]int)
 	}
-	v.fitVocab(i, train...)
+	v.docFreqs = make(map[string]int)
+	v.termFreqs = make(map[string]int)
 
-	return v
+	counted := v.Progress.counter("count", n)
+	counters := make([]*termCounter, numChunks(n, v.Processes))
+	parallelChunks(n, len(counters), func(chunk, start, end int) {
+		counters[chunk] = newTermCounter()
+		for d := start; d < end; d++ {
+			counters[chunk].count(doc(d))
+			counted()
+		}
+	})
+
+	// merge the counts in document order so the learnt vocabulary is deterministic
+	c := newTermCounter()
+	for _, counter := range counters {
+		c.merge(counter)
+	}
+	v.fitVocab(i, c)
 }
 
-// fitVocab learns the vocabulary contained within the supplied training documents
-func (v *CountVectoriser) fitVocab(start int, train ...string) {
-	i := start
+// FitReader is equivalent to Fit() but streams the training documents from r rather
+// than requiring them to be held in memory as a slice of strings.  Documents are
+// delimited within r by delim (e.g. '\n' for one document per line).  This allows
+// the Vocabulary to be learnt from corpora too large to fit in memory.
+func (v *CountVectoriser) FitReader(r io.Reader, delim byte) error {
+	if len(v.Vocabulary) != 0 || v.Vocabulary == nil {
+		v.Vocabulary = make(map[string]int)
+	}
+	v.docFreqs = make(map[string]int)
+	v.termFreqs = make(map[string]int)
+
+	c := newTermCounter()
+	if err := readDocs(r, delim, func(doc string) {
+		c.count(tokenise(v.Tokeniser, doc))
+	}); err != nil {
+		return err
+	}
+	v.fitVocab(0, c)
+
+	return nil
+}
+
+// PartialFit extends the Vocabulary with any new terms found within the supplied
+// training documents.  Unlike the Fit() method, which re-trains the model from
+// scratch, PartialFit() retains the previously learnt Vocabulary and is designed to
+// be called multiple times to support online and mini-batch learning e.g. for
+// corpora arriving in batches from a stream.  New terms are assigned indices following
+// on from those already in the Vocabulary so matrices output from previous calls to
+// Transform() remain valid (albeit with fewer rows).  Frequency based filtering
+// (MinDF, MaxDF and MaxFeatures) requires statistics across the whole corpus and so
+// is not applied by PartialFit().
+func (v *CountVectoriser) PartialFit(train ...string) OnlineVectoriser {
+	if v.Vocabulary == nil {
+		v.Vocabulary = make(map[string]int)
+	}
+	if v.docFreqs == nil {
+		v.docFreqs = make(map[string]int)
+	}
+	if v.termFreqs == nil {
+		v.termFreqs = make(map[string]int)
+	}
+
+	i := len(v.Vocabulary)
 	for _, doc := range train {
+		seen := make(map[string]bool)
 		v.Tokeniser.ForEachIn(doc, func(word string) {
-			_, exists := v.Vocabulary[word]
-			if !exists {
+			if _, exists := v.Vocabulary[word]; !exists {
 				v.Vocabulary[word] = i
 				i++
 			}
+			v.termFreqs[word]++
+			if !seen[word] {
+				seen[word] = true
+				v.docFreqs[word]++
+			}
 		})
 	}
+
+	return v
+}
+
+// termCounter accumulates term and document frequency statistics across a corpus
+// of training documents one document at a time.
+type termCounter struct {
+	// terms holds each distinct term in the order it first occurred
+	terms []string
+	df    map[string]int
+	tf    map[string]int
+	n     int
+}
+
+// newTermCounter creates a new, empty, termCounter.
+func newTermCounter() *termCounter {
+	return &termCounter{
+		df: make(map[string]int),
+		tf: make(map[string]int),
+	}
+}
+
+// count adds the terms of a single document, iterated over by tokens, to the counts.
+func (c *termCounter) count(tokens tokenIterator) {
+	seen := make(map[string]bool)
+	tokens(func(word string) {
+		c.tf[word]++
+		if seen[word] {
+			return
+		}
+		seen[word] = true
+		if _, exists := c.df[word]; !exists {
+			c.terms = append(c.terms, word)
+		}
+		c.df[word]++
+	})
+	c.n++
+}
+
+// merge adds the counts accumulated in o into c.  Terms first occurring in o are
+// ordered after those already present within c.
+func (c *termCounter) merge(o *termCounter) {
+	for _, term := range o.terms {
+		if _, exists := c.df[term]; !exists {
+			c.terms = append(c.terms, term)
+		}
+		c.df[term] += o.df[term]
+		c.tf[term] += o.tf[term]
+	}
+	c.n += o.n
+}
+
+// numChunks returns the number of chunks that n items should be divided into for
+// processing by the specified number of concurrent processes.
+func numChunks(n, processes int) int {
+	if processes > n {
+		processes = n
+	}
+	if processes < 1 {
+		processes = 1
+	}
+	return processes
+}
+
+// parallelChunks divides the range [0, n) into the specified number of contiguous
+// chunks and invokes f for each chunk concurrently, in its own go routine, with the
+// chunk number and the start (inclusive) and end (exclusive) of the chunk.  The
+// function returns once all invocations of f have completed.
+func parallelChunks(n, chunks int, f func(chunk, start, end int)) {
+	if chunks <= 1 {
+		f(0, 0, n)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for chunk := 0; chunk < chunks; chunk++ {
+		wg.Add(1)
+		go func(chunk int) {
+			defer wg.Done()
+			f(chunk, chunk*n/chunks, (chunk+1)*n/chunks)
+		}(chunk)
+	}
+	wg.Wait()
+}
+
+// fitVocab learns the vocabulary from the term statistics accumulated in c.
+// Terms are assigned indices in the order they first occur within the training
+// documents, starting from start, omitting any terms whose document frequency falls
+// outside of the range specified by MinDF and MaxDF and, if MaxFeatures is set, any
+// terms not amongst the MaxFeatures most frequent.
+func (v *CountVectoriser) fitVocab(start int, c *termCounter) {
+	minDF := dfThreshold(v.MinDF, c.n)
+	maxDF := dfThreshold(v.MaxDF, c.n)
+
+	var candidates []string
+	for _, term := range c.terms {
+		if float64(c.df[term]) < minDF || (maxDF > 0 && float64(c.df[term]) > maxDF) {
+			continue
+		}
+		candidates = append(candidates, term)
+	}
+
+	if v.MaxFeatures > 0 && len(candidates) > v.MaxFeatures {
+		candidates = mostFrequent(candidates, c.tf, v.MaxFeatures)
+	}
+
+	i := start
+	for _, term := range candidates {
+		if _, exists := v.Vocabulary[term]; !exists {
+			v.Vocabulary[term] = i
+			i++
+		}
+		v.docFreqs[term] += c.df[term]
+		v.termFreqs[term] += c.tf[term]
+	}
+}
+
+// Prune removes all terms from the Vocabulary for which the supplied predicate
+// function returns true.  The predicate is invoked for each term in the Vocabulary
+// along with the number of training documents in which the term occurred (its
+// document frequency).  The remaining terms are re-indexed, retaining their relative
+// order, so that the Vocabulary indices remain contiguous.  The method returns a
+// mapping of the previous indices to the new indices (or -1 for removed terms) of
+// length equal to the size of the Vocabulary before pruning.  This mapping can be
+// used to realign the rows of matrices previously output from Transform().
+func (v *CountVectoriser) Prune(predicate func(term string, df int) bool) []int {
+	terms := v.terms()
+
+	mapping := make([]int, len(terms))
+	var i int
+	for old, term := range terms {
+		if predicate(term, v.docFreqs[term]) {
+			delete(v.Vocabulary, term)
+			delete(v.docFreqs, term)
+			delete(v.termFreqs, term)
+			mapping[old] = -1
+			continue
+		}
+		v.Vocabulary[term] = i
+		mapping[old] = i
+		i++
+	}
+
+	return mapping
+}
+
+// mostFrequent returns the k terms with the highest frequency in tf, breaking ties
+// in lexicographical order.  The returned terms retain their relative order from
+// the terms slice.
+func mostFrequent(terms []string, tf map[string]int, k int) []string {
+	ranked := make([]string, len(terms))
+	copy(ranked, terms)
+	sort.Slice(ranked, func(i, j int) bool {
+		if tf[ranked[i]] != tf[ranked[j]] {
+			return tf[ranked[i]] > tf[ranked[j]]
+		}
+		return ranked[i] < ranked[j]
+	})
+
+	selected := make(map[string]bool, k)
+	for _, term := range ranked[:k] {
+		selected[term] = true
+	}
+
+	top := terms[:0]
+	for _, term := range terms {
+		if selected[term] {
+			top = append(top, term)
+		}
+	}
+	return top
+}
+
+// dfThreshold converts the document frequency threshold into an absolute number of
+// documents.  Thresholds less than 1 are treated as a proportion of the n documents.
+func dfThreshold(threshold float64, n int) float64 {
+	if threshold < 1 {
+		return threshold * float64(n)
+	}
+	return threshold
 }
 
 // Transform transforms the supplied documents into a term document matrix where each
 // column is a feature vector representing one of the supplied documents.  Each element
 // represents the frequency with which the associated term for that row occurred within
-// that document.  The returned matrix is a sparse matrix type.
+// that document, capped at MaxTF if set, (or simply whether it occurred if Binary is
+// true).  If OOVBuckets is greater than 0, the matrix will contain an additional
+// OOVBuckets rows for terms not present in the Vocabulary.  The returned matrix is a
+// sparse matrix type.
 func (v *CountVectoriser) Transform(docs ...string) (mat.Matrix, error) {
-	mat := sparse.NewDOK(len(v.Vocabulary), len(docs))
+	return v.transform(len(docs), func(d int) tokenIterator {
+		return tokenise(v.Tokeniser, docs[d])
+	}), nil
+}
 
-	for d, doc := range docs {
-		v.Tokeniser.ForEachIn(doc, func(word string) {
-			i, exists := v.Vocabulary[word]
+// TransformTokens is equivalent to Transform() but accepts pre-tokenised documents,
+// each represented as a slice of tokens, rather than raw text.  The Tokeniser is not
+// used.  The returned matrix is a sparse matrix type.
+func (v *CountVectoriser) TransformTokens(docs ...[]string) (mat.Matrix, error) {
+	return v.transform(len(docs), func(d int) tokenIterator {
+		return sliceTokens(docs[d])
+	}), nil
+}
 
-			if exists {
-				mat.Set(i, d, mat.At(i, d)+1)
-			}
-		})
+// transform vectorises n documents, the tokens of which are iterated over by the
+// iterators returned from doc for each document index.
+func (v *CountVectoriser) transform(n int, doc func(d int) tokenIterator) mat.Matrix {
+	mat := sparse.NewDOK(v.Orientation.index(len(v.Vocabulary)+v.OOVBuckets, n))
+
+	vecs := make([]map[int]float64, n)
+	parallelChunks(n, numChunks(n, v.Processes), func(chunk, start, end int) {
+		for d := start; d < end; d++ {
+			vecs[d] = v.vectorise(doc(d))
+		}
+	})
+
+	for d, vec := range vecs {
+		for t, val := range vec {
+			i, j := v.Orientation.index(t, d)
+			mat.Set(i, j, val)
+		}
 	}
-	return mat, nil
+	return mat
+}
+
+// TransformReader is equivalent to Transform() but streams the documents from r
+// rather than requiring them to be held in memory as a slice of strings.  Documents
+// are delimited within r by delim (e.g. '\n' for one document per line) and each
+// becomes a column of the returned matrix in the order read.  Only the non-zero
+// elements of the output are held in memory.  The returned matrix is a sparse
+// matrix type.
+func (v *CountVectoriser) TransformReader(r io.Reader, delim byte) (mat.Matrix, error) {
+	return transformReader(r, delim, len(v.Vocabulary)+v.OOVBuckets, v.Orientation, func(doc string) map[int]float64 {
+		return v.vectorise(tokenise(v.Tokeniser, doc))
+	})
+}
+
+// vectorise returns the non-zero elements of the feature vector for the document
+// with the specified tokens as a map of row indices to values.
+func (v *CountVectoriser) vectorise(tokens tokenIterator) map[int]float64 {
+	vec := make(map[int]float64)
+	tokens(func(word string) {
+		i, exists := v.termIndex(word)
+		if !exists {
+			return
+		}
+
+		if v.Binary {
+			vec[i] = 1
+			return
+		}
+		if v.MaxTF > 0 && vec[i] >= float64(v.MaxTF) {
+			return
+		}
+		vec[i]++
+	})
+	return vec
+}
+
+// termIndex returns the row index of the specified word within the matrices output
+// from Transform(), either from the Vocabulary or, for words outside of the Vocabulary,
+// the OOV bucket it hashes to.  If the word is not within the Vocabulary and there
+// are no OOV buckets, false is returned.
+func (v *CountVectoriser) termIndex(word string) (int, bool) {
+	if i, exists := v.Vocabulary[word]; exists {
+		return i, true
+	}
+	if v.OOVBuckets <= 0 {
+		return 0, false
+	}
+	return len(v.Vocabulary) + int(murmur3.Sum32([]byte(word)))%v.OOVBuckets, true
 }
 
 // FitTransform is exactly equivalent to calling Fit() followed by Transform() on the
@@ -206,6 +963,232 @@ func (v *CountVectoriser) FitTransform(docs ...string) (mat.Matrix, error) {
 	return v.Fit(docs...).Transform(docs...)
 }
 
+// InverseTransform maps the columns (documents) of the supplied term document matrix
+// back to the terms they contain.  For each column in matrix, the returned slice
+// contains the terms corresponding to each non-zero row in that column, ordered by
+// row index.  Rows not corresponding to a term in the Vocabulary (e.g. out-of-vocabulary
+// buckets) are ignored.  This can be useful for debugging which features are present
+// within documents.  If Orientation is DocumentsAsRows, matrix is expected to be a
+// document term matrix and the rows are mapped back to terms instead.
+func (v *CountVectoriser) InverseTransform(matrix mat.Matrix) [][]string {
+	if v.Orientation == DocumentsAsRows {
+		if t, isTypeConv := matrix.(sparse.TypeConverter); isTypeConv {
+			matrix = t.ToCSR().T()
+		} else {
+			matrix = matrix.T()
+		}
+	} else if t, isTypeConv := matrix.(sparse.TypeConverter); isTypeConv {
+		matrix = t.ToCSC()
+	}
+	terms := v.terms()
+	_, c := matrix.Dims()
+
+	docs := make([][]string, c)
+	for j := 0; j < c; j++ {
+		var rows []int
+		ColNonZeroElemDo(matrix, j, func(i, j int, val float64) {
+			if i < len(terms) {
+				rows = append(rows, i)
+			}
+		})
+		sort.Ints(rows)
+
+		docs[j] = make([]string, len(rows))
+		for k, i := range rows {
+			docs[j][k] = terms[i]
+		}
+	}
+	return docs
+}
+
+// FeatureDims returns the number of features (terms) output by Transform(), the size
+// of the Vocabulary plus any OOVBuckets, or -1 if the vectoriser has not been fitted.
+// The input is documents so the number of input features is always -1.
+func (v *CountVectoriser) FeatureDims() (in, out int) {
+	if len(v.Vocabulary) == 0 {
+		return -1, -1
+	}
+	return -1, len(v.Vocabulary) + v.OOVBuckets
+}
+
+// GetFeatureNames returns the names of the features (terms) corresponding to each
+// row of the term document matrices output by Transform(), in row index order.  This
+// can be used to label the rows of matrices e.g. when printing the top weighted terms
+// for a document.  If OOVBuckets is greater than 0, the names of the additional
+// out-of-vocabulary rows are of the form "<oov:N>" where N is the bucket number.
+func (v *CountVectoriser) GetFeatureNames() []string {
+	names := v.terms()
+	for i := 0; i < v.OOVBuckets; i++ {
+		names = append(names, fmt.Sprintf("<oov:%d>", i))
+	}
+	return names
+}
+
+// TermStat holds corpus statistics for a single term within the Vocabulary of a
+// fitted CountVectoriser.
+type TermStat struct {
+	// Term is the term (feature) itself
+	Term string
+
+	// Index is the index of the row representing the term in term document matrices
+	Index int
+
+	// DocFreq is the number of training documents in which the term occurred
+	DocFreq int
+
+	// TermFreq is the total number of occurrences of the term across all training
+	// documents
+	TermFreq int
+}
+
+// TermStats returns corpus statistics, gathered from the training documents during
+// fitting, for each term in the Vocabulary ordered by index.  This allows the
+// vocabulary to be audited (e.g. to identify candidate stop words) without
+// re-scanning the corpus.  Statistics are not available for vocabularies loaded
+// with LoadVocabularyJSON() and so are reported as 0.
+func (v *CountVectoriser) TermStats() []TermStat {
+	stats := make([]TermStat, len(v.Vocabulary))
+	for term, i := range v.Vocabulary {
+		stats[i] = TermStat{
+			Term:     term,
+			Index:    i,
+			DocFreq:  v.docFreqs[term],
+			TermFreq: v.termFreqs[term],
+		}
+	}
+	return stats
+}
+
+// terms returns a slice of the terms in the Vocabulary ordered by their index.
+func (v *CountVectoriser) terms() []string {
+	terms := make([]string, len(v.Vocabulary))
+	for term, i := range v.Vocabulary {
+		terms[i] = term
+	}
+	return terms
+}
+
+// SaveVocabularyJSON serialises the learnt Vocabulary as a JSON object mapping each
+// term to its row index and writes it into w.  This allows a fitted vocabulary to be
+// inspected, versioned and loaded (using the LoadVocabularyJSON() method) in another
+// context for reproducible results.  Terms are written in lexicographical order.
+func (v *CountVectoriser) SaveVocabularyJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(v.Vocabulary)
+}
+
+// LoadVocabularyJSON deserialises a vocabulary, previously serialised using
+// SaveVocabularyJSON(), from r and replaces the receiver's Vocabulary with it.  An
+// error is returned if the indices within the vocabulary are not unique and
+// contiguous from 0.
+func (v *CountVectoriser) LoadVocabularyJSON(r io.Reader) error {
+	var vocab map[string]int
+	if err := json.NewDecoder(r).Decode(&vocab); err != nil {
+		return err
+	}
+
+	seen := make([]bool, len(vocab))
+	for term, i := range vocab {
+		if i < 0 || i >= len(vocab) || seen[i] {
+			return fmt.Errorf("nlp: Invalid index %d for term '%s' in vocabulary of size %d", i, term, len(vocab))
+		}
+		seen[i] = true
+	}
+	v.Vocabulary = vocab
+	v.docFreqs = make(map[string]int)
+	v.termFreqs = make(map[string]int)
+
+	return nil
+}
+
+// countVectoriserHeader is the fixed size portion of a serialised CountVectoriser.
+type countVectoriserHeader struct {
+	MinDF       float64
+	MaxDF       float64
+	MaxFeatures int64
+	Binary      bool
+	MaxTF       int64
+	OOVBuckets  int64
+	Orientation int64
+	Terms       int64
+}
+
+// Save binary serialises the model, including its Vocabulary, term statistics and
+// hyperparameters, and writes it into w.  This is useful for persisting a trained
+// model to disk so that it may be loaded (using the Load() method) in another context
+// (e.g. production) for reproducible results.  The Tokeniser and Processes are not
+// serialised.
+func (v *CountVectoriser) Save(w io.Writer) error {
+	header := countVectoriserHeader{
+		MinDF:       v.MinDF,
+		MaxDF:       v.MaxDF,
+		MaxFeatures: int64(v.MaxFeatures),
+		Binary:      v.Binary,
+		MaxTF:       int64(v.MaxTF),
+		OOVBuckets:  int64(v.OOVBuckets),
+		Orientation: int64(v.Orientation),
+		Terms:       int64(len(v.Vocabulary)),
+	}
+	if err := binary.Write(w, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	for _, term := range v.terms() {
+		if err := writeString(w, term); err != nil {
+			return err
+		}
+		freqs := []int64{int64(v.docFreqs[term]), int64(v.termFreqs[term])}
+		if err := binary.Write(w, binary.LittleEndian, freqs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load binary deserialises the previously serialised model into the receiver.  This is
+// useful for loading a previously trained and saved model from another context
+// (e.g. offline training) for use within another context (e.g. production) for
+// reproducible results.  The receiver's Tokeniser should be configured the same as
+// when the model was trained.  Load should only be performed with trusted data.
+func (v *CountVectoriser) Load(r io.Reader) error {
+	var header countVectoriserHeader
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return err
+	}
+
+	vocab := make(map[string]int, header.Terms)
+	docFreqs := make(map[string]int, header.Terms)
+	termFreqs := make(map[string]int, header.Terms)
+	freqs := make([]int64, 2)
+	for i := 0; i < int(header.Terms); i++ {
+		term, err := readString(r)
+		if err != nil {
+			return err
+		}
+		if err := binary.Read(r, binary.LittleEndian, freqs); err != nil {
+			return err
+		}
+		vocab[term] = i
+		if freqs[0] > 0 {
+			docFreqs[term] = int(freqs[0])
+		}
+		if freqs[1] > 0 {
+			termFreqs[term] = int(freqs[1])
+		}
+	}
+
+	v.MinDF = header.MinDF
+	v.MaxDF = header.MaxDF
+	v.MaxFeatures = int(header.MaxFeatures)
+	v.Binary = header.Binary
+	v.MaxTF = int(header.MaxTF)
+	v.OOVBuckets = int(header.OOVBuckets)
+	v.Orientation = Orientation(header.Orientation)
+	v.Vocabulary = vocab
+	v.docFreqs = docFreqs
+	v.termFreqs = termFreqs
+
+	return nil
+}
+
 // HashingVectoriser can be used to encode one or more text documents into a term document
 // matrix where each column represents a document within the corpus and each row represents
 // a term.  Each element represents the frequency the corresponding term appears in the
@@ -214,6 +1197,20 @@ func (v *CountVectoriser) FitTransform(docs ...string) (mat.Matrix, error) {
 type HashingVectoriser struct {
 	NumFeatures int
 	Tokeniser   Tokeniser
+
+	// Signed, if true, uses a second hash function to determine the sign (+1 or -1)
+	// of the value added to the matrix for each term.  This is referred to as the
+	// signed hashing trick and means that hash collisions are likely to cancel out
+	// rather than accumulate, preserving the inner product between feature vectors in
+	// expectation.  This can materially improve accuracy for small values of
+	// NumFeatures.
+	Signed bool
+
+	// Orientation specifies the layout of matrices output from Transform().  By
+	// default (TermsAsRows) each row represents a term and each column a document.
+	// If set to DocumentsAsRows, the output matrices are transposed so that each
+	// row represents a document.
+	Orientation Orientation
 }
 
 // NewHashingVectoriser creates a new HashingVectoriser.  If stopWords is not an empty slice then
@@ -242,7 +1239,7 @@ func (v *HashingVectoriser) Fit(train ...string) Vectoriser {
 // features based on their hash, it does not require a pre-learnt vocabulary to map
 // features to the correct row in the feature vector.  This method is included
 // for compatibility with other vectorisers.
-func (v *HashingVectoriser) PartialFit(train ...string) Vectoriser {
+func (v *HashingVectoriser) PartialFit(train ...string) OnlineVectoriser {
 	// The hashing vectoriser is stateless and does not requre training so this method
 	// does nothing.
 	return v
@@ -251,21 +1248,140 @@ func (v *HashingVectoriser) PartialFit(train ...string) Vectoriser {
 // Transform transforms the supplied documents into a term document matrix where each
 // column is a feature vector representing one of the supplied documents.  Each element
 // represents the frequency with which the associated term for that row occurred within
-// that document.  The returned matrix is a sparse matrix type.
+// that document (negated for terms hashed with a negative sign if Signed is true).
+// The returned matrix is a sparse matrix type.
 func (v *HashingVectoriser) Transform(docs ...string) (mat.Matrix, error) {
-	mat := sparse.NewDOK(v.NumFeatures, len(docs))
+	mat := sparse.NewDOK(v.Orientation.index(v.NumFeatures, len(docs)))
 
 	for d, doc := range docs {
-		v.Tokeniser.ForEachIn(doc, func(word string) {
-			h := murmur3.Sum32([]byte(word))
-			i := int(h) % v.NumFeatures
+		for t, val := range v.vectorise(tokenise(v.Tokeniser, doc)) {
+			i, j := v.Orientation.index(t, d)
+			mat.Set(i, j, val)
+		}
+	}
+	return mat, nil
+}
 
-			mat.Set(i, d, mat.At(i, d)+1)
-		})
+// TransformTokens is equivalent to Transform() but accepts pre-tokenised documents,
+// each represented as a slice of tokens, rather than raw text.  The Tokeniser is not
+// used.  The returned matrix is a sparse matrix type.
+func (v *HashingVectoriser) TransformTokens(docs ...[]string) (mat.Matrix, error) {
+	mat := sparse.NewDOK(v.Orientation.index(v.NumFeatures, len(docs)))
+
+	for d, doc := range docs {
+		for t, val := range v.vectorise(sliceTokens(doc)) {
+			i, j := v.Orientation.index(t, d)
+			mat.Set(i, j, val)
+		}
 	}
 	return mat, nil
 }
 
+// TransformReader is equivalent to Transform() but streams the documents from r
+// rather than requiring them to be held in memory as a slice of strings.  Documents
+// are delimited within r by delim (e.g. '\n' for one document per line) and each
+// becomes a column of the returned matrix in the order read.  The returned matrix
+// is a sparse matrix type.
+func (v *HashingVectoriser) TransformReader(r io.Reader, delim byte) (mat.Matrix, error) {
+	return transformReader(r, delim, v.NumFeatures, v.Orientation, func(doc string) map[int]float64 {
+		return v.vectorise(tokenise(v.Tokeniser, doc))
+	})
+}
+
+// vectorise returns the non-zero elements of the feature vector for the document
+// with the specified tokens as a map of row indices to values.
+func (v *HashingVectoriser) vectorise(tokens tokenIterator) map[int]float64 {
+	vec := make(map[int]float64)
+	tokens(func(word string) {
+		h := murmur3.Sum32([]byte(word))
+		i := int(h) % v.NumFeatures
+
+		vec[i] += v.sign(word)
+	})
+	return vec
+}
+
+// HashCollisions summarises the hash collisions between distinct terms occurring
+// within a sample of documents vectorised by a HashingVectoriser.
+type HashCollisions struct {
+	// Terms is the number of distinct terms within the documents
+	Terms int
+
+	// Buckets is the number of distinct buckets (rows) to which the terms were hashed
+	Buckets int
+
+	// CollidingTerms is the number of distinct terms sharing a bucket with at least
+	// one other term
+	CollidingTerms int
+
+	// Collisions maps each bucket to which more than one distinct term was hashed
+	// to the terms hashed to it (in lexicographical order)
+	Collisions map[int][]string
+}
+
+// Rate returns the collision rate i.e. the proportion of distinct terms sharing
+// a bucket with at least one other term.
+func (c HashCollisions) Rate() float64 {
+	if c.Terms == 0 {
+		return 0
+	}
+	return float64(c.CollidingTerms) / float64(c.Terms)
+}
+
+// Collisions traces which distinct terms within the supplied sample of documents
+// are hashed to the same bucket (row) and returns a summary.  This can be used to
+// empirically size NumFeatures for a corpus by comparing the collision rate for
+// different values.
+func (v *HashingVectoriser) Collisions(docs ...string) HashCollisions {
+	buckets := make(map[int]map[string]bool)
+	for _, doc := range docs {
+		v.Tokeniser.ForEachIn(doc, func(word string) {
+			i := int(murmur3.Sum32([]byte(word))) % v.NumFeatures
+			if buckets[i] == nil {
+				buckets[i] = make(map[string]bool)
+			}
+			buckets[i][word] = true
+		})
+	}
+
+	c := HashCollisions{
+		Buckets:    len(buckets),
+		Collisions: make(map[int][]string),
+	}
+	for i, terms := range buckets {
+		c.Terms += len(terms)
+		if len(terms) < 2 {
+			continue
+		}
+		c.CollidingTerms += len(terms)
+		for term := range terms {
+			c.Collisions[i] = append(c.Collisions[i], term)
+		}
+		sort.Strings(c.Collisions[i])
+	}
+	return c
+}
+
+// sign returns the value to be added to the matrix for each occurrence of the
+// specified word.  This is always 1 unless Signed is true in which case a second,
+// independent, hash of the word is used to select either 1 or -1.
+func (v *HashingVectoriser) sign(word string) float64 {
+	if v.Signed && murmur3.Sum32WithSeed([]byte(word), signSeed)&1 == 1 {
+		return -1
+	}
+	return 1
+}
+
+// signSeed is the seed for the hash function used to determine the sign of
+// features when using signed feature hashing.
+const signSeed = 0x9747b28c
+
+// FeatureDims returns the number of features output by Transform(), NumFeatures.
+// The input is documents so the number of input features is always -1.
+func (v *HashingVectoriser) FeatureDims() (in, out int) {
+	return -1, v.NumFeatures
+}
+
 // FitTransform for a HashingVectoriser is exactly equivalent to calling
 // Transform() with the same matrix.  For most vectorisers, Fit() must be called
 // prior to Transform() and so this method is a convenience where separate
@@ -277,67 +1393,343 @@ func (v *HashingVectoriser) FitTransform(docs ...string) (mat.Matrix, error) {
 	return v.Transform(docs...)
 }
 
-// Pipeline is a mechanism for composing processing pipelines out of vectorisers
-// transformation steps.  For example to compose a classic LSA/LSI pipeline
-// (vectorisation -> TFIDF transformation -> Truncated SVD) one could use a
-// Pipeline as follows:
-// 	lsaPipeline := NewPipeline(NewCountVectoriser(false), NewTfidfTransformer(), NewTruncatedSVD(100))
-//
-type Pipeline struct {
-	Vectoriser   Vectoriser
-	Transformers []Transformer
+// hashingVectoriserHeader is the fixed size portion of a serialised HashingVectoriser.
+type hashingVectoriserHeader struct {
+	NumFeatures int64
+	Signed      bool
+	Orientation int64
+}
+
+// Save binary serialises the hyperparameters of the vectoriser and writes them into
+// w.  As a HashingVectoriser requires no fitting, this is only useful for persisting
+// the vectoriser as part of a Pipeline.  The Tokeniser is not serialised.
+func (v *HashingVectoriser) Save(w io.Writer) error {
+	header := hashingVectoriserHeader{
+		NumFeatures: int64(v.NumFeatures),
+		Signed:      v.Signed,
+		Orientation: int64(v.Orientation),
+	}
+	return binary.Write(w, binary.LittleEndian, header)
 }
 
-// NewPipeline constructs a new processing pipline with the supplied Vectoriser
-// and one or more transformers
-func NewPipeline(vectoriser Vectoriser, transformers ...Transformer) *Pipeline {
-	pipeline := Pipeline{
-		Vectoriser:   vectoriser,
-		Transformers: transformers,
+// Load binary deserialises the previously serialised hyperparameters into the
+// receiver.  Load should only be performed with trusted data.
+func (v *HashingVectoriser) Load(r io.Reader) error {
+	var header hashingVectoriserHeader
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return err
 	}
+	v.NumFeatures = int(header.NumFeatures)
+	v.Signed = header.Signed
+	v.Orientation = Orientation(header.Orientation)
 
-	return &pipeline
+	return nil
 }
 
-// Fit fits the model(s) to the supplied training data
-func (p *Pipeline) Fit(docs ...string) Vectoriser {
-	if _, err := p.FitTransform(docs...); err != nil {
-		panic("nlp: Failed to Fit pipeline because " + err.Error())
+// FieldWeightedVectoriser vectorises structured documents comprising multiple named
+// fields (e.g. title, body and tags) using a single, shared, vocabulary.  The term
+// frequencies for each field are multiplied by a per field weight before being
+// summed so that, for example, terms occurring in the title of a document can be
+// boosted relative to those occurring in the body.
+type FieldWeightedVectoriser struct {
+	// Vectoriser is the underlying CountVectoriser used to tokenise the text of each
+	// field, learn the shared Vocabulary and count term frequencies.
+	Vectoriser *CountVectoriser
+
+	// Weights maps field names to the multiplier applied to term frequencies within
+	// that field.  Fields not present in Weights have a weight of 1.
+	Weights map[string]float64
+}
+
+// NewFieldWeightedVectoriser creates a new FieldWeightedVectoriser using vectoriser
+// to learn the vocabulary and count terms, and applying the specified per field
+// weights.
+func NewFieldWeightedVectoriser(vectoriser *CountVectoriser, weights map[string]float64) *FieldWeightedVectoriser {
+	return &FieldWeightedVectoriser{
+		Vectoriser: vectoriser,
+		Weights:    weights,
 	}
+}
 
-	return p
+// Fit learns the shared Vocabulary from the terms in all fields of the supplied
+// training documents, each represented as a map of field names to text.  Each
+// document (rather than each field) is counted once for the purposes of document
+// frequency.  Field weights are not applied during fitting.
+func (v *FieldWeightedVectoriser) Fit(train ...map[string]string) *FieldWeightedVectoriser {
+	v.Vectoriser.fit(len(train), func(d int) tokenIterator {
+		return func(f func(token string)) {
+			for _, field := range fieldNames(train[d]) {
+				v.Vectoriser.Tokeniser.ForEachIn(train[d][field], f)
+			}
+		}
+	})
+	return v
 }
 
-// Transform transforms the supplied documents into a matrix representation
-// of numerical feature vectors using a model(s) previously fitted to supplied
-// training data.
-func (p *Pipeline) Transform(docs ...string) (mat.Matrix, error) {
-	matrix, err := p.Vectoriser.Transform(docs...)
-	if err != nil {
-		return matrix, err
+// Transform transforms the supplied documents, each represented as a map of field
+// names to text, into a term document matrix where each column is a feature vector
+// representing one of the supplied documents.  Each element is the sum, across all
+// fields, of the frequency of the associated term within the field multiplied by
+// the weight of the field.  Options on the underlying Vectoriser, such as Binary
+// and MaxTF, are applied to each field individually.  The returned matrix is a
+// sparse matrix type.
+func (v *FieldWeightedVectoriser) Transform(docs ...map[string]string) (mat.Matrix, error) {
+	cv := v.Vectoriser
+	mat := sparse.NewDOK(cv.Orientation.index(len(cv.Vocabulary)+cv.OOVBuckets, len(docs)))
+
+	for d, doc := range docs {
+		for _, field := range fieldNames(doc) {
+			weight, ok := v.Weights[field]
+			if !ok {
+				weight = 1
+			}
+			for t, val := range cv.vectorise(tokenise(cv.Tokeniser, doc[field])) {
+				i, j := cv.Orientation.index(t, d)
+				mat.Set(i, j, mat.At(i, j)+weight*val)
+			}
+		}
 	}
-	for _, t := range p.Transformers {
-		matrix, err = t.Transform(matrix)
-		if err != nil {
-			return matrix, err
+	return mat, nil
+}
+
+// FitTransform is exactly equivalent to calling Fit() followed by Transform() on the
+// same documents.  The returned matrix is a sparse matrix type.
+func (v *FieldWeightedVectoriser) FitTransform(docs ...map[string]string) (mat.Matrix, error) {
+	return v.Fit(docs...).Transform(docs...)
+}
+
+// fieldNames returns the names of the fields in doc in lexicographical order.
+func fieldNames(doc map[string]string) []string {
+	names := make([]string, 0, len(doc))
+	for name := range doc {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DictVectoriser encodes documents represented as maps of feature names to values
+// (e.g. precomputed counts from another system or metadata flags) into a sparse
+// matrix where each column represents a document and each row a feature.  This
+// allows features not derived directly from text to be used with the same
+// transformers and pipelines as the output of the other vectorisers.
+type DictVectoriser struct {
+	// Vocabulary is a map of feature names to indices that point to the row number
+	// representing that feature in the matrices output from Transform() and
+	// FitTransform().  The Vocabulary is populated by Fit() based upon the features
+	// present in the training documents.  Features encountered within Transform() that
+	// were not present in the training documents are ignored.
+	Vocabulary map[string]int
+
+	// Orientation specifies the layout of matrices output from Transform().  By
+	// default (TermsAsRows) each row represents a feature and each column a document.
+	// If set to DocumentsAsRows, the output matrices are transposed so that each
+	// row represents a document.
+	Orientation Orientation
+}
+
+// NewDictVectoriser creates a new DictVectoriser.
+func NewDictVectoriser() *DictVectoriser {
+	return &DictVectoriser{Vocabulary: make(map[string]int)}
+}
+
+// Fit learns the Vocabulary of feature names from the supplied training documents,
+// discarding any previously learnt Vocabulary.  Features are assigned indices in
+// lexicographical order of their names.
+func (v *DictVectoriser) Fit(train ...map[string]float64) *DictVectoriser {
+	var names []string
+	seen := make(map[string]bool)
+	for _, doc := range train {
+		for name := range doc {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+
+	v.Vocabulary = make(map[string]int, len(names))
+	for i, name := range names {
+		v.Vocabulary[name] = i
+	}
+
+	return v
+}
+
+// Transform transforms the supplied documents into a matrix where each column is a
+// feature vector representing one of the supplied documents.  Each element is the
+// value of the associated feature for that row within that document.  The returned
+// matrix is a sparse matrix type.
+func (v *DictVectoriser) Transform(docs ...map[string]float64) (mat.Matrix, error) {
+	mat := sparse.NewDOK(v.Orientation.index(len(v.Vocabulary), len(docs)))
+
+	for d, doc := range docs {
+		for name, val := range doc {
+			t, exists := v.Vocabulary[name]
+			if !exists || val == 0 {
+				continue
+			}
+			i, j := v.Orientation.index(t, d)
+			mat.Set(i, j, val)
+		}
+	}
+	return mat, nil
+}
+
+// FitTransform is exactly equivalent to calling Fit() followed by Transform() on the
+// same documents.  The returned matrix is a sparse matrix type.
+func (v *DictVectoriser) FitTransform(docs ...map[string]float64) (mat.Matrix, error) {
+	return v.Fit(docs...).Transform(docs...)
+}
+
+// GetFeatureNames returns the names of the features corresponding to each row of
+// the matrices output by Transform(), in row index order.
+func (v *DictVectoriser) GetFeatureNames() []string {
+	names := make([]string, len(v.Vocabulary))
+	for name, i := range v.Vocabulary {
+		names[i] = name
+	}
+	return names
+}
+
+// readDocs reads documents, delimited by delim, from r invoking f for each one.
+// The delimiter is not included in the documents passed to f and a trailing
+// delimiter at the end of r does not produce an additional empty document.
+func readDocs(r io.Reader, delim byte, f func(doc string)) error {
+	br := bufio.NewReader(r)
+	for {
+		doc, err := br.ReadString(delim)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if len(doc) > 0 && doc[len(doc)-1] == delim {
+			doc = doc[:len(doc)-1]
+		} else if err == io.EOF && len(doc) == 0 {
+			return nil
+		}
+		f(doc)
+		if err == io.EOF {
+			return nil
 		}
 	}
-	return matrix, nil
 }
 
-// FitTransform transforms the supplied documents into a matrix representation
-// of numerical feature vectors fitting the model to the supplied data in the
-// process.
-func (p *Pipeline) FitTransform(docs ...string) (mat.Matrix, error) {
-	matrix, err := p.Vectoriser.FitTransform(docs...)
+// transformReader builds a sparse matrix with the specified number of features and
+// a document for each document read from r, laid out according to orientation and
+// populated using the vectorise function.
+func transformReader(r io.Reader, delim byte, features int, orientation Orientation, vectorise func(doc string) map[int]float64) (mat.Matrix, error) {
+	var rows, cols []int
+	var data []float64
+	var n int
+
+	err := readDocs(r, delim, func(doc string) {
+		for t, val := range vectorise(doc) {
+			i, j := orientation.index(t, n)
+			rows = append(rows, i)
+			cols = append(cols, j)
+			data = append(data, val)
+		}
+		n++
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	m, c := orientation.index(features, n)
+	coo := sparse.NewCOO(m, c, rows, cols, data)
+	if orientation == DocumentsAsRows {
+		return coo.ToCSR(), nil
+	}
+	return coo.ToCSC(), nil
+}
+
+// TfidfVectoriser combines a CountVectoriser and a TfidfTransformer behind a single
+// Vectoriser, equivalent to a Pipeline of the two, converting documents directly into
+// a matrix of TF-IDF weighted term frequencies.  The orientation of the
+// TfidfTransformer is set to match that of the CountVectoriser.
+type TfidfVectoriser struct {
+	// Vectoriser is the underlying CountVectoriser used to learn the Vocabulary and
+	// count term frequencies.
+	Vectoriser *CountVectoriser
+
+	// Transformer is the underlying TfidfTransformer used to weight the term
+	// frequencies.
+	Transformer *TfidfTransformer
+}
+
+// NewTfidfVectoriser creates a new TfidfVectoriser using a new CountVectoriser,
+// removing the specified stopWords, and a new TfidfTransformer.  Either may be
+// further configured through the Vectoriser and Transformer fields respectively.
+func NewTfidfVectoriser(stopWords ...string) *TfidfVectoriser {
+	return &TfidfVectoriser{
+		Vectoriser:  NewCountVectoriser(stopWords...),
+		Transformer: NewTfidfTransformer(),
+	}
+}
+
+// Fit learns the Vocabulary and inverse document frequency weights from the
+// supplied training documents.
+func (v *TfidfVectoriser) Fit(train ...string) Vectoriser {
+	if _, err := v.FitTransform(train...); err != nil {
+		panic("nlp: Failed to Fit TfidfVectoriser because " + err.Error())
+	}
+
+	return v
+}
+
+// Transform transforms the supplied documents into a matrix of TF-IDF weighted term
+// frequencies using the Vocabulary and weights previously learnt during Fit().  The
+// returned matrix is a sparse matrix type.
+func (v *TfidfVectoriser) Transform(docs ...string) (mat.Matrix, error) {
+	matrix, err := v.Vectoriser.Transform(docs...)
 	if err != nil {
 		return matrix, err
 	}
-	for _, t := range p.Transformers {
-		matrix, err = t.FitTransform(matrix)
-		if err != nil {
-			return matrix, err
-		}
+	v.Transformer.SetOrientation(v.Vectoriser.Orientation)
+	return v.Transformer.Transform(matrix)
+}
+
+// FeatureDims returns the number of features (terms) output by Transform() or -1 if
+// the vectoriser has not been fitted.  The input is documents so the number of input
+// features is always -1.
+func (v *TfidfVectoriser) FeatureDims() (in, out int) {
+	return v.Vectoriser.FeatureDims()
+}
+
+// FitTransform is exactly equivalent to calling Fit() followed by Transform() on the
+// same documents but is more efficient.  The returned matrix is a sparse matrix type.
+func (v *TfidfVectoriser) FitTransform(docs ...string) (mat.Matrix, error) {
+	matrix, err := v.Vectoriser.FitTransform(docs...)
+	if err != nil {
+		return matrix, err
+	}
+	v.Transformer.SetOrientation(v.Vectoriser.Orientation)
+	return v.Transformer.FitTransform(matrix)
+}
+
+// GetFeatureNames returns a slice of the terms in the Vocabulary ordered by index
+// i.e. the term represented by each row (or column) of transformed matrices.
+func (v *TfidfVectoriser) GetFeatureNames() []string {
+	return v.Vectoriser.GetFeatureNames()
+}
+
+// Save binary serialises the underlying CountVectoriser and TfidfTransformer and
+// writes them into w.  This is useful for persisting a trained model to disk so that
+// it may be loaded (using the Load() method) in another context (e.g. production)
+// for reproducible results.
+func (v *TfidfVectoriser) Save(w io.Writer) error {
+	if err := v.Vectoriser.Save(w); err != nil {
+		return err
+	}
+	return v.Transformer.Save(w)
+}
+
+// Load binary deserialises the previously serialised model into the receiver.  Load
+// should only be performed with trusted data.
+func (v *TfidfVectoriser) Load(r io.Reader) error {
+	if err := v.Vectoriser.Load(r); err != nil {
+		return err
 	}
-	return matrix, nil
+	return v.Transformer.Load(r)
 }
@@ -1,9 +1,16 @@
 package nlp
 
 import (
+	"bytes"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/james-bowman/sparse"
+	"github.com/spaolacci/murmur3"
+	"gonum.org/v1/gonum/mat"
 )
 
 var stopWords = []string{"a", "about", "above", "above", "across", "after", "afterwards", "again", "against", "all", "almost", "alone", "along", "already", "also", "although", "always", "am", "among", "amongst", "amoungst", "amount", "an", "and", "another", "any", "anyhow", "anyone", "anything", "anyway", "anywhere", "are", "around", "as", "at", "back", "be", "became", "because", "become", "becomes", "becoming", "been", "before", "beforehand", "behind", "being", "below", "beside", "besides", "between", "beyond", "bill", "both", "bottom", "but", "by", "call", "can", "cannot", "cant", "co", "con", "could", "couldnt", "cry", "de", "describe", "detail", "do", "done", "down", "due", "during", "each", "eg", "eight", "either", "eleven", "else", "elsewhere", "empty", "enough", "etc", "even", "ever", "every", "everyone", "everything", "everywhere", "except", "few", "fifteen", "fify", "fill", "find", "fire", "first", "five", "for", "former", "formerly", "forty", "found", "four", "from", "front", "full", "further", "get", "give", "go", "had", "has", "hasnt", "have", "he", "hence", "her", "here", "hereafter", "hereby", "herein", "hereupon", "hers", "herself", "him", "himself", "his", "how", "however", "hundred", "ie", "if", "in", "inc", "indeed", "interest", "into", "is", "it", "its", "itself", "keep", "last", "latter", "latterly", "least", "less", "ltd", "made", "many", "may", "me", "meanwhile", "might", "mill", "mine", "more", "moreover", "most", "mostly", "move", "much", "must", "my", "myself", "name", "namely", "neither", "never", "nevertheless", "next", "nine", "no", "nobody", "none", "noone", "nor", "not", "nothing", "now", "nowhere", "of", "off", "often", "on", "once", "one", "only", "onto", "or", "other", "others", "otherwise", "our", "ours", "ourselves", "out", "over", "own", "part", "per", "perhaps", "please", "put", "rather", "re", "same", "see", "seem", "seemed", "seeming", "seems", "serious", "several", "she", "should", "show", "side", "since", "sincere", "six", "sixty", "so", "some", "somehow", "someone", "something", "sometime", "sometimes", "somewhere", "still", "such", "system", "take", "ten", "than", "that", "the", "their", "them", "themselves", "then", "thence", "there", "thereafter", "thereby", "therefore", "therein", "thereupon", "these", "they", "thickv", "thin", "third", "this", "those", "though", "three", "through", "throughout", "thru", "thus", "to", "together", "too", "top", "toward", "towards", "twelve", "twenty", "two", "un", "under", "until", "up", "upon", "us", "very", "via", "was", "we", "well", "were", "what", "whatever", "when", "whence", "whenever", "where", "whereafter", "whereas", "whereby", "wherein", "whereupon", "wherever", "whether", "which", "while", "whither", "who", "whoever", "whole", "whom", "whose", "why", "will", "with", "within", "without", "would", "yet", "you", "your", "yours", "yourself", "yourselves"}
@@ -50,6 +57,90 @@ func TestCountVectoriserFit(t *testing.T) {
 		}
 	}
 }
+func TestCountVectoriserFitDocumentFrequency(t *testing.T) {
+	var tests = []struct {
+		minDF     float64
+		maxDF     float64
+		vocabSize int
+		excluded  []string
+	}{
+		{0, 0, 26, nil},
+		{2, 0, 4, []string{"quick", "laughing"}},
+		{0.5, 0, 2, []string{"cow", "brown"}},
+		{0, 3, 25, []string{"the"}},
+		{0, 0.5, 25, []string{"the"}},
+		{2, 2, 2, []string{"the", "dog", "quick"}},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+		vectoriser := NewCountVectoriser()
+		vectoriser.MinDF = test.minDF
+		vectoriser.MaxDF = test.maxDF
+
+		vectoriser.Fit(trainSet...)
+
+		if len(vectoriser.Vocabulary) != test.vocabSize {
+			t.Logf("Expected vocabulary of size %d but found vocabulary %v of size %d",
+				test.vocabSize, vectoriser.Vocabulary, len(vectoriser.Vocabulary))
+			t.Fail()
+		}
+		for _, term := range test.excluded {
+			if _, exists := vectoriser.Vocabulary[term]; exists {
+				t.Logf("Expected term %q to be excluded from vocabulary %v", term, vectoriser.Vocabulary)
+				t.Fail()
+			}
+		}
+		for term, i := range vectoriser.Vocabulary {
+			if i < 0 || i >= len(vectoriser.Vocabulary) {
+				t.Logf("Term %q has index %d outside of vocabulary bounds", term, i)
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestCountVectoriserFitMaxFeatures(t *testing.T) {
+	var tests = []struct {
+		maxFeatures int
+		expected    []string
+	}{
+		{0, nil},
+		{1, []string{"the"}},
+		{2, []string{"the", "dog"}},
+		// ties between brown and cow broken lexicographically
+		{3, []string{"the", "dog", "brown"}},
+		{4, []string{"the", "dog", "brown", "cow"}},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+		vectoriser := NewCountVectoriser()
+		vectoriser.MaxFeatures = test.maxFeatures
+
+		vectoriser.Fit(trainSet...)
+
+		if test.expected == nil {
+			if len(vectoriser.Vocabulary) != 26 {
+				t.Logf("Expected unrestricted vocabulary of size 26 but found %d", len(vectoriser.Vocabulary))
+				t.Fail()
+			}
+			continue
+		}
+
+		if len(vectoriser.Vocabulary) != len(test.expected) {
+			t.Logf("Expected vocabulary %v but found %v", test.expected, vectoriser.Vocabulary)
+			t.Fail()
+		}
+		for _, term := range test.expected {
+			if i, exists := vectoriser.Vocabulary[term]; !exists || i >= len(test.expected) {
+				t.Logf("Expected term %q in vocabulary %v", term, vectoriser.Vocabulary)
+				t.Fail()
+			}
+		}
+	}
+}
+
 func TestCountVectoriserTransform(t *testing.T) {
 	var tests = []struct {
 		train     []string
@@ -84,6 +175,813 @@ func TestCountVectoriserTransform(t *testing.T) {
 	}
 }
 
+func TestTokeniserFunc(t *testing.T) {
+	hashtags := regexp.MustCompile("#\\w+")
+
+	vectoriser := NewCountVectoriser()
+	vectoriser.Tokeniser = TokeniserFunc(func(text string) []string {
+		return hashtags.FindAllString(text, -1)
+	})
+	hashing := NewHashingVectoriser(100)
+	hashing.Tokeniser = vectoriser.Tokeniser
+
+	docs := []string{"loving #golang and #nlp", "more #golang please"}
+	vec, err := vectoriser.FitTransform(docs...)
+	if err != nil {
+		t.Errorf("Error fitting and applying vectoriser caused by %v", err)
+	}
+
+	if len(vectoriser.Vocabulary) != 2 {
+		t.Errorf("Expected vocabulary of 2 hashtags but found %v", vectoriser.Vocabulary)
+	}
+	if vec.At(vectoriser.Vocabulary["#golang"], 1) != 1 {
+		t.Errorf("Expected #golang to occur once in second document")
+	}
+
+	hashed, err := hashing.Transform(docs...)
+	if err != nil {
+		t.Errorf("Error applying hashing vectoriser caused by %v", err)
+	}
+	if nnz := hashed.(sparse.Sparser).NNZ(); nnz != 3 {
+		t.Errorf("Expected 3 non zero hashed features but found %d", nnz)
+	}
+}
+
+func TestCountVectoriserBinary(t *testing.T) {
+	var tests = []struct {
+		binary   bool
+		doc      string
+		term     string
+		expected float64
+	}{
+		{false, "the dog chased the other dog", "dog", 2},
+		{true, "the dog chased the other dog", "dog", 1},
+		{true, "the dog chased the other dog", "chased", 1},
+		{true, "the dog chased the other dog", "cat", 0},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+		vectoriser := NewCountVectoriser()
+		vectoriser.Binary = test.binary
+		vectoriser.Fit(test.doc, "the cat")
+
+		vec, err := vectoriser.Transform(test.doc)
+		if err != nil {
+			t.Errorf("Error applying vectoriser caused by %v", err)
+		}
+
+		if v := vec.At(vectoriser.Vocabulary[test.term], 0); v != test.expected {
+			t.Logf("Expected %f for term %q but found %f", test.expected, test.term, v)
+			t.Fail()
+		}
+	}
+}
+
+func TestCountVectoriserMaxTF(t *testing.T) {
+	var tests = []struct {
+		maxTF    int
+		term     string
+		expected float64
+	}{
+		{0, "dog", 5},
+		{2, "dog", 2},
+		{2, "cat", 1},
+		{10, "dog", 5},
+	}
+
+	doc := "dog dog dog the cat dog dog"
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+		vectoriser := NewCountVectoriser()
+		vectoriser.MaxTF = test.maxTF
+		vectoriser.Fit(doc)
+
+		vec, err := vectoriser.Transform(doc)
+		if err != nil {
+			t.Errorf("Error applying vectoriser caused by %v", err)
+		}
+
+		if v := vec.At(vectoriser.Vocabulary[test.term], 0); v != test.expected {
+			t.Logf("Expected %f for term %q but found %f", test.expected, test.term, v)
+			t.Fail()
+		}
+	}
+}
+
+func TestCountVectoriserParallel(t *testing.T) {
+	docs := append(append([]string{}, trainSet...), testSet...)
+
+	for _, processes := range []int{2, 3, 4, 100} {
+		expected := NewCountVectoriser(stopWords...)
+		expected.MaxFeatures = 10
+		expectedMat, err := expected.FitTransform(docs...)
+		if err != nil {
+			t.Errorf("Error applying vectoriser caused by %v", err)
+		}
+
+		vectoriser := NewCountVectoriser(stopWords...)
+		vectoriser.MaxFeatures = 10
+		vectoriser.Processes = processes
+		result, err := vectoriser.FitTransform(docs...)
+		if err != nil {
+			t.Errorf("Error applying vectoriser caused by %v", err)
+		}
+
+		if !reflect.DeepEqual(expected.Vocabulary, vectoriser.Vocabulary) {
+			t.Errorf("Expected vocabulary %v with %d processes but found %v", expected.Vocabulary, processes, vectoriser.Vocabulary)
+		}
+		if !mat.Equal(expectedMat, result) {
+			t.Errorf("Expected matrix with %d processes:\n%v\nbut found:\n%v", processes, mat.Formatted(expectedMat), mat.Formatted(result))
+		}
+	}
+}
+
+func TestVectoriserTokens(t *testing.T) {
+	tokeniser := NewTokeniser(stopWords...)
+	trainTokens := make([][]string, len(trainSet))
+	for i, doc := range trainSet {
+		trainTokens[i] = tokeniser.Tokenise(doc)
+	}
+	testTokens := make([][]string, len(testSet))
+	for i, doc := range testSet {
+		testTokens[i] = tokeniser.Tokenise(doc)
+	}
+
+	expected := NewCountVectoriser(stopWords...)
+	expectedMat, _ := expected.Fit(trainSet...).Transform(testSet...)
+
+	// the tokeniser should not be used for pre-tokenised input
+	vectoriser := &CountVectoriser{}
+	result, err := vectoriser.FitTokens(trainTokens...).TransformTokens(testTokens...)
+	if err != nil {
+		t.Errorf("Error applying vectoriser caused by %v", err)
+	}
+	if !reflect.DeepEqual(expected.Vocabulary, vectoriser.Vocabulary) {
+		t.Errorf("Expected vocabulary %v but found %v", expected.Vocabulary, vectoriser.Vocabulary)
+	}
+	if !mat.Equal(expectedMat, result) {
+		t.Errorf("Expected matrix:\n%v\nbut found:\n%v", mat.Formatted(expectedMat), mat.Formatted(result))
+	}
+
+	hashing := NewHashingVectoriser(20, stopWords...)
+	expectedMat, _ = hashing.Transform(testSet...)
+	result, err = (&HashingVectoriser{NumFeatures: 20}).TransformTokens(testTokens...)
+	if err != nil {
+		t.Errorf("Error applying vectoriser caused by %v", err)
+	}
+	if !mat.Equal(expectedMat, result) {
+		t.Errorf("Expected matrix:\n%v\nbut found:\n%v", mat.Formatted(expectedMat), mat.Formatted(result))
+	}
+}
+
+func TestCountVectoriserTermStats(t *testing.T) {
+	vectoriser := NewCountVectoriser()
+	vectoriser.Fit("the dog chased the cat", "the cat sat")
+	vectoriser.PartialFit("a dog")
+
+	expected := []TermStat{
+		{"the", 0, 2, 3},
+		{"dog", 1, 2, 2},
+		{"chased", 2, 1, 1},
+		{"cat", 3, 2, 2},
+		{"sat", 4, 1, 1},
+		{"a", 5, 1, 1},
+	}
+	if stats := vectoriser.TermStats(); !reflect.DeepEqual(expected, stats) {
+		t.Errorf("Expected term stats %v but found %v", expected, stats)
+	}
+
+	vectoriser.Prune(func(term string, df int) bool { return term == "the" })
+	if stats := vectoriser.TermStats(); !reflect.DeepEqual(TermStat{"dog", 0, 2, 2}, stats[0]) {
+		t.Errorf("Expected term stats %v after pruning but found %v", TermStat{"dog", 0, 2, 2}, stats[0])
+	}
+}
+
+func TestCountVectoriserVocabularyJSON(t *testing.T) {
+	a := NewCountVectoriser(stopWords...)
+	a.Fit(trainSet...)
+
+	buf := new(bytes.Buffer)
+	if err := a.SaveVocabularyJSON(buf); err != nil {
+		t.Fatalf("Error encoding vocabulary: %v", err)
+	}
+
+	b := NewCountVectoriser(stopWords...)
+	if err := b.LoadVocabularyJSON(buf); err != nil {
+		t.Fatalf("Error decoding vocabulary: %v", err)
+	}
+
+	if !reflect.DeepEqual(a.Vocabulary, b.Vocabulary) {
+		t.Errorf("Expected vocabulary %v but found %v", a.Vocabulary, b.Vocabulary)
+	}
+
+	aVec, _ := a.Transform(testSet...)
+	bVec, _ := b.Transform(testSet...)
+	if !mat.Equal(aVec, bVec) {
+		t.Errorf("Expected matching transforms from original and loaded vocabularies")
+	}
+
+	invalid := []string{
+		`{"dog": 0, "cat": 0}`,
+		`{"dog": 0, "cat": 2}`,
+		`{"dog": -1}`,
+		`["dog"]`,
+	}
+	for _, vocab := range invalid {
+		if err := b.LoadVocabularyJSON(strings.NewReader(vocab)); err == nil {
+			t.Errorf("Expected error loading invalid vocabulary %s", vocab)
+		}
+	}
+}
+
+func TestCountVectoriserPartialFit(t *testing.T) {
+	var vectoriser OnlineVectoriser = NewCountVectoriser()
+
+	batch := NewCountVectoriser()
+	batch.Fit(trainSet...)
+
+	for _, doc := range trainSet {
+		vectoriser.PartialFit(doc)
+	}
+
+	vocab := vectoriser.(*CountVectoriser).Vocabulary
+	if !reflect.DeepEqual(vocab, batch.Vocabulary) {
+		t.Errorf("Expected incrementally learnt vocabulary %v to match batch learnt vocabulary %v", vocab, batch.Vocabulary)
+	}
+
+	before, _ := vectoriser.Transform(testSet...)
+	vectoriser.PartialFit(testSet...)
+	after, _ := vectoriser.Transform(testSet...)
+
+	r, c := before.Dims()
+	ar, ac := after.Dims()
+	if ar <= r || ac != c {
+		t.Errorf("Expected vocabulary to grow from %d terms but found %d", r, ar)
+	}
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			if before.At(i, j) != after.At(i, j) {
+				t.Errorf("Expected existing term indices to be retained after PartialFit but (%d, %d) changed from %f to %f",
+					i, j, before.At(i, j), after.At(i, j))
+			}
+		}
+	}
+}
+
+func TestCountVectoriserOOVBuckets(t *testing.T) {
+	var tests = []struct {
+		buckets int
+		rows    int
+		total   float64
+	}{
+		{0, 26, 31},
+		{1, 27, 38},
+		{10, 36, 38},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+		vectoriser := NewCountVectoriser()
+		vectoriser.OOVBuckets = test.buckets
+		vectoriser.Fit(trainSet...)
+
+		vec, err := vectoriser.Transform(testSet...)
+		if err != nil {
+			t.Errorf("Error applying vectoriser caused by %v", err)
+		}
+
+		m, n := vec.Dims()
+		if m != test.rows || n != len(testSet) {
+			t.Logf("Expected matrix %d x %d but found %d x %d", test.rows, len(testSet), m, n)
+			t.Fail()
+		}
+
+		var total float64
+		for i := 0; i < m; i++ {
+			for j := 0; j < n; j++ {
+				total += vec.At(i, j)
+			}
+		}
+		if total != test.total {
+			t.Logf("Expected %f terms to be counted but found %f", test.total, total)
+			t.Fail()
+		}
+	}
+}
+
+func TestCountVectoriserPrune(t *testing.T) {
+	vectoriser := NewCountVectoriser()
+	original, _ := vectoriser.FitTransform(trainSet...)
+	size := len(vectoriser.Vocabulary)
+
+	removed := make(map[string]bool)
+	mapping := vectoriser.Prune(func(term string, df int) bool {
+		if df == 1 || term == "the" {
+			removed[term] = true
+			return true
+		}
+		return false
+	})
+
+	if len(mapping) != size {
+		t.Errorf("Expected mapping of length %d but found %d", size, len(mapping))
+	}
+	// brown, dog and cow occur in more than one document
+	if len(vectoriser.Vocabulary) != 3 || len(removed) != size-3 {
+		t.Errorf("Expected 3 terms to remain in vocabulary but found %v", vectoriser.Vocabulary)
+	}
+	for term := range removed {
+		if _, exists := vectoriser.Vocabulary[term]; exists {
+			t.Errorf("Expected term %q to be pruned from vocabulary", term)
+		}
+	}
+
+	pruned, _ := vectoriser.Transform(trainSet...)
+	r, c := pruned.Dims()
+	if r != 3 || c != len(trainSet) {
+		t.Errorf("Expected pruned matrix of 3 x %d but found %d x %d", len(trainSet), r, c)
+	}
+
+	var remaining int
+	for old, i := range mapping {
+		if i == -1 {
+			continue
+		}
+		remaining++
+		for j := 0; j < c; j++ {
+			if original.At(old, j) != pruned.At(i, j) {
+				t.Errorf("Expected row %d of original matrix to map to row %d of pruned matrix", old, i)
+			}
+		}
+	}
+	if remaining != 3 {
+		t.Errorf("Expected 3 mapped indices but found %d in %v", remaining, mapping)
+	}
+}
+
+func TestCountVectoriserInverseTransform(t *testing.T) {
+	var tests = []struct {
+		docs     []string
+		expected [][]string
+	}{
+		{
+			docs:     []string{"the dog", "The cat sat on the mat with the Dog", "a moose", ""},
+			expected: [][]string{{"the", "dog"}, {"the", "dog", "cat", "sat", "on", "mat"}, {}, {}},
+		},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+		vectoriser := NewCountVectoriser()
+		vectoriser.OOVBuckets = 2
+		vectoriser.Fit(trainSet...)
+
+		vec, err := vectoriser.Transform(test.docs...)
+		if err != nil {
+			t.Errorf("Error applying vectoriser caused by %v", err)
+		}
+
+		for _, matrix := range []mat.Matrix{vec, mat.DenseCopyOf(vec)} {
+			docs := vectoriser.InverseTransform(matrix)
+
+			if !reflect.DeepEqual(docs, test.expected) {
+				t.Logf("Expected %v but found %v", test.expected, docs)
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestCountVectoriserGetFeatureNames(t *testing.T) {
+	var tests = []struct {
+		oovBuckets int
+		expected   []string
+	}{
+		{0, []string{"the", "cat", "sat", "on", "mat", "dog"}},
+		{2, []string{"the", "cat", "sat", "on", "mat", "dog", "<oov:0>", "<oov:1>"}},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+		vectoriser := NewCountVectoriser()
+		vectoriser.OOVBuckets = test.oovBuckets
+
+		vec, _ := vectoriser.FitTransform("the cat sat on the mat", "the dog")
+		names := vectoriser.GetFeatureNames()
+
+		if !reflect.DeepEqual(names, test.expected) {
+			t.Errorf("Expected feature names %v but found %v", test.expected, names)
+		}
+		if r, _ := vec.Dims(); r != len(names) {
+			t.Errorf("Expected a feature name for each of the %d rows but found %d", r, len(names))
+		}
+		if vec.At(5, 1) != 1 {
+			t.Errorf("Expected row labelled %q to contain term count", names[5])
+		}
+	}
+}
+
+func TestCountVectoriserReader(t *testing.T) {
+	var tests = []struct {
+		delim byte
+		input string
+		docs  []string
+	}{
+		{'\n', strings.Join(trainSet, "\n"), trainSet},
+		{'\n', strings.Join(trainSet, "\n") + "\n", trainSet},
+		{'\x00', strings.Join(testSet, "\x00"), testSet},
+		{'\n', "the quick fox\n\nthe lazy dog", []string{"the quick fox", "", "the lazy dog"}},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		expected := NewCountVectoriser(stopWords...)
+		expectedMat, err := expected.FitTransform(test.docs...)
+		if err != nil {
+			t.Errorf("Error applying vectoriser caused by %v", err)
+		}
+
+		vectoriser := NewCountVectoriser(stopWords...)
+		if err := vectoriser.FitReader(strings.NewReader(test.input), test.delim); err != nil {
+			t.Errorf("Error fitting vectoriser caused by %v", err)
+		}
+		if !reflect.DeepEqual(expected.Vocabulary, vectoriser.Vocabulary) {
+			t.Errorf("Expected vocabulary %v but found %v", expected.Vocabulary, vectoriser.Vocabulary)
+		}
+
+		result, err := vectoriser.TransformReader(strings.NewReader(test.input), test.delim)
+		if err != nil {
+			t.Errorf("Error applying vectoriser caused by %v", err)
+		}
+		if !mat.Equal(expectedMat, result) {
+			t.Errorf("Expected matrix:\n%v\nbut found:\n%v", mat.Formatted(expectedMat), mat.Formatted(result))
+		}
+	}
+}
+
+func TestVectoriserOrientation(t *testing.T) {
+	var tests = []struct {
+		termsAsRows     Vectoriser
+		documentsAsRows Vectoriser
+	}{
+		{
+			termsAsRows:     &CountVectoriser{Tokeniser: NewTokeniser(stopWords...), OOVBuckets: 3},
+			documentsAsRows: &CountVectoriser{Tokeniser: NewTokeniser(stopWords...), OOVBuckets: 3, Orientation: DocumentsAsRows},
+		},
+		{
+			termsAsRows:     NewHashingVectoriser(20, stopWords...),
+			documentsAsRows: &HashingVectoriser{NumFeatures: 20, Tokeniser: NewTokeniser(stopWords...), Orientation: DocumentsAsRows},
+		},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		expected, err := test.termsAsRows.Fit(trainSet...).Transform(testSet...)
+		if err != nil {
+			t.Errorf("Error applying vectoriser caused by %v", err)
+		}
+		result, err := test.documentsAsRows.Fit(trainSet...).Transform(testSet...)
+		if err != nil {
+			t.Errorf("Error applying vectoriser caused by %v", err)
+		}
+
+		if r, c := result.Dims(); r != len(testSet) {
+			t.Errorf("Expected %d rows (one per document) but found %dx%d", len(testSet), r, c)
+		}
+		if !mat.Equal(expected.T(), result) {
+			t.Errorf("Expected matrix:\n%v\nbut found:\n%v", mat.Formatted(expected.T()), mat.Formatted(result))
+		}
+	}
+
+	vectoriser := NewCountVectoriser(stopWords...)
+	vectoriser.Orientation = DocumentsAsRows
+	vectoriser.Fit(trainSet...)
+	result, err := vectoriser.TransformReader(strings.NewReader(strings.Join(testSet, "\n")), '\n')
+	if err != nil {
+		t.Errorf("Error applying vectoriser caused by %v", err)
+	}
+	expected, _ := vectoriser.Transform(testSet...)
+	if !mat.Equal(expected, result) {
+		t.Errorf("Expected matrix:\n%v\nbut found:\n%v", mat.Formatted(expected), mat.Formatted(result))
+	}
+
+	termsAsRows := NewCountVectoriser(stopWords...)
+	termsAsRows.Fit(trainSet...)
+	docs := termsAsRows.InverseTransform(expected.T())
+	if terms := vectoriser.InverseTransform(expected); !reflect.DeepEqual(docs, terms) {
+		t.Errorf("Expected inverse transform %v but found %v", docs, terms)
+	}
+}
+
+func TestTokeniseWithOffsets(t *testing.T) {
+	var tests = []struct {
+		text      string
+		stopWords []string
+		expected  []Token
+	}{
+		{
+			text:     "The quick, brown fox.",
+			expected: []Token{{"the", 0, 3}, {"quick", 4, 9}, {"brown", 11, 16}, {"fox", 17, 20}},
+		},
+		{
+			text:      "The quick, brown fox.",
+			stopWords: []string{"the", "brown"},
+			expected:  []Token{{"quick", 4, 9}, {"fox", 17, 20}},
+		},
+		{
+			text:     "Ⱥb Café  naïve",
+			expected: []Token{{"ⱥb", 0, 3}, {"café", 4, 9}, {"naïve", 11, 17}},
+		},
+		{
+			text:     "",
+			expected: nil,
+		},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+		tokeniser := NewTokeniser(test.stopWords...).(*RegExpTokeniser)
+
+		tokens := tokeniser.TokeniseWithOffsets(test.text)
+		if !reflect.DeepEqual(test.expected, tokens) {
+			t.Errorf("Expected tokens %v but found %v", test.expected, tokens)
+		}
+
+		words := tokeniser.Tokenise(test.text)
+		if len(words) != len(tokens) {
+			t.Errorf("Expected %d tokens consistent with Tokenise() but found %d", len(words), len(tokens))
+			continue
+		}
+		for i, token := range tokens {
+			if token.Text != words[i] {
+				t.Errorf("Expected token %q consistent with Tokenise() but found %q", words[i], token.Text)
+			}
+			if !strings.EqualFold(test.text[token.Start:token.End], token.Text) {
+				t.Errorf("Expected offsets to reference %q but found %q", token.Text, test.text[token.Start:token.End])
+			}
+		}
+	}
+}
+
+func TestNGramTokeniser(t *testing.T) {
+	var tests = []struct {
+		minN, maxN int
+		skip       int
+		stop       []string
+		text       string
+		expected   []string
+	}{
+		{1, 1, 0, []string{}, "Machine learning rocks", []string{"machine", "learning", "rocks"}},
+		{1, 2, 0, []string{}, "Machine learning rocks", []string{"machine", "learning", "rocks", "machine learning", "learning rocks"}},
+		{2, 3, 0, []string{}, "Machine learning rocks", []string{"machine learning", "learning rocks", "machine learning rocks"}},
+		{1, 2, 0, []string{"the"}, "the cat sat", []string{"cat", "sat", "cat sat"}},
+		{3, 3, 0, []string{}, "too short", nil},
+		{2, 2, 1, []string{}, "new shiny york", []string{"new shiny", "new york", "shiny york"}},
+		{3, 3, 1, []string{}, "a b c d", []string{"a b c", "a b d", "a c d", "b c d"}},
+		{1, 2, 2, []string{}, "a b", []string{"a", "b", "a b"}},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+		tokeniser := NewNGramTokeniser(test.minN, test.maxN, test.stop...)
+		tokeniser.Skip = test.skip
+
+		tokens := tokeniser.Tokenise(test.text)
+
+		if !reflect.DeepEqual(tokens, test.expected) {
+			t.Logf("Expected tokens %v but found %v", test.expected, tokens)
+			t.Fail()
+		}
+	}
+}
+
+func TestCountVectoriserNGrams(t *testing.T) {
+	var tests = []struct {
+		train     []string
+		minN      int
+		maxN      int
+		vocabSize int
+	}{
+		{trainSet, 1, 1, 26},
+		{trainSet[0:1], 1, 2, 16},
+		{trainSet[0:1], 1, 3, 23},
+		{trainSet[0:1], 2, 2, 8},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+		vectoriser := NewCountVectoriser()
+		vectoriser.Tokeniser = NewNGramTokeniser(test.minN, test.maxN)
+
+		vec, err := vectoriser.FitTransform(test.train...)
+		if err != nil {
+			t.Errorf("Error fitting and applying vectoriser caused by %v", err)
+		}
+
+		if len(vectoriser.Vocabulary) != test.vocabSize {
+			t.Logf("Expected vocabulary of size %d but found vocabulary %v of size %d",
+				test.vocabSize, vectoriser.Vocabulary, len(vectoriser.Vocabulary))
+			t.Fail()
+		}
+
+		m, n := vec.Dims()
+		if m != test.vocabSize || n != len(test.train) {
+			t.Logf("Expected matrix %d x %d but found %d x %d", test.vocabSize, len(test.train), m, n)
+			t.Fail()
+		}
+	}
+}
+
+func TestCharNGramTokeniser(t *testing.T) {
+	var tests = []struct {
+		minN, maxN     int
+		wordBoundaries bool
+		text           string
+		expected       []string
+	}{
+		{2, 2, false, "Ab  cd", []string{"ab", "b ", " c", "cd"}},
+		{2, 3, false, "abc", []string{"ab", "bc", "abc"}},
+		{2, 2, true, "Ab cd", []string{" a", "ab", "b ", " c", "cd", "d "}},
+		{3, 3, true, "ab c", []string{" ab", "ab ", " c "}},
+		{1, 1, false, "東京", []string{"東", "京"}},
+		{4, 4, false, "abc", nil},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+		tokeniser := NewCharNGramTokeniser(test.minN, test.maxN, test.wordBoundaries)
+
+		tokens := tokeniser.Tokenise(test.text)
+
+		if !reflect.DeepEqual(tokens, test.expected) {
+			t.Logf("Expected tokens %q but found %q", test.expected, tokens)
+			t.Fail()
+		}
+	}
+}
+
+func TestCharNGramVectoriser(t *testing.T) {
+	vectoriser := NewCharNGramVectoriser(3, 3, true)
+
+	vec, err := vectoriser.FitTransform("colour", "color")
+	if err != nil {
+		t.Errorf("Error fitting and applying vectoriser caused by %v", err)
+	}
+
+	// " co", "col", "olo" are shared between both documents
+	if len(vectoriser.Vocabulary) != 8 {
+		t.Errorf("Expected vocabulary of size 8 but found %v", vectoriser.Vocabulary)
+	}
+	for _, ngram := range []string{" co", "col", "olo"} {
+		i, ok := vectoriser.Vocabulary[ngram]
+		if !ok {
+			t.Errorf("Expected n-gram %q in vocabulary but not found", ngram)
+			continue
+		}
+		if vec.At(i, 0) != 1 || vec.At(i, 1) != 1 {
+			t.Errorf("Expected n-gram %q to occur once in each document but found %f and %f", ngram, vec.At(i, 0), vec.At(i, 1))
+		}
+	}
+}
+
+func TestFieldWeightedVectoriser(t *testing.T) {
+	docs := []map[string]string{
+		{"title": "Dog bites man", "body": "The dog was angry with the man"},
+		{"title": "Cat sat", "body": "The cat sat on the mat", "tags": "cat mat"},
+	}
+
+	vectoriser := NewFieldWeightedVectoriser(NewCountVectoriser("the", "was", "with", "on"), map[string]float64{"title": 3, "tags": 0.5})
+	result, err := vectoriser.FitTransform(docs...)
+	if err != nil {
+		t.Errorf("Error applying vectoriser caused by %v", err)
+	}
+
+	var tests = []struct {
+		term     string
+		doc      int
+		expected float64
+	}{
+		{"dog", 0, 4},
+		{"man", 0, 4},
+		{"angry", 0, 1},
+		{"bites", 0, 3},
+		{"cat", 1, 4.5},
+		{"mat", 1, 1.5},
+		{"sat", 1, 4},
+		{"dog", 1, 0},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+		i, exists := vectoriser.Vectoriser.Vocabulary[test.term]
+		if !exists {
+			t.Errorf("Expected term %q in vocabulary %v", test.term, vectoriser.Vectoriser.Vocabulary)
+			continue
+		}
+		if v := result.At(i, test.doc); v != test.expected {
+			t.Errorf("Expected %f for term %q in document %d but found %f", test.expected, test.term, test.doc, v)
+		}
+	}
+
+	if df := vectoriser.Vectoriser.docFreqs["cat"]; df != 1 {
+		t.Errorf("Expected document frequency of 1 for 'cat' but found %d", df)
+	}
+}
+
+func TestDictVectoriser(t *testing.T) {
+	train := []map[string]float64{
+		{"length": 120, "has_image": 1},
+		{"length": 45, "author=bob": 1},
+	}
+	test := []map[string]float64{
+		{"length": 30, "author=bob": 1, "unseen": 7},
+		{},
+	}
+
+	vectoriser := NewDictVectoriser()
+	vectoriser.Fit(train...)
+
+	expectedNames := []string{"author=bob", "has_image", "length"}
+	if names := vectoriser.GetFeatureNames(); !reflect.DeepEqual(expectedNames, names) {
+		t.Errorf("Expected feature names %v but found %v", expectedNames, names)
+	}
+
+	result, err := vectoriser.Transform(test...)
+	if err != nil {
+		t.Errorf("Error applying vectoriser caused by %v", err)
+	}
+	expected := mat.NewDense(3, 2, []float64{
+		1, 0,
+		0, 0,
+		30, 0,
+	})
+	if !mat.Equal(expected, result) {
+		t.Errorf("Expected matrix:\n%v\nbut found:\n%v", mat.Formatted(expected), mat.Formatted(result))
+	}
+
+	vectoriser.Orientation = DocumentsAsRows
+	result, err = vectoriser.FitTransform(train...)
+	if err != nil {
+		t.Errorf("Error applying vectoriser caused by %v", err)
+	}
+	expected = mat.NewDense(2, 3, []float64{
+		0, 1, 120,
+		1, 0, 45,
+	})
+	if !mat.Equal(expected, result) {
+		t.Errorf("Expected matrix:\n%v\nbut found:\n%v", mat.Formatted(expected), mat.Formatted(result))
+	}
+}
+
+func TestTfidfVectoriser(t *testing.T) {
+	train := []string{
+		"The quick brown fox jumped over the lazy dog",
+		"the brown cat sat on the mat",
+		"the dog ate the cat",
+	}
+	test := []string{"the brown dog", "a lazy cat sat"}
+
+	for testRun, orientation := range []Orientation{TermsAsRows, DocumentsAsRows} {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+		vectoriser := NewTfidfVectoriser()
+		vectoriser.Vectoriser.Orientation = orientation
+		vectoriser.Transformer.SetL2Normalization(RowBasedL2Normalization)
+
+		countVectoriser := NewCountVectoriser()
+		countVectoriser.Orientation = orientation
+		tfidf := NewTfidfTransformer()
+		tfidf.SetOrientation(orientation)
+		tfidf.SetL2Normalization(RowBasedL2Normalization)
+		pipeline := NewPipeline(countVectoriser, tfidf)
+
+		result, err := vectoriser.FitTransform(train...)
+		if err != nil {
+			t.Errorf("Error applying vectoriser caused by %v", err)
+		}
+		expected, _ := pipeline.FitTransform(train...)
+		if !mat.EqualApprox(expected, result, 1e-12) {
+			t.Errorf("Expected matrix:\n%v\nbut found:\n%v", mat.Formatted(expected), mat.Formatted(result))
+		}
+
+		vectoriser.Fit(train...)
+		result, err = vectoriser.Transform(test...)
+		if err != nil {
+			t.Errorf("Error applying vectoriser caused by %v", err)
+		}
+		expected, _ = pipeline.Transform(test...)
+		if !mat.EqualApprox(expected, result, 1e-12) {
+			t.Errorf("Expected matrix:\n%v\nbut found:\n%v", mat.Formatted(expected), mat.Formatted(result))
+		}
+
+		if names := vectoriser.GetFeatureNames(); !reflect.DeepEqual(countVectoriser.GetFeatureNames(), names) {
+			t.Errorf("Expected feature names %v but found %v", countVectoriser.GetFeatureNames(), names)
+		}
+	}
+}
+
 func TestHashingVectoriserTransform(t *testing.T) {
 	var tests = []struct {
 		train    []string
@@ -122,3 +1020,111 @@ func TestHashingVectoriserTransform(t *testing.T) {
 		}
 	}
 }
+
+func TestHashingVectoriserTransformReader(t *testing.T) {
+	for _, signed := range []bool{false, true} {
+		vectoriser := NewHashingVectoriser(20, stopWords...)
+		vectoriser.Signed = signed
+
+		expected, err := vectoriser.Transform(testSet...)
+		if err != nil {
+			t.Errorf("Error applying vectoriser caused by %v", err)
+		}
+
+		result, err := vectoriser.TransformReader(strings.NewReader(strings.Join(testSet, "\n")), '\n')
+		if err != nil {
+			t.Errorf("Error applying vectoriser caused by %v", err)
+		}
+
+		if !mat.Equal(expected, result) {
+			t.Errorf("Expected matrix:\n%v\nbut found:\n%v", mat.Formatted(expected), mat.Formatted(result))
+		}
+	}
+}
+
+func TestHashingVectoriserCollisions(t *testing.T) {
+	for _, numFeatures := range []int{1, 5, 20, 100000} {
+		vectoriser := NewHashingVectoriser(numFeatures, stopWords...)
+		c := vectoriser.Collisions(trainSet...)
+
+		counts := NewCountVectoriser(stopWords...)
+		counts.Fit(trainSet...)
+		if c.Terms != len(counts.Vocabulary) {
+			t.Errorf("Expected %d distinct terms but found %d", len(counts.Vocabulary), c.Terms)
+		}
+		if c.Buckets > numFeatures || c.Buckets > c.Terms {
+			t.Errorf("Expected at most %d buckets but found %d", numFeatures, c.Buckets)
+		}
+
+		colliding := 0
+		for bucket, terms := range c.Collisions {
+			if len(terms) < 2 {
+				t.Errorf("Expected at least 2 terms in bucket %d but found %v", bucket, terms)
+			}
+			for _, term := range terms {
+				if h := int(murmur3.Sum32([]byte(term))) % numFeatures; h != bucket {
+					t.Errorf("Expected term %q in bucket %d but found in bucket %d", term, h, bucket)
+				}
+			}
+			colliding += len(terms)
+		}
+		if colliding != c.CollidingTerms {
+			t.Errorf("Expected %d colliding terms but found %d", colliding, c.CollidingTerms)
+		}
+
+		switch numFeatures {
+		case 1:
+			if c.Rate() != 1 {
+				t.Errorf("Expected collision rate of 1 for a single bucket but found %f", c.Rate())
+			}
+		case 100000:
+			if c.Rate() != 0 {
+				t.Errorf("Expected collision rate of 0 but found %f", c.Rate())
+			}
+		}
+	}
+}
+
+func TestHashingVectoriserSigned(t *testing.T) {
+	unsigned := NewHashingVectoriser(260000)
+	signed := NewHashingVectoriser(260000)
+	signed.Signed = true
+
+	expected, _ := unsigned.Transform(testSet...)
+	result, err := signed.Transform(testSet...)
+	if err != nil {
+		t.Errorf("Error applying vectoriser caused by %v", err)
+	}
+
+	var negatives int
+	m, n := result.Dims()
+	for i := 0; i < m; i++ {
+		for j := 0; j < n; j++ {
+			v := result.At(i, j)
+			if math.Abs(v) != expected.At(i, j) {
+				t.Errorf("Expected magnitude %f at (%d, %d) but found %f", expected.At(i, j), i, j, v)
+			}
+			if v < 0 {
+				negatives++
+			}
+		}
+	}
+	if negatives == 0 {
+		t.Errorf("Expected some features to be hashed with a negative sign")
+	}
+
+	// when all features share a single bucket, opposing signs cancel out
+	signed.NumFeatures = 1
+	var plus, minus string
+	for _, word := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		if signed.sign(word) > 0 {
+			plus = word
+		} else {
+			minus = word
+		}
+	}
+	collided, _ := signed.Transform(plus + " " + minus)
+	if collided.At(0, 0) != 0 {
+		t.Errorf("Expected colliding features with opposite signs to cancel but found %f", collided.At(0, 0))
+	}
+}
@@ -1,8 +1,14 @@
 package nlp
 
 import (
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
 	"io"
 	"math"
+	"sort"
+	"strconv"
 
 	"github.com/james-bowman/sparse"
 	"gonum.org/v1/gonum/mat"
@@ -15,23 +21,128 @@ import (
 // More precisely, TfidfTransformer applies a tf-idf algorithm to the matrix where each
 // term frequency is multiplied by the inverse document frequency.  Inverse document
 // frequency is calculated as log(n/df) where df is the number of documents in which the
-// term occurs and n is the total number of documents within the corpus.  We add 1 to both n
-// and df before division to prevent division by zero.
+// term occurs and n is the total number of documents within the corpus.  By default, we add
+// 1 to both n and df before division to prevent division by zero (see IDFScheme for
+// alternative formulae).
 // weightPadding can be used to add a value to weights after calculation to make sure terms with zero idf don't get suppressed entirely
 // l2Normalization can be used to l2 normalize the values in the matrix after a Transform() is done, done on either each row or each column
-// smoothIDF can be used to prevent zero divisions by adding 1 to the numerator and denominator of all IDF calculations as if an extra document with 1 instance of each term was seen
+// slope and pivot are the parameters used for pivoted document length normalization (see PivotedNormalization)
+// idfScheme selects the formula used to calculate inverse document frequency (SmoothIDF by default)
+// idfFunc, if set, is a custom function used to calculate inverse document frequency in place of idfScheme
+// tfScheme selects how raw term frequencies are scaled during Transform() before applying IDF (RawTF by default)
+// orientation specifies whether the matrices supplied to Fit() and Transform() have terms as rows (the default) or documents as rows
 type TfidfTransformer struct {
 	transform       *sparse.DIA
 	weightPadding   float64
 	l2Normalization int
-	smoothIDF       bool
+	idfScheme       IDFScheme
+	idfFunc         func(df, n int) float64
+	tfScheme        TFScheme
+	orientation     Orientation
+	slope           float64
+	pivot           float64
+
+	// avgNorm is the average L2 norm of the weighted training documents used as the
+	// pivot for pivoted normalization if no pivot is specified
+	avgNorm float64
+
+	// n and df are the number of documents and the per-term document frequencies
+	// accumulated through calls to Fit() and PartialFit()
+	n  int
+	df []int
+}
+
+// IDFScheme specifies the formula used to calculate the inverse document frequency (IDF)
+// of terms where n is the total number of documents and df is the number of documents
+// containing the term.
+type IDFScheme int
+
+// IDF scheme options for the TF-IDF Transformer
+const (
+	// SmoothIDF calculates IDF as log((1+n)/(1+df)), preventing zero divisions, as if an
+	// extra document containing 1 instance of each term was seen.  Combined with a weight
+	// padding of 1, this is equivalent to scikit-learn's default (smooth_idf=True).
+	SmoothIDF IDFScheme = iota
+
+	// StandardIDF calculates IDF as log(n/df).  Combined with a weight padding of 1, this
+	// is equivalent to scikit-learn's smooth_idf=False.
+	StandardIDF
+
+	// ProbabilisticIDF calculates IDF as log((n-df)/df).  Terms occurring in half or more
+	// of the documents, which would otherwise be given a negative weight, and terms not
+	// occurring in any documents are given a weight of 0.
+	ProbabilisticIDF
+)
+
+// idf calculates the inverse document frequency for a term occurring in df of the n
+// documents according to the scheme.
+func (s IDFScheme) idf(n, df int) float64 {
+	switch s {
+	case StandardIDF:
+		return math.Log(float64(n) / float64(df))
+	case ProbabilisticIDF:
+		if df == 0 || 2*df >= n {
+			return 0
+		}
+		return math.Log(float64(n-df) / float64(df))
+	default:
+		return math.Log(float64(1+n) / float64(1+df))
+	}
 }
 
-//L2 Normalization options for the TF-IDF Transformer
+// TFScheme specifies how the raw term frequencies (tf) within a term document matrix
+// are scaled before being weighted by inverse document frequency.
+type TFScheme int
+
+// TF scheme options for the TF-IDF Transformer
+const (
+	// RawTF uses the raw term frequencies unmodified.
+	RawTF TFScheme = iota
+
+	// BinaryTF binarises term frequencies so that terms occurring in a document have a
+	// tf of 1 regardless of how many times they occur.  This is the classic "binary
+	// tf-idf" variant and is useful for very short documents such as tweets or queries.
+	BinaryTF
+
+	// AugmentedTF scales term frequencies as 0.5 + 0.5*tf/maxtf where maxtf is the
+	// frequency of the most frequently occurring term within the document.  This
+	// prevents a bias towards longer documents, for example, when computing similarity.
+	// Terms not occurring in a document retain a tf of 0.
+	AugmentedTF
+)
+
+// tf scales the raw term frequency according to the scheme where maxFreq is the
+// frequency of the most frequently occurring term within the same document.
+func (s TFScheme) tf(freq, maxFreq float64) float64 {
+	switch s {
+	case BinaryTF:
+		if freq != 0 {
+			return 1
+		}
+		return 0
+	case AugmentedTF:
+		if freq == 0 {
+			return 0
+		}
+		return 0.5 + 0.5*freq/maxFreq
+	default:
+		return freq
+	}
+}
+
+//L2 Normalization options for the TF-IDF Transformer.  L1 normalization options scale rows or columns
+//to sum to 1 (e.g. so they may be interpreted as probability distributions) rather than to unit length.
+//PivotedNormalization applies pivoted document length normalization (Singhal et al.) to each document
+//(row or column according to the orientation), dividing its weights by (1 - slope) * pivot + slope * norm
+//where norm is the L2 norm of the document.  This corrects the tendency of L2 normalization to
+//over-penalise long documents in retrieval.  See SetPivot() for setting the slope and pivot.
 const (
 	NoL2Normalization = iota
 	RowBasedL2Normalization
 	ColBasedL2Normalization
+	RowBasedL1Normalization
+	ColBasedL1Normalization
+	PivotedNormalization
 )
 
 // NewTfidfTransformer constructs a new TfidfTransformer.
@@ -41,12 +152,60 @@ func NewTfidfTransformer() *TfidfTransformer {
 
 // GetSmoothIDF retrieives a boolean that represents if the current TfidfTransformer is configured to smooth IDF values
 func (t *TfidfTransformer) GetSmoothIDF() bool {
-	return t.smoothIDF
+	return t.idfScheme == SmoothIDF
 }
 
-// SetSmoothIDF sets the TfidfTransformer configuration to either smooth IDF values or during calculation or to leave them raw
+// SetSmoothIDF sets the TfidfTransformer configuration to either smooth IDF values or during calculation or to leave them raw.
+// This is equivalent to setting the IDF scheme to either SmoothIDF or StandardIDF
 func (t *TfidfTransformer) SetSmoothIDF(smoothIDF bool) {
-	t.smoothIDF = smoothIDF
+	if smoothIDF {
+		t.idfScheme = SmoothIDF
+		return
+	}
+	t.idfScheme = StandardIDF
+}
+
+// GetIDFScheme retrieves the formula used to calculate inverse document frequency during Fit()
+func (t *TfidfTransformer) GetIDFScheme() IDFScheme {
+	return t.idfScheme
+}
+
+// SetIDFScheme sets the formula used to calculate inverse document frequency during Fit()
+func (t *TfidfTransformer) SetIDFScheme(scheme IDFScheme) {
+	t.idfScheme = scheme
+}
+
+// GetIDFFunc retrieves the custom function used to calculate inverse document frequency during Fit() or
+// nil if the IDF scheme is used
+func (t *TfidfTransformer) GetIDFFunc() func(df, n int) float64 {
+	return t.idfFunc
+}
+
+// SetIDFFunc sets a custom function used to calculate inverse document frequency during Fit() in place
+// of the IDF scheme.  The function is called for each term with the number of documents containing the
+// term (df) and the total number of documents (n).  This allows alternative weighting curves (e.g. capped
+// IDF) to be used.  Setting the function to nil reverts to using the IDF scheme
+func (t *TfidfTransformer) SetIDFFunc(idf func(df, n int) float64) {
+	t.idfFunc = idf
+}
+
+// idf calculates the inverse document frequency for a term occurring in df of the n documents
+// using either the custom IDF function, if set, or the IDF scheme
+func (t *TfidfTransformer) idf(n, df int) float64 {
+	if t.idfFunc != nil {
+		return t.idfFunc(df, n)
+	}
+	return t.idfScheme.idf(n, df)
+}
+
+// GetTFScheme retrieves the scheme used to scale raw term frequencies during Transform()
+func (t *TfidfTransformer) GetTFScheme() TFScheme {
+	return t.tfScheme
+}
+
+// SetTFScheme sets the scheme used to scale raw term frequencies during Transform()
+func (t *TfidfTransformer) SetTFScheme(scheme TFScheme) {
+	t.tfScheme = scheme
 }
 
 // GetWeightPadding retrieves the weight padding that is added to weights during Fit()
@@ -59,16 +218,43 @@ func (t *TfidfTransformer) SetWeightPadding(wp float64) {
 	t.weightPadding = wp
 }
 
+// GetOrientation retrieves the orientation of the matrices expected by Fit() and Transform()
+func (t *TfidfTransformer) GetOrientation() Orientation {
+	return t.orientation
+}
+
+// SetOrientation sets the orientation of the matrices expected by Fit() and Transform().  This
+// should match the Orientation of the vectoriser used to produce the matrices e.g. DocumentsAsRows
+// if the rows of the matrices represent documents rather than terms
+func (t *TfidfTransformer) SetOrientation(o Orientation) {
+	t.orientation = o
+}
+
 //GetL2Normalization retrieves the type of normalization done during Transform()
 func (t *TfidfTransformer) GetL2Normalization() int {
 	return t.l2Normalization
 }
 
-// SetL2Normalization sets the type of normalization done during Transform()
+// SetL2Normalization sets the type of normalization done during Transform().  Despite the name, this
+// may be set to either an L2 or an L1 normalization option
 func (t *TfidfTransformer) SetL2Normalization(ln int) {
 	t.l2Normalization = ln
 }
 
+// GetPivot retrieves the slope and pivot used for pivoted document length normalization
+func (t *TfidfTransformer) GetPivot() (slope, pivot float64) {
+	return t.slope, t.pivot
+}
+
+// SetPivot sets the slope and pivot used for pivoted document length normalization (see
+// PivotedNormalization).  slope is typically between 0.2 and 0.3 with a slope of 1
+// equivalent to L2 normalization.  If pivot is 0 then the average L2 norm of the
+// documents supplied to Fit() is used as the pivot
+func (t *TfidfTransformer) SetPivot(slope, pivot float64) {
+	t.slope = slope
+	t.pivot = pivot
+}
+
 // Fit takes a training term document matrix, counts term occurrences across all documents
 // and constructs an inverse document frequency transform to apply to matrices in subsequent
 // calls to Transform().
@@ -76,40 +262,154 @@ func (t *TfidfTransformer) Fit(matrix mat.Matrix) Transformer {
 	if t, isTypeConv := matrix.(sparse.TypeConverter); isTypeConv {
 		matrix = t.ToCSR()
 	}
+	t.n = 0
+	t.df = nil
+	t.avgNorm = 0
+	t.PartialFit(matrix)
+
+	return t
+}
+
+// PartialFit incrementally updates the model with the documents in the supplied term
+// document matrix, adding to the document count and per-term document frequencies
+// accumulated from previous calls to Fit() or PartialFit(), and reconstructs the inverse
+// document frequency transform to reflect the updated statistics.  Unlike the Fit()
+// method, which re-trains the model from scratch, PartialFit() is designed to be called
+// multiple times to support online and mini-batch learning e.g. to track a streaming
+// corpus.  The matrix may contain more terms than previously seen (e.g. as the
+// vocabulary of a CountVectoriser is extended by its PartialFit() method) in which case
+// the new terms are appended to the model.  For PivotedNormalization, the average
+// document norm used as the default pivot is updated as a running average over all
+// documents seen, each weighted using the IDF at the time it was supplied.  Note that
+// the accumulated document frequencies are not persisted by Save() so a loaded model
+// is re-trained from scratch by PartialFit().
+func (t *TfidfTransformer) PartialFit(matrix mat.Matrix) OnlineTransformer {
+	if t.orientation == DocumentsAsRows {
+		// transpose so that terms are represented by rows
+		if t, isTypeConv := matrix.(sparse.TypeConverter); isTypeConv {
+			matrix = t.ToCSC().T()
+		} else {
+			matrix = matrix.T()
+		}
+	} else if t, isTypeConv := matrix.(sparse.TypeConverter); isTypeConv {
+		matrix = t.ToCSR()
+	}
 	m, n := matrix.Dims()
 
-	smoothing := 0
-	if t.smoothIDF {
-		smoothing = 1
+	if m > len(t.df) {
+		t.df = append(t.df, make([]int, m-len(t.df))...)
 	}
+	t.n += n
 
-	weights := make([]float64, m)
-	var df int
 	if csr, ok := matrix.(*sparse.CSR); ok {
 		for i := 0; i < m; i++ {
-			// weight padding can be used to ensure terms with zero idf don't get suppressed entirely.
-			weights[i] = math.Log(float64(smoothing+n)/float64(smoothing+csr.RowNNZ(i))) + t.weightPadding
+			t.df[i] += csr.RowNNZ(i)
 		}
 	} else {
 		for i := 0; i < m; i++ {
-			df = 0
 			for j := 0; j < n; j++ {
 				if matrix.At(i, j) != 0 {
-					df++
+					t.df[i]++
 				}
 			}
-			// weight padding can be used to ensure terms with zero idf don't get suppressed entirely.
-			weights[i] = math.Log(float64(smoothing+n)/float64(smoothing+df)) + t.weightPadding
 		}
 	}
 
+	weights := make([]float64, len(t.df))
+	for i, df := range t.df {
+		// weight padding can be used to ensure terms with zero idf don't get suppressed entirely.
+		weights[i] = t.idf(t.n, df) + t.weightPadding
+	}
+
 	// build a diagonal matrix from array of term weighting values for subsequent
 	// multiplication with term document matrics
-	t.transform = sparse.NewDIA(m, m, weights)
+	t.transform = sparse.NewDIA(len(weights), len(weights), weights)
+
+	if t.l2Normalization == PivotedNormalization && t.n > 0 {
+		// fold the norms of the weighted documents into the average document norm
+		maxFreqs := make([]float64, n)
+		nonZeroDo(matrix, func(i, j int, v float64) {
+			maxFreqs[j] = math.Max(maxFreqs[j], v)
+		})
+		norms := make([]float64, n)
+		nonZeroDo(matrix, func(i, j int, v float64) {
+			w := t.tfScheme.tf(v, maxFreqs[j]) * weights[i]
+			norms[j] += w * w
+		})
+		sum := t.avgNorm * float64(t.n-n)
+		for _, norm := range norms {
+			sum += math.Sqrt(norm)
+		}
+		t.avgNorm = sum / float64(t.n)
+	}
 
 	return t
 }
 
+// IDF returns the inverse document frequency weights, learnt during Fit(), for each term
+// (row) in index order.  The weights include any weight padding.  The returned slice is
+// a copy and so may be modified freely.  IDF returns nil if the transformer has not
+// been fitted.
+func (t *TfidfTransformer) IDF() []float64 {
+	if t.transform == nil {
+		return nil
+	}
+	weights := make([]float64, len(t.transform.Diagonal()))
+	copy(weights, t.transform.Diagonal())
+	return weights
+}
+
+// TermIDF returns the inverse document frequency weights, learnt during Fit(), keyed by
+// term using the supplied vocabulary mapping terms to row indices (e.g. the Vocabulary
+// of the CountVectoriser used to produce the training matrix).  Terms in vocabulary with
+// indices outside of the range of the fitted weights are omitted.
+func (t *TfidfTransformer) TermIDF(vocabulary map[string]int) map[string]float64 {
+	weights := t.IDF()
+	idf := make(map[string]float64, len(vocabulary))
+	for term, i := range vocabulary {
+		if i >= 0 && i < len(weights) {
+			idf[term] = weights[i]
+		}
+	}
+	return idf
+}
+
+// SaveTermIDFCSV writes the inverse document frequency weights, learnt during Fit(), into
+// w as CSV with a header row followed by a term,idf row for each term in the supplied
+// vocabulary (e.g. the Vocabulary of the CountVectoriser used to produce the training
+// matrix).  Rows are written in index order.  This is useful for offline analysis or for
+// loading the weights into other systems (e.g. as term boosts within a search engine).
+func (t *TfidfTransformer) SaveTermIDFCSV(w io.Writer, vocabulary map[string]int) error {
+	idf := t.TermIDF(vocabulary)
+	terms := make([]string, 0, len(idf))
+	for term := range idf {
+		terms = append(terms, term)
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		return vocabulary[terms[i]] < vocabulary[terms[j]]
+	})
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"term", "idf"}); err != nil {
+		return err
+	}
+	for _, term := range terms {
+		if err := writer.Write([]string{term, strconv.FormatFloat(idf[term], 'g', -1, 64)}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// SaveTermIDFJSON writes the inverse document frequency weights, learnt during Fit(), into
+// w as a JSON object mapping each term in the supplied vocabulary to its weight.  Terms
+// are written in lexicographical order.  See SaveTermIDFCSV() for more details.
+func (t *TfidfTransformer) SaveTermIDFJSON(w io.Writer, vocabulary map[string]int) error {
+	return json.NewEncoder(w).Encode(t.TermIDF(vocabulary))
+}
+
 // Transform applies the inverse document frequency (IDF) transform by multiplying
 // each term frequency by its corresponding IDF value.  This has the effect of weighting
 // each term frequency according to how often it appears across the whole document corpus
@@ -119,16 +419,34 @@ func (t *TfidfTransformer) Transform(matrix mat.Matrix) (mat.Matrix, error) {
 	if t, isTypeConv := matrix.(sparse.TypeConverter); isTypeConv {
 		matrix = t.ToCSR()
 	}
-	var product sparse.CSR
-
-	// simply multiply the matrix by our idf transform (the diagonal matrix of term weights)
-	product.Mul(t.transform, matrix)
+	product := t.weight(matrix)
 
 	//Perform L2 normalization of the matrix if the option is selected
-	if t.l2Normalization != NoL2Normalization {
+	if t.l2Normalization == PivotedNormalization {
+		pivot := t.pivot
+		if pivot == 0 {
+			pivot = t.avgNorm
+		}
+		norms := t.docNorms(product)
+		rawProduct := product.RawMatrix()
+		for i := 0; i < rawProduct.I; i++ {
+			for j := rawProduct.Indptr[i]; j < rawProduct.Indptr[i+1]; j++ {
+				doc := rawProduct.Ind[j]
+				if t.orientation == DocumentsAsRows {
+					doc = i
+				}
+				if norm := (1-t.slope)*pivot + t.slope*norms[doc]; norm != 0 {
+					rawProduct.Data[j] /= norm
+				}
+			}
+		}
+	} else if t.l2Normalization != NoL2Normalization {
+
+		colBased := t.l2Normalization == ColBasedL2Normalization || t.l2Normalization == ColBasedL1Normalization
+		l1 := t.l2Normalization == RowBasedL1Normalization || t.l2Normalization == ColBasedL1Normalization
 
 		//Transpose the matrix to normalize based on columns
-		if t.l2Normalization == ColBasedL2Normalization {
+		if colBased {
 			product.Clone(product.T().(*sparse.CSC).ToCSR())
 		}
 
@@ -139,24 +457,132 @@ func (t *TfidfTransformer) Transform(matrix mat.Matrix) (mat.Matrix, error) {
 			sum := 0.0
 
 			for j := rawProduct.Indptr[i]; j < rawProduct.Indptr[i+1]; j++ {
-				sum += rawProduct.Data[j] * rawProduct.Data[j]
+				if l1 {
+					sum += math.Abs(rawProduct.Data[j])
+				} else {
+					sum += rawProduct.Data[j] * rawProduct.Data[j]
+				}
 			}
 			if sum == 0.0 {
 				continue
 			}
-			sum = math.Sqrt(sum)
+			if !l1 {
+				sum = math.Sqrt(sum)
+			}
 			for j := rawProduct.Indptr[i]; j < rawProduct.Indptr[i+1]; j++ {
 				rawProduct.Data[j] /= sum
 			}
 		}
 
 		//Transpose the matrix back to original format if Column based normalization
-		if t.l2Normalization == ColBasedL2Normalization {
+		if colBased {
 			product.Clone(product.T().(*sparse.CSC).ToCSR())
 		}
 	}
 
-	return &product, nil
+	return product, nil
+}
+
+// InverseTransform reverses the inverse document frequency (IDF) weighting of the
+// supplied matrix, dividing each weight by the IDF of its term, mapping TF-IDF weights
+// back towards term frequencies.  TF scaling (see TFScheme) and normalisation cannot
+// be reversed and so, if applied, the result is the scaled term frequencies
+// proportional, within each document, to the normalised values.  Terms with an IDF
+// weight of zero cannot be recovered and remain zero.  The returned matrix is a
+// sparse matrix type.
+func (t *TfidfTransformer) InverseTransform(matrix mat.Matrix) (mat.Matrix, error) {
+	weights := t.transform.Diagonal()
+	if f := numFeatures(matrix, t.orientation); f != len(weights) {
+		return nil, fmt.Errorf("nlp: Matrix has %d terms but the transformer was fitted with %d", f, len(weights))
+	}
+	return scaleNonZero(matrix, func(i, j int, v float64) float64 {
+		if w := weights[t.orientation.term(i, j)]; w != 0 {
+			return v / w
+		}
+		return 0
+	}), nil
+}
+
+// weight scales the term frequencies within matrix according to the TF scheme and
+// multiplies them by the inverse document frequency weights returning the result.
+func (t *TfidfTransformer) weight(matrix mat.Matrix) *sparse.CSR {
+	matrix = t.tf(matrix)
+	var product sparse.CSR
+
+	// simply multiply the matrix by our idf transform (the diagonal matrix of term weights)
+	if t.orientation == DocumentsAsRows {
+		product.Mul(matrix, t.transform)
+	} else {
+		product.Mul(t.transform, matrix)
+	}
+
+	return &product
+}
+
+// docNorms returns the L2 norm of each document (row or column according to the
+// orientation) within matrix.
+func (t *TfidfTransformer) docNorms(matrix mat.Matrix) []float64 {
+	r, c := matrix.Dims()
+	norms := make([]float64, c)
+	if t.orientation == DocumentsAsRows {
+		norms = make([]float64, r)
+	}
+	nonZeroDo(matrix, func(i, j int, v float64) {
+		if t.orientation == DocumentsAsRows {
+			norms[i] += v * v
+		} else {
+			norms[j] += v * v
+		}
+	})
+	for i, norm := range norms {
+		norms[i] = math.Sqrt(norm)
+	}
+	return norms
+}
+
+// tf scales the raw term frequencies within matrix according to the TF scheme returning
+// the scaled matrix.  matrix is returned unmodified for the RawTF scheme.
+func (t *TfidfTransformer) tf(matrix mat.Matrix) mat.Matrix {
+	if t.tfScheme == RawTF {
+		return matrix
+	}
+
+	r, c := matrix.Dims()
+	var rows, cols []int
+	var data []float64
+	nonZeroDo(matrix, func(i, j int, v float64) {
+		rows = append(rows, i)
+		cols = append(cols, j)
+		data = append(data, v)
+	})
+
+	// find the maximum term frequency within each document
+	doc := cols
+	maxFreqs := make([]float64, c)
+	if t.orientation == DocumentsAsRows {
+		doc = rows
+		maxFreqs = make([]float64, r)
+	}
+	for k, v := range data {
+		maxFreqs[doc[k]] = math.Max(maxFreqs[doc[k]], v)
+	}
+
+	for k, v := range data {
+		data[k] = t.tfScheme.tf(v, maxFreqs[doc[k]])
+	}
+
+	return sparse.NewCOO(r, c, rows, cols, data).ToCSR()
+}
+
+// FeatureDims returns the number of features (terms) the transformer was fitted with,
+// which is both the number expected in input matrices and output, or -1 if it has not
+// been fitted.
+func (t *TfidfTransformer) FeatureDims() (in, out int) {
+	if t.transform == nil {
+		return -1, -1
+	}
+	n, _ := t.transform.Dims()
+	return n, n
 }
 
 // FitTransform is exactly equivalent to calling Fit() followed by Transform() on the
@@ -170,10 +596,501 @@ func (t *TfidfTransformer) FitTransform(matrix mat.Matrix) (mat.Matrix, error) {
 	return t.Fit(matrix).Transform(matrix)
 }
 
+// tfidfVersion is the version of the serialisation format written by Save().  Models
+// saved by earlier releases contain only the IDF weights, without the magic number
+// and header.
+const tfidfVersion = 1
+
+// tfidfHeader is the fixed size portion of a serialised TfidfTransformer following the
+// magic number.
+type tfidfHeader struct {
+	Version         int64
+	L2Normalization int64
+	IDFScheme       int64
+	TFScheme        int64
+	Orientation     int64
+	WeightPadding   float64
+	Slope           float64
+	Pivot           float64
+	AvgNorm         float64
+}
+
+// Save binary serialises the model, including its hyperparameters, and writes it into
+// w.  This is useful for persisting a trained model to disk so that it may be loaded
+// (using the Load() method) in another context (e.g. production) for reproducible
+// results.  A custom IDF function (see SetIDFFunc()) is not serialised although the
+// fitted weights calculated with it are.
+func (t TfidfTransformer) Save(w io.Writer) error {
+	if _, err := w.Write(modelMagic[:]); err != nil {
+		return err
+	}
+	header := tfidfHeader{
+		Version:         tfidfVersion,
+		L2Normalization: int64(t.l2Normalization),
+		IDFScheme:       int64(t.idfScheme),
+		TFScheme:        int64(t.tfScheme),
+		Orientation:     int64(t.orientation),
+		WeightPadding:   t.weightPadding,
+		Slope:           t.slope,
+		Pivot:           t.pivot,
+		AvgNorm:         t.avgNorm,
+	}
+	if err := binary.Write(w, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	_, err := t.transform.MarshalBinaryTo(w)
+
+	return err
+}
+
+// Load binary deserialises the previously serialised model into the receiver.  This is
+// useful for loading a previously trained and saved model from another context
+// (e.g. offline training) for use within another context (e.g. production) for
+// reproducible results.  Load should only be performed with trusted data.  Models
+// saved by earlier releases, containing only the IDF weights, are loaded with the
+// SmoothIDF and RawTF schemes and no pivot, retaining the normalization, orientation
+// and weight padding of the receiver.  An error is returned if the serialisation
+// format version is not supported.
+func (t *TfidfTransformer) Load(r io.Reader) error {
+	r, versioned, err := readMagic(r)
+	if err != nil {
+		return err
+	}
+	header := tfidfHeader{
+		L2Normalization: int64(t.l2Normalization),
+		IDFScheme:       int64(SmoothIDF),
+		TFScheme:        int64(RawTF),
+		Orientation:     int64(t.orientation),
+		WeightPadding:   t.weightPadding,
+	}
+	if versioned {
+		if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+			return err
+		}
+		if header.Version != tfidfVersion {
+			return fmt.Errorf("nlp: Unsupported serialisation version %d", header.Version)
+		}
+	}
+	var model sparse.DIA
+	if _, err := model.UnmarshalBinaryFrom(r); err != nil {
+		return err
+	}
+
+	t.transform = &model
+	t.l2Normalization = int(header.L2Normalization)
+	t.idfScheme = IDFScheme(header.IDFScheme)
+	t.tfScheme = TFScheme(header.TFScheme)
+	t.orientation = Orientation(header.Orientation)
+	t.weightPadding = header.WeightPadding
+	t.slope = header.Slope
+	t.pivot = header.Pivot
+	t.avgNorm = header.AvgNorm
+	t.n = 0
+	t.df = nil
+
+	return nil
+}
+
+// BM25Transformer takes a raw term document matrix and weights each raw term frequency
+// value using the Okapi BM25 ranking function.  Like TF-IDF, BM25 weights terms by
+// their inverse document frequency but additionally saturates term frequencies, so
+// that repeated occurrences of a term contribute progressively less, and normalises
+// for document length, so that long documents are not unduly favoured.  BM25 typically
+// outperforms TF-IDF for information retrieval.  More precisely, each term frequency
+// tf is transformed to:
+//
+//	idf * tf * (K1 + 1) / (tf + K1 * (1 - B + B * dl / avgdl))
+//
+// where dl is the length of the document (the sum of its term frequencies), avgdl is
+// the average document length across the training corpus and idf is calculated as
+// log(1 + (n - df + 0.5) / (df + 0.5)) where df is the number of documents in which
+// the term occurs and n is the total number of documents within the corpus.  The
+// BM25+ and BM25L variants, which correct the bias of BM25 against long documents,
+// may be selected using Variant.
+type BM25Transformer struct {
+	// K1 controls term frequency saturation.  Higher values mean the weight continues to
+	// increase further with additional occurrences of a term.  Typical values are
+	// between 1.2 and 2.0.
+	K1 float64
+
+	// B controls the degree of document length normalisation between 0 (no
+	// normalisation) and 1 (full normalisation).
+	B float64
+
+	// Variant selects the BM25 variant to apply (OkapiBM25 by default)
+	Variant BM25Variant
+
+	// Delta is the lower bound correction applied by the BM25+ and BM25L variants.
+	// Typical values are 1 for BM25+ and 0.5 for BM25L.  Delta is ignored for OkapiBM25.
+	Delta float64
+
+	idf       []float64
+	avgDocLen float64
+}
+
+// BM25Variant specifies a variant of the BM25 weighting function.
+type BM25Variant int
+
+// BM25 variant options for the BM25 Transformer
+const (
+	// OkapiBM25 is the standard Okapi BM25 weighting function.
+	OkapiBM25 BM25Variant = iota
+
+	// BM25Plus (BM25+) adds Delta to the saturated term frequency of each term occurring
+	// within a document, lower bounding the contribution of a term so that terms within
+	// very long documents are not weighted similarly to absent terms:
+	//
+	//	idf * (tf * (K1 + 1) / (tf + K1 * (1 - B + B * dl / avgdl)) + Delta)
+	BM25Plus
+
+	// BM25L shifts the length normalised term frequency, ctf = tf / (1 - B + B * dl / avgdl),
+	// by Delta to reduce the penalty applied to long documents:
+	//
+	//	idf * (K1 + 1) * (ctf + Delta) / (K1 + ctf + Delta)
+	BM25L
+)
+
+// NewBM25Transformer constructs a new BM25Transformer with the commonly used default
+// values of 1.2 for K1 and 0.75 for B.
+func NewBM25Transformer() *BM25Transformer {
+	return &BM25Transformer{K1: 1.2, B: 0.75}
+}
+
+// weight returns the BM25 weight for a term with inverse document frequency idf
+// occurring tf times in a document with length normalisation factor norm.
+func (t *BM25Transformer) weight(idf, tf, norm float64) float64 {
+	switch t.Variant {
+	case BM25Plus:
+		return idf * (tf*(t.K1+1)/(tf+t.K1*norm) + t.Delta)
+	case BM25L:
+		ctf := tf / norm
+		return idf * (t.K1 + 1) * (ctf + t.Delta) / (t.K1 + ctf + t.Delta)
+	default:
+		return idf * tf * (t.K1 + 1) / (tf + t.K1*norm)
+	}
+}
+
+// Fit takes a training term document matrix, counts term occurrences across all
+// documents and the average document length and constructs the inverse document
+// frequency weights to apply to matrices in subsequent calls to Transform().
+func (t *BM25Transformer) Fit(matrix mat.Matrix) Transformer {
+	if t, isTypeConv := matrix.(sparse.TypeConverter); isTypeConv {
+		matrix = t.ToCSR()
+	}
+	m, n := matrix.Dims()
+
+	df := make([]int, m)
+	var total float64
+	nonZeroDo(matrix, func(i, j int, v float64) {
+		df[i]++
+		total += v
+	})
+
+	t.idf = make([]float64, m)
+	for i := range t.idf {
+		t.idf[i] = math.Log(1 + (float64(n-df[i])+0.5)/(float64(df[i])+0.5))
+	}
+	t.avgDocLen = 0
+	if n > 0 {
+		t.avgDocLen = total / float64(n)
+	}
+
+	return t
+}
+
+// Transform applies the BM25 weighting to each term frequency within matrix.  The
+// length of each document is taken from the term frequencies within its column of
+// matrix.  The returned matrix is a sparse matrix type.
+func (t *BM25Transformer) Transform(matrix mat.Matrix) (mat.Matrix, error) {
+	if t, isTypeConv := matrix.(sparse.TypeConverter); isTypeConv {
+		matrix = t.ToCSC()
+	}
+	m, n := matrix.Dims()
+
+	var rows, cols []int
+	var data []float64
+	for j := 0; j < n; j++ {
+		var docLen float64
+		ColNonZeroElemDo(matrix, j, func(i, j int, v float64) {
+			docLen += v
+		})
+
+		norm := 1 - t.B
+		if t.avgDocLen > 0 {
+			norm += t.B * docLen / t.avgDocLen
+		}
+		ColNonZeroElemDo(matrix, j, func(i, j int, tf float64) {
+			rows = append(rows, i)
+			cols = append(cols, j)
+			data = append(data, t.weight(t.idf[i], tf, norm))
+		})
+	}
+
+	return sparse.NewCOO(m, n, rows, cols, data).ToCSR(), nil
+}
+
+// FeatureDims returns the number of features (terms) the transformer was fitted with,
+// which is both the number expected in input matrices and output, or -1 if it has not
+// been fitted.
+func (t *BM25Transformer) FeatureDims() (in, out int) {
+	if t.idf == nil {
+		return -1, -1
+	}
+	return len(t.idf), len(t.idf)
+}
+
+// FitTransform is exactly equivalent to calling Fit() followed by Transform() on the
+// same matrix.  This is a convenience where separate training data is not being
+// used to fit the model i.e. the model is fitted on the fly to the test data.
+// The returned matrix is a sparse matrix type.
+func (t *BM25Transformer) FitTransform(matrix mat.Matrix) (mat.Matrix, error) {
+	if t, isTypeConv := matrix.(sparse.TypeConverter); isTypeConv {
+		matrix = t.ToCSR()
+	}
+	return t.Fit(matrix).Transform(matrix)
+}
+
+// bm25Header is the fixed size portion of a serialised BM25Transformer.
+type bm25Header struct {
+	K1        float64
+	B         float64
+	Variant   int64
+	Delta     float64
+	AvgDocLen float64
+	Terms     int64
+}
+
 // Save binary serialises the model and writes it into w.  This is useful for persisting
-// a trained model to disk so that it may be loaded (using the Load() method)in another
+// a trained model to disk so that it may be loaded (using the Load() method) in another
 // context (e.g. production) for reproducible results.
-func (t TfidfTransformer) Save(w io.Writer) error {
+func (t BM25Transformer) Save(w io.Writer) error {
+	header := bm25Header{
+		K1:        t.K1,
+		B:         t.B,
+		Variant:   int64(t.Variant),
+		Delta:     t.Delta,
+		AvgDocLen: t.avgDocLen,
+		Terms:     int64(len(t.idf)),
+	}
+	if err := binary.Write(w, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, t.idf)
+}
+
+// Load binary deserialises the previously serialised model into the receiver.  This is
+// useful for loading a previously trained and saved model from another context
+// (e.g. offline training) for use within another context (e.g. production) for
+// reproducible results.  Load should only be performed with trusted data.
+func (t *BM25Transformer) Load(r io.Reader) error {
+	var header bm25Header
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return err
+	}
+	idf := make([]float64, header.Terms)
+	if err := binary.Read(r, binary.LittleEndian, idf); err != nil {
+		return err
+	}
+
+	t.K1 = header.K1
+	t.B = header.B
+	t.Variant = BM25Variant(header.Variant)
+	t.Delta = header.Delta
+	t.avgDocLen = header.AvgDocLen
+	t.idf = idf
+
+	return nil
+}
+
+// PPMITransformer weights a term document (or term co-occurrence) matrix using
+// Pointwise Mutual Information (PMI) between the terms (rows) and contexts (columns).
+// PMI measures how much more often a term occurs within a context than would be
+// expected if they were independent:
+//
+//	pmi(t, c) = log(p(t, c) / (p(t) * p(c))) = log(p(t|c) / p(t))
+//
+// where p(t) is the probability of term t across the training matrix supplied to
+// Fit() and p(t|c) is the probability of term t within context c of the matrix
+// being transformed.  PPMI (Positive PMI) additionally replaces negative values with
+// 0 and is a standard weighting for building word vectors from co-occurrence counts.
+// Only non-zero elements of the input matrix are weighted so zero counts remain 0.
+type PPMITransformer struct {
+	// Positive, if true, replaces negative PMI values with 0 (PPMI).
+	Positive bool
+
+	// Shift, if greater than 1, subtracts log(Shift) from each PMI value (shifted PMI).
+	// Shifted PPMI with a Shift of k corresponds to the objective optimised by
+	// word2vec's skip-gram with k negative samples.
+	Shift float64
+
+	termProbs []float64
+}
+
+// NewPPMITransformer constructs a new PPMITransformer producing Positive PMI weights
+// without any shift.
+func NewPPMITransformer() *PPMITransformer {
+	return &PPMITransformer{Positive: true}
+}
+
+// Fit takes a training term document (or co-occurrence) matrix and calculates the
+// overall probability of each term (row) for use in subsequent calls to Transform().
+func (t *PPMITransformer) Fit(matrix mat.Matrix) Transformer {
+	if t, isTypeConv := matrix.(sparse.TypeConverter); isTypeConv {
+		matrix = t.ToCSR()
+	}
+	m, _ := matrix.Dims()
+
+	t.termProbs = make([]float64, m)
+	var total float64
+	nonZeroDo(matrix, func(i, j int, v float64) {
+		t.termProbs[i] += v
+		total += v
+	})
+	if total != 0 {
+		for i := range t.termProbs {
+			t.termProbs[i] /= total
+		}
+	}
+
+	return t
+}
+
+// Transform applies the PMI weighting to each non-zero element of matrix.  The
+// returned matrix is a sparse matrix type.
+func (t *PPMITransformer) Transform(matrix mat.Matrix) (mat.Matrix, error) {
+	if t, isTypeConv := matrix.(sparse.TypeConverter); isTypeConv {
+		matrix = t.ToCSC()
+	}
+	m, n := matrix.Dims()
+
+	var shift float64
+	if t.Shift > 1 {
+		shift = math.Log(t.Shift)
+	}
+
+	var rows, cols []int
+	var data []float64
+	for j := 0; j < n; j++ {
+		var total float64
+		ColNonZeroElemDo(matrix, j, func(i, j int, v float64) {
+			total += v
+		})
+		ColNonZeroElemDo(matrix, j, func(i, j int, v float64) {
+			if t.termProbs[i] == 0 {
+				return
+			}
+			pmi := math.Log(v/total/t.termProbs[i]) - shift
+			if pmi == 0 || (t.Positive && pmi < 0) {
+				return
+			}
+			rows = append(rows, i)
+			cols = append(cols, j)
+			data = append(data, pmi)
+		})
+	}
+
+	return sparse.NewCOO(m, n, rows, cols, data).ToCSR(), nil
+}
+
+// FitTransform is exactly equivalent to calling Fit() followed by Transform() on the
+// same matrix.  This is a convenience where separate training data is not being
+// used to fit the model i.e. the model is fitted on the fly to the test data.
+// The returned matrix is a sparse matrix type.
+func (t *PPMITransformer) FitTransform(matrix mat.Matrix) (mat.Matrix, error) {
+	if t, isTypeConv := matrix.(sparse.TypeConverter); isTypeConv {
+		matrix = t.ToCSR()
+	}
+	return t.Fit(matrix).Transform(matrix)
+}
+
+// LogEntropyTransformer applies log-entropy weighting to a raw term document matrix as
+// an alternative to TF-IDF.  Log-entropy weighting is commonly applied prior to LSA and
+// combines a local (per document) log weighting with a global (per term) entropy
+// weighting:
+//
+//	log(1 + tf) * (1 + sum_j(p_j * log(p_j)) / log(n))
+//
+// where tf is the frequency of the term within the document, p_j is the proportion of
+// all occurrences of the term (across the training corpus) that occur within document
+// j and n is the total number of documents within the corpus.  The global weight is 1
+// for terms concentrated within a single document and approaches 0 for terms evenly
+// distributed across all documents.
+type LogEntropyTransformer struct {
+	transform *sparse.DIA
+}
+
+// NewLogEntropyTransformer constructs a new LogEntropyTransformer.
+func NewLogEntropyTransformer() *LogEntropyTransformer {
+	return &LogEntropyTransformer{}
+}
+
+// Fit takes a training term document matrix and calculates the global entropy weight
+// of each term to apply to matrices in subsequent calls to Transform().
+func (t *LogEntropyTransformer) Fit(matrix mat.Matrix) Transformer {
+	if t, isTypeConv := matrix.(sparse.TypeConverter); isTypeConv {
+		matrix = t.ToCSR()
+	}
+	m, n := matrix.Dims()
+
+	globalFreqs := make([]float64, m)
+	nonZeroDo(matrix, func(i, j int, v float64) {
+		globalFreqs[i] += v
+	})
+
+	entropies := make([]float64, m)
+	nonZeroDo(matrix, func(i, j int, v float64) {
+		p := v / globalFreqs[i]
+		entropies[i] += p * math.Log(p)
+	})
+
+	weights := make([]float64, m)
+	for i := range weights {
+		weights[i] = 1
+		if n > 1 {
+			weights[i] += entropies[i] / math.Log(float64(n))
+		}
+	}
+	t.transform = sparse.NewDIA(m, m, weights)
+
+	return t
+}
+
+// Transform applies the log-entropy weighting by taking the log of 1 plus each term
+// frequency and multiplying by its corresponding global entropy weight.  The returned
+// matrix is a sparse matrix type.
+func (t *LogEntropyTransformer) Transform(matrix mat.Matrix) (mat.Matrix, error) {
+	if t, isTypeConv := matrix.(sparse.TypeConverter); isTypeConv {
+		matrix = t.ToCSR()
+	}
+	m, n := matrix.Dims()
+
+	var rows, cols []int
+	var data []float64
+	weights := t.transform.Diagonal()
+	nonZeroDo(matrix, func(i, j int, v float64) {
+		rows = append(rows, i)
+		cols = append(cols, j)
+		data = append(data, math.Log1p(v)*weights[i])
+	})
+
+	return sparse.NewCOO(m, n, rows, cols, data).ToCSR(), nil
+}
+
+// FitTransform is exactly equivalent to calling Fit() followed by Transform() on the
+// same matrix.  This is a convenience where separate training data is not being
+// used to fit the model i.e. the model is fitted on the fly to the test data.
+// The returned matrix is a sparse matrix type.
+func (t *LogEntropyTransformer) FitTransform(matrix mat.Matrix) (mat.Matrix, error) {
+	if t, isTypeConv := matrix.(sparse.TypeConverter); isTypeConv {
+		matrix = t.ToCSR()
+	}
+	return t.Fit(matrix).Transform(matrix)
+}
+
+// Save binary serialises the model and writes it into w.  This is useful for persisting
+// a trained model to disk so that it may be loaded (using the Load() method) in another
+// context (e.g. production) for reproducible results.
+func (t LogEntropyTransformer) Save(w io.Writer) error {
 	_, err := t.transform.MarshalBinaryTo(w)
 
 	return err
@@ -183,7 +1100,127 @@ func (t TfidfTransformer) Save(w io.Writer) error {
 // useful for loading a previously trained and saved model from another context
 // (e.g. offline training) for use within another context (e.g. production) for
 // reproducible results.  Load should only be performed with trusted data.
-func (t *TfidfTransformer) Load(r io.Reader) error {
+func (t *LogEntropyTransformer) Load(r io.Reader) error {
+	var model sparse.DIA
+
+	if _, err := model.UnmarshalBinaryFrom(r); err != nil {
+		return err
+	}
+	t.transform = &model
+
+	return nil
+}
+
+// DeltaTfidfTransformer is a supervised variant of TF-IDF for binary classification
+// (e.g. sentiment analysis) that weights each term frequency by the difference between
+// the inverse document frequencies of the term within the documents of each class.
+// Terms occurring evenly across both classes are weighted towards 0 whilst terms
+// predominantly occurring within a single class are given a large magnitude weight,
+// making the resulting features more discriminative than those of unsupervised TF-IDF.
+// More precisely, each term frequency is multiplied by:
+//
+//	log((1+p)/(1+pdf)) - log((1+n)/(1+ndf))
+//
+// where p and n are the number of documents in the positive and negative classes and
+// pdf and ndf are the number of those documents in which the term occurs.  Terms more
+// prevalent in the negative class are therefore given positive weights and terms more
+// prevalent in the positive class negative weights.
+type DeltaTfidfTransformer struct {
+	// Labels holds the class label of each document (column) in the training matrix
+	// supplied to Fit() where true represents the positive class and false the
+	// negative class.  Labels must be set before calling Fit().
+	Labels []bool
+
+	transform *sparse.DIA
+}
+
+// NewDeltaTfidfTransformer constructs a new DeltaTfidfTransformer to be fitted using
+// the specified class labels for the training documents.
+func NewDeltaTfidfTransformer(labels []bool) *DeltaTfidfTransformer {
+	return &DeltaTfidfTransformer{Labels: labels}
+}
+
+// Fit takes a training term document matrix, with a column for each document labelled
+// in Labels, counts term occurrences across the documents of each class and constructs
+// a delta inverse document frequency transform to apply to matrices in subsequent calls
+// to Transform().  Fit panics if the number of Labels does not match the number of
+// documents.
+func (t *DeltaTfidfTransformer) Fit(matrix mat.Matrix) Transformer {
+	if t, isTypeConv := matrix.(sparse.TypeConverter); isTypeConv {
+		matrix = t.ToCSR()
+	}
+	m, n := matrix.Dims()
+	if len(t.Labels) != n {
+		panic(fmt.Sprintf("nlp: Number of labels (%d) does not match number of documents (%d)", len(t.Labels), n))
+	}
+
+	var numPos, numNeg int
+	for _, label := range t.Labels {
+		if label {
+			numPos++
+		} else {
+			numNeg++
+		}
+	}
+
+	posDF := make([]int, m)
+	negDF := make([]int, m)
+	nonZeroDo(matrix, func(i, j int, v float64) {
+		if t.Labels[j] {
+			posDF[i]++
+		} else {
+			negDF[i]++
+		}
+	})
+
+	weights := make([]float64, m)
+	for i := range weights {
+		weights[i] = SmoothIDF.idf(numPos, posDF[i]) - SmoothIDF.idf(numNeg, negDF[i])
+	}
+	t.transform = sparse.NewDIA(m, m, weights)
+
+	return t
+}
+
+// Transform applies the delta inverse document frequency transform by multiplying
+// each term frequency by its corresponding weight.  The returned matrix is a sparse
+// matrix type.
+func (t *DeltaTfidfTransformer) Transform(matrix mat.Matrix) (mat.Matrix, error) {
+	if t, isTypeConv := matrix.(sparse.TypeConverter); isTypeConv {
+		matrix = t.ToCSR()
+	}
+	var product sparse.CSR
+
+	product.Mul(t.transform, matrix)
+
+	return &product, nil
+}
+
+// FitTransform is exactly equivalent to calling Fit() followed by Transform() on the
+// same matrix.  This is a convenience where separate training data is not being
+// used to fit the model i.e. the model is fitted on the fly to the test data.
+// The returned matrix is a sparse matrix type.
+func (t *DeltaTfidfTransformer) FitTransform(matrix mat.Matrix) (mat.Matrix, error) {
+	if t, isTypeConv := matrix.(sparse.TypeConverter); isTypeConv {
+		matrix = t.ToCSR()
+	}
+	return t.Fit(matrix).Transform(matrix)
+}
+
+// Save binary serialises the model and writes it into w.  This is useful for persisting
+// a trained model to disk so that it may be loaded (using the Load() method) in another
+// context (e.g. production) for reproducible results.
+func (t DeltaTfidfTransformer) Save(w io.Writer) error {
+	_, err := t.transform.MarshalBinaryTo(w)
+
+	return err
+}
+
+// Load binary deserialises the previously serialised model into the receiver.  This is
+// useful for loading a previously trained and saved model from another context
+// (e.g. offline training) for use within another context (e.g. production) for
+// reproducible results.  Load should only be performed with trusted data.
+func (t *DeltaTfidfTransformer) Load(r io.Reader) error {
 	var model sparse.DIA
 
 	if _, err := model.UnmarshalBinaryFrom(r); err != nil {
@@ -2,9 +2,15 @@ package nlp
 
 import (
 	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strconv"
 	"testing"
 
 	"github.com/james-bowman/sparse"
+	"gonum.org/v1/gonum/floats"
 	"gonum.org/v1/gonum/mat"
 )
 
@@ -120,6 +126,500 @@ func TestTfidfTransformerTransform(t *testing.T) {
 	}
 }
 
+func TestTfidfTransformerOrientation(t *testing.T) {
+	input := mat.NewDense(6, 4, []float64{
+		1, 3, 5, 2,
+		8, 1, 0, 0,
+		2, 1, 0, 1,
+		0, 0, 0, 0,
+		0, 0, 0, 1,
+		0, 1, 0, 0,
+	})
+
+	for _, norm := range []int{NoL2Normalization, RowBasedL2Normalization, ColBasedL2Normalization} {
+		termsAsRows := NewTfidfTransformer()
+		termsAsRows.SetL2Normalization(norm)
+		expected, err := termsAsRows.FitTransform(input)
+		if err != nil {
+			t.Errorf("Failed tfidf fit transform caused by %v", err)
+		}
+
+		documentsAsRows := NewTfidfTransformer()
+		documentsAsRows.SetOrientation(DocumentsAsRows)
+		// row and column based normalisation are swapped when the matrix is transposed
+		switch norm {
+		case RowBasedL2Normalization:
+			documentsAsRows.SetL2Normalization(ColBasedL2Normalization)
+		case ColBasedL2Normalization:
+			documentsAsRows.SetL2Normalization(RowBasedL2Normalization)
+		}
+		var csr sparse.CSR
+		csr.Clone(input.T())
+		for _, m := range []mat.Matrix{input.T(), &csr} {
+			result, err := documentsAsRows.FitTransform(m)
+			if err != nil {
+				t.Errorf("Failed tfidf fit transform caused by %v", err)
+			}
+
+			if !mat.EqualApprox(expected.T(), result, 0.001) {
+				t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+					mat.Formatted(expected.T()),
+					mat.Formatted(result))
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestTfidfTransformerIDFScheme(t *testing.T) {
+	input := mat.NewDense(5, 4, []float64{
+		1, 3, 5, 2,
+		8, 1, 0, 0,
+		2, 1, 0, 1,
+		0, 0, 0, 1,
+		0, 0, 0, 0,
+	})
+
+	var tests = []struct {
+		scheme    IDFScheme
+		padding   float64
+		transform []float64
+	}{
+		{
+			scheme:    SmoothIDF,
+			transform: []float64{math.Log(5.0 / 5), math.Log(5.0 / 3), math.Log(5.0 / 4), math.Log(5.0 / 2), math.Log(5.0 / 1)},
+		},
+		{
+			scheme:    SmoothIDF,
+			padding:   1,
+			transform: []float64{1 + math.Log(5.0/5), 1 + math.Log(5.0/3), 1 + math.Log(5.0/4), 1 + math.Log(5.0/2), 1 + math.Log(5.0/1)},
+		},
+		{
+			scheme:    StandardIDF,
+			transform: []float64{math.Log(4.0 / 4), math.Log(4.0 / 2), math.Log(4.0 / 3), math.Log(4.0 / 1), math.Inf(1)},
+		},
+		{
+			scheme:    ProbabilisticIDF,
+			transform: []float64{0, 0, 0, math.Log(3.0 / 1), 0},
+		},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+		transformer := NewTfidfTransformer()
+		transformer.SetIDFScheme(test.scheme)
+		transformer.SetWeightPadding(test.padding)
+
+		transformer.Fit(input)
+
+		weights := transformer.transform.Diagonal()
+		for i, v := range weights {
+			if math.Abs(v-test.transform[i]) > 1e-12 && v != test.transform[i] {
+				t.Logf("Expected weights: \n%v\n but found: \n%v\n",
+					test.transform, weights)
+				t.Fail()
+				break
+			}
+		}
+	}
+
+	// custom IDF functions should take precedence over the IDF scheme
+	transformer := NewTfidfTransformer()
+	transformer.SetIDFScheme(StandardIDF)
+	transformer.SetIDFFunc(func(df, n int) float64 {
+		return math.Min(float64(n)/float64(df+1), 2)
+	})
+	transformer.Fit(input)
+	expected := []float64{4.0 / 5, 4.0 / 3, 1, 2, 2}
+	if weights := transformer.IDF(); !reflect.DeepEqual(expected, weights) {
+		t.Errorf("Expected weights %v but found %v", expected, weights)
+	}
+	transformer.SetIDFFunc(nil)
+	if transformer.GetIDFFunc() != nil {
+		t.Errorf("Expected IDF function to be cleared")
+	}
+
+	transformer = NewTfidfTransformer()
+	if !transformer.GetSmoothIDF() || transformer.GetIDFScheme() != SmoothIDF {
+		t.Errorf("Expected SmoothIDF by default but found %v", transformer.GetIDFScheme())
+	}
+	transformer.SetSmoothIDF(false)
+	if transformer.GetSmoothIDF() || transformer.GetIDFScheme() != StandardIDF {
+		t.Errorf("Expected StandardIDF but found %v", transformer.GetIDFScheme())
+	}
+}
+
+func TestTfidfTransformerTFScheme(t *testing.T) {
+	input := mat.NewDense(3, 3, []float64{
+		1, 3, 0,
+		4, 0, 2,
+		0, 1, 0,
+	})
+
+	var tests = []struct {
+		scheme      TFScheme
+		orientation Orientation
+		expected    []float64
+	}{
+		{
+			scheme: RawTF,
+			expected: []float64{
+				1, 3, 0,
+				4, 0, 2,
+				0, 1, 0,
+			},
+		},
+		{
+			scheme: BinaryTF,
+			expected: []float64{
+				1, 1, 0,
+				1, 0, 1,
+				0, 1, 0,
+			},
+		},
+		{
+			scheme: AugmentedTF,
+			expected: []float64{
+				0.5 + 0.5*1/4, 0.5 + 0.5*3/3, 0,
+				0.5 + 0.5*4/4, 0, 0.5 + 0.5*2/2,
+				0, 0.5 + 0.5*1/3, 0,
+			},
+		},
+		{
+			scheme:      AugmentedTF,
+			orientation: DocumentsAsRows,
+			expected: []float64{
+				0.5 + 0.5*1/3, 0.5 + 0.5*3/3, 0,
+				0.5 + 0.5*4/4, 0, 0.5 + 0.5*2/4,
+				0, 0.5 + 0.5*1/1, 0,
+			},
+		},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+		transformer := NewTfidfTransformer()
+		transformer.SetOrientation(test.orientation)
+		transformer.SetTFScheme(test.scheme)
+		transformer.SetWeightPadding(1)
+		if transformer.GetTFScheme() != test.scheme {
+			t.Errorf("Expected TF scheme %v but found %v", test.scheme, transformer.GetTFScheme())
+		}
+
+		result, err := transformer.FitTransform(input)
+		if err != nil {
+			t.Errorf("Failed to transform matrix because %v", err)
+		}
+
+		idf := transformer.IDF()
+		r, c := result.Dims()
+		for i := 0; i < r; i++ {
+			for j := 0; j < c; j++ {
+				w := idf[i]
+				if test.orientation == DocumentsAsRows {
+					w = idf[j]
+				}
+				if v, e := result.At(i, j), test.expected[i*c+j]*w; math.Abs(v-e) > 1e-12 {
+					t.Errorf("Expected %f at (%d, %d) but found %f", e, i, j, v)
+				}
+			}
+		}
+	}
+}
+
+func TestTfidfTransformerPartialFit(t *testing.T) {
+	batches := []*mat.Dense{
+		mat.NewDense(3, 2, []float64{
+			1, 3,
+			2, 0,
+			0, 0,
+		}),
+		mat.NewDense(3, 1, []float64{
+			1,
+			0,
+			4,
+		}),
+		mat.NewDense(4, 2, []float64{
+			0, 2,
+			1, 0,
+			0, 0,
+			5, 0,
+		}),
+	}
+	corpus := mat.NewDense(4, 5, []float64{
+		1, 3, 1, 0, 2,
+		2, 0, 0, 1, 0,
+		0, 0, 4, 0, 0,
+		0, 0, 0, 5, 0,
+	})
+
+	var transformer OnlineTransformer = NewTfidfTransformer()
+	for _, batch := range batches {
+		transformer = transformer.PartialFit(batch)
+	}
+
+	expected := NewTfidfTransformer()
+	expected.Fit(corpus)
+
+	if idf, exp := transformer.(*TfidfTransformer).IDF(), expected.IDF(); !reflect.DeepEqual(exp, idf) {
+		t.Errorf("Expected weights %v but found %v", exp, idf)
+	}
+
+	result, err := transformer.Transform(corpus)
+	if err != nil {
+		t.Errorf("Failed to transform matrix because %v", err)
+	}
+	expectedResult, _ := expected.Transform(corpus)
+	if !mat.Equal(expectedResult, result) {
+		t.Errorf("Expected result %v but found %v", mat.Formatted(expectedResult), mat.Formatted(result))
+	}
+
+	// Fit should discard previously accumulated statistics
+	transformer.Fit(batches[0])
+	expected.Fit(batches[0])
+	if idf, exp := transformer.(*TfidfTransformer).IDF(), expected.IDF(); !reflect.DeepEqual(exp, idf) {
+		t.Errorf("Expected weights %v but found %v", exp, idf)
+	}
+}
+
+func TestTfidfTransformerNormalization(t *testing.T) {
+	input := mat.NewDense(3, 3, []float64{
+		1, 3, 0,
+		2, 1, 0,
+		0, 1, 0,
+	})
+
+	for _, norm := range []int{RowBasedL2Normalization, ColBasedL2Normalization, RowBasedL1Normalization, ColBasedL1Normalization} {
+		transformer := NewTfidfTransformer()
+		transformer.SetL2Normalization(norm)
+		result, err := transformer.FitTransform(input)
+		if err != nil {
+			t.Errorf("Failed tfidf fit transform caused by %v", err)
+		}
+
+		m := result
+		if norm == ColBasedL2Normalization || norm == ColBasedL1Normalization {
+			m = result.T()
+		}
+		r, c := m.Dims()
+		for i := 0; i < r; i++ {
+			var sum float64
+			for j := 0; j < c; j++ {
+				if norm == RowBasedL1Normalization || norm == ColBasedL1Normalization {
+					sum += math.Abs(m.At(i, j))
+				} else {
+					sum += m.At(i, j) * m.At(i, j)
+				}
+			}
+			// vectors of all zeros are left unnormalised
+			if sum != 0 && math.Abs(sum-1) > 1e-12 {
+				t.Errorf("Expected normalised vector %d to have norm 1 (normalization %d) but found %f", i, norm, sum)
+			}
+		}
+	}
+}
+
+func TestTfidfTransformerPivotedNormalization(t *testing.T) {
+	input := mat.NewDense(4, 3, []float64{
+		1, 3, 0,
+		2, 1, 0,
+		0, 1, 1,
+		0, 4, 2,
+	})
+
+	var tests = []struct {
+		slope float64
+		pivot float64
+	}{
+		{slope: 1},
+		{slope: 0.25},
+		{slope: 0.25, pivot: 2},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+		weighted, _ := NewTfidfTransformer().FitTransform(input)
+		r, c := weighted.Dims()
+		norms := make([]float64, c)
+		var avgNorm float64
+		for j := 0; j < c; j++ {
+			norms[j] = floats.Norm(mat.Col(nil, j, weighted), 2)
+			avgNorm += norms[j] / float64(c)
+		}
+		pivot := test.pivot
+		if pivot == 0 {
+			pivot = avgNorm
+		}
+		expected := mat.NewDense(r, c, nil)
+		expected.Apply(func(i, j int, v float64) float64 {
+			return weighted.At(i, j) / ((1-test.slope)*pivot + test.slope*norms[j])
+		}, expected)
+
+		for _, orientation := range []Orientation{TermsAsRows, DocumentsAsRows} {
+			transformer := NewTfidfTransformer()
+			transformer.SetOrientation(orientation)
+			transformer.SetL2Normalization(PivotedNormalization)
+			transformer.SetPivot(test.slope, test.pivot)
+			if slope, pivot := transformer.GetPivot(); slope != test.slope || pivot != test.pivot {
+				t.Errorf("Expected slope %f and pivot %f but found %f and %f", test.slope, test.pivot, slope, pivot)
+			}
+
+			var result mat.Matrix
+			if orientation == DocumentsAsRows {
+				result, _ = transformer.FitTransform(mat.DenseCopyOf(input.T()))
+				result = result.T()
+			} else {
+				result, _ = transformer.FitTransform(input)
+			}
+			if !mat.EqualApprox(expected, result, 1e-12) {
+				t.Errorf("Expected result %v but found %v", mat.Formatted(expected), mat.Formatted(result))
+			}
+		}
+	}
+}
+
+func TestTfidfTransformerPivotedNormalizationPartialFit(t *testing.T) {
+	batches := []*mat.Dense{
+		mat.NewDense(3, 2, []float64{
+			1, 3,
+			2, 0,
+			0, 1,
+		}),
+		mat.NewDense(4, 3, []float64{
+			1, 0, 2,
+			0, 1, 0,
+			4, 0, 0,
+			0, 2, 1,
+		}),
+	}
+
+	transformer := NewTfidfTransformer()
+	transformer.SetL2Normalization(PivotedNormalization)
+	transformer.SetPivot(0.25, 0)
+
+	// the average norm should be the mean of the norms of all documents, each weighted
+	// using the IDF at the time it was supplied
+	var sum float64
+	var docs int
+	for _, batch := range batches {
+		transformer.PartialFit(batch)
+		idf := transformer.IDF()
+		r, c := batch.Dims()
+		for j := 0; j < c; j++ {
+			var norm float64
+			for i := 0; i < r; i++ {
+				norm += math.Pow(batch.At(i, j)*idf[i], 2)
+			}
+			sum += math.Sqrt(norm)
+			docs++
+		}
+	}
+	if expected := sum / float64(docs); math.Abs(transformer.avgNorm-expected) > 1e-12 {
+		t.Errorf("Expected average norm %f but found %f", expected, transformer.avgNorm)
+	}
+
+	// Fit should discard the previously accumulated average norm and a single call to
+	// PartialFit on an unfitted model should match Fit
+	expected := NewTfidfTransformer()
+	expected.SetL2Normalization(PivotedNormalization)
+	expected.SetPivot(0.25, 0)
+	expected.Fit(batches[1])
+	transformer.Fit(batches[1])
+	if math.Abs(transformer.avgNorm-expected.avgNorm) > 1e-12 {
+		t.Errorf("Expected average norm %f but found %f", expected.avgNorm, transformer.avgNorm)
+	}
+	online := NewTfidfTransformer()
+	online.SetL2Normalization(PivotedNormalization)
+	online.SetPivot(0.25, 0)
+	online.PartialFit(batches[1])
+	if math.Abs(online.avgNorm-expected.avgNorm) > 1e-12 {
+		t.Errorf("Expected average norm %f but found %f", expected.avgNorm, online.avgNorm)
+	}
+}
+
+func TestTfidfTransformerIDF(t *testing.T) {
+	transformer := NewTfidfTransformer()
+	if idf := transformer.IDF(); idf != nil {
+		t.Errorf("Expected nil IDF weights before fitting but found %v", idf)
+	}
+
+	vectoriser := NewCountVectoriser()
+	counts, _ := vectoriser.FitTransform("the cat sat", "the dog sat", "the dog ran")
+	transformer.Fit(counts)
+
+	expected := map[string]float64{
+		"the": math.Log(4.0 / 4),
+		"cat": math.Log(4.0 / 2),
+		"sat": math.Log(4.0 / 3),
+		"dog": math.Log(4.0 / 3),
+		"ran": math.Log(4.0 / 2),
+	}
+	idf := transformer.IDF()
+	for term, i := range vectoriser.Vocabulary {
+		if math.Abs(idf[i]-expected[term]) > 1e-12 {
+			t.Errorf("Expected IDF %f for term %q but found %f", expected[term], term, idf[i])
+		}
+	}
+	if termIDF := transformer.TermIDF(vectoriser.Vocabulary); len(termIDF) != len(expected) {
+		t.Errorf("Expected IDF for %d terms but found %v", len(expected), termIDF)
+	} else {
+		for term, v := range termIDF {
+			if math.Abs(v-expected[term]) > 1e-12 {
+				t.Errorf("Expected IDF %f for term %q but found %f", expected[term], term, v)
+			}
+		}
+	}
+
+	// modifying the returned weights should not affect the model
+	idf[0] = 100
+	if transformer.IDF()[0] == 100 {
+		t.Errorf("Expected IDF() to return a copy of the weights")
+	}
+}
+
+func TestTfidfTransformerSaveTermIDF(t *testing.T) {
+	transformer := NewTfidfTransformer()
+	transformer.SetWeightPadding(1)
+	vocabulary := map[string]int{"the": 0, "dog": 1, "cat": 2}
+	transformer.Fit(mat.NewDense(3, 3, []float64{
+		1, 1, 1,
+		0, 1, 1,
+		1, 0, 0,
+	}))
+
+	var buf bytes.Buffer
+	if err := transformer.SaveTermIDFCSV(&buf, vocabulary); err != nil {
+		t.Errorf("Failed to save IDF weights as CSV because %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Errorf("Failed to read CSV because %v", err)
+	}
+	expectedTerms := []string{"term", "the", "dog", "cat"}
+	expectedIDF := []float64{0, 1, 1 + math.Log(4.0/3), 1 + math.Log(4.0/2)}
+	if len(rows) != len(expectedTerms) {
+		t.Fatalf("Expected %d rows but found %v", len(expectedTerms), rows)
+	}
+	for i, row := range rows[1:] {
+		idf, _ := strconv.ParseFloat(row[1], 64)
+		if row[0] != expectedTerms[i+1] || math.Abs(idf-expectedIDF[i+1]) > 1e-12 {
+			t.Errorf("Expected row %s,%f but found %v", expectedTerms[i+1], expectedIDF[i+1], row)
+		}
+	}
+
+	buf.Reset()
+	if err := transformer.SaveTermIDFJSON(&buf, vocabulary); err != nil {
+		t.Errorf("Failed to save IDF weights as JSON because %v", err)
+	}
+	var termIDF map[string]float64
+	if err := json.NewDecoder(&buf).Decode(&termIDF); err != nil {
+		t.Errorf("Failed to read JSON because %v", err)
+	}
+	if !reflect.DeepEqual(transformer.TermIDF(vocabulary), termIDF) {
+		t.Errorf("Expected IDF weights %v but found %v", transformer.TermIDF(vocabulary), termIDF)
+	}
+}
+
 func TestTfidfTransformerSaveLoad(t *testing.T) {
 	var transforms = []struct {
 		wantedTransform *sparse.DIA
@@ -154,6 +654,403 @@ func TestTfidfTransformerSaveLoad(t *testing.T) {
 	}
 }
 
+func TestTfidfTransformerSaveLoadPivotedNormalization(t *testing.T) {
+	input := mat.NewDense(4, 3, []float64{
+		1, 3, 0,
+		2, 1, 0,
+		0, 1, 1,
+		0, 4, 2,
+	})
+
+	var tests = []struct {
+		slope float64
+		pivot float64
+	}{
+		{slope: 0.25},
+		{slope: 0.25, pivot: 2},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		a := NewTfidfTransformer()
+		a.SetL2Normalization(PivotedNormalization)
+		a.SetPivot(test.slope, test.pivot)
+		expected, _ := a.FitTransform(input)
+
+		buf := new(bytes.Buffer)
+		if err := a.Save(buf); err != nil {
+			t.Errorf("Error encoding: %v\n", err)
+			continue
+		}
+
+		b := NewTfidfTransformer()
+		if err := b.Load(buf); err != nil {
+			t.Errorf("Error unencoding: %v\n", err)
+			continue
+		}
+		if b.GetL2Normalization() != PivotedNormalization {
+			t.Errorf("Expected normalization %d but found %d", PivotedNormalization, b.GetL2Normalization())
+		}
+		if slope, pivot := b.GetPivot(); slope != test.slope || pivot != test.pivot {
+			t.Errorf("Expected slope %f and pivot %f but found %f and %f", test.slope, test.pivot, slope, pivot)
+		}
+
+		result, err := b.Transform(input)
+		if err != nil {
+			t.Errorf("Failed to transform matrix because %v", err)
+			continue
+		}
+		if !mat.EqualApprox(expected, result, 1e-12) {
+			t.Errorf("Expected result %v but found %v", mat.Formatted(expected), mat.Formatted(result))
+		}
+	}
+}
+
+func TestTfidfTransformerSaveLoadHyperparameters(t *testing.T) {
+	a := NewTfidfTransformer()
+	a.SetIDFScheme(ProbabilisticIDF)
+	a.SetTFScheme(AugmentedTF)
+	a.SetOrientation(DocumentsAsRows)
+	a.SetWeightPadding(1)
+	a.SetL2Normalization(RowBasedL1Normalization)
+	a.Fit(mat.NewDense(3, 2, []float64{1, 0, 2, 1, 0, 3}))
+
+	buf := new(bytes.Buffer)
+	if err := a.Save(buf); err != nil {
+		t.Fatalf("Error encoding: %v\n", err)
+	}
+	b := NewTfidfTransformer()
+	if err := b.Load(buf); err != nil {
+		t.Fatalf("Error unencoding: %v\n", err)
+	}
+
+	if b.GetIDFScheme() != a.GetIDFScheme() || b.GetTFScheme() != a.GetTFScheme() ||
+		b.GetOrientation() != a.GetOrientation() || b.GetWeightPadding() != a.GetWeightPadding() ||
+		b.GetL2Normalization() != a.GetL2Normalization() {
+		t.Errorf("Expected hyperparameters of loaded model to match those of saved model")
+	}
+	if !mat.Equal(a.transform, b.transform) {
+		t.Errorf("Wanted %v but got %v\n", mat.Formatted(a.transform), mat.Formatted(b.transform))
+	}
+}
+
+func TestTfidfTransformerLoadLegacy(t *testing.T) {
+	var tests = []struct {
+		weights []float64
+	}{
+		{weights: []float64{1}},
+		{weights: []float64{1, 5}},
+		{weights: []float64{1, 5, 2.5}},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		// models saved by earlier releases contain only the IDF weights
+		weights := sparse.NewDIA(len(test.weights), len(test.weights), test.weights)
+		buf := new(bytes.Buffer)
+		if _, err := weights.MarshalBinaryTo(buf); err != nil {
+			t.Fatalf("Error encoding: %v\n", err)
+		}
+
+		transformer := NewTfidfTransformer()
+		transformer.SetIDFScheme(StandardIDF)
+		transformer.SetTFScheme(BinaryTF)
+		transformer.SetPivot(0.25, 2)
+		transformer.SetL2Normalization(RowBasedL2Normalization)
+		if err := transformer.Load(buf); err != nil {
+			t.Errorf("Error unencoding: %v\n", err)
+			continue
+		}
+		if !mat.Equal(weights, transformer.transform) {
+			t.Errorf("Wanted %v but got %v\n", mat.Formatted(weights), mat.Formatted(transformer.transform))
+		}
+		if slope, pivot := transformer.GetPivot(); transformer.GetIDFScheme() != SmoothIDF ||
+			transformer.GetTFScheme() != RawTF || slope != 0 || pivot != 0 {
+			t.Errorf("Expected legacy model to be loaded with default schemes and no pivot")
+		}
+		if transformer.GetL2Normalization() != RowBasedL2Normalization {
+			t.Errorf("Expected normalization of receiver to be retained")
+		}
+	}
+}
+
+func TestTfidfTransformerLoadUnsupportedVersion(t *testing.T) {
+	transformer := NewTfidfTransformer()
+	transformer.Fit(mat.NewDense(2, 2, []float64{1, 0, 1, 1}))
+	buf := new(bytes.Buffer)
+	if err := transformer.Save(buf); err != nil {
+		t.Fatalf("Error encoding: %v\n", err)
+	}
+	buf.Bytes()[len(modelMagic)] = 99
+	if err := NewTfidfTransformer().Load(buf); err == nil {
+		t.Errorf("Expected error loading unsupported version but received nil")
+	}
+}
+
+func TestBM25Transformer(t *testing.T) {
+	input := mat.NewDense(3, 3, []float64{
+		2, 0, 1,
+		1, 1, 0,
+		0, 3, 0,
+	})
+	// document lengths of 3, 4 and 1 give an average document length of 8/3
+	docLens := []float64{3, 4, 1}
+	avgDocLen := 8.0 / 3
+	dfs := []float64{2, 2, 1}
+	n := 3.0
+
+	var tests = []struct {
+		k1, b   float64
+		variant BM25Variant
+		delta   float64
+	}{
+		{1.2, 0.75, OkapiBM25, 0},
+		{2, 0, OkapiBM25, 0},
+		{1.5, 1, OkapiBM25, 1},
+		{1.2, 0.75, BM25Plus, 1},
+		{1.2, 0.75, BM25L, 0.5},
+		{1.2, 0.75, BM25L, 0},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		expected := mat.NewDense(3, 3, nil)
+		for i := 0; i < 3; i++ {
+			idf := math.Log(1 + (n-dfs[i]+0.5)/(dfs[i]+0.5))
+			for j := 0; j < 3; j++ {
+				tf := input.At(i, j)
+				if tf == 0 {
+					continue
+				}
+				norm := 1 - test.b + test.b*docLens[j]/avgDocLen
+				switch test.variant {
+				case OkapiBM25:
+					expected.Set(i, j, idf*tf*(test.k1+1)/(tf+test.k1*norm))
+				case BM25Plus:
+					expected.Set(i, j, idf*(tf*(test.k1+1)/(tf+test.k1*norm)+test.delta))
+				case BM25L:
+					ctf := tf / norm
+					expected.Set(i, j, idf*(test.k1+1)*(ctf+test.delta)/(test.k1+ctf+test.delta))
+				}
+			}
+		}
+
+		transformer := NewBM25Transformer()
+		transformer.K1 = test.k1
+		transformer.B = test.b
+		transformer.Variant = test.variant
+		transformer.Delta = test.delta
+
+		for _, m := range []mat.Matrix{input, sparse.NewCSR(3, 3, []int{0, 2, 4, 5}, []int{0, 2, 0, 1, 1}, []float64{2, 1, 1, 1, 3})} {
+			result, err := transformer.FitTransform(m)
+			if err != nil {
+				t.Errorf("Failed BM25 fit transform caused by %v", err)
+			}
+
+			if !mat.EqualApprox(expected, result, 1e-12) {
+				t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+					mat.Formatted(expected),
+					mat.Formatted(result))
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestBM25TransformerSaveLoad(t *testing.T) {
+	input := mat.NewDense(3, 3, []float64{
+		2, 0, 1,
+		1, 1, 0,
+		0, 3, 0,
+	})
+
+	a := NewBM25Transformer()
+	a.K1 = 1.6
+	a.B = 0.5
+	a.Variant = BM25Plus
+	a.Delta = 1
+	expected, _ := a.FitTransform(input)
+
+	buf := new(bytes.Buffer)
+	if err := a.Save(buf); err != nil {
+		t.Fatalf("Error encoding: %v\n", err)
+	}
+
+	b := &BM25Transformer{}
+	if err := b.Load(buf); err != nil {
+		t.Fatalf("Error unencoding: %v\n", err)
+	}
+
+	result, err := b.Transform(input)
+	if err != nil {
+		t.Errorf("Failed BM25 transform caused by %v", err)
+	}
+	if b.K1 != a.K1 || b.B != a.B || b.Variant != a.Variant || b.Delta != a.Delta || !mat.Equal(expected, result) {
+		t.Logf("Wanted %v but got %v\n", mat.Formatted(expected), mat.Formatted(result))
+		t.Fail()
+	}
+}
+
+func TestPPMITransformer(t *testing.T) {
+	input := mat.NewDense(3, 3, []float64{
+		4, 0, 1,
+		1, 2, 0,
+		0, 2, 2,
+	})
+	total := 12.0
+	rowSums := []float64{5, 3, 4}
+	colSums := []float64{5, 4, 3}
+
+	var tests = []struct {
+		positive bool
+		shift    float64
+	}{
+		{positive: true},
+		{positive: false},
+		{positive: true, shift: 2},
+		{positive: false, shift: 5},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		expected := mat.NewDense(3, 3, nil)
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 3; j++ {
+				v := input.At(i, j)
+				if v == 0 {
+					continue
+				}
+				pmi := math.Log((v / total) / ((rowSums[i] / total) * (colSums[j] / total)))
+				if test.shift > 1 {
+					pmi -= math.Log(test.shift)
+				}
+				if test.positive && pmi < 0 {
+					pmi = 0
+				}
+				expected.Set(i, j, pmi)
+			}
+		}
+
+		transformer := NewPPMITransformer()
+		transformer.Positive = test.positive
+		transformer.Shift = test.shift
+		result, err := transformer.FitTransform(input)
+		if err != nil {
+			t.Errorf("Failed PPMI fit transform caused by %v", err)
+		}
+
+		if !mat.EqualApprox(expected, result, 1e-12) {
+			t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+				mat.Formatted(expected),
+				mat.Formatted(result))
+			t.Fail()
+		}
+	}
+}
+
+func TestLogEntropyTransformer(t *testing.T) {
+	input := mat.NewDense(3, 4, []float64{
+		2, 2, 2, 2,
+		4, 0, 0, 0,
+		1, 3, 0, 0,
+	})
+	p := []float64{0.25, 0.75}
+	weights := []float64{
+		0,
+		1,
+		1 + (p[0]*math.Log(p[0])+p[1]*math.Log(p[1]))/math.Log(4),
+	}
+
+	expected := mat.NewDense(3, 4, nil)
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 4; j++ {
+			expected.Set(i, j, math.Log(1+input.At(i, j))*weights[i])
+		}
+	}
+
+	transformer := NewLogEntropyTransformer()
+	result, err := transformer.FitTransform(input)
+	if err != nil {
+		t.Errorf("Failed log-entropy fit transform caused by %v", err)
+	}
+
+	if !mat.EqualApprox(expected, result, 1e-12) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat.Formatted(expected),
+			mat.Formatted(result))
+		t.Fail()
+	}
+
+	buf := new(bytes.Buffer)
+	if err := transformer.Save(buf); err != nil {
+		t.Fatalf("Error encoding: %v\n", err)
+	}
+	loaded := NewLogEntropyTransformer()
+	if err := loaded.Load(buf); err != nil {
+		t.Fatalf("Error unencoding: %v\n", err)
+	}
+	if result, _ = loaded.Transform(input); !mat.EqualApprox(expected, result, 1e-12) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat.Formatted(expected),
+			mat.Formatted(result))
+		t.Fail()
+	}
+}
+
+func TestDeltaTfidfTransformer(t *testing.T) {
+	input := mat.NewDense(3, 5, []float64{
+		1, 2, 0, 0, 0,
+		0, 0, 1, 3, 1,
+		1, 1, 1, 1, 0,
+	})
+	labels := []bool{true, true, false, false, false}
+
+	// 2 positive and 3 negative documents
+	weights := []float64{
+		math.Log(3.0/3) - math.Log(4.0/1),
+		math.Log(3.0/1) - math.Log(4.0/4),
+		math.Log(3.0/3) - math.Log(4.0/3),
+	}
+	expected := mat.NewDense(3, 5, nil)
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 5; j++ {
+			expected.Set(i, j, input.At(i, j)*weights[i])
+		}
+	}
+
+	transformer := NewDeltaTfidfTransformer(labels)
+	result, err := transformer.FitTransform(input)
+	if err != nil {
+		t.Errorf("Failed delta tfidf fit transform caused by %v", err)
+	}
+
+	if !mat.EqualApprox(expected, result, 1e-12) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat.Formatted(expected),
+			mat.Formatted(result))
+		t.Fail()
+	}
+
+	buf := new(bytes.Buffer)
+	if err := transformer.Save(buf); err != nil {
+		t.Fatalf("Error encoding: %v\n", err)
+	}
+	loaded := &DeltaTfidfTransformer{}
+	if err := loaded.Load(buf); err != nil {
+		t.Fatalf("Error unencoding: %v\n", err)
+	}
+	if result, _ = loaded.Transform(input); !mat.EqualApprox(expected, result, 1e-12) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat.Formatted(expected),
+			mat.Formatted(result))
+		t.Fail()
+	}
+}
+
 func benchmarkTFIDFFitTransform(t Transformer, m, n int, b *testing.B) {
 	mat := mat.NewDense(m, n, nil)
 
@@ -174,3 +1071,36 @@ func BenchmarkTFIDFFitTransform2000x1000(b *testing.B) {
 func BenchmarkTFIDFFitTransform20000x10000(b *testing.B) {
 	benchmarkTFIDFFitTransform(NewTfidfTransformer(), 20000, 10000, b)
 }
+
+func TestTfidfTransformerInverseTransform(t *testing.T) {
+	input := mat.NewDense(4, 3, []float64{
+		1, 0, 2,
+		0, 3, 1,
+		2, 1, 0,
+		1, 1, 1,
+	})
+
+	for testRun, orientation := range []Orientation{TermsAsRows, DocumentsAsRows} {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		m := mat.Matrix(input)
+		if orientation == DocumentsAsRows {
+			m = mat.DenseCopyOf(input.T())
+		}
+
+		tfidf := NewTfidfTransformer()
+		tfidf.SetOrientation(orientation)
+		tfidf.SetWeightPadding(1)
+		weighted, err := tfidf.FitTransform(m)
+		if err != nil {
+			t.Errorf("Failed to fit TF-IDF because %v", err)
+		}
+		result, err := tfidf.InverseTransform(weighted)
+		if err != nil {
+			t.Errorf("Failed to inverse transform because %v", err)
+		}
+		if !mat.EqualApprox(m, result, 1e-12) {
+			t.Errorf("Expected matrix:\n%v\nbut found:\n%v", mat.Formatted(m), mat.Formatted(result))
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package nlp
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"golang.org/x/exp/rand"
+)
+
+// Word2Vec learns word embeddings directly from a corpus of documents using the
+// skip-gram with negative sampling (SGNS) model described by Mikolov et al. in
+// "Distributed Representations of Words and Phrases and their Compositionality"
+// (https://arxiv.org/pdf/1310.4546.pdf).  The vector of each term is trained to
+// predict the terms occurring within a window around it, against randomly sampled
+// noise terms, so terms occurring in similar contexts are represented by similar
+// vectors.  This allows domain specific embeddings to be trained without relying on
+// pretrained models.  The trained vectors are returned as Embeddings supporting
+// nearest neighbour queries and use with an EmbeddingVectoriser.
+type Word2Vec struct {
+	// K is the dimensionality of the word vectors
+	K int
+
+	// Window is the maximum number of tokens either side of each term considered to
+	// be its context.  For each term, the size of the window is sampled uniformly
+	// between 1 and Window so that closer terms are weighted more heavily.
+	Window int
+
+	// Negative is the number of negative (noise) terms sampled for each context term
+	Negative int
+
+	// Sample is the subsampling threshold.  Occurrences of terms with a frequency
+	// greater than Sample are randomly discarded during training, with a probability
+	// increasing with frequency, to speed up training and improve the vectors of rarer
+	// terms.  A value of 0 disables subsampling.
+	Sample float64
+
+	// Epochs is the number of training passes across the documents
+	Epochs int
+
+	// LearningRate is the initial learning rate which decays linearly to
+	// MinLearningRate over the course of training
+	LearningRate float64
+
+	// MinLearningRate is the final learning rate
+	MinLearningRate float64
+
+	// MinCount is the minimum number of times a term must occur within the training
+	// documents to be included in the Vocabulary.  Less frequent terms are ignored.
+	MinCount int
+
+	// Tokeniser is used to tokenise input text into terms
+	Tokeniser Tokeniser
+
+	// Rnd is the random number generator used to initialise vectors and sample
+	// windows, discarded terms and negative terms
+	Rnd *rand.Rand
+
+	// Vocabulary is a map of terms to indices learnt during Fit()
+	Vocabulary map[string]int
+
+	// vectors holds the (input) word vectors of each term in the Vocabulary (terms x K)
+	vectors []float64
+}
+
+// NewWord2Vec creates a new Word2Vec learning word vectors of k dimensions with
+// default values (as per the reference implementation) of a Window of 5, 5 Negative
+// samples, a Sample threshold of 0.001, 5 Epochs, a LearningRate of 0.025 decaying to
+// 0.0001 and a MinCount of 5.  If stopWords is not an empty slice then stop words
+// will be removed.
+func NewWord2Vec(k int, stopWords ...string) *Word2Vec {
+	return &Word2Vec{
+		K:               k,
+		Window:          5,
+		Negative:        5,
+		Sample:          1e-3,
+		Epochs:          5,
+		LearningRate:    0.025,
+		MinLearningRate: 0.0001,
+		MinCount:        5,
+		Tokeniser:       NewTokeniser(stopWords...),
+		Rnd:             rand.New(rand.NewSource(uint64(time.Now().UnixNano()))),
+	}
+}
+
+// Fit trains word vectors for the terms within the specified training documents.
+// Calling Fit() a second time re-trains the model from scratch.
+func (w *Word2Vec) Fit(train ...string) *Word2Vec {
+	docs := make([][]string, len(train))
+	for d, doc := range train {
+		tokenise(w.Tokeniser, doc)(func(token string) {
+			docs[d] = append(docs[d], token)
+		})
+	}
+	return w.FitTokens(docs...)
+}
+
+// FitTokens is equivalent to Fit() but accepts pre-tokenised training documents,
+// each represented as a slice of tokens, rather than raw text.  The Tokeniser is not
+// used.
+func (w *Word2Vec) FitTokens(train ...[]string) *Word2Vec {
+	var counts []int
+	w.Vocabulary, counts = learnVocabulary(train, w.MinCount)
+	noise := unigramNoise(counts)
+
+	docs := make([][]int, len(train))
+	var total int
+	for d, doc := range train {
+		for _, token := range doc {
+			if i, exists := w.Vocabulary[token]; exists {
+				docs[d] = append(docs[d], i)
+			}
+		}
+		total += len(docs[d])
+	}
+
+	w.vectors = make([]float64, len(counts)*w.K)
+	for i := range w.vectors {
+		w.vectors[i] = (w.Rnd.Float64() - 0.5) / float64(w.K)
+	}
+	out := make([]float64, len(counts)*w.K)
+	grad := make([]float64, w.K)
+
+	if total == 0 {
+		return w
+	}
+
+	var processed int
+	var sentence []int
+	for epoch := 0; epoch < w.Epochs; epoch++ {
+		for _, doc := range docs {
+			sentence = w.subsample(sentence[:0], doc, counts, total)
+			processed += len(doc)
+			rate := w.LearningRate - (w.LearningRate-w.MinLearningRate)*float64(processed)/float64(w.Epochs*total)
+
+			for p, term := range sentence {
+				vec := w.vectors[term*w.K : (term+1)*w.K]
+				b := w.Rnd.Intn(w.Window) + 1
+				for c := p - b; c <= p+b; c++ {
+					if c < 0 || c == p || c >= len(sentence) {
+						continue
+					}
+					negativeSampling(vec, out, sentence[c], w.Negative, noise, w.Rnd, rate, grad, true)
+				}
+			}
+		}
+	}
+	return w
+}
+
+// subsample appends the terms of doc to sentence, randomly discarding occurrences
+// of frequent terms according to the Sample threshold, and returns the result.
+func (w *Word2Vec) subsample(sentence []int, doc []int, counts []int, total int) []int {
+	if w.Sample <= 0 {
+		return append(sentence, doc...)
+	}
+	threshold := w.Sample * float64(total)
+	for _, term := range doc {
+		f := float64(counts[term])
+		if keep := (math.Sqrt(f/threshold) + 1) * threshold / f; keep < w.Rnd.Float64() {
+			continue
+		}
+		sentence = append(sentence, term)
+	}
+	return sentence
+}
+
+// Embeddings returns the trained word vectors as Embeddings.
+func (w *Word2Vec) Embeddings() *Embeddings {
+	terms := make([]string, len(w.Vocabulary))
+	for term, i := range w.Vocabulary {
+		terms[i] = term
+	}
+
+	embeddings := NewEmbeddings(w.K)
+	for i, term := range terms {
+		embeddings.Add(term, w.vectors[i*w.K:(i+1)*w.K])
+	}
+	return embeddings
+}
+
+// learnVocabulary returns a vocabulary, mapping terms to indices in lexicographical
+// order, of the terms occurring at least minCount times within the tokenised
+// documents along with the number of occurrences of each term.
+func learnVocabulary(docs [][]string, minCount int) (map[string]int, []int) {
+	counts := make(map[string]int)
+	for _, doc := range docs {
+		for _, token := range doc {
+			counts[token]++
+		}
+	}
+	var terms []string
+	for term, count := range counts {
+		if count >= minCount {
+			terms = append(terms, term)
+		}
+	}
+	sort.Strings(terms)
+
+	vocabulary := make(map[string]int, len(terms))
+	termCounts := make([]int, len(terms))
+	for i, term := range terms {
+		vocabulary[term] = i
+		termCounts[i] = counts[term]
+	}
+	return vocabulary, termCounts
+}
+
+// unigramNoise returns the cumulative noise distribution used for negative sampling
+// in which the probability of each term is proportional to its frequency raised to
+// the power 0.75.
+func unigramNoise(counts []int) []float64 {
+	noise := make([]float64, len(counts))
+	var sum float64
+	for i, count := range counts {
+		sum += math.Pow(float64(count), 0.75)
+		noise[i] = sum
+	}
+	for i := range noise {
+		noise[i] /= sum
+	}
+	return noise
+}
+
+// negativeSampling performs a single step of stochastic gradient descent updating
+// the input vector in to better predict the target term against negative terms
+// sampled from the cumulative noise distribution.  outs holds the output vectors of
+// all terms which, if updateOut is true, are also updated.  grad is used as scratch
+// space and must be the same length as in.
+func negativeSampling(in, outs []float64, target, negative int, noise []float64, rnd *rand.Rand, rate float64, grad []float64, updateOut bool) {
+	k := len(in)
+	for i := range grad {
+		grad[i] = 0
+	}
+	for n := 0; n <= negative; n++ {
+		term, label := target, 1.0
+		if n > 0 {
+			term = sort.SearchFloat64s(noise, rnd.Float64())
+			if term >= len(noise) {
+				term = len(noise) - 1
+			}
+			if term == target {
+				continue
+			}
+			label = 0
+		}
+		out := outs[term*k : (term+1)*k]
+		var dot float64
+		for i, x := range in {
+			dot += x * out[i]
+		}
+		g := (label - sigmoid(dot)) * rate
+		for i := range grad {
+			grad[i] += g * out[i]
+		}
+		if updateOut {
+			for i, x := range in {
+				out[i] += g * x
+			}
+		}
+	}
+	for i := range in {
+		in[i] += grad[i]
+	}
+}
+
+// sigmoid returns the logistic sigmoid of x.
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
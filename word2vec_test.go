@@ -0,0 +1,72 @@
+package nlp
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"golang.org/x/exp/rand"
+)
+
+func TestWord2Vec(t *testing.T) {
+	animals := []string{"cat", "dog", "pet", "kitten", "puppy"}
+	vehicles := []string{"car", "road", "wheel", "truck", "traffic"}
+
+	// documents randomly mixing terms from within only one of the 2 groups
+	rnd := rand.New(rand.NewSource(uint64(0)))
+	var docs []string
+	for d := 0; d < 200; d++ {
+		group := animals
+		if d%2 == 1 {
+			group = vehicles
+		}
+		var doc []string
+		for i := 0; i < 8; i++ {
+			doc = append(doc, group[rnd.Intn(len(group))])
+		}
+		docs = append(docs, strings.Join(doc, " "))
+	}
+	// a rare term that should be excluded from the vocabulary
+	docs = append(docs, "cat unicorn dog")
+
+	var tests = []struct {
+		sample   float64
+		term     string
+		expected []string
+	}{
+		{sample: 0, term: "cat", expected: []string{"dog", "kitten", "pet", "puppy"}},
+		{sample: 0, term: "road", expected: []string{"car", "traffic", "truck", "wheel"}},
+		{sample: 1e-2, term: "cat", expected: []string{"dog", "kitten", "pet", "puppy"}},
+		{sample: 1e-2, term: "road", expected: []string{"car", "traffic", "truck", "wheel"}},
+	}
+
+	for testRun, test := range tests {
+		t.Logf("**** Test Run %d.\n", testRun+1)
+
+		w2v := NewWord2Vec(10)
+		// set Rnd to fixed constant seed for deterministic results
+		w2v.Rnd = rand.New(rand.NewSource(uint64(0)))
+		w2v.Sample = test.sample
+		w2v.Epochs = 20
+		w2v.Fit(docs...)
+
+		if _, exists := w2v.Vocabulary["unicorn"]; exists || len(w2v.Vocabulary) != 10 {
+			t.Errorf("Expected vocabulary of 10 terms excluding 'unicorn' but found %v", w2v.Vocabulary)
+		}
+
+		embeddings := w2v.Embeddings()
+		if embeddings.Len() != 10 || embeddings.Dims != 10 {
+			t.Errorf("Expected 10 embeddings of 10 dimensions but found %d of %d", embeddings.Len(), embeddings.Dims)
+		}
+
+		var similar []string
+		for _, match := range embeddings.MostSimilar(test.term, 4) {
+			similar = append(similar, match.ID.(string))
+		}
+		sort.Strings(similar)
+		if !reflect.DeepEqual(test.expected, similar) {
+			t.Errorf("Expected terms most similar to '%s' to be %v but found %v", test.term, test.expected, similar)
+		}
+	}
+}